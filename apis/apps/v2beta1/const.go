@@ -12,11 +12,44 @@ const (
 	LabelsManagedByKey       string = "apps.emqx.io/managed-by" // emqx-operator
 	LabelsDBRoleKey          string = "apps.emqx.io/db-role"    // core, replicant
 	LabelsPodTemplateHashKey string = "apps.emqx.io/pod-template-hash"
+	LabelsBackupScheduleKey  string = "apps.emqx.io/backup-schedule"
+	// The metrics service is selected by the ServiceMonitor with it, the dashboard service has the same default labels
+	LabelsMetricsKey string = "apps.emqx.io/metrics"
 )
 
 const (
 	// annotations
-	AnnotationsLastEMQXConfigKey string = "apps.emqx.io/last-emqx-configuration"
+	AnnotationsLastEMQXConfigKey     string = "apps.emqx.io/last-emqx-configuration"
+	AnnotationsLastConfigSourcesKey  string = "apps.emqx.io/last-config-sources"
+	AnnotationsLastTLSCertificateKey string = "apps.emqx.io/last-tls-certificate"
+	AnnotationsLastListenersKey      string = "apps.emqx.io/last-listeners"
+	AnnotationsLastAuthenticationKey string = "apps.emqx.io/last-authentication"
+	AnnotationsLastAuthorizationKey  string = "apps.emqx.io/last-authorization"
+	AnnotationsPromoteKey            string = "apps.emqx.io/promote"
+	AnnotationsAbortKey              string = "apps.emqx.io/abort"
+	AnnotationsRetiredAtKey          string = "apps.emqx.io/retired-at"
+	AnnotationsReadOnlyConfigHashKey string = "apps.emqx.io/read-only-config-hash"
+	// The hash of the read-only configs of EMQX, it is copied to the Pod templates as AnnotationsReadOnlyConfigHashKey
+	AnnotationsLastReadOnlyConfigHashKey string = "apps.emqx.io/last-read-only-config-hash"
+	// The hash of the read-only configs when ".spec.config.restartOnReadOnlyConfigChange" is enabled, it isn't copied to the Pod templates
+	AnnotationsInitialReadOnlyConfigHashKey string = "apps.emqx.io/initial-read-only-config-hash"
+
+	// The original configs of the EMQX listeners overridden by ".spec.listeners", they are restored when the listeners are removed
+	AnnotationsOverriddenListenersKey string = "apps.emqx.io/overridden-listeners"
+)
+
+const (
+	// update strategy types
+	UpdateStrategyRecreate      string = "Recreate"
+	UpdateStrategyRollingUpdate string = "RollingUpdate"
+	UpdateStrategyCanary        string = "Canary"
+)
+
+const (
+	// pvc retention policy types
+	PVCRetentionPolicyRetain      string = "Retain"
+	PVCRetentionPolicyDelete      string = "Delete"
+	PVCRetentionPolicyDeleteAfter string = "DeleteAfter"
 )
 
 const (
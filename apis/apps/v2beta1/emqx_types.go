@@ -19,6 +19,7 @@ package v2beta1
 import (
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
 // +kubebuilder:object:root=true
@@ -96,6 +97,44 @@ type EMQXSpec struct {
 	// If the EMQX replicant node exist, this service will selector the EMQX replicant node
 	// Else this service will selector EMQX core node
 	ListenersServiceTemplate *ServiceTemplate `json:"listenersServiceTemplate,omitempty"`
+	// ExtraListenersServiceTemplates are the objects that describe the additional EMQX listener services that will be created,
+	// each of them serves the selected listeners with its own service type and annotations,
+	// the selected listeners are removed from the service of ListenersServiceTemplate.
+	// +listType=map
+	// +listMapKey=name
+	ExtraListenersServiceTemplates []ListenersServiceTemplate `json:"extraListenersServiceTemplates,omitempty"`
+
+	// Monitoring is the object that describes the Prometheus Operator monitor for the EMQX built-in Prometheus endpoint
+	// It just works when the monitoring.coreos.com CRDs are installed
+	Monitoring *Monitoring `json:"monitoring,omitempty"`
+
+	// Listeners are the MQTT listeners of EMQX, they are rendered into the EMQX config and the listeners service,
+	// and are applied to the running EMQX nodes by the EMQX listeners API.
+	// They take precedence over the same listeners in ".spec.config.data".
+	// +listType=map
+	// +listMapKey=type
+	// +listMapKey=name
+	Listeners []Listener `json:"listeners,omitempty"`
+
+	// TLS is the object that describes the certificates of the EMQX SSL, WSS and dashboard HTTPS listeners
+	// The certificates are mounted into the EMQX core and replicant nodes, and reloaded without restarting the pods when they are rotated
+	TLS *TLS `json:"tls,omitempty"`
+
+	// DashboardIngress is the object that describes the route to the EMQX dashboard service,
+	// it points at the dashboard port found in the EMQX config, so it follows the port when the config is changed.
+	DashboardIngress *RouteTemplate `json:"dashboardIngress,omitempty"`
+
+	// WebsocketRoutes is the object that describes the routes to the EMQX WebSocket listeners,
+	// a route is created for each of the ws listeners, or the wss listeners when the kind is TLSRoute.
+	WebsocketRoutes *RouteTemplate `json:"websocketRoutes,omitempty"`
+
+	// Authentication is the authentication chain of EMQX, the authenticators are applied in order by the EMQX authentication API.
+	// The credentials are loaded from the secrets, so they are not stored in the EMQX custom resource.
+	Authentication []Authenticator `json:"authentication,omitempty"`
+
+	// Authorization is the object that describes the authorization settings and sources of EMQX,
+	// they are applied by the EMQX authorization API.
+	Authorization *Authorization `json:"authorization,omitempty"`
 }
 
 type BootstrapAPIKey struct {
@@ -122,18 +161,80 @@ type Config struct {
 	//+kubebuilder:validation:Enum=Merge;Replace
 	//+kubebuilder:default=Merge
 	Mode string `json:"mode,omitempty"`
-	// EMQX config, HOCON format, like etc/emqx.conf file
+	// EMQX config, HOCON format, like etc/emqx.conf file.
+	// The placeholder like "${secret:<name>/<key>}" in a quoted string is replaced by the value of the key in the Secret,
+	// like `password = "${secret:mysql/password}"`, the configs with placeholders are not written to the ConfigMap of EMQX.
 	Data string `json:"data,omitempty"`
+	// From is a list of ConfigMaps and Secrets that contain the HOCON config, they are merged in order, and then merged with data.
+	// The configs from Secrets are not written to the ConfigMap of EMQX, the EMQX nodes mount the config from the Secret "<name>-secret-configs" instead.
+	From []ConfigSource `json:"from,omitempty"`
+	// AutoCorrectDrift re-applies the config when the live config of EMQX is different from it,
+	// like the config is changed by the dashboard or the API. The drift is recorded in the status anyway.
+	AutoCorrectDrift bool `json:"autoCorrectDrift,omitempty"`
+	// RestartOnReadOnlyConfigChange applies the changes of the read-only configs in data, like `node`, `cluster`, `dashboard` and `rpc`,
+	// by creating a new revision of the EMQX nodes, which replaces the old nodes by the update strategy.
+	// The read-only configs in from are also included, the ones from Secrets are tracked by the versions of the sources.
+	// Otherwise, the changes of the read-only configs are ignored.
+	// Enabling it doesn't replace the EMQX nodes, only the later changes do.
+	RestartOnReadOnlyConfigChange bool `json:"restartOnReadOnlyConfigChange,omitempty"`
+}
+
+type ConfigSource struct {
+	// Selects a key of a ConfigMap in the namespace of EMQX.
+	ConfigMapKeyRef *corev1.ConfigMapKeySelector `json:"configMapKeyRef,omitempty"`
+	// Selects a key of a Secret in the namespace of EMQX.
+	SecretKeyRef *corev1.SecretKeySelector `json:"secretKeyRef,omitempty"`
 }
 
 type UpdateStrategy struct {
-	//+kubebuilder:validation:Enum=Recreate
+	// Type of update strategy.
+	// Recreate: create a new statefulSet / replicaSet for the new revision, and then evacuate and remove the old one node by node.
+	// RollingUpdate: replace the replicant nodes in batches, the number of nodes is limited by rollingUpdate.maxSurge and rollingUpdate.maxUnavailable.
+	// Canary: like RollingUpdate, but pause after canary.replicas replicant nodes have been moved to the new revision, until the EMQX Custom Resource is annotated by "apps.emqx.io/promote=true".
+	// RollingUpdate and Canary just work for replicant nodes, core nodes are always updated by Recreate.
+	//+kubebuilder:validation:Enum=Recreate;RollingUpdate;Canary
 	//+kubebuilder:default=Recreate
 	Type string `json:"type,omitempty"`
 	// Number of seconds before evacuation connection start.
 	InitialDelaySeconds int32 `json:"initialDelaySeconds,omitempty"`
 	// Number of seconds before evacuation connection timeout.
 	EvacuationStrategy EvacuationStrategy `json:"evacuationStrategy,omitempty"`
+	// Rolling update config params. Work for RollingUpdate and Canary.
+	RollingUpdate *RollingUpdateStrategy `json:"rollingUpdate,omitempty"`
+	// Canary config params. Present only if type = Canary.
+	Canary *CanaryStrategy `json:"canary,omitempty"`
+	// Indicates that the update is paused, the old nodes will not be scaled down until it is resumed or promoted.
+	// The update can be promoted by annotating the EMQX Custom Resource with "apps.emqx.io/promote=true",
+	// or aborted by annotating the EMQX Custom Resource with "apps.emqx.io/abort=true".
+	Paused bool `json:"paused,omitempty"`
+	// The maximum time in seconds for the nodes of the update revision to join the EMQX cluster,
+	// otherwise the update will be rolled back to the current revision automatically.
+	// 0 means the update will never be rolled back.
+	//+kubebuilder:validation:Minimum=0
+	ProgressDeadlineSeconds int32 `json:"progressDeadlineSeconds,omitempty"`
+}
+
+type RollingUpdateStrategy struct {
+	// The maximum number of replicant nodes that can be unavailable during the update.
+	// Value can be an absolute number (ex: 5) or a percentage of desired replicant nodes (ex: 10%).
+	// Absolute number is calculated from percentage by rounding down.
+	// This can not be 0 if MaxSurge is 0.
+	//+kubebuilder:default="25%"
+	MaxUnavailable *intstr.IntOrString `json:"maxUnavailable,omitempty"`
+	// The maximum number of replicant nodes that can be scheduled above the desired number of nodes.
+	// Value can be an absolute number (ex: 5) or a percentage of desired replicant nodes (ex: 10%).
+	// Absolute number is calculated from percentage by rounding up.
+	// This can not be 0 if MaxUnavailable is 0.
+	//+kubebuilder:default="25%"
+	MaxSurge *intstr.IntOrString `json:"maxSurge,omitempty"`
+}
+
+type CanaryStrategy struct {
+	// The number of replicant nodes that will be moved to the new revision before pausing.
+	// Value can be an absolute number (ex: 1) or a percentage of desired replicant nodes (ex: 10%).
+	// Absolute number is calculated from percentage by rounding up, and it is at least 1.
+	//+kubebuilder:default="10%"
+	Replicas *intstr.IntOrString `json:"replicas,omitempty"`
 }
 
 type EvacuationStrategy struct {
@@ -165,6 +266,34 @@ type EMQXReplicantTemplate struct {
 	// Specification of the desired behavior of the EMQX replicant node.
 	// More info: https://git.k8s.io/community/contributors/devel/sig-architecture/api-conventions.md#spec-and-status
 	Spec EMQXReplicantTemplateSpec `json:"spec,omitempty"`
+	// Autoscaling scales the replicant nodes by the MQTT connections or sessions per node.
+	// When it is set, the number of replicant nodes is recorded in ".status.replicantAutoscalingStatus.replicas",
+	// and ".spec.replicantTemplate.spec.replicas" is only used as the initial number.
+	Autoscaling *ReplicantAutoscaling `json:"autoscaling,omitempty"`
+}
+
+type ReplicantAutoscaling struct {
+	// MinReplicas is the lower limit for the number of replicant nodes.
+	// Defaults to 1.
+	//+kubebuilder:validation:Minimum=1
+	//+kubebuilder:default:=1
+	MinReplicas *int32 `json:"minReplicas,omitempty"`
+	// MaxReplicas is the upper limit for the number of replicant nodes.
+	//+kubebuilder:validation:Required
+	//+kubebuilder:validation:Minimum=1
+	MaxReplicas int32 `json:"maxReplicas"`
+	// TargetConnectionsPerNode is the target average number of the connected MQTT clients per replicant node.
+	// Just work in EMQX 5.1 or later.
+	//+kubebuilder:validation:Minimum=1
+	TargetConnectionsPerNode *int64 `json:"targetConnectionsPerNode,omitempty"`
+	// TargetSessionsPerNode is the target average number of the MQTT sessions per replicant node.
+	//+kubebuilder:validation:Minimum=1
+	TargetSessionsPerNode *int64 `json:"targetSessionsPerNode,omitempty"`
+	// ScaleDownDelaySeconds is the number of seconds to wait after the last scale up before scaling down.
+	// Defaults to 300.
+	//+kubebuilder:validation:Minimum=0
+	//+kubebuilder:default:=300
+	ScaleDownDelaySeconds int32 `json:"scaleDownDelaySeconds,omitempty"`
 }
 
 type EMQXCoreTemplateSpec struct {
@@ -178,6 +307,31 @@ type EMQXCoreTemplateSpec struct {
 	// any volumes in the template, with the same name.
 	// More than EMQXReplicantTemplateSpec
 	VolumeClaimTemplates corev1.PersistentVolumeClaimSpec `json:"volumeClaimTemplates,omitempty"`
+	// PVCRetentionPolicy describes what happens to the persistentVolumeClaims of the retired statefulSets,
+	// the statefulSets are retired when they are pruned by ".spec.revisionHistoryLimit" or the update is aborted.
+	// If it is not set, the persistentVolumeClaims are deleted with the statefulSets.
+	PVCRetentionPolicy *PVCRetentionPolicy `json:"pvcRetentionPolicy,omitempty"`
+}
+
+type PVCRetentionPolicy struct {
+	// Type of the retention policy.
+	// Retain: keep the persistentVolumeClaims until they are deleted manually.
+	// Delete: delete the persistentVolumeClaims with the statefulSets.
+	// DeleteAfter: keep the persistentVolumeClaims for deleteAfter, and then delete them.
+	//+kubebuilder:validation:Enum=Retain;Delete;DeleteAfter
+	//+kubebuilder:default=Delete
+	Type string `json:"type,omitempty"`
+	// How long the persistentVolumeClaims are kept after the statefulSets are retired, like "24h". Present only if type = DeleteAfter.
+	DeleteAfter *metav1.Duration `json:"deleteAfter,omitempty"`
+	// Snapshot creates a VolumeSnapshot for each persistentVolumeClaim, the persistentVolumeClaim will not be deleted until the snapshot is ready to use.
+	// It requires the snapshot.storage.k8s.io CRDs and a CSI driver that supports snapshots.
+	// The VolumeSnapshots are owned by the EMQX, they are deleted with it.
+	Snapshot *PVCSnapshot `json:"snapshot,omitempty"`
+}
+
+type PVCSnapshot struct {
+	// The name of the VolumeSnapshotClass, the default VolumeSnapshotClass is used if it is empty.
+	VolumeSnapshotClassName string `json:"volumeSnapshotClassName,omitempty"`
 }
 
 type EMQXReplicantTemplateSpec struct {
@@ -312,6 +466,302 @@ type ServiceTemplate struct {
 	Spec corev1.ServiceSpec `json:"spec,omitempty"`
 }
 
+type ListenersServiceTemplate struct {
+	// Name of the service is "<EMQX name>-listeners-<name>".
+	//+kubebuilder:validation:Required
+	//+kubebuilder:validation:Pattern=`^[a-z0-9]([a-z0-9-]*[a-z0-9])?$`
+	Name string `json:"name"`
+	// Listeners served by the service, they are the service port names of the listeners,
+	// like "tcp-default", "ws-default" or "quic-default".
+	//+kubebuilder:validation:MinItems=1
+	Listeners []string `json:"listeners"`
+
+	ServiceTemplate `json:",inline"`
+}
+
+type Monitoring struct {
+	// Kind of the monitor will be created, it selects both of the EMQX core and replicant nodes.
+	// PodMonitor: scrape the EMQX pods directly.
+	// ServiceMonitor: create a headless service for the EMQX pods, and scrape it.
+	//+kubebuilder:validation:Enum=PodMonitor;ServiceMonitor
+	//+kubebuilder:default=PodMonitor
+	Kind string `json:"kind,omitempty"`
+	// Labels of the monitor, they are usually used by the Prometheus to select the monitors.
+	Labels map[string]string `json:"labels,omitempty"`
+	// Interval at which metrics should be scraped.
+	// If not specified Prometheus' global scrape interval is used.
+	//+kubebuilder:validation:Pattern:=`^(0|(([0-9]+)h)?(([0-9]+)m)?(([0-9]+)s)?(([0-9]+)ms)?)$`
+	Interval string `json:"interval,omitempty"`
+	// The basic auth credentials for the endpoint, it just works when "prometheus.enable_basic_auth" is true in the EMQX config.
+	// The key is the username, and the secret is the password.
+	// It is required when "prometheus.enable_basic_auth" is true.
+	BasicAuth *SecretRef `json:"basicAuth,omitempty"`
+}
+
+type RouteTemplate struct {
+	// Kind of the route will be created.
+	// Ingress: the networking.k8s.io/v1 Ingress.
+	// HTTPRoute, TLSRoute, TCPRoute: the gateway.networking.k8s.io routes, they just work when the Gateway API CRDs are installed.
+	//+kubebuilder:validation:Enum=Ingress;HTTPRoute;TLSRoute;TCPRoute
+	//+kubebuilder:default=Ingress
+	Kind string `json:"kind,omitempty"`
+	// Standard object's metadata, the labels and annotations are added to the route.
+	// More info: https://git.k8s.io/community/contributors/devel/sig-architecture/api-conventions.md#metadata
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	// IngressClassName of the Ingress, it just works for the Ingress.
+	IngressClassName *string `json:"ingressClassName,omitempty"`
+	// ParentRefs are the Gateways that the route attaches to, it is required by the Gateway API routes.
+	ParentRefs []ParentReference `json:"parentRefs,omitempty"`
+	// Hosts of the route, the route matches all the hosts if not specified.
+	// It does not work for the TCPRoute.
+	Hosts []string `json:"hosts,omitempty"`
+	// Path prefix of the route, it just works for the Ingress and the HTTPRoute.
+	// Defaults to "/" for the dashboard, and "/mqtt" for the WebSocket listeners.
+	//+kubebuilder:validation:Pattern=`^/`
+	Path string `json:"path,omitempty"`
+	// TLS is the secret of the certificate which terminates TLS for the hosts, it just works for the Ingress.
+	TLS *RouteTLS `json:"tls,omitempty"`
+}
+
+type ParentReference struct {
+	// Group of the parent resource.
+	//+kubebuilder:default=gateway.networking.k8s.io
+	Group string `json:"group,omitempty"`
+	// Kind of the parent resource.
+	//+kubebuilder:default=Gateway
+	Kind string `json:"kind,omitempty"`
+	// Name of the parent resource.
+	//+kubebuilder:validation:Required
+	Name string `json:"name"`
+	// Namespace of the parent resource, defaults to the namespace of the EMQX custom resource.
+	Namespace string `json:"namespace,omitempty"`
+	// SectionName is the name of the listener of the Gateway.
+	SectionName string `json:"sectionName,omitempty"`
+}
+
+type RouteTLS struct {
+	// SecretName of the certificate.
+	//+kubebuilder:validation:Required
+	SecretName string `json:"secretName"`
+}
+
+type Authenticator struct {
+	// Mechanism of the authenticator.
+	//+kubebuilder:validation:Enum=password_based;jwt
+	//+kubebuilder:default=password_based
+	Mechanism string `json:"mechanism,omitempty"`
+	// Backend of the password based authenticator, it is not used by the jwt authenticator.
+	//+kubebuilder:validation:Enum=built_in_database;http;redis;postgresql
+	Backend string `json:"backend,omitempty"`
+	// Enable the authenticator.
+	// This is a pointer to distinguish between `false` and not specified.
+	//+kubebuilder:default:=true
+	Enable *bool `json:"enable,omitempty"`
+	// UserIDType is the type of the user ID of the built_in_database backend.
+	//+kubebuilder:validation:Enum=username;clientid
+	UserIDType string `json:"userIdType,omitempty"`
+	// PasswordHashAlgorithm of the built_in_database, redis and postgresql backends.
+	PasswordHashAlgorithm *PasswordHashAlgorithm `json:"passwordHashAlgorithm,omitempty"`
+	// JWT is the config of the jwt authenticator.
+	JWT *JWTAuthenticator `json:"jwt,omitempty"`
+	// HTTP is the config of the http backend.
+	HTTP *HTTPAuthSource `json:"http,omitempty"`
+	// Redis is the config of the redis backend, "cmd" is the command to get the password hash.
+	Redis *RedisAuthSource `json:"redis,omitempty"`
+	// PostgreSQL is the config of the postgresql backend, "query" is the query to get the password hash.
+	PostgreSQL *PostgreSQLAuthSource `json:"postgresql,omitempty"`
+}
+
+type PasswordHashAlgorithm struct {
+	//+kubebuilder:validation:Enum=plain;md5;sha;sha256;sha512;bcrypt
+	//+kubebuilder:default=sha256
+	Name string `json:"name,omitempty"`
+	// SaltPosition just works for the plain, md5, sha, sha256 and sha512 algorithms.
+	//+kubebuilder:validation:Enum=prefix;suffix;disable
+	SaltPosition string `json:"saltPosition,omitempty"`
+}
+
+type JWTAuthenticator struct {
+	// UseJWKS fetches the public keys from the JWKS endpoint.
+	UseJWKS bool `json:"useJWKS,omitempty"`
+	// Endpoint of the JWKS, it is required when useJWKS is true.
+	Endpoint string `json:"endpoint,omitempty"`
+	// Algorithm to verify the JWT when useJWKS is false.
+	//+kubebuilder:validation:Enum=hmac-based;public-key
+	Algorithm string `json:"algorithm,omitempty"`
+	// Secret of the hmac-based algorithm.
+	Secret *KeyRef `json:"secret,omitempty"`
+	// PublicKey of the public-key algorithm, it is a PEM encoded public key or certificate.
+	PublicKey string `json:"publicKey,omitempty"`
+	// From is the field of the MQTT CONNECT packet which carries the JWT.
+	//+kubebuilder:validation:Enum=password;username
+	//+kubebuilder:default=password
+	From string `json:"from,omitempty"`
+}
+
+type HTTPAuthSource struct {
+	//+kubebuilder:validation:Enum=get;post
+	//+kubebuilder:default=post
+	Method string `json:"method,omitempty"`
+	//+kubebuilder:validation:Required
+	URL string `json:"url"`
+	// Headers of the HTTP request.
+	Headers map[string]string `json:"headers,omitempty"`
+	// SecretHeaders are the headers whose values are loaded from the secrets, like "Authorization".
+	SecretHeaders map[string]KeyRef `json:"secretHeaders,omitempty"`
+	// Body of the HTTP request, the placeholders like "${username}" are replaced by EMQX.
+	Body map[string]string `json:"body,omitempty"`
+}
+
+type RedisAuthSource struct {
+	//+kubebuilder:validation:Enum=single;sentinel;cluster
+	//+kubebuilder:default=single
+	RedisType string `json:"redisType,omitempty"`
+	// Server is the "host:port" of the single redis, or the comma separated "host:port" list of the sentinel and cluster redis.
+	//+kubebuilder:validation:Required
+	Server string `json:"server"`
+	// Sentinel is the name of the redis sentinel, it is required by the sentinel redis.
+	Sentinel string  `json:"sentinel,omitempty"`
+	Database int32   `json:"database,omitempty"`
+	Username string  `json:"username,omitempty"`
+	Password *KeyRef `json:"password,omitempty"`
+	//+kubebuilder:validation:Required
+	Cmd string `json:"cmd"`
+}
+
+type PostgreSQLAuthSource struct {
+	// Server is the "host:port" of the PostgreSQL.
+	//+kubebuilder:validation:Required
+	Server string `json:"server"`
+	//+kubebuilder:validation:Required
+	Database string  `json:"database"`
+	Username string  `json:"username,omitempty"`
+	Password *KeyRef `json:"password,omitempty"`
+	//+kubebuilder:validation:Required
+	Query string `json:"query"`
+}
+
+type Authorization struct {
+	// NoMatch is the action when none of the sources matches.
+	//+kubebuilder:validation:Enum=allow;deny
+	//+kubebuilder:default=allow
+	NoMatch string `json:"noMatch,omitempty"`
+	// DenyAction is the action when the authorization is denied.
+	//+kubebuilder:validation:Enum=ignore;disconnect
+	//+kubebuilder:default=ignore
+	DenyAction string `json:"denyAction,omitempty"`
+	// CacheEnabled enables the authorization cache.
+	// This is a pointer to distinguish between `false` and not specified.
+	//+kubebuilder:default:=true
+	CacheEnabled *bool `json:"cacheEnabled,omitempty"`
+	// Sources of the authorization, they are checked in order.
+	// +listType=map
+	// +listMapKey=type
+	Sources []AuthorizationSource `json:"sources,omitempty"`
+}
+
+type AuthorizationSource struct {
+	// Type of the source.
+	//+kubebuilder:validation:Required
+	//+kubebuilder:validation:Enum=built_in_database;file;http;redis;postgresql
+	Type string `json:"type"`
+	// Enable the source.
+	// This is a pointer to distinguish between `false` and not specified.
+	//+kubebuilder:default:=true
+	Enable *bool `json:"enable,omitempty"`
+	// Rules of the file source, it is the content of the "acl.conf" file.
+	Rules string `json:"rules,omitempty"`
+	// HTTP is the config of the http source.
+	HTTP *HTTPAuthSource `json:"http,omitempty"`
+	// Redis is the config of the redis source, "cmd" is the command to get the rules.
+	Redis *RedisAuthSource `json:"redis,omitempty"`
+	// PostgreSQL is the config of the postgresql source, "query" is the query to get the rules.
+	PostgreSQL *PostgreSQLAuthSource `json:"postgresql,omitempty"`
+}
+
+type Listener struct {
+	// Name of the listener, the listener is "listeners.<type>.<name>" in the EMQX config.
+	//+kubebuilder:validation:Required
+	//+kubebuilder:validation:Pattern=`^[a-z0-9]([a-z0-9-]*[a-z0-9])?$`
+	Name string `json:"name"`
+	// Type of the listener.
+	//+kubebuilder:validation:Required
+	//+kubebuilder:validation:Enum=tcp;ssl;ws;wss;quic
+	Type string `json:"type"`
+	// Port of the listener, the listener binds on all interfaces.
+	//+kubebuilder:validation:Required
+	//+kubebuilder:validation:Minimum=1
+	//+kubebuilder:validation:Maximum=65535
+	Port int32 `json:"port"`
+	// Enable the listener.
+	//+kubebuilder:default=true
+	Enable *bool `json:"enable,omitempty"`
+	// The maximum number of concurrent connections allowed by the listener.
+	// Defaults to infinity.
+	//+kubebuilder:validation:Minimum=1
+	MaxConnections *int32 `json:"maxConnections,omitempty"`
+	// Enable the PROXY protocol V1/2 if the listener is behind a load balancer.
+	// Not supported by the quic listener.
+	ProxyProtocol bool `json:"proxyProtocol,omitempty"`
+	// TLSSecretName is the name of the Secret with the type "kubernetes.io/tls" of the ssl, wss or quic listener,
+	// it contains the "tls.crt" and the "tls.key".
+	TLSSecretName string `json:"tlsSecretName,omitempty"`
+}
+
+type TLS struct {
+	// Listeners is the certificate of the "listeners.ssl.default" and "listeners.wss.default" listeners.
+	Listeners *TLSCertificate `json:"listeners,omitempty"`
+	// Dashboard is the certificate of the "dashboard.listeners.https" listener.
+	// The listener still needs to be enabled by "dashboard.listeners.https.bind" in the EMQX config.
+	Dashboard *TLSCertificate `json:"dashboard,omitempty"`
+	// APIClient is the TLS config of EMQX Operator calling the EMQX management API,
+	// it works when the EMQX Operator calls the management API by the "dashboard.listeners.https" listener.
+	APIClient *APIClientTLS `json:"apiClient,omitempty"`
+}
+
+type APIClientTLS struct {
+	// CASecretName is the name of the Secret which contains the CA bundle "ca.crt" to verify the certificate of the EMQX nodes.
+	// Defaults to the Secret of ".spec.tls.dashboard" if it contains "ca.crt".
+	// The certificate is verified by the system trust store if no CA bundle is provided, e.g. it is issued by a public CA.
+	CASecretName string `json:"caSecretName,omitempty"`
+	// InsecureSkipVerify skips the verification of the EMQX nodes certificate.
+	// It is insecure, and should only be used for testing.
+	InsecureSkipVerify bool `json:"insecureSkipVerify,omitempty"`
+	// CertSecretName is the name of the Secret with the type "kubernetes.io/tls",
+	// it contains the "tls.crt" and "tls.key" of the client certificate for mutual TLS.
+	CertSecretName string `json:"certSecretName,omitempty"`
+	// ServerName is used to verify the hostname of the EMQX nodes certificate.
+	// Defaults to the DNS name of the dashboard service, like "<EMQX name>-dashboard.<namespace>.svc.<cluster domain>".
+	ServerName string `json:"serverName,omitempty"`
+}
+
+type TLSCertificate struct {
+	// SecretName is the name of the Secret with the type "kubernetes.io/tls",
+	// it contains the "tls.crt", the "tls.key" and the optional "ca.crt".
+	// Only one of secretName and issuerRef can be set.
+	SecretName string `json:"secretName,omitempty"`
+	// IssuerRef is the cert-manager Issuer or ClusterIssuer, EMQX Operator will create a cert-manager Certificate issued by it,
+	// and the certificate will be stored in the Secret "<EMQX name>-listeners-tls" or "<EMQX name>-dashboard-tls".
+	// It just works when the cert-manager.io CRDs are installed.
+	IssuerRef *IssuerRef `json:"issuerRef,omitempty"`
+	// DNSNames of the certificate issued by the issuerRef.
+	// Defaults to the DNS names of the listeners service or the dashboard service.
+	DNSNames []string `json:"dnsNames,omitempty"`
+}
+
+type IssuerRef struct {
+	// Name of the issuer
+	//+kubebuilder:validation:Required
+	Name string `json:"name"`
+	// Kind of the issuer
+	//+kubebuilder:validation:Enum=Issuer;ClusterIssuer
+	//+kubebuilder:default=Issuer
+	Kind string `json:"kind,omitempty"`
+	// Group of the issuer
+	//+kubebuilder:default="cert-manager.io"
+	Group string `json:"group,omitempty"`
+}
+
 // +kubebuilder:object:root=true
 // EMQXList contains a list of EMQX
 type EMQXList struct {
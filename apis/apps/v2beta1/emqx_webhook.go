@@ -0,0 +1,458 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2beta1
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+
+	emperror "emperror.dev/errors"
+	hocon "github.com/rory-z/go-hocon"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// log is for logging in this package.
+var emqxlog = logf.Log.WithName("emqx-resource")
+
+func (r *EMQX) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(r).
+		Complete()
+}
+
+//+kubebuilder:webhook:path=/mutate-apps-emqx-io-v2beta1-emqx,mutating=true,failurePolicy=fail,sideEffects=None,groups=apps.emqx.io,resources=emqxes,verbs=create;update,versions=v2beta1,name=mutating.emqx.emqx.io,admissionReviewVersions={v1,v1beta1}
+
+var _ webhook.Defaulter = &EMQX{}
+
+// Default implements webhook.Defaulter so a webhook will be registered for the type
+func (r *EMQX) Default() {
+	emqxlog.Info("default", "name", r.Name)
+
+	defaultLabels(r)
+	defaultServiceTemplate(r)
+	defaultRouteTemplate(r)
+}
+
+//+kubebuilder:webhook:path=/validate-apps-emqx-io-v2beta1-emqx,mutating=false,failurePolicy=fail,sideEffects=None,groups=apps.emqx.io,resources=emqxes,verbs=create;update,versions=v2beta1,name=validator.emqx.emqx.io,admissionReviewVersions={v1,v1beta1}
+
+var _ webhook.Validator = &EMQX{}
+
+// ValidateCreate implements webhook.Validator so a webhook will be registered for the type
+func (r *EMQX) ValidateCreate() (admission.Warnings, error) {
+	emqxlog.Info("validate create", "name", r.Name)
+
+	for _, cb := range []func(*EMQX) error{
+		validateConfig,
+		validateListeners,
+		validateExtraListenersServiceTemplates,
+		validatePorts,
+		validateReplicant,
+		validateAutoscaling,
+		validateTLS,
+		validateMonitoring,
+		validateRouteTemplates,
+		validateAuthentication,
+		validateAuthorization,
+		validateUpdateStrategy,
+		validatePVCRetentionPolicy,
+	} {
+		if err := cb(r); err != nil {
+			emqxlog.Error(err, "validate create failed")
+			return nil, err
+		}
+	}
+	return nil, nil
+}
+
+// ValidateUpdate implements webhook.Validator so a webhook will be registered for the type
+func (r *EMQX) ValidateUpdate(old runtime.Object) (admission.Warnings, error) {
+	emqxlog.Info("validate update", "name", r.Name)
+	oldEMQX := old.(*EMQX)
+
+	for _, cb := range []func(*EMQX) error{
+		validateConfig,
+		validateListeners,
+		validateExtraListenersServiceTemplates,
+		validatePorts,
+		validateReplicant,
+		validateAutoscaling,
+		validateTLS,
+		validateMonitoring,
+		validateRouteTemplates,
+		validateAuthentication,
+		validateAuthorization,
+		validateUpdateStrategy,
+		validatePVCRetentionPolicy,
+	} {
+		if err := cb(r); err != nil {
+			emqxlog.Error(err, "validate update failed")
+			return nil, err
+		}
+	}
+
+	for _, cb := range []func(new, old *EMQX) error{
+		validateBootstrapAPIKeys,
+		validateVolumeClaimTemplates,
+	} {
+		if err := cb(r, oldEMQX); err != nil {
+			emqxlog.Error(err, "validate update failed")
+			return nil, err
+		}
+	}
+	return nil, nil
+}
+
+// ValidateDelete implements webhook.Validator so a webhook will be registered for the type
+func (r *EMQX) ValidateDelete() (admission.Warnings, error) {
+	emqxlog.Info("validate delete", "name", r.Name)
+
+	return nil, nil
+}
+
+func defaultLabels(r *EMQX) {
+	r.Labels = CloneAndMergeMap(DefaultLabels(r), r.Labels)
+}
+
+func defaultServiceTemplate(r *EMQX) {
+	templates := []*ServiceTemplate{r.Spec.DashboardServiceTemplate, r.Spec.ListenersServiceTemplate}
+	for i := range r.Spec.ExtraListenersServiceTemplates {
+		templates = append(templates, &r.Spec.ExtraListenersServiceTemplates[i].ServiceTemplate)
+	}
+	for _, s := range templates {
+		if s != nil && s.Enabled == nil {
+			enabled := true
+			s.Enabled = &enabled
+		}
+	}
+}
+
+func defaultRouteTemplate(r *EMQX) {
+	for _, route := range []struct {
+		template *RouteTemplate
+		path     string
+	}{
+		{r.Spec.DashboardIngress, "/"},
+		{r.Spec.WebsocketRoutes, "/mqtt"},
+	} {
+		if route.template == nil {
+			continue
+		}
+		if route.template.Kind == "" {
+			route.template.Kind = "Ingress"
+		}
+		if route.template.Path == "" && (route.template.Kind == "Ingress" || route.template.Kind == "HTTPRoute") {
+			route.template.Path = route.path
+		}
+	}
+}
+
+func validateConfig(r *EMQX) error {
+	if _, err := hocon.ParseString(r.Spec.Config.Data); err != nil {
+		return emperror.Wrap(err, `the field ".spec.config.data" is not a valid HOCON config`)
+	}
+	for i, source := range r.Spec.Config.From {
+		if (source.ConfigMapKeyRef == nil) == (source.SecretKeyRef == nil) {
+			return fmt.Errorf(`only one of the fields ".spec.config.from[%d].configMapKeyRef" and ".spec.config.from[%d].secretKeyRef" must be set`, i, i)
+		}
+	}
+	return nil
+}
+
+func validateListeners(r *EMQX) error {
+	ports := map[int32]string{}
+	for _, listener := range r.Spec.Listeners {
+		id := fmt.Sprintf("%s-%s", listener.Type, listener.Name)
+		// The service port name must be no more than 15 characters
+		if len(id) > 15 {
+			return fmt.Errorf(`the type and name of the listener "%s" must be no more than 15 characters`, id)
+		}
+		if listener.TLSSecretName != "" && listener.Type != "ssl" && listener.Type != "wss" && listener.Type != "quic" {
+			return fmt.Errorf(`the field "tlsSecretName" of the listener "%s" just works for the ssl, wss and quic listeners`, id)
+		}
+		if listener.ProxyProtocol && listener.Type == "quic" {
+			return fmt.Errorf(`the field "proxyProtocol" of the listener "%s" is not supported by the quic listener`, id)
+		}
+		if !listener.IsEnabled() {
+			continue
+		}
+		if other, ok := ports[listener.Port]; ok {
+			return fmt.Errorf("the port %d of the listener %s conflicts with the listener %s", listener.Port, id, other)
+		}
+		ports[listener.Port] = id
+	}
+	return nil
+}
+
+func validateExtraListenersServiceTemplates(r *EMQX) error {
+	listeners := map[string]string{}
+	for _, template := range r.Spec.ExtraListenersServiceTemplates {
+		for _, listener := range template.Listeners {
+			if other, ok := listeners[listener]; ok {
+				return fmt.Errorf(`the listener "%s" is served by both of the extra listeners services "%s" and "%s"`, listener, other, template.Name)
+			}
+			listeners[listener] = template.Name
+		}
+	}
+	return nil
+}
+
+func validatePorts(r *EMQX) error {
+	dashboardPorts, err := GetDashboardPortMap(r.Spec.Config.Data)
+	if err != nil {
+		return emperror.Wrap(err, "failed to get dashboard ports")
+	}
+	listenerPorts, err := GetListenersServicePorts(r.Spec.Config.Data)
+	if err != nil {
+		return emperror.Wrap(err, "failed to get listener ports")
+	}
+	listenerPorts = append(listenerPorts, GetSpecListenersServicePorts(r.Spec.Listeners)...)
+
+	for name, port := range dashboardPorts {
+		for _, listener := range listenerPorts {
+			if listener.Port == port {
+				return fmt.Errorf("the port %d of %s conflicts with the listener %s", port, name, listener.Name)
+			}
+		}
+	}
+	return nil
+}
+
+func validateReplicant(r *EMQX) error {
+	if !IsExistReplicant(r) {
+		return nil
+	}
+	if r.Spec.CoreTemplate.Spec.Replicas == nil || *r.Spec.CoreTemplate.Spec.Replicas == 0 {
+		return errors.New(`the field ".spec.coreTemplate.spec.replicas" must be greater than 0 when ".spec.replicantTemplate" is set`)
+	}
+	return nil
+}
+
+func validateAutoscaling(r *EMQX) error {
+	if r.Spec.ReplicantTemplate == nil || r.Spec.ReplicantTemplate.Autoscaling == nil {
+		return nil
+	}
+	autoscaling := r.Spec.ReplicantTemplate.Autoscaling
+	if autoscaling.TargetConnectionsPerNode == nil && autoscaling.TargetSessionsPerNode == nil {
+		return errors.New(`one of the fields ".spec.replicantTemplate.autoscaling.targetConnectionsPerNode" and ".spec.replicantTemplate.autoscaling.targetSessionsPerNode" must be set`)
+	}
+	if autoscaling.MinReplicas != nil && *autoscaling.MinReplicas > autoscaling.MaxReplicas {
+		return errors.New(`the field ".spec.replicantTemplate.autoscaling.minReplicas" must not be greater than ".spec.replicantTemplate.autoscaling.maxReplicas"`)
+	}
+	return nil
+}
+
+func validateTLS(r *EMQX) error {
+	if r.Spec.TLS == nil {
+		return nil
+	}
+	if err := validateTLSCertificate(".spec.tls.listeners", r.Spec.TLS.Listeners); err != nil {
+		return err
+	}
+	return validateTLSCertificate(".spec.tls.dashboard", r.Spec.TLS.Dashboard)
+}
+
+func validateTLSCertificate(field string, cert *TLSCertificate) error {
+	if cert == nil {
+		return nil
+	}
+	if (cert.SecretName == "") == (cert.IssuerRef == nil) {
+		return fmt.Errorf(`one and only one of the fields "%s.secretName" and "%s.issuerRef" must be set`, field, field)
+	}
+	return nil
+}
+
+func validateMonitoring(r *EMQX) error {
+	if r.Spec.Monitoring == nil || r.Spec.Monitoring.BasicAuth != nil {
+		return nil
+	}
+	config, err := hocon.ParseString(r.Spec.Config.Data)
+	if err != nil {
+		return nil
+	}
+	if config.GetString("prometheus.enable_basic_auth") == "true" {
+		return errors.New(`the field ".spec.monitoring.basicAuth" must be set when "prometheus.enable_basic_auth" is true`)
+	}
+	return nil
+}
+
+func validateRouteTemplates(r *EMQX) error {
+	if err := validateRouteTemplate(".spec.dashboardIngress", r.Spec.DashboardIngress); err != nil {
+		return err
+	}
+	return validateRouteTemplate(".spec.websocketRoutes", r.Spec.WebsocketRoutes)
+}
+
+func validateRouteTemplate(field string, route *RouteTemplate) error {
+	if route == nil {
+		return nil
+	}
+	if route.Kind == "Ingress" {
+		if len(route.ParentRefs) > 0 {
+			return fmt.Errorf(`the field "%s.parentRefs" is not supported by the Ingress`, field)
+		}
+		return nil
+	}
+	if len(route.ParentRefs) == 0 {
+		return fmt.Errorf(`the field "%s.parentRefs" is required by the %s`, field, route.Kind)
+	}
+	if route.IngressClassName != nil {
+		return fmt.Errorf(`the field "%s.ingressClassName" is not supported by the %s`, field, route.Kind)
+	}
+	if route.TLS != nil {
+		return fmt.Errorf(`the field "%s.tls" is not supported by the %s`, field, route.Kind)
+	}
+	if route.Path != "" && route.Kind != "HTTPRoute" {
+		return fmt.Errorf(`the field "%s.path" is not supported by the %s`, field, route.Kind)
+	}
+	if len(route.Hosts) > 0 && route.Kind == "TCPRoute" {
+		return fmt.Errorf(`the field "%s.hosts" is not supported by the %s`, field, route.Kind)
+	}
+	return nil
+}
+
+func validateAuthentication(r *EMQX) error {
+	ids := map[string]struct{}{}
+	for _, authenticator := range r.Spec.Authentication {
+		id := authenticator.ID()
+		if _, ok := ids[id]; ok {
+			return fmt.Errorf(`the authenticator "%s" is duplicated`, id)
+		}
+		ids[id] = struct{}{}
+
+		if authenticator.Mechanism == "jwt" {
+			if authenticator.Backend != "" {
+				return fmt.Errorf(`the field "backend" is not supported by the jwt authenticator`)
+			}
+			if authenticator.JWT == nil {
+				return fmt.Errorf(`the field "jwt" is required by the jwt authenticator`)
+			}
+			if authenticator.JWT.UseJWKS && authenticator.JWT.Endpoint == "" {
+				return fmt.Errorf(`the field "jwt.endpoint" is required when "jwt.useJWKS" is true`)
+			}
+			if !authenticator.JWT.UseJWKS && authenticator.JWT.Algorithm == "hmac-based" && authenticator.JWT.Secret == nil {
+				return fmt.Errorf(`the field "jwt.secret" is required by the hmac-based algorithm`)
+			}
+			if !authenticator.JWT.UseJWKS && authenticator.JWT.Algorithm == "public-key" && authenticator.JWT.PublicKey == "" {
+				return fmt.Errorf(`the field "jwt.publicKey" is required by the public-key algorithm`)
+			}
+			continue
+		}
+		if authenticator.Backend == "" {
+			return fmt.Errorf(`the field "backend" is required by the password_based authenticator`)
+		}
+		if err := validateAuthSource(id, authenticator.Backend, authenticator.HTTP, authenticator.Redis, authenticator.PostgreSQL); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func validateAuthorization(r *EMQX) error {
+	if r.Spec.Authorization == nil {
+		return nil
+	}
+	for _, source := range r.Spec.Authorization.Sources {
+		if err := validateAuthSource(source.Type, source.Type, source.HTTP, source.Redis, source.PostgreSQL); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateAuthSource checks the config of the backend is set, the authenticators and the authorization sources share the http, redis and postgresql configs
+func validateAuthSource(id, backend string, http *HTTPAuthSource, redis *RedisAuthSource, postgresql *PostgreSQLAuthSource) error {
+	for _, field := range []struct {
+		name string
+		set  bool
+	}{
+		{"http", http != nil},
+		{"redis", redis != nil},
+		{"postgresql", postgresql != nil},
+	} {
+		if field.name == backend && !field.set {
+			return fmt.Errorf(`the field "%s" is required by "%s"`, field.name, id)
+		}
+		if field.name != backend && field.set {
+			return fmt.Errorf(`the field "%s" is not supported by "%s"`, field.name, id)
+		}
+	}
+	if redis != nil && redis.RedisType == "sentinel" && redis.Sentinel == "" {
+		return fmt.Errorf(`the field "redis.sentinel" is required by the sentinel redis of "%s"`, id)
+	}
+	return nil
+}
+
+func validateUpdateStrategy(r *EMQX) error {
+	rollingUpdate := r.Spec.UpdateStrategy.RollingUpdate
+	if rollingUpdate == nil || rollingUpdate.MaxSurge == nil || rollingUpdate.MaxUnavailable == nil {
+		return nil
+	}
+	// The percentages are scaled by 100, so that they are 0 only if they are 0%, like "00%"
+	maxSurge, err := intstr.GetScaledValueFromIntOrPercent(rollingUpdate.MaxSurge, 100, true)
+	if err != nil {
+		return fmt.Errorf(`the field ".spec.updateStrategy.rollingUpdate.maxSurge" is invalid: %w`, err)
+	}
+	maxUnavailable, err := intstr.GetScaledValueFromIntOrPercent(rollingUpdate.MaxUnavailable, 100, true)
+	if err != nil {
+		return fmt.Errorf(`the field ".spec.updateStrategy.rollingUpdate.maxUnavailable" is invalid: %w`, err)
+	}
+	if maxSurge == 0 && maxUnavailable == 0 {
+		return errors.New(`the field ".spec.updateStrategy.rollingUpdate.maxUnavailable" may not be 0 when ".spec.updateStrategy.rollingUpdate.maxSurge" is 0`)
+	}
+	return nil
+}
+
+func validatePVCRetentionPolicy(r *EMQX) error {
+	policy := r.Spec.CoreTemplate.Spec.PVCRetentionPolicy
+	if policy == nil {
+		return nil
+	}
+	if policy.Type == PVCRetentionPolicyDeleteAfter && (policy.DeleteAfter == nil || policy.DeleteAfter.Duration <= 0) {
+		return errors.New(`the field ".spec.coreTemplate.spec.pvcRetentionPolicy.deleteAfter" must be greater than 0 when ".spec.coreTemplate.spec.pvcRetentionPolicy.type" is DeleteAfter`)
+	}
+	return nil
+}
+
+func validateBootstrapAPIKeys(new, old *EMQX) error {
+	if !reflect.DeepEqual(new.Spec.BootstrapAPIKeys, old.Spec.BootstrapAPIKeys) {
+		return errors.New(`refuse to update the field ".spec.bootstrapAPIKeys"`)
+	}
+	return nil
+}
+
+func validateVolumeClaimTemplates(new, old *EMQX) error {
+	newVCT := new.Spec.CoreTemplate.Spec.VolumeClaimTemplates.DeepCopy()
+	oldVCT := old.Spec.CoreTemplate.Spec.VolumeClaimTemplates.DeepCopy()
+	if newVCT.Resources.Requests.Storage().Cmp(*oldVCT.Resources.Requests.Storage()) < 0 {
+		return errors.New(`refuse to decrease the storage size of the field ".spec.coreTemplate.spec.volumeClaimTemplates"`)
+	}
+
+	// The storage size can be expanded online, but the others can not be changed
+	delete(newVCT.Resources.Requests, corev1.ResourceStorage)
+	delete(oldVCT.Resources.Requests, corev1.ResourceStorage)
+	if !reflect.DeepEqual(newVCT, oldVCT) {
+		return errors.New(`refuse to update the field ".spec.coreTemplate.spec.volumeClaimTemplates"`)
+	}
+	return nil
+}
@@ -0,0 +1,423 @@
+/*
+Copyright 2021.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2beta1
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/utils/ptr"
+)
+
+func TestEMQXDefault(t *testing.T) {
+	instance := &EMQX{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   "emqx",
+			Labels: map[string]string{"foo": "bar"},
+		},
+		Spec: EMQXSpec{
+			DashboardServiceTemplate: &ServiceTemplate{},
+			ListenersServiceTemplate: &ServiceTemplate{Enabled: ptr.To(false)},
+			DashboardIngress:         &RouteTemplate{},
+			WebsocketRoutes:          &RouteTemplate{Kind: "TLSRoute"},
+		},
+	}
+	instance.Default()
+
+	assert.Equal(t, map[string]string{
+		"foo":              "bar",
+		LabelsInstanceKey:  "emqx",
+		LabelsManagedByKey: "emqx-operator",
+	}, instance.Labels)
+	assert.Equal(t, ptr.To(true), instance.Spec.DashboardServiceTemplate.Enabled)
+	assert.Equal(t, ptr.To(false), instance.Spec.ListenersServiceTemplate.Enabled)
+	assert.Equal(t, &RouteTemplate{Kind: "Ingress", Path: "/"}, instance.Spec.DashboardIngress)
+	assert.Equal(t, &RouteTemplate{Kind: "TLSRoute"}, instance.Spec.WebsocketRoutes)
+}
+
+func TestEMQXValidateCreate(t *testing.T) {
+	instance := &EMQX{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "emqx",
+		},
+		Spec: EMQXSpec{
+			Config: Config{
+				Data: `listeners.tcp.default.bind = 1883`,
+			},
+			CoreTemplate: EMQXCoreTemplate{
+				Spec: EMQXCoreTemplateSpec{
+					EMQXReplicantTemplateSpec: EMQXReplicantTemplateSpec{
+						Replicas: ptr.To(int32(2)),
+					},
+				},
+			},
+		},
+	}
+	_, err := instance.ValidateCreate()
+	assert.NoError(t, err)
+
+	t.Run("invalid config", func(t *testing.T) {
+		e := instance.DeepCopy()
+		e.Spec.Config.Data = `listeners.tcp.default.bind = {`
+		_, err := e.ValidateCreate()
+		assert.ErrorContains(t, err, "is not a valid HOCON config")
+	})
+
+	t.Run("dashboard port conflicts with listener port", func(t *testing.T) {
+		e := instance.DeepCopy()
+		e.Spec.Config.Data = `listeners.tcp.default.bind = 18083`
+		_, err := e.ValidateCreate()
+		assert.ErrorContains(t, err, "conflicts with the listener tcp-default")
+
+		e.Spec.Config.Data = `
+		dashboard.listeners.http.bind = 18084
+		listeners.tcp.default.bind = 18083
+		`
+		_, err = e.ValidateCreate()
+		assert.NoError(t, err)
+	})
+
+	t.Run("replicant template without core nodes", func(t *testing.T) {
+		e := instance.DeepCopy()
+		e.Spec.CoreTemplate.Spec.Replicas = ptr.To(int32(0))
+		e.Spec.ReplicantTemplate = &EMQXReplicantTemplate{
+			Spec: EMQXReplicantTemplateSpec{
+				Replicas: ptr.To(int32(3)),
+			},
+		}
+		_, err := e.ValidateCreate()
+		assert.ErrorContains(t, err, `".spec.coreTemplate.spec.replicas" must be greater than 0`)
+
+		e.Spec.ReplicantTemplate.Spec.Replicas = ptr.To(int32(0))
+		_, err = e.ValidateCreate()
+		assert.NoError(t, err)
+	})
+
+	t.Run("monitoring without basic auth", func(t *testing.T) {
+		e := instance.DeepCopy()
+		e.Spec.Config.Data = `prometheus.enable_basic_auth = true`
+		e.Spec.Monitoring = &Monitoring{Kind: "PodMonitor"}
+		_, err := e.ValidateCreate()
+		assert.ErrorContains(t, err, `".spec.monitoring.basicAuth" must be set`)
+
+		e.Spec.Monitoring.BasicAuth = &SecretRef{
+			Key:    KeyRef{SecretName: "prometheus", SecretKey: "username"},
+			Secret: KeyRef{SecretName: "prometheus", SecretKey: "password"},
+		}
+		_, err = e.ValidateCreate()
+		assert.NoError(t, err)
+	})
+
+	t.Run("invalid autoscaling", func(t *testing.T) {
+		e := instance.DeepCopy()
+		e.Spec.ReplicantTemplate = &EMQXReplicantTemplate{
+			Spec: EMQXReplicantTemplateSpec{
+				Replicas: ptr.To(int32(2)),
+			},
+			Autoscaling: &ReplicantAutoscaling{
+				MinReplicas: ptr.To(int32(2)),
+				MaxReplicas: 5,
+			},
+		}
+		_, err := e.ValidateCreate()
+		assert.ErrorContains(t, err, `must be set`)
+
+		e.Spec.ReplicantTemplate.Autoscaling.TargetConnectionsPerNode = ptr.To(int64(10000))
+		_, err = e.ValidateCreate()
+		assert.NoError(t, err)
+
+		e.Spec.ReplicantTemplate.Autoscaling.MinReplicas = ptr.To(int32(6))
+		_, err = e.ValidateCreate()
+		assert.ErrorContains(t, err, `must not be greater than`)
+	})
+
+	t.Run("invalid listeners", func(t *testing.T) {
+		e := instance.DeepCopy()
+		e.Spec.Listeners = []Listener{
+			{Name: "internal", Type: "tcp", Port: 11883},
+			{Name: "default", Type: "quic", Port: 14567},
+		}
+		_, err := e.ValidateCreate()
+		assert.NoError(t, err)
+
+		e.Spec.Listeners[1].Name = "very-long-name"
+		_, err = e.ValidateCreate()
+		assert.ErrorContains(t, err, `must be no more than 15 characters`)
+
+		e.Spec.Listeners[1].Name = "default"
+		e.Spec.Listeners[0].TLSSecretName = "emqx-tls"
+		_, err = e.ValidateCreate()
+		assert.ErrorContains(t, err, `just works for the ssl, wss and quic listeners`)
+
+		e.Spec.Listeners[0].TLSSecretName = ""
+		e.Spec.Listeners[1].ProxyProtocol = true
+		_, err = e.ValidateCreate()
+		assert.ErrorContains(t, err, `is not supported by the quic listener`)
+
+		e.Spec.Listeners[1].ProxyProtocol = false
+		e.Spec.Listeners[1].Port = 11883
+		_, err = e.ValidateCreate()
+		assert.ErrorContains(t, err, `conflicts with the listener tcp-internal`)
+
+		e.Spec.Listeners[1].Enable = ptr.To(false)
+		_, err = e.ValidateCreate()
+		assert.NoError(t, err)
+
+		e.Spec.Listeners[0].Port = 18083
+		_, err = e.ValidateCreate()
+		assert.ErrorContains(t, err, `the port 18083 of dashboard conflicts with the listener tcp-internal`)
+	})
+
+	t.Run("invalid extra listeners service templates", func(t *testing.T) {
+		e := instance.DeepCopy()
+		e.Spec.ExtraListenersServiceTemplates = []ListenersServiceTemplate{
+			{Name: "mqtt", Listeners: []string{"tcp-default", "ssl-default"}},
+			{Name: "ws", Listeners: []string{"ws-default"}},
+		}
+		_, err := e.ValidateCreate()
+		assert.NoError(t, err)
+
+		e.Spec.ExtraListenersServiceTemplates[1].Listeners = append(e.Spec.ExtraListenersServiceTemplates[1].Listeners, "tcp-default")
+		_, err = e.ValidateCreate()
+		assert.ErrorContains(t, err, `the listener "tcp-default" is served by both of the extra listeners services "mqtt" and "ws"`)
+	})
+
+	t.Run("invalid routes", func(t *testing.T) {
+		e := instance.DeepCopy()
+		e.Spec.DashboardIngress = &RouteTemplate{Kind: "Ingress", Path: "/", TLS: &RouteTLS{SecretName: "emqx-tls"}}
+		e.Spec.WebsocketRoutes = &RouteTemplate{Kind: "HTTPRoute", Path: "/mqtt", ParentRefs: []ParentReference{{Name: "gateway"}}}
+		_, err := e.ValidateCreate()
+		assert.NoError(t, err)
+
+		e.Spec.DashboardIngress.ParentRefs = []ParentReference{{Name: "gateway"}}
+		_, err = e.ValidateCreate()
+		assert.ErrorContains(t, err, `the field ".spec.dashboardIngress.parentRefs" is not supported by the Ingress`)
+
+		e.Spec.DashboardIngress.ParentRefs = nil
+		e.Spec.WebsocketRoutes.ParentRefs = nil
+		_, err = e.ValidateCreate()
+		assert.ErrorContains(t, err, `the field ".spec.websocketRoutes.parentRefs" is required by the HTTPRoute`)
+
+		e.Spec.WebsocketRoutes.ParentRefs = []ParentReference{{Name: "gateway"}}
+		e.Spec.WebsocketRoutes.TLS = &RouteTLS{SecretName: "emqx-tls"}
+		_, err = e.ValidateCreate()
+		assert.ErrorContains(t, err, `the field ".spec.websocketRoutes.tls" is not supported by the HTTPRoute`)
+
+		e.Spec.WebsocketRoutes.TLS = nil
+		e.Spec.WebsocketRoutes.Kind = "TLSRoute"
+		_, err = e.ValidateCreate()
+		assert.ErrorContains(t, err, `the field ".spec.websocketRoutes.path" is not supported by the TLSRoute`)
+
+		e.Spec.WebsocketRoutes.Path = ""
+		e.Spec.WebsocketRoutes.Kind = "TCPRoute"
+		e.Spec.WebsocketRoutes.Hosts = []string{"mqtt.example.com"}
+		_, err = e.ValidateCreate()
+		assert.ErrorContains(t, err, `the field ".spec.websocketRoutes.hosts" is not supported by the TCPRoute`)
+	})
+
+	t.Run("invalid authentication", func(t *testing.T) {
+		e := instance.DeepCopy()
+		e.Spec.Authentication = []Authenticator{
+			{Backend: "built_in_database"},
+			{Mechanism: "jwt", JWT: &JWTAuthenticator{Algorithm: "hmac-based", Secret: &KeyRef{SecretName: "jwt", SecretKey: "secret"}}},
+		}
+		_, err := e.ValidateCreate()
+		assert.NoError(t, err)
+
+		e.Spec.Authentication = append(e.Spec.Authentication, Authenticator{Mechanism: "password_based", Backend: "built_in_database"})
+		_, err = e.ValidateCreate()
+		assert.ErrorContains(t, err, `the authenticator "password_based:built_in_database" is duplicated`)
+
+		e.Spec.Authentication[2] = Authenticator{Backend: "redis"}
+		_, err = e.ValidateCreate()
+		assert.ErrorContains(t, err, `the field "redis" is required by "password_based:redis"`)
+
+		e.Spec.Authentication[2].HTTP = &HTTPAuthSource{URL: "http://auth:8080"}
+		e.Spec.Authentication[2].Redis = &RedisAuthSource{Server: "redis:6379", Cmd: "HMGET mqtt_user:${username} password_hash"}
+		_, err = e.ValidateCreate()
+		assert.ErrorContains(t, err, `the field "http" is not supported by "password_based:redis"`)
+
+		e.Spec.Authentication[2].HTTP = nil
+		e.Spec.Authentication[1].JWT.Secret = nil
+		_, err = e.ValidateCreate()
+		assert.ErrorContains(t, err, `the field "jwt.secret" is required by the hmac-based algorithm`)
+
+		e.Spec.Authentication[1].JWT.UseJWKS = true
+		_, err = e.ValidateCreate()
+		assert.ErrorContains(t, err, `the field "jwt.endpoint" is required when "jwt.useJWKS" is true`)
+	})
+
+	t.Run("invalid authorization", func(t *testing.T) {
+		e := instance.DeepCopy()
+		e.Spec.Authorization = &Authorization{
+			Sources: []AuthorizationSource{
+				{Type: "file", Rules: "{allow, all}."},
+				{Type: "redis", Redis: &RedisAuthSource{RedisType: "sentinel", Server: "redis:26379", Cmd: "HGETALL mqtt_acl:${username}"}},
+			},
+		}
+		_, err := e.ValidateCreate()
+		assert.ErrorContains(t, err, `the field "redis.sentinel" is required by the sentinel redis of "redis"`)
+
+		e.Spec.Authorization.Sources[1].Redis.Sentinel = "mymaster"
+		_, err = e.ValidateCreate()
+		assert.NoError(t, err)
+
+		e.Spec.Authorization.Sources[0].HTTP = &HTTPAuthSource{URL: "http://authz:8080"}
+		_, err = e.ValidateCreate()
+		assert.ErrorContains(t, err, `the field "http" is not supported by "file"`)
+	})
+
+	t.Run("invalid tls", func(t *testing.T) {
+		e := instance.DeepCopy()
+		e.Spec.TLS = &TLS{
+			Listeners: &TLSCertificate{},
+		}
+		_, err := e.ValidateCreate()
+		assert.ErrorContains(t, err, `".spec.tls.listeners.secretName" and ".spec.tls.listeners.issuerRef" must be set`)
+
+		e.Spec.TLS.Listeners.SecretName = "emqx-tls"
+		_, err = e.ValidateCreate()
+		assert.NoError(t, err)
+
+		e.Spec.TLS.Dashboard = &TLSCertificate{
+			SecretName: "emqx-tls",
+			IssuerRef:  &IssuerRef{Name: "ca-issuer"},
+		}
+		_, err = e.ValidateCreate()
+		assert.ErrorContains(t, err, `".spec.tls.dashboard.secretName" and ".spec.tls.dashboard.issuerRef" must be set`)
+	})
+
+	t.Run("maxSurge and maxUnavailable are both 0", func(t *testing.T) {
+		e := instance.DeepCopy()
+		e.Spec.UpdateStrategy.RollingUpdate = &RollingUpdateStrategy{
+			MaxSurge:       ptr.To(intstr.FromString("0%")),
+			MaxUnavailable: ptr.To(intstr.FromInt(0)),
+		}
+		_, err := e.ValidateCreate()
+		assert.ErrorContains(t, err, `may not be 0`)
+
+		e.Spec.UpdateStrategy.RollingUpdate.MaxSurge = ptr.To(intstr.FromString("00%"))
+		_, err = e.ValidateCreate()
+		assert.ErrorContains(t, err, `may not be 0`)
+
+		e.Spec.UpdateStrategy.RollingUpdate.MaxUnavailable = ptr.To(intstr.FromInt(1))
+		_, err = e.ValidateCreate()
+		assert.NoError(t, err)
+
+		e.Spec.UpdateStrategy.RollingUpdate.MaxSurge = ptr.To(intstr.FromString("a%"))
+		_, err = e.ValidateCreate()
+		assert.ErrorContains(t, err, `the field ".spec.updateStrategy.rollingUpdate.maxSurge" is invalid`)
+	})
+
+	t.Run("invalid config sources", func(t *testing.T) {
+		e := instance.DeepCopy()
+		e.Spec.Config.From = []ConfigSource{{}}
+		_, err := e.ValidateCreate()
+		assert.ErrorContains(t, err, `only one of the fields ".spec.config.from[0].configMapKeyRef" and ".spec.config.from[0].secretKeyRef" must be set`)
+
+		e.Spec.Config.From[0].SecretKeyRef = &corev1.SecretKeySelector{
+			LocalObjectReference: corev1.LocalObjectReference{Name: "emqx-config"},
+			Key:                  "emqx.conf",
+		}
+		_, err = e.ValidateCreate()
+		assert.NoError(t, err)
+	})
+
+	t.Run("deleteAfter of pvcRetentionPolicy is not set", func(t *testing.T) {
+		e := instance.DeepCopy()
+		e.Spec.CoreTemplate.Spec.PVCRetentionPolicy = &PVCRetentionPolicy{
+			Type: PVCRetentionPolicyDeleteAfter,
+		}
+		_, err := e.ValidateCreate()
+		assert.ErrorContains(t, err, `".spec.coreTemplate.spec.pvcRetentionPolicy.deleteAfter" must be greater than 0`)
+
+		e.Spec.CoreTemplate.Spec.PVCRetentionPolicy.DeleteAfter = &metav1.Duration{Duration: time.Hour}
+		_, err = e.ValidateCreate()
+		assert.NoError(t, err)
+	})
+}
+
+func TestEMQXValidateUpdate(t *testing.T) {
+	instance := &EMQX{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "emqx",
+		},
+		Spec: EMQXSpec{
+			BootstrapAPIKeys: []BootstrapAPIKey{
+				{Key: "test_key", Secret: "secret"},
+			},
+			CoreTemplate: EMQXCoreTemplate{
+				Spec: EMQXCoreTemplateSpec{
+					EMQXReplicantTemplateSpec: EMQXReplicantTemplateSpec{
+						Replicas: ptr.To(int32(2)),
+					},
+					VolumeClaimTemplates: corev1.PersistentVolumeClaimSpec{
+						Resources: corev1.VolumeResourceRequirements{
+							Requests: corev1.ResourceList{
+								corev1.ResourceStorage: resource.MustParse("1Gi"),
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	t.Run("should pass", func(t *testing.T) {
+		new := instance.DeepCopy()
+		new.Spec.Image = "emqx:5.1"
+		_, err := new.ValidateUpdate(instance)
+		assert.NoError(t, err)
+	})
+
+	t.Run("should return error if config is invalid", func(t *testing.T) {
+		new := instance.DeepCopy()
+		new.Spec.Config.Data = `{`
+		_, err := new.ValidateUpdate(instance)
+		assert.ErrorContains(t, err, "is not a valid HOCON config")
+	})
+
+	t.Run("should return error if bootstrapAPIKeys is changed", func(t *testing.T) {
+		new := instance.DeepCopy()
+		new.Spec.BootstrapAPIKeys[0].Secret = "new_secret"
+		_, err := new.ValidateUpdate(instance)
+		assert.ErrorContains(t, err, `refuse to update the field ".spec.bootstrapAPIKeys"`)
+	})
+
+	t.Run("should return error if volumeClaimTemplates is changed", func(t *testing.T) {
+		new := instance.DeepCopy()
+		new.Spec.CoreTemplate.Spec.VolumeClaimTemplates.StorageClassName = ptr.To("standard")
+		_, err := new.ValidateUpdate(instance)
+		assert.ErrorContains(t, err, `refuse to update the field ".spec.coreTemplate.spec.volumeClaimTemplates"`)
+	})
+
+	t.Run("should pass if the storage size of volumeClaimTemplates is increased", func(t *testing.T) {
+		new := instance.DeepCopy()
+		new.Spec.CoreTemplate.Spec.VolumeClaimTemplates.Resources.Requests[corev1.ResourceStorage] = resource.MustParse("2Gi")
+		_, err := new.ValidateUpdate(instance)
+		assert.NoError(t, err)
+	})
+
+	t.Run("should return error if the storage size of volumeClaimTemplates is decreased", func(t *testing.T) {
+		new := instance.DeepCopy()
+		new.Spec.CoreTemplate.Spec.VolumeClaimTemplates.Resources.Requests[corev1.ResourceStorage] = resource.MustParse("512Mi")
+		_, err := new.ValidateUpdate(instance)
+		assert.ErrorContains(t, err, `refuse to decrease the storage size of the field ".spec.coreTemplate.spec.volumeClaimTemplates"`)
+	})
+}
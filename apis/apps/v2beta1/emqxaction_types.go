@@ -0,0 +1,133 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2beta1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// EMQXActionSpec defines the desired state of EMQXAction
+type EMQXActionSpec struct {
+	// InstanceName represents the name of EMQX CR, the action will be created in it
+	// +kubebuilder:validation:Required
+	InstanceName string `json:"instanceName"`
+	// Type is the type of the action, like "http", "kafka_producer" or "mysql"
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	Type string `json:"type"`
+	// Connector is the name of the connector used by the action, its type must be the same as the action,
+	// the connector can be managed by EMQXConnector
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	Connector string `json:"connector"`
+	// Config is the config of the action in the EMQX actions API, like "parameters", except the "type", the "name" and the "connector"
+	// More info: https://docs.emqx.com/en/emqx/latest/admin/api-docs.html
+	// +kubebuilder:pruning:PreserveUnknownFields
+	Config runtime.RawExtension `json:"config,omitempty"`
+	// SecretConfig are the config fields whose values are loaded from the secrets,
+	// the keys are the dotted paths of the fields, like "password" or "authentication.password"
+	SecretConfig map[string]KeyRef `json:"secretConfig,omitempty"`
+}
+
+// EMQXActionStatus defines the observed state of EMQXAction
+type EMQXActionStatus struct {
+	// Phase represents the phase of EMQXAction.
+	Phase ActionPhase `json:"phase,omitempty"`
+	// Message represents the reason of the failure.
+	Message string `json:"message,omitempty"`
+	// Status is the status of the action reported by EMQX, like "connected" or "disconnected".
+	Status string `json:"status,omitempty"`
+	// ConfigHash is the hash of the config and the versions of the secrets which were applied to EMQX,
+	// the action is updated when the config or the secrets are changed.
+	ConfigHash string `json:"configHash,omitempty"`
+	// LastSyncedTime represents the last time the action was applied to EMQX.
+	LastSyncedTime *metav1.Time `json:"lastSyncedTime,omitempty"`
+}
+
+type ActionPhase string
+
+const (
+	ActionPhaseSynced ActionPhase = "Synced"
+	ActionPhaseFailed ActionPhase = "Failed"
+)
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:storageversion
+// +kubebuilder:resource:shortName=emqxaction
+// +kubebuilder:printcolumn:name="Instance",type="string",JSONPath=".spec.instanceName"
+// +kubebuilder:printcolumn:name="Type",type="string",JSONPath=".spec.type"
+// +kubebuilder:printcolumn:name="Connection",type="string",JSONPath=".status.status"
+// +kubebuilder:printcolumn:name="Status",type="string",JSONPath=".status.phase"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+// EMQXAction is the Schema for the emqxactions API
+type EMQXAction struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   EMQXActionSpec   `json:"spec,omitempty"`
+	Status EMQXActionStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// EMQXActionList contains a list of EMQXAction
+type EMQXActionList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []EMQXAction `json:"items"`
+}
+
+// ID returns the ID of the action in the EMQX actions API
+func (c *EMQXAction) ID() string {
+	return c.Spec.Type + ":" + c.Name
+}
+
+func (s *EMQXActionStatus) SetSynced(status, configHash string) {
+	if s.ConfigHash != configHash || s.Phase != ActionPhaseSynced {
+		now := metav1.Now()
+		s.LastSyncedTime = &now
+	}
+	s.Phase = ActionPhaseSynced
+	s.Message = ""
+	s.Status = status
+	s.ConfigHash = configHash
+}
+
+func (s *EMQXActionStatus) SetFailed(message string) {
+	s.Phase = ActionPhaseFailed
+	s.Message = message
+}
+
+// GetInstanceName returns the name of the EMQX cluster which the EMQXAction is applied to
+func (a *EMQXAction) GetInstanceName() string {
+	return a.Spec.InstanceName
+}
+
+// IsFailed returns whether the EMQXAction is failed with the message
+func (a *EMQXAction) IsFailed(message string) bool {
+	return a.Status.Phase == ActionPhaseFailed && a.Status.Message == message
+}
+
+func (a *EMQXAction) SetFailed(message string) {
+	a.Status.SetFailed(message)
+}
+
+func init() {
+	SchemeBuilder.Register(&EMQXAction{}, &EMQXActionList{})
+}
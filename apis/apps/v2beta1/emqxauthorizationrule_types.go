@@ -0,0 +1,139 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2beta1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// EMQXAuthorizationRuleSpec defines the desired state of EMQXAuthorizationRule
+// +kubebuilder:validation:XValidation:rule="!(has(self.username) && has(self.clientId))",message="only one of username and clientId can be set"
+type EMQXAuthorizationRuleSpec struct {
+	// InstanceName represents the name of EMQX CR, the rules will be applied to its built-in database authorization source
+	// +kubebuilder:validation:Required
+	InstanceName string `json:"instanceName"`
+	// Username represents the rules are applied to the clients with the username,
+	// only one of username and clientId can be set, the rules are applied to all clients if neither is set
+	Username string `json:"username,omitempty"`
+	// ClientID represents the rules are applied to the client with the client ID,
+	// only one of username and clientId can be set, the rules are applied to all clients if neither is set
+	ClientID string `json:"clientId,omitempty"`
+	// Rules are checked in order, the first matched rule decides whether the client is allowed
+	// +kubebuilder:validation:MinItems=1
+	Rules []AuthorizationRule `json:"rules"`
+}
+
+type AuthorizationRule struct {
+	// +kubebuilder:validation:Enum=allow;deny
+	// +kubebuilder:validation:Required
+	Permission string `json:"permission"`
+	// +kubebuilder:validation:Enum=publish;subscribe;all
+	// +kubebuilder:validation:Required
+	Action string `json:"action"`
+	// Topic is the topic filter, the placeholders like "${username}" and "${clientid}" are supported
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	Topic string `json:"topic"`
+}
+
+// EMQXAuthorizationRuleStatus defines the observed state of EMQXAuthorizationRule
+type EMQXAuthorizationRuleStatus struct {
+	// Phase represents the phase of EMQXAuthorizationRule.
+	Phase AuthorizationRulePhase `json:"phase,omitempty"`
+	// Message represents the reason of the failure.
+	Message string `json:"message,omitempty"`
+	// Target is the target of the rules which were applied to EMQX, like "users/<username>", "clients/<clientid>" or "all",
+	// the rules of the old target are deleted from EMQX when the target is changed.
+	Target string `json:"target,omitempty"`
+	// LastSyncedTime represents the last time the rules were applied to EMQX.
+	LastSyncedTime *metav1.Time `json:"lastSyncedTime,omitempty"`
+}
+
+type AuthorizationRulePhase string
+
+const (
+	AuthorizationRulePhaseSynced AuthorizationRulePhase = "Synced"
+	AuthorizationRulePhaseFailed AuthorizationRulePhase = "Failed"
+)
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:storageversion
+// +kubebuilder:resource:shortName=emqxauthzrule
+// +kubebuilder:printcolumn:name="Instance",type="string",JSONPath=".spec.instanceName"
+// +kubebuilder:printcolumn:name="Target",type="string",JSONPath=".status.target"
+// +kubebuilder:printcolumn:name="Status",type="string",JSONPath=".status.phase"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+// EMQXAuthorizationRule is the Schema for the emqxauthorizationrules API
+type EMQXAuthorizationRule struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   EMQXAuthorizationRuleSpec   `json:"spec,omitempty"`
+	Status EMQXAuthorizationRuleStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// EMQXAuthorizationRuleList contains a list of EMQXAuthorizationRule
+type EMQXAuthorizationRuleList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []EMQXAuthorizationRule `json:"items"`
+}
+
+// Target returns the target of the rules in the built-in database authorization source
+func (s *EMQXAuthorizationRuleSpec) Target() string {
+	if s.Username != "" {
+		return "users/" + s.Username
+	}
+	if s.ClientID != "" {
+		return "clients/" + s.ClientID
+	}
+	return "all"
+}
+
+func (s *EMQXAuthorizationRuleStatus) SetSynced(target string) {
+	now := metav1.Now()
+	s.Phase = AuthorizationRulePhaseSynced
+	s.Message = ""
+	s.Target = target
+	s.LastSyncedTime = &now
+}
+
+func (s *EMQXAuthorizationRuleStatus) SetFailed(message string) {
+	s.Phase = AuthorizationRulePhaseFailed
+	s.Message = message
+}
+
+// GetInstanceName returns the name of the EMQX cluster which the EMQXAuthorizationRule is applied to
+func (r *EMQXAuthorizationRule) GetInstanceName() string {
+	return r.Spec.InstanceName
+}
+
+// IsFailed returns whether the EMQXAuthorizationRule is failed with the message
+func (r *EMQXAuthorizationRule) IsFailed(message string) bool {
+	return r.Status.Phase == AuthorizationRulePhaseFailed && r.Status.Message == message
+}
+
+func (r *EMQXAuthorizationRule) SetFailed(message string) {
+	r.Status.SetFailed(message)
+}
+
+func init() {
+	SchemeBuilder.Register(&EMQXAuthorizationRule{}, &EMQXAuthorizationRuleList{})
+}
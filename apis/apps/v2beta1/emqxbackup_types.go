@@ -0,0 +1,144 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2beta1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// EMQXBackupSpec defines the desired state of EMQXBackup
+type EMQXBackupSpec struct {
+	// InstanceName represents the name of EMQX CR, the data of this EMQX cluster will be exported
+	// +kubebuilder:validation:Required
+	InstanceName string `json:"instanceName"`
+	// Storage represents where the exported archive will be stored,
+	// only one of persistentVolumeClaim and s3 can be set
+	// +kubebuilder:validation:Required
+	Storage BackupStorage `json:"storage"`
+}
+
+type BackupStorage struct {
+	// PersistentVolumeClaim stores the archive in the PVC,
+	// EMQX Operator will create a Job to download the archive to the PVC
+	PersistentVolumeClaim *PVCBackupStorage `json:"persistentVolumeClaim,omitempty"`
+	// S3 stores the archive in the S3 compatible object storage, like AWS S3 or MinIO
+	S3 *S3BackupStorage `json:"s3,omitempty"`
+}
+
+type PVCBackupStorage struct {
+	// ClaimName is the name of the PVC, it must be in the same namespace as the EMQXBackup
+	// +kubebuilder:validation:Required
+	ClaimName string `json:"claimName"`
+	// Image is used by the Job to download the archive, it must contain the curl and the sh
+	// +kubebuilder:default:="curlimages/curl:8.5.0"
+	Image string `json:"image,omitempty"`
+}
+
+type S3BackupStorage struct {
+	// Endpoint is the host and the port of the S3 compatible object storage, like "s3.amazonaws.com" or "minio.default.svc:9000"
+	// +kubebuilder:validation:Required
+	Endpoint string `json:"endpoint"`
+	// +kubebuilder:validation:Required
+	Bucket string `json:"bucket"`
+	Region string `json:"region,omitempty"`
+	// Prefix is prepended to the name of the archive in the bucket
+	Prefix string `json:"prefix,omitempty"`
+	// Insecure uses the HTTP instead of the HTTPS to connect the object storage
+	Insecure bool `json:"insecure,omitempty"`
+	// CredentialsRef references the access key and the secret key of the object storage,
+	// the key of it is the access key, and the secret of it is the secret key
+	// +kubebuilder:validation:Required
+	CredentialsRef SecretRef `json:"credentialsRef"`
+}
+
+// EMQXBackupStatus defines the observed state of EMQXBackup
+type EMQXBackupStatus struct {
+	// Phase represents the phase of EMQXBackup.
+	Phase BackupPhase `json:"phase,omitempty"`
+	// Message represents the reason of the failure.
+	Message string `json:"message,omitempty"`
+	// Retries represents the number of the retries after the transient failures, like the EMQX API is unavailable.
+	Retries int32 `json:"retries,omitempty"`
+	// Node represents the EMQX node which exported the archive.
+	Node string `json:"node,omitempty"`
+	// Filename represents the name of the exported archive.
+	Filename string `json:"filename,omitempty"`
+	// Size represents the size of the exported archive in bytes.
+	Size int64 `json:"size,omitempty"`
+	// Location represents where the archive is stored, like "pvc://<claimName>/<filename>" or "s3://<bucket>/<key>".
+	Location string `json:"location,omitempty"`
+	// CreatedAt represents the time when the archive was exported.
+	CreatedAt metav1.Time `json:"createdAt,omitempty"`
+	// CompletedTime represents the time when the archive was stored.
+	CompletedTime metav1.Time `json:"completedTime,omitempty"`
+}
+
+type BackupPhase string
+
+const (
+	BackupPhaseProcessing BackupPhase = "Processing"
+	BackupPhaseCompleted  BackupPhase = "Completed"
+	BackupPhaseFailed     BackupPhase = "Failed"
+)
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:storageversion
+// +kubebuilder:resource:shortName=emqxbk
+// +kubebuilder:printcolumn:name="Instance",type="string",JSONPath=".spec.instanceName"
+// +kubebuilder:printcolumn:name="Status",type="string",JSONPath=".status.phase"
+// +kubebuilder:printcolumn:name="Size",type="integer",JSONPath=".status.size"
+// +kubebuilder:printcolumn:name="Location",type="string",JSONPath=".status.location",priority=1
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+// EMQXBackup is the Schema for the emqxbackups API
+type EMQXBackup struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   EMQXBackupSpec   `json:"spec,omitempty"`
+	Status EMQXBackupStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// EMQXBackupList contains a list of EMQXBackup
+type EMQXBackupList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []EMQXBackup `json:"items"`
+}
+
+func (s *EMQXBackupStatus) SetFailed(message string) {
+	s.Phase = BackupPhaseFailed
+	s.Message = message
+	s.CompletedTime = metav1.Now()
+}
+
+func (s *EMQXBackupStatus) SetCompleted(location string) {
+	s.Phase = BackupPhaseCompleted
+	s.Message = ""
+	s.Location = location
+	s.CompletedTime = metav1.Now()
+}
+
+func (s *EMQXBackupStatus) IsFinished() bool {
+	return s.Phase == BackupPhaseCompleted || s.Phase == BackupPhaseFailed
+}
+
+func init() {
+	SchemeBuilder.Register(&EMQXBackup{}, &EMQXBackupList{})
+}
@@ -0,0 +1,83 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2beta1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// EMQXBackupScheduleSpec defines the desired state of EMQXBackupSchedule
+type EMQXBackupScheduleSpec struct {
+	// Schedule is the cron expression of the backup, like "0 2 * * *"
+	// More info: https://en.wikipedia.org/wiki/Cron
+	// +kubebuilder:validation:Required
+	Schedule string `json:"schedule"`
+	// Suspend tells the controller to suspend the subsequent backups
+	Suspend bool `json:"suspend,omitempty"`
+	// BackupTemplate is the spec of the EMQXBackup which will be created by the schedule
+	// +kubebuilder:validation:Required
+	BackupTemplate EMQXBackupSpec `json:"backupTemplate"`
+	// Retention represents how many and how long the EMQXBackups created by the schedule will be kept,
+	// the archive will be deleted together with the EMQXBackup
+	Retention BackupRetention `json:"retention,omitempty"`
+}
+
+type BackupRetention struct {
+	// MaxCount is the max number of the completed EMQXBackups to keep
+	// +kubebuilder:validation:Minimum=1
+	MaxCount *int32 `json:"maxCount,omitempty"`
+	// MaxAge is the max age of the EMQXBackups to keep, like "168h"
+	MaxAge *metav1.Duration `json:"maxAge,omitempty"`
+}
+
+// EMQXBackupScheduleStatus defines the observed state of EMQXBackupSchedule
+type EMQXBackupScheduleStatus struct {
+	// LastScheduleTime represents the last time the EMQXBackup was created by the schedule
+	LastScheduleTime metav1.Time `json:"lastScheduleTime,omitempty"`
+	// LastSuccessfulTime represents the last time the EMQXBackup created by the schedule was completed
+	LastSuccessfulTime metav1.Time `json:"lastSuccessfulTime,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:storageversion
+// +kubebuilder:resource:shortName=emqxbks
+// +kubebuilder:printcolumn:name="Schedule",type="string",JSONPath=".spec.schedule"
+// +kubebuilder:printcolumn:name="Suspend",type="boolean",JSONPath=".spec.suspend"
+// +kubebuilder:printcolumn:name="Last Schedule",type="date",JSONPath=".status.lastScheduleTime"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+// EMQXBackupSchedule is the Schema for the emqxbackupschedules API
+type EMQXBackupSchedule struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   EMQXBackupScheduleSpec   `json:"spec,omitempty"`
+	Status EMQXBackupScheduleStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// EMQXBackupScheduleList contains a list of EMQXBackupSchedule
+type EMQXBackupScheduleList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []EMQXBackupSchedule `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&EMQXBackupSchedule{}, &EMQXBackupScheduleList{})
+}
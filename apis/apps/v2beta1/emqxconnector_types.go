@@ -0,0 +1,128 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2beta1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// EMQXConnectorSpec defines the desired state of EMQXConnector
+type EMQXConnectorSpec struct {
+	// InstanceName represents the name of EMQX CR, the connector will be created in it
+	// +kubebuilder:validation:Required
+	InstanceName string `json:"instanceName"`
+	// Type is the type of the connector, like "http", "kafka_producer" or "mysql"
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	Type string `json:"type"`
+	// Config is the config of the connector in the EMQX connectors API, except the "type" and the "name"
+	// More info: https://docs.emqx.com/en/emqx/latest/admin/api-docs.html
+	// +kubebuilder:pruning:PreserveUnknownFields
+	Config runtime.RawExtension `json:"config,omitempty"`
+	// SecretConfig are the config fields whose values are loaded from the secrets,
+	// the keys are the dotted paths of the fields, like "password" or "authentication.password"
+	SecretConfig map[string]KeyRef `json:"secretConfig,omitempty"`
+}
+
+// EMQXConnectorStatus defines the observed state of EMQXConnector
+type EMQXConnectorStatus struct {
+	// Phase represents the phase of EMQXConnector.
+	Phase ConnectorPhase `json:"phase,omitempty"`
+	// Message represents the reason of the failure.
+	Message string `json:"message,omitempty"`
+	// Status is the status of the connector reported by EMQX, like "connected" or "disconnected".
+	Status string `json:"status,omitempty"`
+	// ConfigHash is the hash of the config and the versions of the secrets which were applied to EMQX,
+	// the connector is updated when the config or the secrets are changed.
+	ConfigHash string `json:"configHash,omitempty"`
+	// LastSyncedTime represents the last time the connector was applied to EMQX.
+	LastSyncedTime *metav1.Time `json:"lastSyncedTime,omitempty"`
+}
+
+type ConnectorPhase string
+
+const (
+	ConnectorPhaseSynced ConnectorPhase = "Synced"
+	ConnectorPhaseFailed ConnectorPhase = "Failed"
+)
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:storageversion
+// +kubebuilder:resource:shortName=emqxconnector
+// +kubebuilder:printcolumn:name="Instance",type="string",JSONPath=".spec.instanceName"
+// +kubebuilder:printcolumn:name="Type",type="string",JSONPath=".spec.type"
+// +kubebuilder:printcolumn:name="Connection",type="string",JSONPath=".status.status"
+// +kubebuilder:printcolumn:name="Status",type="string",JSONPath=".status.phase"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+// EMQXConnector is the Schema for the emqxconnectors API
+type EMQXConnector struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   EMQXConnectorSpec   `json:"spec,omitempty"`
+	Status EMQXConnectorStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// EMQXConnectorList contains a list of EMQXConnector
+type EMQXConnectorList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []EMQXConnector `json:"items"`
+}
+
+// ID returns the ID of the connector in the EMQX connectors API
+func (c *EMQXConnector) ID() string {
+	return c.Spec.Type + ":" + c.Name
+}
+
+func (s *EMQXConnectorStatus) SetSynced(status, configHash string) {
+	if s.ConfigHash != configHash || s.Phase != ConnectorPhaseSynced {
+		now := metav1.Now()
+		s.LastSyncedTime = &now
+	}
+	s.Phase = ConnectorPhaseSynced
+	s.Message = ""
+	s.Status = status
+	s.ConfigHash = configHash
+}
+
+func (s *EMQXConnectorStatus) SetFailed(message string) {
+	s.Phase = ConnectorPhaseFailed
+	s.Message = message
+}
+
+// GetInstanceName returns the name of the EMQX cluster which the EMQXConnector is applied to
+func (c *EMQXConnector) GetInstanceName() string {
+	return c.Spec.InstanceName
+}
+
+// IsFailed returns whether the EMQXConnector is failed with the message
+func (c *EMQXConnector) IsFailed(message string) bool {
+	return c.Status.Phase == ConnectorPhaseFailed && c.Status.Message == message
+}
+
+func (c *EMQXConnector) SetFailed(message string) {
+	c.Status.SetFailed(message)
+}
+
+func init() {
+	SchemeBuilder.Register(&EMQXConnector{}, &EMQXConnectorList{})
+}
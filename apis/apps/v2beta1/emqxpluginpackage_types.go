@@ -0,0 +1,174 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2beta1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// EMQXPluginPackageSpec defines the desired state of EMQXPluginPackage
+type EMQXPluginPackageSpec struct {
+	// InstanceName represents the name of EMQX CR, the plugin will be installed on all nodes of it
+	// +kubebuilder:validation:Required
+	InstanceName string `json:"instanceName"`
+	// NameVsn is the name and the version of the plugin, like "emqx_plugin_template-5.0.0",
+	// it must be the same as the name of the package without the ".tar.gz" suffix
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Pattern:=`^[a-zA-Z0-9_]+-[a-zA-Z0-9._-]+$`
+	NameVsn string `json:"nameVsn"`
+	// Source is where the package of the plugin is loaded from, only one of url, configMap and image can be set
+	// +kubebuilder:validation:Required
+	Source PluginSource `json:"source"`
+	// Enable represents whether the plugin is started on all nodes
+	// +kubebuilder:default:=true
+	Enable *bool `json:"enable,omitempty"`
+	// Position is the position of the plugin in the sort order of all plugins,
+	// like "front", "rear", "before:<nameVsn>" or "after:<nameVsn>"
+	// +kubebuilder:validation:Pattern:=`^(front|rear|(before|after):.+)$`
+	Position string `json:"position,omitempty"`
+	// Config is the config of the plugin, it must match the schema of the plugin
+	// +kubebuilder:pruning:PreserveUnknownFields
+	Config runtime.RawExtension `json:"config,omitempty"`
+}
+
+type PluginSource struct {
+	// URL of the package, the package is downloaded and uploaded to EMQX by a Job with the "curlimages/curl" image
+	URL string `json:"url,omitempty"`
+	// ConfigMap contains the package in its binaryData, note the size of the ConfigMap is limited to 1MiB
+	ConfigMap *PluginConfigMapSource `json:"configMap,omitempty"`
+	// Image contains the package, the package is uploaded to EMQX by a Job
+	Image *PluginImageSource `json:"image,omitempty"`
+}
+
+type PluginConfigMapSource struct {
+	// +kubebuilder:validation:Required
+	Name string `json:"name"`
+	// +kubebuilder:validation:Required
+	Key string `json:"key"`
+}
+
+type PluginImageSource struct {
+	// Image contains the package, it must contain the cp
+	// +kubebuilder:validation:Required
+	Image string `json:"image"`
+	// Path of the package in the image
+	// +kubebuilder:validation:Required
+	Path string `json:"path"`
+	// UploaderImage is used by the Job to upload the package, it must contain the curl and the sh
+	// +kubebuilder:default:="curlimages/curl:8.5.0"
+	UploaderImage string `json:"uploaderImage,omitempty"`
+}
+
+// EMQXPluginPackageStatus defines the observed state of EMQXPluginPackage
+type EMQXPluginPackageStatus struct {
+	// Phase represents the phase of EMQXPluginPackage.
+	Phase PluginPackagePhase `json:"phase,omitempty"`
+	// Message represents the reason of the failure.
+	Message string `json:"message,omitempty"`
+	// NameVsn is the plugin which was installed, the plugin is uninstalled from EMQX when the nameVsn is changed.
+	NameVsn string `json:"nameVsn,omitempty"`
+	// ConfigHash is the hash of the position and the config which were applied to EMQX.
+	ConfigHash string `json:"configHash,omitempty"`
+	// Nodes are the status of the plugin on each node reported by EMQX.
+	Nodes []PluginNodeStatus `json:"nodes,omitempty"`
+	// LastSyncedTime represents the last time the plugin was applied to EMQX.
+	LastSyncedTime *metav1.Time `json:"lastSyncedTime,omitempty"`
+}
+
+type PluginNodeStatus struct {
+	Node string `json:"node"`
+	// Status of the plugin on the node, like "running" or "stopped".
+	Status string `json:"status"`
+}
+
+type PluginPackagePhase string
+
+const (
+	PluginPackagePhaseInstalling PluginPackagePhase = "Installing"
+	PluginPackagePhaseSynced     PluginPackagePhase = "Synced"
+	PluginPackagePhaseFailed     PluginPackagePhase = "Failed"
+)
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:storageversion
+// +kubebuilder:resource:shortName=emqxpluginpkg
+// +kubebuilder:printcolumn:name="Instance",type="string",JSONPath=".spec.instanceName"
+// +kubebuilder:printcolumn:name="Plugin",type="string",JSONPath=".spec.nameVsn"
+// +kubebuilder:printcolumn:name="Enable",type="boolean",JSONPath=".spec.enable"
+// +kubebuilder:printcolumn:name="Status",type="string",JSONPath=".status.phase"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+// EMQXPluginPackage is the Schema for the emqxpluginpackages API,
+// it manages the plugins of EMQX 5, the EmqxPlugin of apps.emqx.io/v1beta4 is only for EMQX 4
+type EMQXPluginPackage struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   EMQXPluginPackageSpec   `json:"spec,omitempty"`
+	Status EMQXPluginPackageStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// EMQXPluginPackageList contains a list of EMQXPluginPackage
+type EMQXPluginPackageList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []EMQXPluginPackage `json:"items"`
+}
+
+func (s *EMQXPluginPackageStatus) SetInstalling(nameVsn string) {
+	s.Phase = PluginPackagePhaseInstalling
+	s.Message = ""
+	s.NameVsn = nameVsn
+}
+
+func (s *EMQXPluginPackageStatus) SetSynced(nameVsn, configHash string, nodes []PluginNodeStatus) {
+	if s.Phase != PluginPackagePhaseSynced || s.ConfigHash != configHash || s.NameVsn != nameVsn {
+		now := metav1.Now()
+		s.LastSyncedTime = &now
+	}
+	s.Phase = PluginPackagePhaseSynced
+	s.Message = ""
+	s.NameVsn = nameVsn
+	s.ConfigHash = configHash
+	s.Nodes = nodes
+}
+
+func (s *EMQXPluginPackageStatus) SetFailed(message string) {
+	s.Phase = PluginPackagePhaseFailed
+	s.Message = message
+}
+
+// GetInstanceName returns the name of the EMQX cluster which the EMQXPluginPackage is applied to
+func (p *EMQXPluginPackage) GetInstanceName() string {
+	return p.Spec.InstanceName
+}
+
+// IsFailed returns whether the EMQXPluginPackage is failed with the message
+func (p *EMQXPluginPackage) IsFailed(message string) bool {
+	return p.Status.Phase == PluginPackagePhaseFailed && p.Status.Message == message
+}
+
+func (p *EMQXPluginPackage) SetFailed(message string) {
+	p.Status.SetFailed(message)
+}
+
+func init() {
+	SchemeBuilder.Register(&EMQXPluginPackage{}, &EMQXPluginPackageList{})
+}
@@ -0,0 +1,158 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2beta1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// EMQXRestoreSpec defines the desired state of EMQXRestore
+type EMQXRestoreSpec struct {
+	// InstanceName represents the name of EMQX CR, the archive will be imported into this EMQX cluster
+	// +kubebuilder:validation:Required
+	InstanceName string `json:"instanceName"`
+	// Source represents where the archive is,
+	// only one of backupName, persistentVolumeClaim, secret and s3 can be set
+	// +kubebuilder:validation:Required
+	Source RestoreSource `json:"source"`
+}
+
+type RestoreSource struct {
+	// BackupName is the name of the completed EMQXBackup in the same namespace, the archive stored by it will be imported
+	BackupName string `json:"backupName,omitempty"`
+	// PersistentVolumeClaim imports the archive in the PVC,
+	// EMQX Operator will create a Job to upload the archive to EMQX
+	PersistentVolumeClaim *PVCRestoreSource `json:"persistentVolumeClaim,omitempty"`
+	// Secret imports the archive in the Secret, the archive must be smaller than 1MiB
+	Secret *SecretRestoreSource `json:"secret,omitempty"`
+	// S3 imports the archive in the S3 compatible object storage, like AWS S3 or MinIO
+	S3 *S3RestoreSource `json:"s3,omitempty"`
+}
+
+type PVCRestoreSource struct {
+	PVCBackupStorage `json:",inline"`
+	// Filename is the name of the archive in the PVC, like "emqx-export-2024-01-01-00-00-00.000.tar.gz"
+	// +kubebuilder:validation:Required
+	Filename string `json:"filename"`
+}
+
+type SecretRestoreSource struct {
+	// +kubebuilder:validation:Required
+	SecretName string `json:"secretName"`
+	// Key is the key of the archive in the Secret, it is used as the name of the archive, so it must end with ".tar.gz"
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Pattern:=`\.tar\.gz$`
+	Key string `json:"key"`
+}
+
+type S3RestoreSource struct {
+	S3BackupStorage `json:",inline"`
+	// Key is the key of the archive in the bucket, the prefix will be prepended to it
+	// +kubebuilder:validation:Required
+	Key string `json:"key"`
+}
+
+// EMQXRestoreStatus defines the observed state of EMQXRestore
+type EMQXRestoreStatus struct {
+	// Phase represents the phase of EMQXRestore.
+	Phase RestorePhase `json:"phase,omitempty"`
+	// Message represents the reason of the failure.
+	Message string `json:"message,omitempty"`
+	// Retries represents the number of the retries after the transient failures, like the EMQX API is unavailable.
+	Retries int32 `json:"retries,omitempty"`
+	// Node represents the EMQX node which imported the archive.
+	Node string `json:"node,omitempty"`
+	// Filename represents the name of the imported archive.
+	Filename string `json:"filename,omitempty"`
+	// Failures represent the database tables and the config root keys which were failed to import,
+	// it is empty if all of them were imported.
+	Failures []RestoreFailure `json:"failures,omitempty"`
+	// StartedTime represents the time when the restore started.
+	StartedTime metav1.Time `json:"startedTime,omitempty"`
+	// CompletedTime represents the time when the restore was completed or failed.
+	CompletedTime metav1.Time `json:"completedTime,omitempty"`
+}
+
+type RestoreFailure struct {
+	// Name is the name of the database table or the config root key
+	Name string `json:"name,omitempty"`
+	// Reason is the reason of the failure returned by EMQX
+	Reason string `json:"reason"`
+}
+
+type RestorePhase string
+
+const (
+	RestorePhaseProcessing RestorePhase = "Processing"
+	RestorePhaseCompleted  RestorePhase = "Completed"
+	RestorePhaseFailed     RestorePhase = "Failed"
+)
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:storageversion
+// +kubebuilder:resource:shortName=emqxrs
+// +kubebuilder:printcolumn:name="Instance",type="string",JSONPath=".spec.instanceName"
+// +kubebuilder:printcolumn:name="Status",type="string",JSONPath=".status.phase"
+// +kubebuilder:printcolumn:name="Filename",type="string",JSONPath=".status.filename",priority=1
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+// EMQXRestore is the Schema for the emqxrestores API
+type EMQXRestore struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   EMQXRestoreSpec   `json:"spec,omitempty"`
+	Status EMQXRestoreStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// EMQXRestoreList contains a list of EMQXRestore
+type EMQXRestoreList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []EMQXRestore `json:"items"`
+}
+
+func (s *EMQXRestoreStatus) SetProcessing() {
+	s.Phase = RestorePhaseProcessing
+	if s.StartedTime.IsZero() {
+		s.StartedTime = metav1.Now()
+	}
+}
+
+func (s *EMQXRestoreStatus) SetFailed(message string, failures []RestoreFailure) {
+	s.Phase = RestorePhaseFailed
+	s.Message = message
+	s.Failures = failures
+	s.CompletedTime = metav1.Now()
+}
+
+func (s *EMQXRestoreStatus) SetCompleted() {
+	s.Phase = RestorePhaseCompleted
+	s.Message = ""
+	s.Failures = nil
+	s.CompletedTime = metav1.Now()
+}
+
+func (s *EMQXRestoreStatus) IsFinished() bool {
+	return s.Phase == RestorePhaseCompleted || s.Phase == RestorePhaseFailed
+}
+
+func init() {
+	SchemeBuilder.Register(&EMQXRestore{}, &EMQXRestoreList{})
+}
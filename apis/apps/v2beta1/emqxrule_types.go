@@ -0,0 +1,131 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2beta1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// EMQXRuleSpec defines the desired state of EMQXRule
+type EMQXRuleSpec struct {
+	// InstanceName represents the name of EMQX CR, the rule will be created in it
+	// +kubebuilder:validation:Required
+	InstanceName string `json:"instanceName"`
+	// SQL is the SQL statement of the rule, like `SELECT * FROM "t/#"`
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	SQL string `json:"sql"`
+	// Actions are the IDs of the actions which the rule outputs to, like "http:my-action",
+	// the ID of the action managed by EMQXAction is "<type>:<name>"
+	Actions []string `json:"actions,omitempty"`
+	// +kubebuilder:default:=true
+	Enable *bool `json:"enable,omitempty"`
+	// Description of the rule
+	Description string `json:"description,omitempty"`
+}
+
+// EMQXRuleStatus defines the observed state of EMQXRule
+type EMQXRuleStatus struct {
+	// Phase represents the phase of EMQXRule.
+	Phase RulePhase `json:"phase,omitempty"`
+	// Message represents the reason of the failure.
+	Message string `json:"message,omitempty"`
+	// Metrics are the metrics of the rule reported by EMQX, they are reset when the rule is updated.
+	Metrics *RuleMetrics `json:"metrics,omitempty"`
+	// ConfigHash is the hash of the rule which was applied to EMQX, the rule is updated when it is changed.
+	ConfigHash string `json:"configHash,omitempty"`
+	// LastSyncedTime represents the last time the rule was applied to EMQX.
+	LastSyncedTime *metav1.Time `json:"lastSyncedTime,omitempty"`
+}
+
+type RuleMetrics struct {
+	// Matched is the number of the messages which matched the SQL.
+	Matched int64 `json:"matched"`
+	// Passed is the number of the messages which passed the SQL.
+	Passed int64 `json:"passed"`
+	// Failed is the number of the messages which failed to execute the SQL.
+	Failed int64 `json:"failed"`
+}
+
+type RulePhase string
+
+const (
+	RulePhaseSynced RulePhase = "Synced"
+	RulePhaseFailed RulePhase = "Failed"
+)
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:storageversion
+// +kubebuilder:resource:shortName=emqxrule
+// +kubebuilder:printcolumn:name="Instance",type="string",JSONPath=".spec.instanceName"
+// +kubebuilder:printcolumn:name="Matched",type="integer",JSONPath=".status.metrics.matched"
+// +kubebuilder:printcolumn:name="Passed",type="integer",JSONPath=".status.metrics.passed"
+// +kubebuilder:printcolumn:name="Failed",type="integer",JSONPath=".status.metrics.failed"
+// +kubebuilder:printcolumn:name="Status",type="string",JSONPath=".status.phase"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+// EMQXRule is the Schema for the emqxrules API
+type EMQXRule struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   EMQXRuleSpec   `json:"spec,omitempty"`
+	Status EMQXRuleStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// EMQXRuleList contains a list of EMQXRule
+type EMQXRuleList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []EMQXRule `json:"items"`
+}
+
+func (s *EMQXRuleStatus) SetSynced(metrics *RuleMetrics, configHash string) {
+	if s.ConfigHash != configHash || s.Phase != RulePhaseSynced {
+		now := metav1.Now()
+		s.LastSyncedTime = &now
+	}
+	s.Phase = RulePhaseSynced
+	s.Message = ""
+	s.Metrics = metrics
+	s.ConfigHash = configHash
+}
+
+func (s *EMQXRuleStatus) SetFailed(message string) {
+	s.Phase = RulePhaseFailed
+	s.Message = message
+}
+
+// GetInstanceName returns the name of the EMQX cluster which the EMQXRule is applied to
+func (r *EMQXRule) GetInstanceName() string {
+	return r.Spec.InstanceName
+}
+
+// IsFailed returns whether the EMQXRule is failed with the message
+func (r *EMQXRule) IsFailed(message string) bool {
+	return r.Status.Phase == RulePhaseFailed && r.Status.Message == message
+}
+
+func (r *EMQXRule) SetFailed(message string) {
+	r.Status.SetFailed(message)
+}
+
+func init() {
+	SchemeBuilder.Register(&EMQXRule{}, &EMQXRuleList{})
+}
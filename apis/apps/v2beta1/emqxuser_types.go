@@ -0,0 +1,127 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2beta1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// EMQXUserSpec defines the desired state of EMQXUser
+type EMQXUserSpec struct {
+	// InstanceName represents the name of EMQX CR, the user will be created in its built-in database authenticator
+	// +kubebuilder:validation:Required
+	InstanceName string `json:"instanceName"`
+	// UserID is the username or the client ID of the user, it depends on the "userIdType" of the built-in database authenticator
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	UserID string `json:"userId"`
+	// Password is the secret key of the plain text password,
+	// only one of password and passwordHash can be set
+	Password *KeyRef `json:"password,omitempty"`
+	// PasswordHash is the secret keys of the password hash and the salt, they are imported into the built-in database directly,
+	// so they must be hashed by the "passwordHashAlgorithm" of the built-in database authenticator
+	PasswordHash *PasswordHashRef `json:"passwordHash,omitempty"`
+	// IsSuperuser represents whether the user is a superuser, the superuser skips the authorization checks
+	IsSuperuser bool `json:"isSuperuser,omitempty"`
+}
+
+type PasswordHashRef struct {
+	// +kubebuilder:validation:Required
+	Hash KeyRef  `json:"hash"`
+	Salt *KeyRef `json:"salt,omitempty"`
+}
+
+// EMQXUserStatus defines the observed state of EMQXUser
+type EMQXUserStatus struct {
+	// Phase represents the phase of EMQXUser.
+	Phase UserPhase `json:"phase,omitempty"`
+	// Message represents the reason of the failure.
+	Message string `json:"message,omitempty"`
+	// UserID is the ID of the user which was applied to EMQX, the user is deleted from EMQX when the userId is changed.
+	UserID string `json:"userId,omitempty"`
+	// CredentialHash is the hash of the spec and the versions of the Secrets which were applied to EMQX,
+	// the user is updated when the credential in the Secret is changed.
+	CredentialHash string `json:"credentialHash,omitempty"`
+	// LastSyncedTime represents the last time the user was applied to EMQX.
+	LastSyncedTime *metav1.Time `json:"lastSyncedTime,omitempty"`
+}
+
+type UserPhase string
+
+const (
+	UserPhaseSynced UserPhase = "Synced"
+	UserPhaseFailed UserPhase = "Failed"
+)
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:storageversion
+// +kubebuilder:resource:shortName=emqxuser
+// +kubebuilder:printcolumn:name="Instance",type="string",JSONPath=".spec.instanceName"
+// +kubebuilder:printcolumn:name="User",type="string",JSONPath=".spec.userId"
+// +kubebuilder:printcolumn:name="Superuser",type="boolean",JSONPath=".spec.isSuperuser"
+// +kubebuilder:printcolumn:name="Status",type="string",JSONPath=".status.phase"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+// EMQXUser is the Schema for the emqxusers API
+type EMQXUser struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   EMQXUserSpec   `json:"spec,omitempty"`
+	Status EMQXUserStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// EMQXUserList contains a list of EMQXUser
+type EMQXUserList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []EMQXUser `json:"items"`
+}
+
+func (s *EMQXUserStatus) SetSynced(userID, credentialHash string) {
+	now := metav1.Now()
+	s.Phase = UserPhaseSynced
+	s.Message = ""
+	s.UserID = userID
+	s.CredentialHash = credentialHash
+	s.LastSyncedTime = &now
+}
+
+func (s *EMQXUserStatus) SetFailed(message string) {
+	s.Phase = UserPhaseFailed
+	s.Message = message
+}
+
+// GetInstanceName returns the name of the EMQX cluster which the EMQXUser is applied to
+func (u *EMQXUser) GetInstanceName() string {
+	return u.Spec.InstanceName
+}
+
+// IsFailed returns whether the EMQXUser is failed with the message
+func (u *EMQXUser) IsFailed(message string) bool {
+	return u.Status.Phase == UserPhaseFailed && u.Status.Message == message
+}
+
+func (u *EMQXUser) SetFailed(message string) {
+	u.Status.SetFailed(message)
+}
+
+func init() {
+	SchemeBuilder.Register(&EMQXUser{}, &EMQXUserList{})
+}
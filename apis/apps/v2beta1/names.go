@@ -57,6 +57,28 @@ func (instance *EMQX) ListenersServiceNamespacedName() types.NamespacedName {
 	}
 }
 
+func (instance *EMQX) ExtraListenersServiceNamespacedName(name string) types.NamespacedName {
+	return types.NamespacedName{
+		Namespace: instance.Namespace,
+		Name:      fmt.Sprintf("%s-listeners-%s", instance.Name, name),
+	}
+}
+
+func (instance *EMQX) DashboardIngressNamespacedName() types.NamespacedName {
+	return types.NamespacedName{
+		Namespace: instance.Namespace,
+		Name:      fmt.Sprintf("%s-dashboard", instance.Name),
+	}
+}
+
+// WebsocketRouteNamespacedName returns the name of the route to the listener, the listener is the service port name, like "ws-default"
+func (instance *EMQX) WebsocketRouteNamespacedName(listener string) types.NamespacedName {
+	return types.NamespacedName{
+		Namespace: instance.Namespace,
+		Name:      fmt.Sprintf("%s-%s", instance.Name, listener),
+	}
+}
+
 func (instance *EMQX) BootstrapAPIKeyNamespacedName() types.NamespacedName {
 	return types.NamespacedName{
 		Namespace: instance.Namespace,
@@ -77,3 +99,46 @@ func (instance *EMQX) ConfigsNamespacedName() types.NamespacedName {
 		Name:      fmt.Sprintf("%s-configs", instance.Name),
 	}
 }
+
+func (instance *EMQX) SecretConfigsNamespacedName() types.NamespacedName {
+	return types.NamespacedName{
+		Namespace: instance.Namespace,
+		Name:      fmt.Sprintf("%s-secret-configs", instance.Name),
+	}
+}
+
+func (instance *EMQX) MetricsNamespacedName() types.NamespacedName {
+	return types.NamespacedName{
+		Namespace: instance.Namespace,
+		Name:      fmt.Sprintf("%s-metrics", instance.Name),
+	}
+}
+
+func (instance *EMQX) MetricsBasicAuthNamespacedName() types.NamespacedName {
+	return types.NamespacedName{
+		Namespace: instance.Namespace,
+		Name:      fmt.Sprintf("%s-metrics-basic-auth", instance.Name),
+	}
+}
+
+func (instance *EMQX) ListenersTLSNamespacedName() types.NamespacedName {
+	name := fmt.Sprintf("%s-listeners-tls", instance.Name)
+	if instance.Spec.TLS != nil && instance.Spec.TLS.Listeners != nil && instance.Spec.TLS.Listeners.SecretName != "" {
+		name = instance.Spec.TLS.Listeners.SecretName
+	}
+	return types.NamespacedName{
+		Namespace: instance.Namespace,
+		Name:      name,
+	}
+}
+
+func (instance *EMQX) DashboardTLSNamespacedName() types.NamespacedName {
+	name := fmt.Sprintf("%s-dashboard-tls", instance.Name)
+	if instance.Spec.TLS != nil && instance.Spec.TLS.Dashboard != nil && instance.Spec.TLS.Dashboard.SecretName != "" {
+		name = instance.Spec.TLS.Dashboard.SecretName
+	}
+	return types.NamespacedName{
+		Namespace: instance.Namespace,
+		Name:      name,
+	}
+}
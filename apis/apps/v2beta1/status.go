@@ -17,8 +17,10 @@ limitations under the License.
 package v2beta1
 
 import (
+	"slices"
 	"sort"
 
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 )
@@ -30,11 +32,108 @@ type EMQXStatus struct {
 
 	CoreNodes       []EMQXNode      `json:"coreNodes,omitempty"`
 	CoreNodesStatus EMQXNodesStatus `json:"coreNodesStatus,omitempty"`
+	// CoreVolumeExpansionStatus is the progress of expanding the persistentVolumeClaims of the core nodes.
+	CoreVolumeExpansionStatus *VolumeExpansionStatus `json:"coreVolumeExpansionStatus,omitempty"`
+	// RetiredVolumeClaims are the persistentVolumeClaims of the retired core statefulSets,
+	// which are retained or waiting for deletion by ".spec.coreTemplate.spec.pvcRetentionPolicy".
+	RetiredVolumeClaims []RetiredVolumeClaimStatus `json:"retiredVolumeClaims,omitempty"`
 
 	ReplicantNodes       []EMQXNode      `json:"replicantNodes,omitempty"`
 	ReplicantNodesStatus EMQXNodesStatus `json:"replicantNodesStatus,omitempty"`
 
 	NodeEvacuationsStatus []NodeEvacuationStatus `json:"nodEvacuationsStatus,omitempty"`
+
+	ReplicantAutoscalingStatus *ReplicantAutoscalingStatus `json:"replicantAutoscalingStatus,omitempty"`
+
+	// ConfigDrift is the config items of EMQX which are different from ".spec.config.data", at most 10 items are recorded.
+	ConfigDrift []ConfigDriftItem `json:"configDrift,omitempty"`
+
+	// AuthenticationStatus is the health of the authenticators of ".spec.authentication".
+	AuthenticationStatus []AuthSourceStatus `json:"authenticationStatus,omitempty"`
+	// AuthorizationStatus is the health of the authorization sources of ".spec.authorization".
+	AuthorizationStatus []AuthSourceStatus `json:"authorizationStatus,omitempty"`
+}
+
+type ConfigDriftItem struct {
+	// Path of the config item, like "mqtt.max_packet_size".
+	Path string `json:"path"`
+	// The value in ".spec.config.data".
+	Desired string `json:"desired,omitempty"`
+	// The value in the running EMQX.
+	Live string `json:"live,omitempty"`
+}
+
+type AuthSourceStatus struct {
+	// ID of the authenticator, like "password_based:redis" or "jwt", or the type of the authorization source, like "http".
+	ID string `json:"id"`
+	// Status reported by EMQX, like "connected", "disconnected" or "connecting".
+	Status string `json:"status,omitempty"`
+	// Message is the reason when the status can not be fetched.
+	Message string `json:"message,omitempty"`
+}
+
+type VolumeExpansionStatus struct {
+	// The storage size requested by ".spec.coreTemplate.spec.volumeClaimTemplates".
+	TargetSize resource.Quantity   `json:"targetSize"`
+	Claims     []VolumeClaimStatus `json:"claims,omitempty"`
+}
+
+type VolumeClaimStatus struct {
+	// Name of the persistentVolumeClaim.
+	Name string `json:"name"`
+	// Capacity is the actual size of the volume.
+	Capacity resource.Quantity `json:"capacity,omitempty"`
+	// Phase is one of "Resizing", "FileSystemResizePending", "Completed" or "Unsupported".
+	Phase VolumeExpansionPhase `json:"phase,omitempty"`
+	// Message is the reason when the volume can not be expanded.
+	Message string `json:"message,omitempty"`
+}
+
+type VolumeExpansionPhase string
+
+const (
+	VolumeExpansionResizing                VolumeExpansionPhase = "Resizing"
+	VolumeExpansionFileSystemResizePending VolumeExpansionPhase = "FileSystemResizePending"
+	VolumeExpansionCompleted               VolumeExpansionPhase = "Completed"
+	VolumeExpansionUnsupported             VolumeExpansionPhase = "Unsupported"
+)
+
+type RetiredVolumeClaimStatus struct {
+	// Name of the persistentVolumeClaim.
+	Name string `json:"name"`
+	// Phase is one of "Retained", "PendingDeletion" or "Snapshotting".
+	Phase RetiredVolumeClaimPhase `json:"phase,omitempty"`
+	// The time when the statefulSet of the persistentVolumeClaim was retired.
+	RetiredTime metav1.Time `json:"retiredTime,omitempty"`
+	// The time after which the persistentVolumeClaim will be deleted.
+	DeletionTime *metav1.Time `json:"deletionTime,omitempty"`
+	// The name of the VolumeSnapshot of the persistentVolumeClaim.
+	Snapshot string `json:"snapshot,omitempty"`
+	// Message is the reason when the snapshot can not be created.
+	Message string `json:"message,omitempty"`
+}
+
+type RetiredVolumeClaimPhase string
+
+const (
+	RetiredVolumeClaimRetained        RetiredVolumeClaimPhase = "Retained"
+	RetiredVolumeClaimPendingDeletion RetiredVolumeClaimPhase = "PendingDeletion"
+	RetiredVolumeClaimSnapshotting    RetiredVolumeClaimPhase = "Snapshotting"
+)
+
+type ReplicantAutoscalingStatus struct {
+	// The number of the connected MQTT clients on all running replicant nodes.
+	CurrentConnections int64 `json:"currentConnections,omitempty"`
+	// The number of the MQTT sessions on all running replicant nodes.
+	CurrentSessions int64 `json:"currentSessions,omitempty"`
+	// The number of replicant nodes calculated by the autoscaling policy.
+	DesiredReplicas int32 `json:"desiredReplicas,omitempty"`
+	// The number of replicant nodes applied by the autoscaling policy, it overrides ".spec.replicantTemplate.spec.replicas".
+	Replicas int32 `json:"replicas,omitempty"`
+	// The last time the replicant nodes were scaled up by the autoscaling policy.
+	LastScaleUpTime *metav1.Time `json:"lastScaleUpTime,omitempty"`
+	// The last time the replicant nodes were scaled down by the autoscaling policy.
+	LastScaleDownTime *metav1.Time `json:"lastScaleDownTime,omitempty"`
 }
 
 type NodeEvacuationStatus struct {
@@ -66,6 +165,9 @@ type EMQXNodesStatus struct {
 	UpdateReplicas int32  `json:"updateReplicas,omitempty"`
 
 	CollisionCount *int32 `json:"collisionCount,omitempty"`
+
+	// The revision has been aborted, it will not be created again until the pod template is changed.
+	AbortedRevision string `json:"abortedRevision,omitempty"`
 }
 
 type EMQXNode struct {
@@ -102,6 +204,33 @@ const (
 	Ready                     string = "Ready"
 )
 
+const (
+	UpdatePaused   string = "UpdatePaused"
+	UpdatePromoted string = "UpdatePromoted"
+	UpdateAborted  string = "UpdateAborted"
+	RolledBack     string = "RolledBack"
+	// RollingBack is true while the update is being rolled back, RolledBack is set when it is finished
+	RollingBack   string = "RollingBack"
+	ConfigDrifted string = "ConfigDrifted"
+	// AuthSecretsMissing is true when the Secrets referenced by ".spec.authentication" or ".spec.authorization" are not found
+	AuthSecretsMissing string = "AuthSecretsMissing"
+	// APIClientCAMissing is true when the EMQX management API is called by HTTPS without the CA bundle provided by ".spec.tls",
+	// so the certificate of the EMQX nodes is verified by the system trust store
+	APIClientCAMissing string = "APIClientCAMissing"
+)
+
+// The conditions describe the lifecycle of the EMQX cluster, the others,
+// like UpdatePaused, just record what happened during the update.
+var clusterConditionTypes = []string{
+	Initialized,
+	CoreNodesProgressing,
+	CoreNodesReady,
+	ReplicantNodesProgressing,
+	ReplicantNodesReady,
+	Available,
+	Ready,
+}
+
 func (s *EMQXStatus) SetCondition(c metav1.Condition) {
 	c.LastTransitionTime = metav1.Now()
 	pos, _ := s.GetCondition(c.Type)
@@ -118,7 +247,7 @@ func (s *EMQXStatus) SetCondition(c metav1.Condition) {
 func (s *EMQXStatus) GetLastTrueCondition() *metav1.Condition {
 	for i := range s.Conditions {
 		c := s.Conditions[i]
-		if c.Status == metav1.ConditionTrue {
+		if c.Status == metav1.ConditionTrue && slices.Contains(clusterConditionTypes, c.Type) {
 			return &c
 		}
 	}
@@ -111,6 +111,24 @@ func TestGetLastTrueCondition(t *testing.T) {
 
 	c := status.GetLastTrueCondition()
 	assert.Equal(t, Initialized, c.Type)
+
+	t.Run("ignore the conditions do not describe the lifecycle of the cluster", func(t *testing.T) {
+		status := &EMQXStatus{
+			Conditions: []metav1.Condition{
+				{
+					Type:   UpdatePaused,
+					Status: metav1.ConditionTrue,
+				},
+				{
+					Type:   Ready,
+					Status: metav1.ConditionTrue,
+				},
+			},
+		}
+
+		c := status.GetLastTrueCondition()
+		assert.Equal(t, Ready, c.Type)
+	})
 }
 
 func TestGetCondition(t *testing.T) {
@@ -35,6 +35,15 @@ func IsExistReplicant(instance *EMQX) bool {
 	return instance.Spec.ReplicantTemplate != nil && instance.Spec.ReplicantTemplate.Spec.Replicas != nil && *instance.Spec.ReplicantTemplate.Spec.Replicas > 0
 }
 
+// GetReplicantReplicas returns the desired number of replicant nodes,
+// it is the one applied by the autoscaling if it is set, otherwise ".spec.replicantTemplate.spec.replicas"
+func GetReplicantReplicas(instance *EMQX) int32 {
+	if instance.Spec.ReplicantTemplate.Autoscaling != nil && instance.Status.ReplicantAutoscalingStatus != nil && instance.Status.ReplicantAutoscalingStatus.Replicas > 0 {
+		return instance.Status.ReplicantAutoscalingStatus.Replicas
+	}
+	return *instance.Spec.ReplicantTemplate.Spec.Replicas
+}
+
 func DefaultLabels(instance *EMQX) map[string]string {
 	labels := map[string]string{}
 	labels[LabelsInstanceKey] = instance.Name
@@ -367,6 +376,48 @@ func GetListenersServicePorts(hoconString string) ([]corev1.ServicePort, error)
 	return svcPorts, nil
 }
 
+func (l *Listener) IsEnabled() bool {
+	return l.Enable == nil || *l.Enable
+}
+
+// ID returns the ID of the authenticator in the EMQX authentication API, like "password_based:redis" or "jwt"
+func (a *Authenticator) ID() string {
+	if a.Mechanism == "jwt" {
+		return a.Mechanism
+	}
+	mechanism := a.Mechanism
+	if mechanism == "" {
+		mechanism = "password_based"
+	}
+	return fmt.Sprintf("%s:%s", mechanism, a.Backend)
+}
+
+// GetSpecListenersServicePorts returns the service ports of the enabled listeners in ".spec.listeners"
+func GetSpecListenersServicePorts(listeners []Listener) []corev1.ServicePort {
+	svcPorts := []corev1.ServicePort{}
+	for _, listener := range listeners {
+		if !listener.IsEnabled() {
+			continue
+		}
+		protocol := corev1.ProtocolTCP
+		if listener.Type == "quic" {
+			protocol = corev1.ProtocolUDP
+		}
+		svcPorts = append(svcPorts, corev1.ServicePort{
+			Name:       fmt.Sprintf("%s-%s", listener.Type, listener.Name),
+			Protocol:   protocol,
+			Port:       listener.Port,
+			TargetPort: intstr.FromInt32(listener.Port),
+		})
+	}
+
+	sort.Slice(svcPorts, func(i, j int) bool {
+		return svcPorts[i].Name < svcPorts[j].Name
+	})
+
+	return svcPorts
+}
+
 func MergeServicePorts(ports1, ports2 []corev1.ServicePort) []corev1.ServicePort {
 	ports := append(ports1, ports2...)
 
@@ -22,6 +22,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/utils/ptr"
 )
 
 func TestGetDashboardPortMap(t *testing.T) {
@@ -250,6 +251,28 @@ func TestGetListenersServicePorts(t *testing.T) {
 	})
 }
 
+func TestGetSpecListenersServicePorts(t *testing.T) {
+	got := GetSpecListenersServicePorts([]Listener{
+		{Name: "internal", Type: "tcp", Port: 11883},
+		{Name: "default", Type: "quic", Port: 14567},
+		{Name: "disabled", Type: "ws", Port: 18083, Enable: ptr.To(false)},
+	})
+	assert.Equal(t, []corev1.ServicePort{
+		{
+			Name:       "quic-default",
+			Protocol:   corev1.ProtocolUDP,
+			Port:       14567,
+			TargetPort: intstr.Parse("14567"),
+		},
+		{
+			Name:       "tcp-internal",
+			Protocol:   corev1.ProtocolTCP,
+			Port:       11883,
+			TargetPort: intstr.Parse("11883"),
+		},
+	}, got)
+}
+
 func TestMergeServicePorts(t *testing.T) {
 	t.Run("duplicate name", func(t *testing.T) {
 		ports1 := []corev1.ServicePort{
@@ -349,3 +372,9 @@ func TestMergeMap(t *testing.T) {
 	}
 	assert.Equal(t, expect, mergeMap(m1, m2))
 }
+
+func TestAuthenticatorID(t *testing.T) {
+	assert.Equal(t, "password_based:built_in_database", (&Authenticator{Backend: "built_in_database"}).ID())
+	assert.Equal(t, "password_based:redis", (&Authenticator{Mechanism: "password_based", Backend: "redis"}).ID())
+	assert.Equal(t, "jwt", (&Authenticator{Mechanism: "jwt"}).ID())
+}
@@ -108,6 +108,9 @@ var _ = BeforeSuite(func() {
 	})
 	Expect(err).NotTo(HaveOccurred())
 
+	err = (&EMQX{}).SetupWebhookWithManager(mgr)
+	Expect(err).NotTo(HaveOccurred())
+
 	err = (&Rebalance{}).SetupWebhookWithManager(mgr)
 	Expect(err).NotTo(HaveOccurred())
 
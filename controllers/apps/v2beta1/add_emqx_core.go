@@ -27,8 +27,19 @@ type addCore struct {
 }
 
 func (a *addCore) reconcile(ctx context.Context, logger logr.Logger, instance *appsv2beta1.EMQX, _ innerReq.RequesterInterface) subResult {
-	preSts := getNewStatefulSet(instance)
+	caSecrets, err := getTLSSecretsWithCA(ctx, a.Client, instance)
+	if err != nil {
+		return subResult{err: emperror.Wrap(err, "failed to get tls secrets")}
+	}
+	preSts := getNewStatefulSet(instance, caSecrets)
 	preStsHash := preSts.Labels[appsv2beta1.LabelsPodTemplateHashKey]
+	isAborted := preStsHash == instance.Status.CoreNodesStatus.AbortedRevision
+	if instance.Status.CoreNodesStatus.AbortedRevision != "" && !isAborted {
+		instance.Status.CoreNodesStatus.AbortedRevision = ""
+		if err := a.Client.Status().Update(ctx, instance); err != nil {
+			return subResult{err: emperror.Wrap(err, "failed to update status")}
+		}
+	}
 	updateSts, _, _ := getStateFulSetList(ctx, a.Client, instance)
 
 	patchCalculateFunc := func(storage, new *appsv1.StatefulSet) *patch.PatchResult {
@@ -42,6 +53,15 @@ func (a *addCore) reconcile(ctx context.Context, logger logr.Logger, instance *a
 		)
 		return patchResult
 	}
+	if isAborted {
+		// The update has been aborted, don't create it again until the pod template is changed,
+		// but keep the other fields of the existing statefulSet in sync
+		if updateSts == nil {
+			return subResult{}
+		}
+		preSts.Spec.Template = *updateSts.Spec.Template.DeepCopy()
+		preStsHash = updateSts.Labels[appsv2beta1.LabelsPodTemplateHashKey]
+	}
 	if patchResult := patchCalculateFunc(updateSts, preSts); !patchResult.IsEmpty() {
 		// Create new statefulSet
 		logger.Info("got different pod template for EMQX core nodes, will create new statefulSet", "statefulSet", klog.KObj(preSts), "patch", string(patchResult.Patch))
@@ -75,6 +95,12 @@ func (a *addCore) reconcile(ctx context.Context, logger logr.Logger, instance *a
 	preSts.ObjectMeta = updateSts.DeepCopy().ObjectMeta
 	preSts.Spec.Template.ObjectMeta = updateSts.DeepCopy().Spec.Template.ObjectMeta
 	preSts.Spec.Selector = updateSts.DeepCopy().Spec.Selector
+	// The volumeClaimTemplates of statefulSet are immutable, the storage size is expanded by syncPVCs
+	preSts.Spec.VolumeClaimTemplates = updateSts.DeepCopy().Spec.VolumeClaimTemplates
+	// The statefulSet is scaled in by syncPods node by node, after the nodes are evacuated
+	if *preSts.Spec.Replicas < *updateSts.Spec.Replicas {
+		preSts.Spec.Replicas = updateSts.Spec.Replicas
+	}
 	if patchResult, _ := a.Patcher.Calculate(
 		updateSts.DeepCopy(),
 		preSts.DeepCopy(),
@@ -113,10 +139,10 @@ func (a *addCore) updateEMQXStatus(ctx context.Context, instance *appsv2beta1.EM
 	})
 }
 
-func getNewStatefulSet(instance *appsv2beta1.EMQX) *appsv1.StatefulSet {
+func getNewStatefulSet(instance *appsv2beta1.EMQX, caSecrets map[string]bool) *appsv1.StatefulSet {
 	svcPorts, _ := appsv2beta1.GetDashboardServicePort(instance.Spec.Config.Data)
 
-	preSts := generateStatefulSet(instance)
+	preSts := generateStatefulSet(instance, caSecrets)
 	podTemplateSpecHash := computeHash(preSts.Spec.Template.DeepCopy(), instance.Status.CoreNodesStatus.CollisionCount)
 	preSts.Name = preSts.Name + "-" + podTemplateSpecHash
 	preSts.Labels = appsv2beta1.CloneAndAddLabel(preSts.Labels, appsv2beta1.LabelsPodTemplateHashKey, podTemplateSpecHash)
@@ -141,7 +167,7 @@ func getNewStatefulSet(instance *appsv2beta1.EMQX) *appsv1.StatefulSet {
 	return preSts
 }
 
-func generateStatefulSet(instance *appsv2beta1.EMQX) *appsv1.StatefulSet {
+func generateStatefulSet(instance *appsv2beta1.EMQX, caSecrets map[string]bool) *appsv1.StatefulSet {
 	labels := appsv2beta1.CloneAndMergeMap(
 		appsv2beta1.DefaultCoreLabels(instance),
 		instance.Spec.CoreTemplate.Labels,
@@ -287,14 +313,8 @@ func generateStatefulSet(instance *appsv2beta1.EMQX) *appsv1.StatefulSet {
 							},
 						},
 						{
-							Name: "bootstrap-config",
-							VolumeSource: corev1.VolumeSource{
-								ConfigMap: &corev1.ConfigMapVolumeSource{
-									LocalObjectReference: corev1.LocalObjectReference{
-										Name: instance.ConfigsNamespacedName().Name,
-									},
-								},
-							},
+							Name:         "bootstrap-config",
+							VolumeSource: configVolumeSource(instance),
 						},
 						{
 							Name: instance.CoreNamespacedName().Name + "-log",
@@ -336,5 +356,14 @@ func generateStatefulSet(instance *appsv2beta1.EMQX) *appsv1.StatefulSet {
 		}, sts.Spec.Template.Spec.Volumes...)
 	}
 
+	tlsVolumes, tlsVolumeMounts, tlsEnv := generateTLSPodSpec(instance, caSecrets)
+	sts.Spec.Template.Spec.Volumes = append(sts.Spec.Template.Spec.Volumes, tlsVolumes...)
+	sts.Spec.Template.Spec.Containers[0].VolumeMounts = append(sts.Spec.Template.Spec.Containers[0].VolumeMounts, tlsVolumeMounts...)
+	sts.Spec.Template.Spec.Containers[0].Env = append(sts.Spec.Template.Spec.Containers[0].Env, tlsEnv...)
+
+	if hash := readOnlyConfigHash(instance); hash != "" {
+		sts.Spec.Template.Annotations = appsv2beta1.CloneAndAddLabel(sts.Spec.Template.Annotations, appsv2beta1.AnnotationsReadOnlyConfigHashKey, hash)
+	}
+
 	return sts
 }
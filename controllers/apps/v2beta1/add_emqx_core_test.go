@@ -3,12 +3,19 @@ package v2beta1
 import (
 	"testing"
 
+	"github.com/cisco-open/k8s-objectmatcher/patch"
 	appsv2beta1 "github.com/emqx/emqx-operator/apis/apps/v2beta1"
+	"github.com/emqx/emqx-operator/internal/handler"
 	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
 	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 )
 
 func TestGetNewStatefulSet(t *testing.T) {
@@ -43,7 +50,7 @@ func TestGetNewStatefulSet(t *testing.T) {
 
 	t.Run("check metadata", func(t *testing.T) {
 		emqx := instance.DeepCopy()
-		got := getNewStatefulSet(emqx)
+		got := getNewStatefulSet(emqx, nil)
 
 		assert.Equal(t, emqx.Spec.CoreTemplate.Annotations, got.Annotations)
 		assert.Equal(t, "core-label-value", got.Labels["core-label-key"])
@@ -54,9 +61,25 @@ func TestGetNewStatefulSet(t *testing.T) {
 		assert.Equal(t, emqx.Namespace, got.Namespace)
 	})
 
+	t.Run("check read-only config hash", func(t *testing.T) {
+		emqx := instance.DeepCopy()
+		emqx.Spec.Config.Data = `node.cookie = "emqx"`
+		got := getNewStatefulSet(emqx, nil)
+		assert.NotContains(t, got.Spec.Template.Annotations, appsv2beta1.AnnotationsReadOnlyConfigHashKey)
+
+		emqx.Spec.Config.RestartOnReadOnlyConfigChange = true
+		emqx.Annotations = map[string]string{appsv2beta1.AnnotationsLastReadOnlyConfigHashKey: computeReadOnlyConfigHash(emqx.Spec.Config.Data)}
+		newGot := getNewStatefulSet(emqx, nil)
+		assert.NotEmpty(t, readOnlyConfigHash(emqx))
+		assert.Equal(t, readOnlyConfigHash(emqx), newGot.Spec.Template.Annotations[appsv2beta1.AnnotationsReadOnlyConfigHashKey])
+		assert.Equal(t, "core-annotation-value", newGot.Spec.Template.Annotations["core-annotation-key"])
+		assert.NotEqual(t, got.Labels[appsv2beta1.LabelsPodTemplateHashKey], newGot.Labels[appsv2beta1.LabelsPodTemplateHashKey])
+		assert.NotContains(t, emqx.Spec.CoreTemplate.Annotations, appsv2beta1.AnnotationsReadOnlyConfigHashKey)
+	})
+
 	t.Run("check selector and pod metadata", func(t *testing.T) {
 		emqx := instance.DeepCopy()
-		got := getNewStatefulSet(emqx)
+		got := getNewStatefulSet(emqx, nil)
 		assert.Equal(t, emqx.Spec.CoreTemplate.ObjectMeta.Annotations, got.Spec.Template.Annotations)
 		assert.EqualValues(t, map[string]string{
 			appsv2beta1.LabelsInstanceKey:        "emqx",
@@ -78,7 +101,7 @@ func TestGetNewStatefulSet(t *testing.T) {
 	t.Run("check http port", func(t *testing.T) {
 		emqx := instance.DeepCopy()
 		emqx.Spec.Config.Data = "dashboard.listeners.http.bind = 18083"
-		got := getNewStatefulSet(emqx)
+		got := getNewStatefulSet(emqx, nil)
 
 		assert.Contains(t, got.Spec.Template.Spec.Containers[0].Ports,
 			corev1.ContainerPort{
@@ -99,7 +122,7 @@ func TestGetNewStatefulSet(t *testing.T) {
 	t.Run("check https port", func(t *testing.T) {
 		emqx := instance.DeepCopy()
 		emqx.Spec.Config.Data = "dashboard.listeners.http.bind = 0 \n dashboard.listeners.https.bind = 18084"
-		got := getNewStatefulSet(emqx)
+		got := getNewStatefulSet(emqx, nil)
 
 		assert.Contains(t, got.Spec.Template.Spec.Containers[0].Ports,
 			corev1.ContainerPort{
@@ -120,7 +143,7 @@ func TestGetNewStatefulSet(t *testing.T) {
 	t.Run("check http and https port", func(t *testing.T) {
 		emqx := instance.DeepCopy()
 		emqx.Spec.Config.Data = "dashboard.listeners.http.bind = 18083 \n dashboard.listeners.https.bind = 18084"
-		got := getNewStatefulSet(emqx)
+		got := getNewStatefulSet(emqx, nil)
 
 		assert.Contains(t, got.Spec.Template.Spec.Containers[0].Ports,
 			corev1.ContainerPort{
@@ -167,7 +190,7 @@ func TestGetNewStatefulSet(t *testing.T) {
 		}
 
 		fs := corev1.PersistentVolumeFilesystem
-		got := generateStatefulSet(emqx)
+		got := generateStatefulSet(emqx, nil)
 		assert.Equal(t, []corev1.PersistentVolumeClaim{
 			{
 				ObjectMeta: metav1.ObjectMeta{
@@ -201,3 +224,57 @@ func TestGetNewStatefulSet(t *testing.T) {
 		})
 	})
 }
+
+func newFakePatcher() *handler.Patcher {
+	annotator := patch.NewAnnotator(handler.LastAppliedAnnotation)
+	return &handler.Patcher{
+		Annotator: annotator,
+		Maker:     patch.NewPatchMaker(annotator, &patch.K8sStrategicMergePatcher{}, &patch.BaseJSONMergePatcher{}),
+	}
+}
+
+func TestAddCoreAbortedRevision(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = appsv2beta1.AddToScheme(scheme)
+	_ = appsv1.AddToScheme(scheme)
+	_ = corev1.AddToScheme(scheme)
+
+	instance := &appsv2beta1.EMQX{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "emqx",
+			Namespace: "emqx",
+		},
+		Spec: appsv2beta1.EMQXSpec{
+			Image:         "emqx:5.0",
+			ClusterDomain: "cluster.local",
+		},
+	}
+	instance.Spec.CoreTemplate.Spec.Replicas = ptr.To(int32(3))
+	currentSts := getNewStatefulSet(instance, nil)
+	currentSts.TypeMeta = metav1.TypeMeta{}
+	currentHash := currentSts.Labels[appsv2beta1.LabelsPodTemplateHashKey]
+
+	// The update to the new image has been aborted, and then the replicas are changed
+	instance.Spec.Image = "emqx:5.1"
+	instance.Spec.CoreTemplate.Spec.Replicas = ptr.To(int32(5))
+	instance.Status.CoreNodesStatus.CurrentRevision = currentHash
+	instance.Status.CoreNodesStatus.UpdateRevision = currentHash
+	instance.Status.CoreNodesStatus.AbortedRevision = getNewStatefulSet(instance, nil).Labels[appsv2beta1.LabelsPodTemplateHashKey]
+
+	fakeClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(instance, currentSts).WithStatusSubresource(instance).Build()
+	a := &addCore{&EMQXReconciler{
+		Handler:       &handler.Handler{Client: fakeClient, Patcher: newFakePatcher()},
+		Scheme:        scheme,
+		EventRecorder: record.NewFakeRecorder(10),
+	}}
+	assert.Equal(t, subResult{}, a.reconcile(ctx, logger, instance, nil))
+
+	list := &appsv1.StatefulSetList{}
+	assert.NoError(t, fakeClient.List(ctx, list, client.InNamespace("emqx")))
+	assert.Len(t, list.Items, 1)
+	assert.Equal(t, currentSts.Name, list.Items[0].Name)
+	assert.Equal(t, "emqx:5.0", list.Items[0].Spec.Template.Spec.Containers[0].Image)
+	assert.Equal(t, int32(5), *list.Items[0].Spec.Replicas)
+	assert.Equal(t, currentHash, instance.Status.CoreNodesStatus.UpdateRevision)
+	assert.NotEmpty(t, instance.Status.CoreNodesStatus.AbortedRevision)
+}
@@ -33,9 +33,20 @@ func (a *addRepl) reconcile(ctx context.Context, logger logr.Logger, instance *a
 		return subResult{}
 	}
 
-	preRs := getNewReplicaSet(instance)
+	caSecrets, err := getTLSSecretsWithCA(ctx, a.Client, instance)
+	if err != nil {
+		return subResult{err: emperror.Wrap(err, "failed to get tls secrets")}
+	}
+	preRs := getNewReplicaSet(instance, caSecrets)
 	preRsHash := preRs.Labels[appsv2beta1.LabelsPodTemplateHashKey]
-	updateRs, _, _ := getReplicaSetList(ctx, a.Client, instance)
+	isAborted := preRsHash == instance.Status.ReplicantNodesStatus.AbortedRevision
+	if instance.Status.ReplicantNodesStatus.AbortedRevision != "" && !isAborted {
+		instance.Status.ReplicantNodesStatus.AbortedRevision = ""
+		if err := a.Client.Status().Update(ctx, instance); err != nil {
+			return subResult{err: emperror.Wrap(err, "failed to update status")}
+		}
+	}
+	updateRs, currentRs, _ := getReplicaSetList(ctx, a.Client, instance)
 
 	patchCalculateFunc := func(storage, new *appsv1.ReplicaSet) *patch.PatchResult {
 		if storage == nil {
@@ -49,10 +60,20 @@ func (a *addRepl) reconcile(ctx context.Context, logger logr.Logger, instance *a
 		return patchResult
 	}
 
+	if isAborted {
+		// The update has been aborted, don't create it again until the pod template is changed,
+		// but keep the other fields of the existing replicaSet in sync
+		if updateRs == nil {
+			return subResult{}
+		}
+		preRs.Spec.Template = *updateRs.Spec.Template.DeepCopy()
+		preRsHash = updateRs.Labels[appsv2beta1.LabelsPodTemplateHashKey]
+	}
 	if patchResult := patchCalculateFunc(updateRs, preRs); !patchResult.IsEmpty() {
 		//Crete Rs
 		logger.Info("got different pod template for EMQX replicant nodes, will create new replicaSet", "replicaSet", klog.KObj(preRs), "patch", string(patchResult.Patch))
 
+		preRs.Spec.Replicas = ptr.To(getUpdateRsReplicas(instance, nil, currentRs))
 		_ = ctrl.SetControllerReference(instance, preRs, a.Scheme)
 		if err := a.Handler.Create(ctx, preRs); err != nil {
 			if k8sErrors.IsAlreadyExists(emperror.Cause(err)) {
@@ -82,6 +103,7 @@ func (a *addRepl) reconcile(ctx context.Context, logger logr.Logger, instance *a
 	preRs.ObjectMeta = updateRs.DeepCopy().ObjectMeta
 	preRs.Spec.Template.ObjectMeta = updateRs.DeepCopy().Spec.Template.ObjectMeta
 	preRs.Spec.Selector = updateRs.DeepCopy().Spec.Selector
+	preRs.Spec.Replicas = ptr.To(getUpdateRsReplicas(instance, updateRs, currentRs))
 	if patchResult, _ := a.Patcher.Calculate(
 		updateRs.DeepCopy(),
 		preRs.DeepCopy(),
@@ -120,10 +142,10 @@ func (a *addRepl) updateEMQXStatus(ctx context.Context, instance *appsv2beta1.EM
 	})
 }
 
-func getNewReplicaSet(instance *appsv2beta1.EMQX) *appsv1.ReplicaSet {
+func getNewReplicaSet(instance *appsv2beta1.EMQX, caSecrets map[string]bool) *appsv1.ReplicaSet {
 	svcPorts, _ := appsv2beta1.GetDashboardServicePort(instance.Spec.Config.Data)
 
-	preRs := generateReplicaSet(instance)
+	preRs := generateReplicaSet(instance, caSecrets)
 	podTemplateSpecHash := computeHash(preRs.Spec.Template.DeepCopy(), instance.Status.ReplicantNodesStatus.CollisionCount)
 	preRs.Name = preRs.Name + "-" + podTemplateSpecHash
 	preRs.Labels = appsv2beta1.CloneAndAddLabel(preRs.Labels, appsv2beta1.LabelsPodTemplateHashKey, podTemplateSpecHash)
@@ -149,13 +171,13 @@ func getNewReplicaSet(instance *appsv2beta1.EMQX) *appsv1.ReplicaSet {
 	return preRs
 }
 
-func generateReplicaSet(instance *appsv2beta1.EMQX) *appsv1.ReplicaSet {
+func generateReplicaSet(instance *appsv2beta1.EMQX, caSecrets map[string]bool) *appsv1.ReplicaSet {
 	labels := appsv2beta1.CloneAndMergeMap(
 		appsv2beta1.DefaultReplicantLabels(instance),
 		instance.Spec.ReplicantTemplate.Labels,
 	)
 
-	return &appsv1.ReplicaSet{
+	rs := &appsv1.ReplicaSet{
 		TypeMeta: metav1.TypeMeta{
 			Kind:       "ReplicaSet",
 			APIVersion: "apps/v1",
@@ -167,7 +189,7 @@ func generateReplicaSet(instance *appsv2beta1.EMQX) *appsv1.ReplicaSet {
 			Labels:      labels,
 		},
 		Spec: appsv1.ReplicaSetSpec{
-			Replicas: instance.Spec.ReplicantTemplate.Spec.Replicas,
+			Replicas: ptr.To(appsv2beta1.GetReplicantReplicas(instance)),
 			Selector: &metav1.LabelSelector{
 				MatchLabels: labels,
 			},
@@ -289,14 +311,8 @@ func generateReplicaSet(instance *appsv2beta1.EMQX) *appsv1.ReplicaSet {
 							},
 						},
 						{
-							Name: "bootstrap-config",
-							VolumeSource: corev1.VolumeSource{
-								ConfigMap: &corev1.ConfigMapVolumeSource{
-									LocalObjectReference: corev1.LocalObjectReference{
-										Name: instance.ConfigsNamespacedName().Name,
-									},
-								},
-							},
+							Name:         "bootstrap-config",
+							VolumeSource: configVolumeSource(instance),
 						},
 						{
 							Name: instance.ReplicantNamespacedName().Name + "-log",
@@ -315,4 +331,15 @@ func generateReplicaSet(instance *appsv2beta1.EMQX) *appsv1.ReplicaSet {
 			},
 		},
 	}
+
+	tlsVolumes, tlsVolumeMounts, tlsEnv := generateTLSPodSpec(instance, caSecrets)
+	rs.Spec.Template.Spec.Volumes = append(rs.Spec.Template.Spec.Volumes, tlsVolumes...)
+	rs.Spec.Template.Spec.Containers[0].VolumeMounts = append(rs.Spec.Template.Spec.Containers[0].VolumeMounts, tlsVolumeMounts...)
+	rs.Spec.Template.Spec.Containers[0].Env = append(rs.Spec.Template.Spec.Containers[0].Env, tlsEnv...)
+
+	if hash := readOnlyConfigHash(instance); hash != "" {
+		rs.Spec.Template.Annotations = appsv2beta1.CloneAndAddLabel(rs.Spec.Template.Annotations, appsv2beta1.AnnotationsReadOnlyConfigHashKey, hash)
+	}
+
+	return rs
 }
@@ -46,7 +46,7 @@ func TestGetNewReplicaSet(t *testing.T) {
 
 	t.Run("check metadata", func(t *testing.T) {
 		emqx := instance.DeepCopy()
-		got := getNewReplicaSet(emqx)
+		got := getNewReplicaSet(emqx, nil)
 
 		assert.Equal(t, emqx.Spec.ReplicantTemplate.Annotations, got.Annotations)
 		assert.Equal(t, "repl-label-value", got.Labels["repl-label-key"])
@@ -59,7 +59,7 @@ func TestGetNewReplicaSet(t *testing.T) {
 
 	t.Run("check selector and pod metadata", func(t *testing.T) {
 		emqx := instance.DeepCopy()
-		got := getNewReplicaSet(emqx)
+		got := getNewReplicaSet(emqx, nil)
 		assert.Equal(t, emqx.Spec.ReplicantTemplate.ObjectMeta.Annotations, got.Spec.Template.Annotations)
 		assert.EqualValues(t, map[string]string{
 			appsv2beta1.LabelsInstanceKey:        "emqx",
@@ -81,7 +81,7 @@ func TestGetNewReplicaSet(t *testing.T) {
 	t.Run("check http port", func(t *testing.T) {
 		emqx := instance.DeepCopy()
 		emqx.Spec.Config.Data = "dashboard.listeners.http.bind = 18083"
-		got := getNewReplicaSet(emqx)
+		got := getNewReplicaSet(emqx, nil)
 
 		assert.Contains(t, got.Spec.Template.Spec.Containers[0].Ports,
 			corev1.ContainerPort{
@@ -102,7 +102,7 @@ func TestGetNewReplicaSet(t *testing.T) {
 	t.Run("check https port", func(t *testing.T) {
 		emqx := instance.DeepCopy()
 		emqx.Spec.Config.Data = "dashboard.listeners.http.bind = 0 \n dashboard.listeners.https.bind = 18084"
-		got := getNewReplicaSet(emqx)
+		got := getNewReplicaSet(emqx, nil)
 
 		assert.Contains(t, got.Spec.Template.Spec.Containers[0].Ports,
 			corev1.ContainerPort{
@@ -123,7 +123,7 @@ func TestGetNewReplicaSet(t *testing.T) {
 	t.Run("check http and https port", func(t *testing.T) {
 		emqx := instance.DeepCopy()
 		emqx.Spec.Config.Data = "dashboard.listeners.http.bind = 18083 \n dashboard.listeners.https.bind = 18084"
-		got := getNewReplicaSet(emqx)
+		got := getNewReplicaSet(emqx, nil)
 
 		assert.Contains(t, got.Spec.Template.Spec.Containers[0].Ports,
 			corev1.ContainerPort{
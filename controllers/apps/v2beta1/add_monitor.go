@@ -0,0 +1,218 @@
+package v2beta1
+
+import (
+	"context"
+
+	emperror "emperror.dev/errors"
+	appsv2beta1 "github.com/emqx/emqx-operator/apis/apps/v2beta1"
+	innerReq "github.com/emqx/emqx-operator/internal/requester"
+	"github.com/go-logr/logr"
+	"github.com/rory-z/go-hocon"
+	corev1 "k8s.io/api/core/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const prometheusStatsPath = "/api/v5/prometheus/stats"
+
+type addMonitor struct {
+	*EMQXReconciler
+}
+
+func (a *addMonitor) reconcile(ctx context.Context, logger logr.Logger, instance *appsv2beta1.EMQX, _ innerReq.RequesterInterface) subResult {
+	if err := a.deleteUnusedResources(ctx, instance); err != nil {
+		return subResult{err: emperror.Wrap(err, "failed to delete unused monitor")}
+	}
+	if instance.Spec.Monitoring == nil {
+		return subResult{}
+	}
+
+	gvk := schema.GroupVersionKind{Group: "monitoring.coreos.com", Version: "v1", Kind: instance.Spec.Monitoring.Kind}
+	if _, err := a.Client.RESTMapper().RESTMapping(gvk.GroupKind(), gvk.Version); err != nil {
+		if meta.IsNoMatchError(err) {
+			logger.V(1).Info("the monitoring.coreos.com CRDs are not installed, skip to create monitor", "kind", gvk.Kind)
+			return subResult{}
+		}
+		return subResult{err: emperror.Wrap(err, "failed to get REST mapping for monitor")}
+	}
+
+	if isPrometheusBasicAuthEnabled(instance) && instance.Spec.Monitoring.BasicAuth == nil {
+		a.EventRecorder.Event(instance, corev1.EventTypeWarning, "MissingMetricsBasicAuth", `".spec.monitoring.basicAuth" is required when "prometheus.enable_basic_auth" is true`)
+	}
+
+	resources := []client.Object{}
+	if gvk.Kind == "ServiceMonitor" {
+		resources = append(resources, generateMetricsService(instance))
+	}
+	resources = append(resources, generateMonitor(instance, gvk))
+
+	if err := a.CreateOrUpdateList(ctx, a.Scheme, logger, instance, resources); err != nil {
+		return subResult{err: emperror.Wrap(err, "failed to create or update monitor")}
+	}
+	return subResult{}
+}
+
+// deleteUnusedResources deletes the monitor of the kind which is not selected, and the metrics service which is not used anymore,
+// the monitor is switched between PodMonitor and ServiceMonitor, or removed.
+// It also deletes the basic auth secret copied from the bootstrap API key by the former versions.
+func (a *addMonitor) deleteUnusedResources(ctx context.Context, instance *appsv2beta1.EMQX) error {
+	monitoring := instance.Spec.Monitoring
+	resources := []client.Object{}
+	for _, kind := range []string{"PodMonitor", "ServiceMonitor"} {
+		if monitoring != nil && monitoring.Kind == kind {
+			continue
+		}
+		monitor := &unstructured.Unstructured{}
+		monitor.SetGroupVersionKind(schema.GroupVersionKind{Group: "monitoring.coreos.com", Version: "v1", Kind: kind})
+		resources = append(resources, monitor)
+	}
+	if monitoring == nil || monitoring.Kind != "ServiceMonitor" {
+		resources = append(resources, &corev1.Service{})
+	}
+	resources = append(resources, &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: instance.MetricsBasicAuthNamespacedName().Name}})
+
+	for _, obj := range resources {
+		name := instance.MetricsNamespacedName().Name
+		if obj.GetName() != "" {
+			name = obj.GetName()
+		}
+		if err := a.Client.Get(ctx, types.NamespacedName{Namespace: instance.Namespace, Name: name}, obj); err != nil {
+			if k8sErrors.IsNotFound(err) || meta.IsNoMatchError(err) {
+				continue
+			}
+			return err
+		}
+		// Don't delete the resources which are not created by EMQX operator
+		if !metav1.IsControlledBy(obj, instance) {
+			continue
+		}
+		if err := a.Client.Delete(ctx, obj); client.IgnoreNotFound(err) != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func isPrometheusBasicAuthEnabled(instance *appsv2beta1.EMQX) bool {
+	config, err := hocon.ParseString(instance.Spec.Config.Data)
+	if err != nil {
+		return false
+	}
+	return config.GetString("prometheus.enable_basic_auth") == "true"
+}
+
+// getMetricsPort returns the name and the scheme of the dashboard port, the Prometheus endpoint is served on it
+func getMetricsPort(instance *appsv2beta1.EMQX) (name, scheme string) {
+	portMap, _ := appsv2beta1.GetDashboardPortMap(instance.Spec.Config.Data)
+	if _, ok := portMap["dashboard"]; ok {
+		return "dashboard", "http"
+	}
+	if _, ok := portMap["dashboard-https"]; ok {
+		return "dashboard-https", "https"
+	}
+	return "dashboard", "http"
+}
+
+func generateMetricsService(instance *appsv2beta1.EMQX) *corev1.Service {
+	ports, _ := appsv2beta1.GetDashboardServicePort(instance.Spec.Config.Data)
+	labels := appsv2beta1.CloneAndMergeMap(appsv2beta1.DefaultLabels(instance), instance.Labels)
+	labels[appsv2beta1.LabelsMetricsKey] = "true"
+	return &corev1.Service{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "Service",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: instance.Namespace,
+			Name:      instance.MetricsNamespacedName().Name,
+			Labels:    labels,
+		},
+		Spec: corev1.ServiceSpec{
+			Type:      corev1.ServiceTypeClusterIP,
+			ClusterIP: corev1.ClusterIPNone,
+			Selector:  appsv2beta1.DefaultLabels(instance),
+			Ports:     ports,
+		},
+	}
+}
+
+func generateMonitor(instance *appsv2beta1.EMQX, gvk schema.GroupVersionKind) *unstructured.Unstructured {
+	portName, scheme := getMetricsPort(instance)
+	endpoint := map[string]interface{}{
+		"port":   portName,
+		"path":   prometheusStatsPath,
+		"scheme": scheme,
+	}
+	if scheme == "https" {
+		endpoint["tlsConfig"] = generateMetricsTLSConfig(instance)
+	}
+	if instance.Spec.Monitoring.Interval != "" {
+		endpoint["interval"] = instance.Spec.Monitoring.Interval
+	}
+	if basicAuth := instance.Spec.Monitoring.BasicAuth; basicAuth != nil && isPrometheusBasicAuthEnabled(instance) {
+		endpoint["basicAuth"] = map[string]interface{}{
+			"username": map[string]interface{}{"name": basicAuth.Key.SecretName, "key": basicAuth.Key.SecretKey},
+			"password": map[string]interface{}{"name": basicAuth.Secret.SecretName, "key": basicAuth.Secret.SecretKey},
+		}
+	}
+
+	selector := map[string]interface{}{}
+	for k, v := range appsv2beta1.DefaultLabels(instance) {
+		selector[k] = v
+	}
+	if gvk.Kind == "ServiceMonitor" {
+		selector[appsv2beta1.LabelsMetricsKey] = "true"
+	}
+	spec := map[string]interface{}{
+		"selector": map[string]interface{}{
+			"matchLabels": selector,
+		},
+	}
+	if gvk.Kind == "ServiceMonitor" {
+		spec["endpoints"] = []interface{}{endpoint}
+	} else {
+		spec["podMetricsEndpoints"] = []interface{}{endpoint}
+	}
+
+	monitor := &unstructured.Unstructured{}
+	monitor.SetGroupVersionKind(gvk)
+	monitor.SetNamespace(instance.Namespace)
+	monitor.SetName(instance.MetricsNamespacedName().Name)
+	monitor.SetLabels(appsv2beta1.CloneAndMergeMap(appsv2beta1.DefaultLabels(instance), instance.Spec.Monitoring.Labels))
+	_ = unstructured.SetNestedField(monitor.Object, spec, "spec")
+	return monitor
+}
+
+// generateMetricsTLSConfig returns the TLS config of scraping the dashboard HTTPS listener,
+// the certificate of the EMQX nodes is verified in the same way as the EMQX management API.
+func generateMetricsTLSConfig(instance *appsv2beta1.EMQX) map[string]interface{} {
+	if isAPIClientInsecure(instance) {
+		return map[string]interface{}{"insecureSkipVerify": true}
+	}
+
+	var apiClient *appsv2beta1.APIClientTLS
+	if instance.Spec.TLS != nil {
+		apiClient = instance.Spec.TLS.APIClient
+	}
+	caSecretName := instance.DashboardTLSNamespacedName().Name
+	if apiClient != nil && apiClient.CASecretName != "" {
+		caSecretName = apiClient.CASecretName
+	}
+	secretKey := func(name, key string) map[string]interface{} {
+		return map[string]interface{}{"name": name, "key": key}
+	}
+	tlsConfig := map[string]interface{}{
+		"ca":         map[string]interface{}{"secret": secretKey(caSecretName, "ca.crt")},
+		"serverName": getAPIClientServerName(instance),
+	}
+	if apiClient != nil && apiClient.CertSecretName != "" {
+		tlsConfig["cert"] = map[string]interface{}{"secret": secretKey(apiClient.CertSecretName, corev1.TLSCertKey)}
+		tlsConfig["keySecret"] = secretKey(apiClient.CertSecretName, corev1.TLSPrivateKeyKey)
+	}
+	return tlsConfig
+}
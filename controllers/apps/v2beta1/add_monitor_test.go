@@ -0,0 +1,209 @@
+package v2beta1
+
+import (
+	"testing"
+
+	appsv2beta1 "github.com/emqx/emqx-operator/apis/apps/v2beta1"
+	"github.com/emqx/emqx-operator/internal/handler"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestGenerateMonitor(t *testing.T) {
+	instance := &appsv2beta1.EMQX{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "emqx",
+			Namespace: "emqx",
+		},
+		Spec: appsv2beta1.EMQXSpec{
+			Monitoring: &appsv2beta1.Monitoring{
+				Kind:     "PodMonitor",
+				Labels:   map[string]string{"release": "prometheus"},
+				Interval: "15s",
+			},
+		},
+	}
+	gvk := schema.GroupVersionKind{Group: "monitoring.coreos.com", Version: "v1", Kind: "PodMonitor"}
+
+	t.Run("generate pod monitor", func(t *testing.T) {
+		got := generateMonitor(instance, gvk)
+		assert.Equal(t, gvk, got.GroupVersionKind())
+		assert.Equal(t, "emqx-metrics", got.GetName())
+		assert.Equal(t, "prometheus", got.GetLabels()["release"])
+
+		matchLabels, _, _ := unstructured.NestedStringMap(got.Object, "spec", "selector", "matchLabels")
+		assert.Equal(t, appsv2beta1.DefaultLabels(instance), matchLabels)
+
+		endpoints, _, _ := unstructured.NestedSlice(got.Object, "spec", "podMetricsEndpoints")
+		assert.Equal(t, []interface{}{
+			map[string]interface{}{
+				"port":     "dashboard",
+				"path":     "/api/v5/prometheus/stats",
+				"scheme":   "http",
+				"interval": "15s",
+			},
+		}, endpoints)
+	})
+
+	t.Run("generate service monitor with https and basic auth", func(t *testing.T) {
+		emqx := instance.DeepCopy()
+		emqx.Spec.Monitoring.Kind = "ServiceMonitor"
+		emqx.Spec.Monitoring.Interval = ""
+		emqx.Spec.Config.Data = `
+		dashboard.listeners.http.bind = 0
+		dashboard.listeners.https.bind = 18084
+		prometheus.enable_basic_auth = true
+		`
+		emqx.Spec.ClusterDomain = "cluster.local"
+		emqx.Spec.Monitoring.BasicAuth = &appsv2beta1.SecretRef{
+			Key:    appsv2beta1.KeyRef{SecretName: "prometheus", SecretKey: "username"},
+			Secret: appsv2beta1.KeyRef{SecretName: "prometheus", SecretKey: "password"},
+		}
+		gvk := gvk
+		gvk.Kind = "ServiceMonitor"
+
+		got := generateMonitor(emqx, gvk)
+		matchLabels, _, _ := unstructured.NestedStringMap(got.Object, "spec", "selector", "matchLabels")
+		assert.Equal(t, "true", matchLabels[appsv2beta1.LabelsMetricsKey])
+
+		endpoints, _, _ := unstructured.NestedSlice(got.Object, "spec", "endpoints")
+		assert.Equal(t, []interface{}{
+			map[string]interface{}{
+				"port":   "dashboard-https",
+				"path":   "/api/v5/prometheus/stats",
+				"scheme": "https",
+				"tlsConfig": map[string]interface{}{
+					"ca":         map[string]interface{}{"secret": map[string]interface{}{"name": "emqx-dashboard-tls", "key": "ca.crt"}},
+					"serverName": "emqx-dashboard.emqx.svc.cluster.local",
+				},
+				"basicAuth": map[string]interface{}{
+					"username": map[string]interface{}{"name": "prometheus", "key": "username"},
+					"password": map[string]interface{}{"name": "prometheus", "key": "password"},
+				},
+			},
+		}, endpoints)
+	})
+
+	t.Run("generate monitor with the api client tls", func(t *testing.T) {
+		emqx := instance.DeepCopy()
+		emqx.Spec.Config.Data = `
+		dashboard.listeners.http.bind = 0
+		dashboard.listeners.https.bind = 18084
+		`
+		emqx.Spec.TLS = &appsv2beta1.TLS{
+			APIClient: &appsv2beta1.APIClientTLS{
+				CASecretName:   "emqx-ca",
+				CertSecretName: "emqx-client",
+				ServerName:     "emqx.example.com",
+			},
+		}
+		got := generateMonitor(emqx, gvk)
+		endpoints, _, _ := unstructured.NestedSlice(got.Object, "spec", "podMetricsEndpoints")
+		assert.Equal(t, map[string]interface{}{
+			"ca":         map[string]interface{}{"secret": map[string]interface{}{"name": "emqx-ca", "key": "ca.crt"}},
+			"cert":       map[string]interface{}{"secret": map[string]interface{}{"name": "emqx-client", "key": "tls.crt"}},
+			"keySecret":  map[string]interface{}{"name": "emqx-client", "key": "tls.key"},
+			"serverName": "emqx.example.com",
+		}, endpoints[0].(map[string]interface{})["tlsConfig"])
+
+		emqx.Spec.TLS.APIClient.InsecureSkipVerify = true
+		got = generateMonitor(emqx, gvk)
+		endpoints, _, _ = unstructured.NestedSlice(got.Object, "spec", "podMetricsEndpoints")
+		assert.Equal(t, map[string]interface{}{
+			"insecureSkipVerify": true,
+		}, endpoints[0].(map[string]interface{})["tlsConfig"])
+	})
+}
+
+func TestGenerateMetricsService(t *testing.T) {
+	instance := &appsv2beta1.EMQX{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "emqx",
+			Namespace: "emqx",
+		},
+	}
+	got := generateMetricsService(instance)
+	assert.Equal(t, "emqx-metrics", got.Name)
+	assert.Equal(t, "true", got.Labels[appsv2beta1.LabelsMetricsKey])
+	assert.Equal(t, corev1.ClusterIPNone, got.Spec.ClusterIP)
+	assert.Equal(t, appsv2beta1.DefaultLabels(instance), got.Spec.Selector)
+	assert.Equal(t, []corev1.ServicePort{
+		{
+			Name:       "dashboard",
+			Protocol:   corev1.ProtocolTCP,
+			Port:       18083,
+			TargetPort: intstr.FromInt(18083),
+		},
+	}, got.Spec.Ports)
+}
+
+func TestDeleteUnusedMonitorResources(t *testing.T) {
+	podMonitorGVK := schema.GroupVersionKind{Group: "monitoring.coreos.com", Version: "v1", Kind: "PodMonitor"}
+	serviceMonitorGVK := schema.GroupVersionKind{Group: "monitoring.coreos.com", Version: "v1", Kind: "ServiceMonitor"}
+	scheme := runtime.NewScheme()
+	_ = appsv2beta1.AddToScheme(scheme)
+	_ = corev1.AddToScheme(scheme)
+	restMapper := meta.NewDefaultRESTMapper(nil)
+	restMapper.Add(corev1.SchemeGroupVersion.WithKind("Service"), meta.RESTScopeNamespace)
+	restMapper.Add(corev1.SchemeGroupVersion.WithKind("Secret"), meta.RESTScopeNamespace)
+	for _, gvk := range []schema.GroupVersionKind{podMonitorGVK, serviceMonitorGVK} {
+		scheme.AddKnownTypeWithName(gvk, &unstructured.Unstructured{})
+		scheme.AddKnownTypeWithName(gvk.GroupVersion().WithKind(gvk.Kind+"List"), &unstructured.UnstructuredList{})
+		restMapper.Add(gvk, meta.RESTScopeNamespace)
+	}
+
+	instance := &appsv2beta1.EMQX{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "emqx",
+			Namespace: "emqx",
+			UID:       "fake-uid",
+		},
+		Spec: appsv2beta1.EMQXSpec{
+			Monitoring: &appsv2beta1.Monitoring{Kind: "PodMonitor"},
+		},
+	}
+	ownerRef := *metav1.NewControllerRef(instance, appsv2beta1.GroupVersion.WithKind("EMQX"))
+	serviceMonitor := generateMonitor(instance, serviceMonitorGVK)
+	serviceMonitor.SetOwnerReferences([]metav1.OwnerReference{ownerRef})
+	podMonitor := generateMonitor(instance, podMonitorGVK)
+	podMonitor.SetOwnerReferences([]metav1.OwnerReference{ownerRef})
+	svc := generateMetricsService(instance)
+	svc.OwnerReferences = []metav1.OwnerReference{ownerRef}
+
+	fakeClient := fake.NewClientBuilder().WithScheme(scheme).WithRESTMapper(restMapper).WithObjects(serviceMonitor, podMonitor, svc).Build()
+	a := &addMonitor{&EMQXReconciler{Handler: &handler.Handler{Client: fakeClient}}}
+	exists := func(obj client.Object) bool {
+		err := fakeClient.Get(ctx, client.ObjectKeyFromObject(obj), obj)
+		return !k8sErrors.IsNotFound(err)
+	}
+
+	t.Run("switch to PodMonitor", func(t *testing.T) {
+		assert.NoError(t, a.deleteUnusedResources(ctx, instance))
+		assert.False(t, exists(serviceMonitor.DeepCopy()))
+		assert.False(t, exists(svc.DeepCopy()))
+		assert.True(t, exists(podMonitor.DeepCopy()))
+	})
+
+	t.Run("remove monitor", func(t *testing.T) {
+		emqx := instance.DeepCopy()
+		emqx.Spec.Monitoring = nil
+		assert.NoError(t, a.deleteUnusedResources(ctx, emqx))
+		assert.False(t, exists(podMonitor.DeepCopy()))
+	})
+
+	t.Run("don't delete the monitor not owned by EMQX", func(t *testing.T) {
+		monitor := generateMonitor(instance, serviceMonitorGVK)
+		assert.NoError(t, fakeClient.Create(ctx, monitor))
+		assert.NoError(t, a.deleteUnusedResources(ctx, instance))
+		assert.True(t, exists(monitor.DeepCopy()))
+	})
+}
@@ -0,0 +1,248 @@
+package v2beta1
+
+import (
+	"slices"
+	"strings"
+
+	appsv2beta1 "github.com/emqx/emqx-operator/apis/apps/v2beta1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const gatewayAPIGroup = "gateway.networking.k8s.io"
+
+// generateRoutes returns the routes of ".spec.dashboardIngress" and ".spec.websocketRoutes",
+// they point at the ports of the services generated by generateDashboardService and generateListenerService
+func generateRoutes(instance *appsv2beta1.EMQX, configStr string) []client.Object {
+	routes := []client.Object{}
+	if route := generateDashboardRoute(instance, configStr); route != nil {
+		routes = append(routes, route)
+	}
+	routes = append(routes, generateWebsocketRoutes(instance, configStr)...)
+	return routes
+}
+
+// newRouteLists returns the lists of all the kinds of routes generated by generateRoutes
+func newRouteLists() []client.ObjectList {
+	lists := []client.ObjectList{
+		&networkingv1.IngressList{TypeMeta: metav1.TypeMeta{APIVersion: "networking.k8s.io/v1", Kind: "IngressList"}},
+	}
+	for _, gvk := range []schema.GroupVersionKind{
+		{Group: gatewayAPIGroup, Version: "v1", Kind: "HTTPRouteList"},
+		{Group: gatewayAPIGroup, Version: "v1alpha2", Kind: "TLSRouteList"},
+		{Group: gatewayAPIGroup, Version: "v1alpha2", Kind: "TCPRouteList"},
+	} {
+		list := &unstructured.UnstructuredList{}
+		list.SetGroupVersionKind(gvk)
+		lists = append(lists, list)
+	}
+	return lists
+}
+
+func generateDashboardRoute(instance *appsv2beta1.EMQX, configStr string) client.Object {
+	template := instance.Spec.DashboardIngress
+	if template == nil {
+		return nil
+	}
+	if instance.Spec.DashboardServiceTemplate != nil && instance.Spec.DashboardServiceTemplate.Enabled != nil && !*instance.Spec.DashboardServiceTemplate.Enabled {
+		return nil
+	}
+
+	ports, _ := appsv2beta1.GetDashboardServicePort(configStr)
+	// The TLSRoute passes the TLS connections through to the dashboard HTTPS listener,
+	// the others prefer the dashboard HTTP listener
+	names := []string{"dashboard", "dashboard-https"}
+	if template.Kind == "TLSRoute" {
+		names = []string{"dashboard-https"}
+	}
+	for _, name := range names {
+		for _, port := range ports {
+			if port.Name == name {
+				return generateRoute(instance, template, instance.DashboardIngressNamespacedName(), instance.DashboardServiceNamespacedName().Name, port.Port, "/")
+			}
+		}
+	}
+	return nil
+}
+
+func generateWebsocketRoutes(instance *appsv2beta1.EMQX, configStr string) []client.Object {
+	template := instance.Spec.WebsocketRoutes
+	if template == nil {
+		return nil
+	}
+
+	// The TLSRoute passes the TLS connections through to the wss listeners
+	prefix := "ws-"
+	if template.Kind == "TLSRoute" {
+		prefix = "wss-"
+	}
+
+	routes := []client.Object{}
+	for _, port := range getListenersServicePorts(instance, configStr) {
+		if !strings.HasPrefix(port.Name, prefix) {
+			continue
+		}
+		svcName := getListenerServiceName(instance, port.Name)
+		if svcName == "" {
+			continue
+		}
+		routes = append(routes, generateRoute(instance, template, instance.WebsocketRouteNamespacedName(port.Name), svcName, port.Port, "/mqtt"))
+	}
+	return routes
+}
+
+// getListenerServiceName returns the name of the service which serves the listener, it returns empty string if the service is disabled
+func getListenerServiceName(instance *appsv2beta1.EMQX, listener string) string {
+	for _, template := range instance.Spec.ExtraListenersServiceTemplates {
+		if slices.Contains(template.Listeners, listener) {
+			if template.Enabled != nil && !*template.Enabled {
+				return ""
+			}
+			return instance.ExtraListenersServiceNamespacedName(template.Name).Name
+		}
+	}
+	if instance.Spec.ListenersServiceTemplate != nil && instance.Spec.ListenersServiceTemplate.Enabled != nil && !*instance.Spec.ListenersServiceTemplate.Enabled {
+		return ""
+	}
+	return instance.ListenersServiceNamespacedName().Name
+}
+
+func generateRoute(instance *appsv2beta1.EMQX, template *appsv2beta1.RouteTemplate, name types.NamespacedName, svcName string, port int32, defaultPath string) client.Object {
+	path := template.Path
+	if path == "" {
+		path = defaultPath
+	}
+	if template.Kind == "" || template.Kind == "Ingress" {
+		return generateIngress(instance, template, name, svcName, port, path)
+	}
+	return generateGatewayRoute(instance, template, name, svcName, port, path)
+}
+
+func generateIngress(instance *appsv2beta1.EMQX, template *appsv2beta1.RouteTemplate, name types.NamespacedName, svcName string, port int32, path string) *networkingv1.Ingress {
+	pathType := networkingv1.PathTypePrefix
+	http := &networkingv1.HTTPIngressRuleValue{
+		Paths: []networkingv1.HTTPIngressPath{
+			{
+				Path:     path,
+				PathType: &pathType,
+				Backend: networkingv1.IngressBackend{
+					Service: &networkingv1.IngressServiceBackend{
+						Name: svcName,
+						Port: networkingv1.ServiceBackendPort{Number: port},
+					},
+				},
+			},
+		},
+	}
+
+	hosts := template.Hosts
+	if len(hosts) == 0 {
+		hosts = []string{""}
+	}
+	rules := []networkingv1.IngressRule{}
+	for _, host := range hosts {
+		rules = append(rules, networkingv1.IngressRule{
+			Host: host,
+			IngressRuleValue: networkingv1.IngressRuleValue{
+				HTTP: http.DeepCopy(),
+			},
+		})
+	}
+
+	ingress := &networkingv1.Ingress{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "networking.k8s.io/v1",
+			Kind:       "Ingress",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:   name.Namespace,
+			Name:        name.Name,
+			Labels:      appsv2beta1.CloneAndMergeMap(appsv2beta1.DefaultLabels(instance), template.ObjectMeta.Labels),
+			Annotations: template.ObjectMeta.Annotations,
+		},
+		Spec: networkingv1.IngressSpec{
+			IngressClassName: template.IngressClassName,
+			Rules:            rules,
+		},
+	}
+	if template.TLS != nil {
+		ingress.Spec.TLS = []networkingv1.IngressTLS{
+			{
+				Hosts:      template.Hosts,
+				SecretName: template.TLS.SecretName,
+			},
+		}
+	}
+	return ingress
+}
+
+func generateGatewayRoute(instance *appsv2beta1.EMQX, template *appsv2beta1.RouteTemplate, name types.NamespacedName, svcName string, port int32, path string) *unstructured.Unstructured {
+	parentRefs := []interface{}{}
+	for _, ref := range template.ParentRefs {
+		parentRef := map[string]interface{}{
+			"name": ref.Name,
+		}
+		if ref.Group != "" {
+			parentRef["group"] = ref.Group
+		}
+		if ref.Kind != "" {
+			parentRef["kind"] = ref.Kind
+		}
+		if ref.Namespace != "" {
+			parentRef["namespace"] = ref.Namespace
+		}
+		if ref.SectionName != "" {
+			parentRef["sectionName"] = ref.SectionName
+		}
+		parentRefs = append(parentRefs, parentRef)
+	}
+
+	rule := map[string]interface{}{
+		"backendRefs": []interface{}{
+			map[string]interface{}{
+				"name": svcName,
+				"port": int64(port),
+			},
+		},
+	}
+	if template.Kind == "HTTPRoute" {
+		rule["matches"] = []interface{}{
+			map[string]interface{}{
+				"path": map[string]interface{}{
+					"type":  "PathPrefix",
+					"value": path,
+				},
+			},
+		}
+	}
+
+	spec := map[string]interface{}{
+		"parentRefs": parentRefs,
+		"rules":      []interface{}{rule},
+	}
+	if template.Kind != "TCPRoute" && len(template.Hosts) > 0 {
+		hostnames := []interface{}{}
+		for _, host := range template.Hosts {
+			hostnames = append(hostnames, host)
+		}
+		spec["hostnames"] = hostnames
+	}
+
+	// The HTTPRoute is GA in the Gateway API, the TLSRoute and the TCPRoute are still experimental
+	version := "v1alpha2"
+	if template.Kind == "HTTPRoute" {
+		version = "v1"
+	}
+	route := &unstructured.Unstructured{}
+	route.SetGroupVersionKind(schema.GroupVersionKind{Group: gatewayAPIGroup, Version: version, Kind: template.Kind})
+	route.SetNamespace(name.Namespace)
+	route.SetName(name.Name)
+	route.SetLabels(appsv2beta1.CloneAndMergeMap(appsv2beta1.DefaultLabels(instance), template.ObjectMeta.Labels))
+	route.SetAnnotations(template.ObjectMeta.Annotations)
+	_ = unstructured.SetNestedField(route.Object, spec, "spec")
+	return route
+}
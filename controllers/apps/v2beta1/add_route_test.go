@@ -0,0 +1,265 @@
+package v2beta1
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+
+	appsv2beta1 "github.com/emqx/emqx-operator/apis/apps/v2beta1"
+	"github.com/emqx/emqx-operator/internal/handler"
+	innerReq "github.com/emqx/emqx-operator/internal/requester"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestGenerateDashboardRoute(t *testing.T) {
+	instance := &appsv2beta1.EMQX{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "emqx",
+			Namespace: "emqx",
+		},
+		Spec: appsv2beta1.EMQXSpec{
+			DashboardIngress: &appsv2beta1.RouteTemplate{
+				Kind: "Ingress",
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations: map[string]string{"foo": "bar"},
+				},
+				IngressClassName: ptr.To("nginx"),
+				Hosts:            []string{"dashboard.example.com"},
+				Path:             "/",
+				TLS:              &appsv2beta1.RouteTLS{SecretName: "dashboard-tls"},
+			},
+		},
+	}
+
+	t.Run("check ingress", func(t *testing.T) {
+		pathType := networkingv1.PathTypePrefix
+		got := generateDashboardRoute(instance, "dashboard.listeners.http.bind = 18084")
+		assert.Equal(t, &networkingv1.Ingress{
+			TypeMeta: metav1.TypeMeta{
+				APIVersion: "networking.k8s.io/v1",
+				Kind:       "Ingress",
+			},
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace:   "emqx",
+				Name:        "emqx-dashboard",
+				Labels:      appsv2beta1.DefaultLabels(instance),
+				Annotations: map[string]string{"foo": "bar"},
+			},
+			Spec: networkingv1.IngressSpec{
+				IngressClassName: ptr.To("nginx"),
+				Rules: []networkingv1.IngressRule{
+					{
+						Host: "dashboard.example.com",
+						IngressRuleValue: networkingv1.IngressRuleValue{
+							HTTP: &networkingv1.HTTPIngressRuleValue{
+								Paths: []networkingv1.HTTPIngressPath{
+									{
+										Path:     "/",
+										PathType: &pathType,
+										Backend: networkingv1.IngressBackend{
+											Service: &networkingv1.IngressServiceBackend{
+												Name: "emqx-dashboard",
+												Port: networkingv1.ServiceBackendPort{Number: 18084},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+				TLS: []networkingv1.IngressTLS{
+					{
+						Hosts:      []string{"dashboard.example.com"},
+						SecretName: "dashboard-tls",
+					},
+				},
+			},
+		}, got)
+	})
+
+	t.Run("check tls route", func(t *testing.T) {
+		emqx := instance.DeepCopy()
+		emqx.Spec.DashboardIngress = &appsv2beta1.RouteTemplate{
+			Kind:       "TLSRoute",
+			ParentRefs: []appsv2beta1.ParentReference{{Name: "gateway", SectionName: "tls"}},
+			Hosts:      []string{"dashboard.example.com"},
+		}
+		assert.Nil(t, generateDashboardRoute(emqx, ""))
+
+		got := generateDashboardRoute(emqx, "dashboard.listeners.https.bind = 18084").(*unstructured.Unstructured)
+		assert.Equal(t, "gateway.networking.k8s.io/v1alpha2", got.GetAPIVersion())
+		assert.Equal(t, "TLSRoute", got.GetKind())
+		assert.Equal(t, "emqx-dashboard", got.GetName())
+		assert.Equal(t, map[string]interface{}{
+			"parentRefs": []interface{}{
+				map[string]interface{}{"name": "gateway", "sectionName": "tls"},
+			},
+			"hostnames": []interface{}{"dashboard.example.com"},
+			"rules": []interface{}{
+				map[string]interface{}{
+					"backendRefs": []interface{}{
+						map[string]interface{}{"name": "emqx-dashboard", "port": int64(18084)},
+					},
+				},
+			},
+		}, got.Object["spec"])
+	})
+
+	t.Run("check disabled dashboard service", func(t *testing.T) {
+		emqx := instance.DeepCopy()
+		emqx.Spec.DashboardServiceTemplate = &appsv2beta1.ServiceTemplate{Enabled: ptr.To(false)}
+		assert.Nil(t, generateDashboardRoute(emqx, ""))
+	})
+}
+
+func TestGenerateWebsocketRoutes(t *testing.T) {
+	instance := &appsv2beta1.EMQX{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "emqx",
+			Namespace: "emqx",
+		},
+		Spec: appsv2beta1.EMQXSpec{
+			WebsocketRoutes: &appsv2beta1.RouteTemplate{
+				Kind:       "HTTPRoute",
+				ParentRefs: []appsv2beta1.ParentReference{{Name: "gateway", Namespace: "gateway-system"}},
+				Hosts:      []string{"mqtt.example.com"},
+			},
+		},
+	}
+
+	t.Run("check http route", func(t *testing.T) {
+		got := generateWebsocketRoutes(instance, "")
+		assert.Len(t, got, 1)
+		route := got[0].(*unstructured.Unstructured)
+		assert.Equal(t, "gateway.networking.k8s.io/v1", route.GetAPIVersion())
+		assert.Equal(t, "HTTPRoute", route.GetKind())
+		assert.Equal(t, "emqx-ws-default", route.GetName())
+		assert.Equal(t, map[string]interface{}{
+			"parentRefs": []interface{}{
+				map[string]interface{}{"name": "gateway", "namespace": "gateway-system"},
+			},
+			"hostnames": []interface{}{"mqtt.example.com"},
+			"rules": []interface{}{
+				map[string]interface{}{
+					"matches": []interface{}{
+						map[string]interface{}{
+							"path": map[string]interface{}{"type": "PathPrefix", "value": "/mqtt"},
+						},
+					},
+					"backendRefs": []interface{}{
+						map[string]interface{}{"name": "emqx-listeners", "port": int64(8083)},
+					},
+				},
+			},
+		}, route.Object["spec"])
+	})
+
+	t.Run("check extra listeners service", func(t *testing.T) {
+		emqx := instance.DeepCopy()
+		emqx.Spec.ExtraListenersServiceTemplates = []appsv2beta1.ListenersServiceTemplate{
+			{Name: "ws", Listeners: []string{"ws-default"}},
+		}
+		got := generateWebsocketRoutes(emqx, "")
+		assert.Len(t, got, 1)
+		rules, _, _ := unstructured.NestedSlice(got[0].(*unstructured.Unstructured).Object, "spec", "rules")
+		assert.Equal(t, []interface{}{
+			map[string]interface{}{"name": "emqx-listeners-ws", "port": int64(8083)},
+		}, rules[0].(map[string]interface{})["backendRefs"])
+	})
+
+	t.Run("check tcp route", func(t *testing.T) {
+		emqx := instance.DeepCopy()
+		emqx.Spec.WebsocketRoutes.Kind = "TCPRoute"
+		got := generateWebsocketRoutes(emqx, "listeners.ws.default.bind = 8083\nlisteners.ws.internal.bind = 18083")
+		assert.Len(t, got, 2)
+		_, found, _ := unstructured.NestedSlice(got[0].(*unstructured.Unstructured).Object, "spec", "hostnames")
+		assert.False(t, found)
+	})
+
+	t.Run("check disabled listeners service", func(t *testing.T) {
+		emqx := instance.DeepCopy()
+		emqx.Spec.ListenersServiceTemplate = &appsv2beta1.ServiceTemplate{Enabled: ptr.To(false)}
+		assert.Empty(t, generateWebsocketRoutes(emqx, ""))
+	})
+}
+
+func TestDeleteUnusedRoutes(t *testing.T) {
+	httpRouteGVK := schema.GroupVersionKind{Group: gatewayAPIGroup, Version: "v1", Kind: "HTTPRoute"}
+	scheme := runtime.NewScheme()
+	_ = appsv2beta1.AddToScheme(scheme)
+	_ = corev1.AddToScheme(scheme)
+	_ = networkingv1.AddToScheme(scheme)
+	scheme.AddKnownTypeWithName(httpRouteGVK, &unstructured.Unstructured{})
+	scheme.AddKnownTypeWithName(httpRouteGVK.GroupVersion().WithKind("HTTPRouteList"), &unstructured.UnstructuredList{})
+	// The TLSRoute and the TCPRoute CRDs are not installed
+	restMapper := meta.NewDefaultRESTMapper(nil)
+	restMapper.Add(httpRouteGVK, meta.RESTScopeNamespace)
+	restMapper.Add(networkingv1.SchemeGroupVersion.WithKind("Ingress"), meta.RESTScopeNamespace)
+	restMapper.Add(corev1.SchemeGroupVersion.WithKind("Service"), meta.RESTScopeNamespace)
+
+	instance := &appsv2beta1.EMQX{
+		ObjectMeta: metav1.ObjectMeta{Name: "emqx", Namespace: "emqx", UID: "emqx-uid"},
+		Spec: appsv2beta1.EMQXSpec{
+			DashboardIngress: &appsv2beta1.RouteTemplate{Kind: "Ingress"},
+			WebsocketRoutes:  &appsv2beta1.RouteTemplate{Kind: "Ingress"},
+		},
+		Status: appsv2beta1.EMQXStatus{
+			Conditions: []metav1.Condition{{Type: appsv2beta1.CoreNodesReady, Status: metav1.ConditionTrue}},
+		},
+	}
+	fakeClient := fake.NewClientBuilder().WithScheme(scheme).WithRESTMapper(restMapper).WithObjects(instance).Build()
+	a := &addSvc{&EMQXReconciler{
+		Handler:       &handler.Handler{Client: fakeClient, Patcher: newFakePatcher()},
+		Scheme:        scheme,
+		EventRecorder: record.NewFakeRecorder(10),
+	}, &emqxLiveConfig{}}
+	requester := &innerReq.FakeRequester{
+		ReqFunc: func(method string, url url.URL, body []byte, header http.Header) (*http.Response, []byte, error) {
+			return &http.Response{StatusCode: 200}, nil, nil
+		},
+	}
+	getRouteNames := func() []string {
+		names := []string{}
+		ingressList := &networkingv1.IngressList{}
+		assert.NoError(t, fakeClient.List(ctx, ingressList, client.InNamespace("emqx")))
+		for _, ingress := range ingressList.Items {
+			names = append(names, "Ingress/"+ingress.Name)
+		}
+		routeList := &unstructured.UnstructuredList{}
+		routeList.SetGroupVersionKind(httpRouteGVK.GroupVersion().WithKind("HTTPRouteList"))
+		assert.NoError(t, fakeClient.List(ctx, routeList, client.InNamespace("emqx")))
+		for _, route := range routeList.Items {
+			names = append(names, "HTTPRoute/"+route.GetName())
+		}
+		return names
+	}
+
+	emqx := instance.DeepCopy()
+	assert.Nil(t, a.reconcile(ctx, logger, emqx, requester).err)
+	assert.ElementsMatch(t, []string{"Ingress/emqx-dashboard", "Ingress/emqx-ws-default"}, getRouteNames())
+
+	// The kind of the websocket routes is switched
+	emqx = instance.DeepCopy()
+	emqx.Spec.WebsocketRoutes.Kind = "HTTPRoute"
+	assert.Nil(t, a.reconcile(ctx, logger, emqx, requester).err)
+	assert.ElementsMatch(t, []string{"Ingress/emqx-dashboard", "HTTPRoute/emqx-ws-default"}, getRouteNames())
+
+	// The routes are removed
+	emqx = instance.DeepCopy()
+	emqx.Spec.DashboardIngress = nil
+	emqx.Spec.WebsocketRoutes = nil
+	assert.Nil(t, a.reconcile(ctx, logger, emqx, requester).err)
+	assert.Empty(t, getRouteNames())
+}
@@ -3,12 +3,15 @@ package v2beta1
 import (
 	"context"
 	"net/http"
+	"slices"
+	"strings"
 
 	emperror "emperror.dev/errors"
 	appsv2beta1 "github.com/emqx/emqx-operator/apis/apps/v2beta1"
 	innerReq "github.com/emqx/emqx-operator/internal/requester"
 	"github.com/go-logr/logr"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -16,6 +19,7 @@ import (
 
 type addSvc struct {
 	*EMQXReconciler
+	liveConfig *emqxLiveConfig
 }
 
 func (a *addSvc) reconcile(ctx context.Context, logger logr.Logger, instance *appsv2beta1.EMQX, r innerReq.RequesterInterface) subResult {
@@ -27,7 +31,7 @@ func (a *addSvc) reconcile(ctx context.Context, logger logr.Logger, instance *ap
 		return subResult{}
 	}
 
-	configStr, err := a.getEMQXConfigsByAPI(r)
+	configStr, err := a.liveConfig.get(r)
 	if err != nil {
 		return subResult{err: emperror.Wrap(err, "failed to get emqx configs by api")}
 	}
@@ -36,17 +40,116 @@ func (a *addSvc) reconcile(ctx context.Context, logger logr.Logger, instance *ap
 	if dashboard := generateDashboardService(instance, configStr); dashboard != nil {
 		resources = append(resources, dashboard)
 	}
+	listenersServices := []client.Object{}
 	if listeners := generateListenerService(instance, configStr); listeners != nil {
-		resources = append(resources, listeners)
+		listenersServices = append(listenersServices, listeners)
+	}
+	for _, listeners := range generateExtraListenersServices(instance, configStr) {
+		listenersServices = append(listenersServices, listeners)
+	}
+	resources = append(resources, listenersServices...)
+	for _, route := range generateRoutes(instance, configStr) {
+		gvk := route.GetObjectKind().GroupVersionKind()
+		if gvk.Group == gatewayAPIGroup {
+			if _, err := a.Client.RESTMapper().RESTMapping(gvk.GroupKind(), gvk.Version); err != nil {
+				if !meta.IsNoMatchError(err) {
+					return subResult{err: emperror.Wrap(err, "failed to get REST mapping for route")}
+				}
+				logger.V(1).Info("the gateway.networking.k8s.io CRDs are not installed, skip to create route", "kind", gvk.Kind)
+				continue
+			}
+		}
+		resources = append(resources, route)
 	}
 
 	if err := a.CreateOrUpdateList(ctx, a.Scheme, logger, instance, resources); err != nil {
-		return subResult{err: emperror.Wrap(err, "failed to create or update services")}
+		return subResult{err: emperror.Wrap(err, "failed to create or update services and routes")}
+	}
+
+	// The listeners service is not generated when all the listeners are served by the extra listeners services
+	listenersServiceName := instance.ListenersServiceNamespacedName().Name
+	isListenersService := func(name string) bool {
+		return name == listenersServiceName || strings.HasPrefix(name, listenersServiceName+"-")
+	}
+	if err := a.deleteUnusedResources(ctx, logger, instance, &corev1.ServiceList{}, listenersServices, isListenersService); err != nil {
+		return subResult{err: emperror.Wrap(err, "failed to delete unused listeners services")}
+	}
+	// All the routes created by EMQX Operator are generated by generateRoutes, delete the ones removed or switched to another kind
+	for _, list := range newRouteLists() {
+		routes := []client.Object{}
+		for _, route := range resources {
+			if route.GetObjectKind().GroupVersionKind().Kind+"List" == list.GetObjectKind().GroupVersionKind().Kind {
+				routes = append(routes, route)
+			}
+		}
+		if err := a.deleteUnusedResources(ctx, logger, instance, list, routes, func(string) bool { return true }); err != nil {
+			return subResult{err: emperror.Wrap(err, "failed to delete unused routes")}
+		}
 	}
 	return subResult{}
 }
 
-func (a *addSvc) getEMQXConfigsByAPI(r innerReq.RequesterInterface) (string, error) {
+// deleteUnusedResources deletes the resources of the list which are created by EMQX Operator and selected by isManaged,
+// but are not in the generated resources any more, like the resources removed from the EMQX custom resource
+func (a *addSvc) deleteUnusedResources(ctx context.Context, logger logr.Logger, instance *appsv2beta1.EMQX, list client.ObjectList, generated []client.Object, isManaged func(name string) bool) error {
+	if err := a.Client.List(ctx, list,
+		client.InNamespace(instance.Namespace),
+		client.MatchingLabels(appsv2beta1.DefaultLabels(instance)),
+	); err != nil {
+		if meta.IsNoMatchError(err) {
+			return nil
+		}
+		return err
+	}
+	items, err := meta.ExtractList(list)
+	if err != nil {
+		return err
+	}
+
+	names := map[string]struct{}{}
+	for _, obj := range generated {
+		names[obj.GetName()] = struct{}{}
+	}
+	for _, item := range items {
+		obj, ok := item.(client.Object)
+		if !ok || !isManaged(obj.GetName()) || !metav1.IsControlledBy(obj, instance) {
+			continue
+		}
+		if _, ok := names[obj.GetName()]; ok {
+			continue
+		}
+		if err := a.Client.Delete(ctx, obj); client.IgnoreNotFound(err) != nil {
+			return err
+		}
+		logger.Info("deleted unused resource", "name", obj.GetName())
+	}
+	return nil
+}
+
+// emqxLiveConfig is the config of the running EMQX nodes, it is fetched once in a reconcile and shared by the subreconcilers,
+// the subreconcilers which change the config by the API of EMQX must reset it
+type emqxLiveConfig struct {
+	config  string
+	fetched bool
+}
+
+func (c *emqxLiveConfig) get(r innerReq.RequesterInterface) (string, error) {
+	if c.fetched {
+		return c.config, nil
+	}
+	config, err := getEMQXConfigsByAPI(r)
+	if err != nil {
+		return "", err
+	}
+	c.config, c.fetched = config, true
+	return config, nil
+}
+
+func (c *emqxLiveConfig) reset() {
+	c.config, c.fetched = "", false
+}
+
+func getEMQXConfigsByAPI(r innerReq.RequesterInterface) (string, error) {
 	url := r.GetURL("api/v5/configs")
 
 	resp, body, err := r.Request("GET", url, nil, http.Header{
@@ -104,7 +207,51 @@ func generateListenerService(instance *appsv2beta1.EMQX, configStr string) *core
 		svc.Spec = *instance.Spec.ListenersServiceTemplate.Spec.DeepCopy()
 	}
 
+	// The listeners served by the extra listeners services are removed from this service
+	extraListeners := map[string]struct{}{}
+	for _, template := range instance.Spec.ExtraListenersServiceTemplates {
+		if template.Enabled != nil && !*template.Enabled {
+			continue
+		}
+		for _, listener := range template.Listeners {
+			extraListeners[listener] = struct{}{}
+		}
+	}
+	ports := []corev1.ServicePort{}
+	for _, port := range getListenersServicePorts(instance, configStr) {
+		if _, ok := extraListeners[port.Name]; !ok {
+			ports = append(ports, port)
+		}
+	}
+	if len(ports) == 0 {
+		return nil
+	}
+
+	svc.Spec.Ports = appsv2beta1.MergeServicePorts(
+		svc.Spec.Ports,
+		ports,
+	)
+	svc.Spec.Selector = getListenersServiceSelector(instance)
+	return &corev1.Service{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "Service",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:   instance.Namespace,
+			Name:        instance.ListenersServiceNamespacedName().Name,
+			Labels:      appsv2beta1.CloneAndMergeMap(appsv2beta1.DefaultLabels(instance), svc.ObjectMeta.Labels),
+			Annotations: svc.ObjectMeta.Annotations,
+		},
+		Spec: svc.Spec,
+	}
+}
+
+// getListenersServicePorts returns the service ports of all the listeners,
+// the default listeners are served if there is no listener in the EMQX config
+func getListenersServicePorts(instance *appsv2beta1.EMQX, configStr string) []corev1.ServicePort {
 	ports, _ := appsv2beta1.GetListenersServicePorts(configStr)
+	ports = appsv2beta1.MergeServicePorts(appsv2beta1.GetSpecListenersServicePorts(instance.Spec.Listeners), ports)
 	if len(ports) == 0 {
 		ports = append(ports, []corev1.ServicePort{
 			{
@@ -134,25 +281,53 @@ func generateListenerService(instance *appsv2beta1.EMQX, configStr string) *core
 		}...)
 	}
 
-	svc.Spec.Ports = appsv2beta1.MergeServicePorts(
-		svc.Spec.Ports,
-		ports,
-	)
-	svc.Spec.Selector = appsv2beta1.DefaultCoreLabels(instance)
-	if appsv2beta1.IsExistReplicant(instance) && instance.Status.ReplicantNodesStatus.ReadyReplicas > 0 {
-		svc.Spec.Selector = appsv2beta1.DefaultReplicantLabels(instance)
+	return ports
+}
+
+// generateExtraListenersServices returns the services of ".spec.extraListenersServiceTemplates",
+// each of them selects the same EMQX nodes as the listeners service
+func generateExtraListenersServices(instance *appsv2beta1.EMQX, configStr string) []*corev1.Service {
+	listenerPorts := getListenersServicePorts(instance, configStr)
+
+	services := []*corev1.Service{}
+	for _, template := range instance.Spec.ExtraListenersServiceTemplates {
+		if template.Enabled != nil && !*template.Enabled {
+			continue
+		}
+		ports := []corev1.ServicePort{}
+		for _, port := range listenerPorts {
+			if slices.Contains(template.Listeners, port.Name) {
+				ports = append(ports, port)
+			}
+		}
+		if len(ports) == 0 {
+			continue
+		}
+
+		spec := template.Spec.DeepCopy()
+		spec.Ports = appsv2beta1.MergeServicePorts(spec.Ports, ports)
+		spec.Selector = getListenersServiceSelector(instance)
+		services = append(services, &corev1.Service{
+			TypeMeta: metav1.TypeMeta{
+				APIVersion: "v1",
+				Kind:       "Service",
+			},
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace:   instance.Namespace,
+				Name:        instance.ExtraListenersServiceNamespacedName(template.Name).Name,
+				Labels:      appsv2beta1.CloneAndMergeMap(appsv2beta1.DefaultLabels(instance), template.ObjectMeta.Labels),
+				Annotations: template.ObjectMeta.Annotations,
+			},
+			Spec: *spec,
+		})
 	}
-	return &corev1.Service{
-		TypeMeta: metav1.TypeMeta{
-			APIVersion: "v1",
-			Kind:       "Service",
-		},
-		ObjectMeta: metav1.ObjectMeta{
-			Namespace:   instance.Namespace,
-			Name:        instance.ListenersServiceNamespacedName().Name,
-			Labels:      appsv2beta1.CloneAndMergeMap(appsv2beta1.DefaultLabels(instance), svc.ObjectMeta.Labels),
-			Annotations: svc.ObjectMeta.Annotations,
-		},
-		Spec: svc.Spec,
+	return services
+}
+
+// getListenersServiceSelector returns the labels of the replicant nodes if they are ready, otherwise the core nodes
+func getListenersServiceSelector(instance *appsv2beta1.EMQX) map[string]string {
+	if appsv2beta1.IsExistReplicant(instance) && instance.Status.ReplicantNodesStatus.ReadyReplicas > 0 {
+		return appsv2beta1.DefaultReplicantLabels(instance)
 	}
+	return appsv2beta1.DefaultCoreLabels(instance)
 }
@@ -1,14 +1,23 @@
 package v2beta1
 
 import (
+	"net/http"
+	"net/url"
 	"testing"
 
 	appsv2beta1 "github.com/emqx/emqx-operator/apis/apps/v2beta1"
+	"github.com/emqx/emqx-operator/internal/handler"
+	innerReq "github.com/emqx/emqx-operator/internal/requester"
 	"github.com/stretchr/testify/assert"
 	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/tools/record"
 	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 )
 
 func TestGenerateHeadlessSVC(t *testing.T) {
@@ -180,3 +189,178 @@ func TestGenerateDashboardService(t *testing.T) {
 		assert.Nil(t, got)
 	})
 }
+
+func TestGenerateExtraListenersServices(t *testing.T) {
+	instance := &appsv2beta1.EMQX{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "emqx",
+			Namespace: "emqx",
+			Labels: map[string]string{
+				"foo": "bar",
+			},
+		},
+		Spec: appsv2beta1.EMQXSpec{
+			ListenersServiceTemplate: &appsv2beta1.ServiceTemplate{
+				Enabled: ptr.To(true),
+			},
+			ExtraListenersServiceTemplates: []appsv2beta1.ListenersServiceTemplate{
+				{
+					Name:      "mqtt",
+					Listeners: []string{"tcp-default", "ssl-default"},
+					ServiceTemplate: appsv2beta1.ServiceTemplate{
+						Enabled: ptr.To(true),
+						ObjectMeta: metav1.ObjectMeta{
+							Annotations: map[string]string{
+								"service.beta.kubernetes.io/aws-load-balancer-type": "nlb",
+							},
+						},
+						Spec: corev1.ServiceSpec{
+							Type:                  corev1.ServiceTypeLoadBalancer,
+							ExternalTrafficPolicy: corev1.ServiceExternalTrafficPolicyLocal,
+						},
+					},
+				},
+			},
+		},
+	}
+
+	t.Run("check extra services", func(t *testing.T) {
+		got := generateExtraListenersServices(instance, "")
+		assert.Len(t, got, 1)
+		assert.Equal(t, "emqx-listeners-mqtt", got[0].Name)
+		assert.Equal(t, "emqx", got[0].Namespace)
+		assert.Equal(t, map[string]string{"service.beta.kubernetes.io/aws-load-balancer-type": "nlb"}, got[0].Annotations)
+		assert.Equal(t, appsv2beta1.DefaultLabels(instance), got[0].Labels)
+		assert.Equal(t, appsv2beta1.DefaultCoreLabels(instance), got[0].Spec.Selector)
+		assert.Equal(t, corev1.ServiceTypeLoadBalancer, got[0].Spec.Type)
+		assert.Equal(t, corev1.ServiceExternalTrafficPolicyLocal, got[0].Spec.ExternalTrafficPolicy)
+		assert.ElementsMatch(t, []corev1.ServicePort{
+			{
+				Name:       "tcp-default",
+				Protocol:   corev1.ProtocolTCP,
+				Port:       1883,
+				TargetPort: intstr.FromInt(1883),
+			},
+			{
+				Name:       "ssl-default",
+				Protocol:   corev1.ProtocolTCP,
+				Port:       8883,
+				TargetPort: intstr.FromInt(8883),
+			},
+		}, got[0].Spec.Ports)
+	})
+
+	t.Run("check listeners service", func(t *testing.T) {
+		got := generateListenerService(instance, "")
+		assert.NotNil(t, got)
+		assert.ElementsMatch(t, []corev1.ServicePort{
+			{
+				Name:       "ws-default",
+				Protocol:   corev1.ProtocolTCP,
+				Port:       8083,
+				TargetPort: intstr.FromInt(8083),
+			},
+			{
+				Name:       "wss-default",
+				Protocol:   corev1.ProtocolTCP,
+				Port:       8084,
+				TargetPort: intstr.FromInt(8084),
+			},
+		}, got.Spec.Ports)
+	})
+
+	t.Run("check all listeners are served by extra services", func(t *testing.T) {
+		emqx := instance.DeepCopy()
+		emqx.Spec.ExtraListenersServiceTemplates = append(emqx.Spec.ExtraListenersServiceTemplates, appsv2beta1.ListenersServiceTemplate{
+			Name:      "ws",
+			Listeners: []string{"ws-default", "wss-default"},
+			ServiceTemplate: appsv2beta1.ServiceTemplate{
+				Enabled: ptr.To(true),
+			},
+		})
+		assert.Nil(t, generateListenerService(emqx, ""))
+		assert.Len(t, generateExtraListenersServices(emqx, ""), 2)
+	})
+
+	t.Run("check disabled", func(t *testing.T) {
+		emqx := instance.DeepCopy()
+		emqx.Spec.ExtraListenersServiceTemplates[0].Enabled = ptr.To(false)
+		assert.Empty(t, generateExtraListenersServices(emqx, ""))
+		// The listeners of the disabled template are served by the listeners service
+		got := generateListenerService(emqx, "")
+		assert.NotNil(t, got)
+		assert.Len(t, got.Spec.Ports, 4)
+	})
+
+	t.Run("check selector", func(t *testing.T) {
+		emqx := instance.DeepCopy()
+		emqx.Spec.ReplicantTemplate = &appsv2beta1.EMQXReplicantTemplate{
+			Spec: appsv2beta1.EMQXReplicantTemplateSpec{
+				Replicas: ptr.To(int32(3)),
+			},
+		}
+		emqx.Status.ReplicantNodesStatus.ReadyReplicas = 3
+		got := generateExtraListenersServices(emqx, "")
+		assert.Equal(t, appsv2beta1.DefaultReplicantLabels(emqx), got[0].Spec.Selector)
+	})
+}
+
+func TestDeleteUnusedListenersServices(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = appsv2beta1.AddToScheme(scheme)
+	_ = corev1.AddToScheme(scheme)
+	_ = networkingv1.AddToScheme(scheme)
+
+	instance := &appsv2beta1.EMQX{
+		ObjectMeta: metav1.ObjectMeta{Name: "emqx", Namespace: "emqx", UID: "emqx-uid"},
+		Spec: appsv2beta1.EMQXSpec{
+			ExtraListenersServiceTemplates: []appsv2beta1.ListenersServiceTemplate{
+				{Name: "mqtt", Listeners: []string{"tcp-default", "ssl-default"}},
+				{Name: "ws", Listeners: []string{"ws-default", "wss-default"}},
+			},
+		},
+		Status: appsv2beta1.EMQXStatus{
+			Conditions: []metav1.Condition{{Type: appsv2beta1.CoreNodesReady, Status: metav1.ConditionTrue}},
+		},
+	}
+	// The service is not created by EMQX Operator
+	unmanaged := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "emqx-listeners-custom", Namespace: "emqx", Labels: appsv2beta1.DefaultLabels(instance)},
+	}
+	fakeClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(instance, unmanaged).Build()
+	a := &addSvc{&EMQXReconciler{
+		Handler:       &handler.Handler{Client: fakeClient, Patcher: newFakePatcher()},
+		Scheme:        scheme,
+		EventRecorder: record.NewFakeRecorder(10),
+	}, &emqxLiveConfig{}}
+	requester := &innerReq.FakeRequester{
+		ReqFunc: func(method string, url url.URL, body []byte, header http.Header) (*http.Response, []byte, error) {
+			return &http.Response{StatusCode: 200}, nil, nil
+		},
+	}
+	getServiceNames := func() []string {
+		list := &corev1.ServiceList{}
+		assert.NoError(t, fakeClient.List(ctx, list, client.InNamespace("emqx")))
+		names := []string{}
+		for _, svc := range list.Items {
+			names = append(names, svc.Name)
+		}
+		return names
+	}
+
+	emqx := instance.DeepCopy()
+	emqx.Spec.ExtraListenersServiceTemplates = emqx.Spec.ExtraListenersServiceTemplates[:1]
+	assert.Nil(t, a.reconcile(ctx, logger, emqx, requester).err)
+	assert.ElementsMatch(t, []string{"emqx-dashboard", "emqx-listeners", "emqx-listeners-mqtt", "emqx-listeners-custom"}, getServiceNames())
+
+	// All the listeners are moved to the extra listeners services
+	emqx = instance.DeepCopy()
+	assert.Nil(t, a.reconcile(ctx, logger, emqx, requester).err)
+	assert.ElementsMatch(t, []string{"emqx-dashboard", "emqx-listeners-mqtt", "emqx-listeners-ws", "emqx-listeners-custom"}, getServiceNames())
+
+	// The extra listeners service is removed
+	emqx = instance.DeepCopy()
+	emqx.Spec.ExtraListenersServiceTemplates = emqx.Spec.ExtraListenersServiceTemplates[1:]
+	assert.Nil(t, a.reconcile(ctx, logger, emqx, requester).err)
+	assert.ElementsMatch(t, []string{"emqx-dashboard", "emqx-listeners", "emqx-listeners-ws", "emqx-listeners-custom"}, getServiceNames())
+}
@@ -0,0 +1,348 @@
+package v2beta1
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	emperror "emperror.dev/errors"
+	appsv2beta1 "github.com/emqx/emqx-operator/apis/apps/v2beta1"
+	innerReq "github.com/emqx/emqx-operator/internal/requester"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	apiResourceFinalizer = "apps.emqx.io/finalizer"
+	// apiResourceWaitInterval is the interval to wait for the EMQX cluster to be ready
+	apiResourceWaitInterval = 5 * time.Second
+	// apiResourceSyncInterval is the interval to check the resources in EMQX, and to retry the failed resources
+	apiResourceSyncInterval = time.Minute
+	// apiResourceDeleteTimeout is the time to retry deleting the resource from EMQX, the finalizer is removed after it
+	apiResourceDeleteTimeout = 5 * time.Minute
+)
+
+// apiResourceObject is the custom resource which is applied to the EMQX cluster of ".spec.instanceName"
+// through the EMQX HTTP API, like EMQXConnector and EMQXUser
+type apiResourceObject interface {
+	client.Object
+	GetInstanceName() string
+	IsFailed(message string) bool
+	SetFailed(message string)
+}
+
+// apiResourceHandler applies an apiResourceObject to EMQX, and deletes it from EMQX before its finalizer is removed
+type apiResourceHandler struct {
+	sync   func(emqx *appsv2beta1.EMQX, requester innerReq.RequesterInterface) (ctrl.Result, error)
+	delete func(requester innerReq.RequesterInterface) error
+}
+
+// apiResourceLifecycle is the lifecycle shared by the reconcilers of the apiResourceObjects,
+// the reconcilers only apply and delete the resources of their kinds
+type apiResourceLifecycle struct {
+	client        client.Client
+	eventRecorder record.EventRecorder
+	// kind is the prefix of the event reasons, like "Connector"
+	kind string
+}
+
+// reconcile gets the object and its EMQX cluster, manages the finalizer,
+// and calls the handler when the EMQX cluster is ready
+func (l *apiResourceLifecycle) reconcile(ctx context.Context, req ctrl.Request, obj apiResourceObject, h apiResourceHandler) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+
+	if err := l.client.Get(ctx, req.NamespacedName, obj); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	emqx := &appsv2beta1.EMQX{}
+	if err := l.client.Get(ctx, types.NamespacedName{
+		Name:      obj.GetInstanceName(),
+		Namespace: obj.GetNamespace(),
+	}, emqx); err != nil {
+		if !k8sErrors.IsNotFound(err) {
+			return ctrl.Result{}, emperror.Wrap(err, "failed to get EMQX")
+		}
+		// The resource is deleted with the EMQX cluster
+		if !obj.GetDeletionTimestamp().IsZero() {
+			controllerutil.RemoveFinalizer(obj, apiResourceFinalizer)
+			return ctrl.Result{}, l.client.Update(ctx, obj)
+		}
+		return l.setFailed(ctx, obj, fmt.Sprintf("EMQX %s is not found", obj.GetInstanceName()))
+	}
+
+	if !obj.GetDeletionTimestamp().IsZero() {
+		return l.finalize(ctx, obj, emqx, h)
+	}
+
+	if !emqx.Status.IsConditionTrue(appsv2beta1.Ready) {
+		logger.V(1).Info("EMQX is not ready, wait for it", "emqx", emqx.Name)
+		return ctrl.Result{RequeueAfter: apiResourceWaitInterval}, nil
+	}
+
+	requester, err := newRequester(ctx, l.client, emqx)
+	if err != nil {
+		return ctrl.Result{}, emperror.Wrap(err, "failed to create EMQX API requester")
+	}
+	if requester == nil {
+		return ctrl.Result{RequeueAfter: apiResourceWaitInterval}, nil
+	}
+
+	if !controllerutil.ContainsFinalizer(obj, apiResourceFinalizer) {
+		controllerutil.AddFinalizer(obj, apiResourceFinalizer)
+		if err := l.client.Update(ctx, obj); err != nil {
+			return ctrl.Result{}, emperror.Wrap(err, "failed to add finalizer")
+		}
+	}
+
+	return h.sync(emqx, requester)
+}
+
+// finalize deletes the resource from EMQX and removes the finalizer. The EMQX cluster doesn't need to be ready,
+// the resource is deleted by any running EMQX node. The finalizer is removed without deleting the resource
+// if the EMQX cluster is being deleted, or the resource is not deleted in apiResourceDeleteTimeout,
+// so the deletion is not blocked by an unhealthy EMQX cluster.
+func (l *apiResourceLifecycle) finalize(ctx context.Context, obj apiResourceObject, emqx *appsv2beta1.EMQX, h apiResourceHandler) (ctrl.Result, error) {
+	if !controllerutil.ContainsFinalizer(obj, apiResourceFinalizer) {
+		return ctrl.Result{}, nil
+	}
+
+	if emqx.GetDeletionTimestamp().IsZero() {
+		err := func() error {
+			requester, err := newRequester(ctx, l.client, emqx)
+			if err != nil {
+				return emperror.Wrap(err, "failed to create EMQX API requester")
+			}
+			if requester == nil {
+				return emperror.New("no EMQX node is ready")
+			}
+			return h.delete(requester)
+		}()
+		if err != nil {
+			if time.Since(obj.GetDeletionTimestamp().Time) < apiResourceDeleteTimeout {
+				log.FromContext(ctx).V(1).Info("failed to delete the resource from EMQX, retry", "error", err.Error())
+				return ctrl.Result{RequeueAfter: apiResourceWaitInterval}, nil
+			}
+			l.eventRecorder.Event(obj, corev1.EventTypeWarning, l.kind+"DeleteFailed",
+				fmt.Sprintf("Failed to delete the %s from EMQX in %s, it is left in EMQX: %s", strings.ToLower(l.kind), apiResourceDeleteTimeout, err.Error()))
+		}
+	}
+
+	controllerutil.RemoveFinalizer(obj, apiResourceFinalizer)
+	return ctrl.Result{}, l.client.Update(ctx, obj)
+}
+
+// setFailed records the failure in the status, the event is only emitted when the message is changed,
+// and the object is retried after apiResourceSyncInterval
+func (l *apiResourceLifecycle) setFailed(ctx context.Context, obj apiResourceObject, message string) (ctrl.Result, error) {
+	result := ctrl.Result{RequeueAfter: apiResourceSyncInterval}
+	if obj.IsFailed(message) {
+		return result, nil
+	}
+	obj.SetFailed(message)
+	l.eventRecorder.Event(obj, corev1.EventTypeWarning, l.kind+"Failed", message)
+	return result, l.client.Status().Update(ctx, obj)
+}
+
+// syncAPIResource applies the resource to EMQX, and updates the status by setSynced with the resource in EMQX
+func (l *apiResourceLifecycle) syncAPIResource(
+	ctx context.Context,
+	obj apiResourceObject,
+	requester innerReq.RequesterInterface,
+	res apiResource,
+	lastHash string,
+	setSynced func(body []byte),
+) (ctrl.Result, error) {
+	body, err := applyAPIResource(requester, res, lastHash)
+	if err != nil {
+		return l.setFailed(ctx, obj, fmt.Sprintf("Failed to apply the %s: %s", strings.ToLower(l.kind), err.Error()))
+	}
+
+	old := obj.DeepCopyObject()
+	setSynced(body)
+	if !equality.Semantic.DeepEqual(old, obj) {
+		if res.hash() != lastHash {
+			l.eventRecorder.Event(obj, corev1.EventTypeNormal, l.kind+"Synced", fmt.Sprintf("%s %s is synced", strings.ToLower(l.kind), res.id))
+		}
+		if err := l.client.Status().Update(ctx, obj); err != nil {
+			return ctrl.Result{}, emperror.Wrap(err, "failed to update status")
+		}
+	}
+	// Check the resource in EMQX periodically, it is created again if the EMQX cluster is recreated
+	return ctrl.Result{RequeueAfter: apiResourceSyncInterval}, nil
+}
+
+// requests returns the requests of the objects in the namespace which match
+func (l *apiResourceLifecycle) requests(ctx context.Context, list client.ObjectList, namespace string, match func(apiResourceObject) bool) []reconcile.Request {
+	if err := l.client.List(ctx, list, client.InNamespace(namespace)); err != nil {
+		return nil
+	}
+	objs, err := meta.ExtractList(list)
+	if err != nil {
+		return nil
+	}
+	requests := []reconcile.Request{}
+	for _, o := range objs {
+		if obj, ok := o.(apiResourceObject); ok && match(obj) {
+			requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(obj)})
+		}
+	}
+	return requests
+}
+
+// apiResource is the connector, the action or the rule which is managed through the EMQX HTTP API
+type apiResource struct {
+	// path is the path of the API, like "api/v5/connectors"
+	path string
+	// id is the ID of the resource in the path of the API, like "http:my-connector"
+	id string
+	// keys are the fields which identify the resource, they are only sent on creating
+	keys map[string]interface{}
+	body map[string]interface{}
+	// secretVersions are the versions of the Secrets whose values are set in the body, by the dotted paths of the fields,
+	// the values are replaced by the versions when the body is hashed, so the hash in the status doesn't leak them
+	secretVersions map[string]string
+}
+
+func (res apiResource) hash() string {
+	body := res.body
+	if len(res.secretVersions) > 0 {
+		body = runtime.DeepCopyJSON(res.body)
+		for path, version := range res.secretVersions {
+			_ = unstructured.SetNestedField(body, version, strings.Split(path, ".")...)
+		}
+	}
+	data, _ := json.Marshal(body)
+	return computeConfigHash(string(data))
+}
+
+// applyAPIResource creates the resource if it does not exist in EMQX, e.g. the EMQX cluster is recreated,
+// and updates the resource if its hash is different from the last applied hash.
+// It returns the resource in EMQX.
+func applyAPIResource(r innerReq.RequesterInterface, res apiResource, lastHash string) ([]byte, error) {
+	url := r.GetURL(fmt.Sprintf("%s/%s", res.path, res.id))
+	resp, body, err := r.Request("GET", url, nil, nil)
+	if err != nil {
+		return nil, emperror.Wrapf(err, "failed to get API %s", url.String())
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		create := maps.Clone(res.body)
+		maps.Copy(create, res.keys)
+		data, _ := json.Marshal(create)
+		url = r.GetURL(res.path)
+		resp, body, err = r.Request("POST", url, data, nil)
+		if err != nil {
+			return nil, emperror.Wrapf(err, "failed to post API %s", url.String())
+		}
+		if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+			return nil, emperror.Errorf("failed to post API %s, status : %s, body: %s", url.String(), resp.Status, body)
+		}
+	case resp.StatusCode != http.StatusOK:
+		return nil, emperror.Errorf("failed to get API %s, status : %s, body: %s", url.String(), resp.Status, body)
+	case res.hash() != lastHash:
+		data, _ := json.Marshal(res.body)
+		resp, body, err = r.Request("PUT", url, data, nil)
+		if err != nil {
+			return nil, emperror.Wrapf(err, "failed to put API %s", url.String())
+		}
+		if resp.StatusCode != http.StatusOK {
+			return nil, emperror.Errorf("failed to put API %s, status : %s, body: %s", url.String(), resp.Status, body)
+		}
+	}
+	return body, nil
+}
+
+// getEscapedURL returns the URL of the API path whose last segment is the ID, the ID is escaped in the request,
+// because it is given by the user, like the user ID, and may contain "/" or "?"
+func getEscapedURL(r innerReq.RequesterInterface, path, id string) url.URL {
+	u := r.GetURL(path + "/" + id)
+	u.RawPath = path + "/" + url.PathEscape(id)
+	return u
+}
+
+func deleteAPIResource(r innerReq.RequesterInterface, res apiResource) error {
+	url := r.GetURL(fmt.Sprintf("%s/%s", res.path, res.id))
+	resp, body, err := r.Request("DELETE", url, nil, nil)
+	if err != nil {
+		return emperror.Wrapf(err, "failed to delete API %s", url.String())
+	}
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNotFound {
+		return emperror.Errorf("failed to delete API %s, status : %s, body: %s", url.String(), resp.Status, body)
+	}
+	return nil
+}
+
+// setAPIResourceConfig sets the body of the resource by the config and the secret config,
+// and records the versions of the Secrets to hash the body
+func setAPIResourceConfig(ctx context.Context, k8sClient client.Client, namespace string, res *apiResource, config runtime.RawExtension, secretConfig map[string]appsv2beta1.KeyRef) error {
+	reader := &secretVersionReader{ctx: ctx, client: k8sClient, namespace: namespace}
+	body, err := generateAPIResourceConfig(config, secretConfig, reader.read)
+	if err != nil {
+		return err
+	}
+	res.body = body
+	res.secretVersions = map[string]string{}
+	for path, ref := range secretConfig {
+		res.secretVersions[path] = reader.versions[ref]
+	}
+	return nil
+}
+
+// generateAPIResourceConfig returns the config with the values of the secret config,
+// the keys of the secret config are the dotted paths of the fields
+func generateAPIResourceConfig(config runtime.RawExtension, secretConfig map[string]appsv2beta1.KeyRef, readSecret func(appsv2beta1.KeyRef) (string, error)) (map[string]interface{}, error) {
+	body := map[string]interface{}{}
+	if len(config.Raw) > 0 {
+		if err := json.Unmarshal(config.Raw, &body); err != nil {
+			return nil, emperror.Wrap(err, "failed to unmarshal config")
+		}
+	}
+
+	paths := []string{}
+	for path := range secretConfig {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		value, err := readSecret(secretConfig[path])
+		if err != nil {
+			return nil, err
+		}
+		if err := unstructured.SetNestedField(body, value, strings.Split(path, ".")...); err != nil {
+			return nil, emperror.Wrapf(err, "failed to set config %s", path)
+		}
+	}
+	return body, nil
+}
+
+// isAPIResourceDependency returns whether the object is the EMQX or one of the secrets used by the resource
+func isAPIResourceDependency(obj client.Object, instanceName string, secretConfig map[string]appsv2beta1.KeyRef) bool {
+	if _, ok := obj.(*appsv2beta1.EMQX); ok {
+		return obj.GetName() == instanceName
+	}
+	for _, ref := range secretConfig {
+		if ref.SecretName == obj.GetName() {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,299 @@
+package v2beta1
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	appsv2beta1 "github.com/emqx/emqx-operator/apis/apps/v2beta1"
+	innerReq "github.com/emqx/emqx-operator/internal/requester"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestApplyAPIResource(t *testing.T) {
+	res := apiResource{
+		path: "api/v5/connectors",
+		id:   "http:emqx",
+		keys: map[string]interface{}{"type": "http", "name": "emqx"},
+		body: map[string]interface{}{"url": "http://webhook:8080"},
+	}
+
+	t.Run("create", func(t *testing.T) {
+		requests := []string{}
+		requester := &innerReq.FakeRequester{
+			ReqFunc: func(method string, url url.URL, body []byte, header http.Header) (*http.Response, []byte, error) {
+				requests = append(requests, method+" "+url.Path+" "+string(body))
+				if method == "GET" {
+					return &http.Response{StatusCode: 404, Status: "404 Not Found"}, nil, nil
+				}
+				return &http.Response{StatusCode: 201}, []byte(`{"status":"connecting"}`), nil
+			},
+		}
+		got, err := applyAPIResource(requester, res, res.hash())
+		assert.NoError(t, err)
+		assert.JSONEq(t, `{"status":"connecting"}`, string(got))
+		assert.Equal(t, []string{
+			"GET api/v5/connectors/http:emqx ",
+			`POST api/v5/connectors {"name":"emqx","type":"http","url":"http://webhook:8080"}`,
+		}, requests)
+	})
+
+	t.Run("update", func(t *testing.T) {
+		requests := []string{}
+		requester := &innerReq.FakeRequester{
+			ReqFunc: func(method string, url url.URL, body []byte, header http.Header) (*http.Response, []byte, error) {
+				requests = append(requests, method+" "+url.Path+" "+string(body))
+				return &http.Response{StatusCode: 200}, []byte(`{"status":"connected"}`), nil
+			},
+		}
+		_, err := applyAPIResource(requester, res, "fake")
+		assert.NoError(t, err)
+		assert.Equal(t, []string{
+			"GET api/v5/connectors/http:emqx ",
+			`PUT api/v5/connectors/http:emqx {"url":"http://webhook:8080"}`,
+		}, requests)
+	})
+
+	t.Run("nothing changed", func(t *testing.T) {
+		requester := &innerReq.FakeRequester{
+			ReqFunc: func(method string, url url.URL, body []byte, header http.Header) (*http.Response, []byte, error) {
+				assert.Equal(t, "GET", method)
+				return &http.Response{StatusCode: 200}, []byte(`{"status":"connected"}`), nil
+			},
+		}
+		got, err := applyAPIResource(requester, res, res.hash())
+		assert.NoError(t, err)
+		assert.JSONEq(t, `{"status":"connected"}`, string(got))
+	})
+
+	t.Run("failed to create", func(t *testing.T) {
+		requester := &innerReq.FakeRequester{
+			ReqFunc: func(method string, url url.URL, body []byte, header http.Header) (*http.Response, []byte, error) {
+				if method == "GET" {
+					return &http.Response{StatusCode: 404, Status: "404 Not Found"}, nil, nil
+				}
+				return &http.Response{StatusCode: 400, Status: "400 Bad Request"}, []byte(`{"code":"BAD_REQUEST"}`), nil
+			},
+		}
+		_, err := applyAPIResource(requester, res, "")
+		assert.ErrorContains(t, err, "400 Bad Request")
+	})
+}
+
+func TestAPIResourceLifecycle(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = appsv2beta1.AddToScheme(scheme)
+	handlerNotCalled := apiResourceHandler{
+		sync: func(*appsv2beta1.EMQX, innerReq.RequesterInterface) (ctrl.Result, error) {
+			assert.Fail(t, "should not sync")
+			return ctrl.Result{}, nil
+		},
+		delete: func(innerReq.RequesterInterface) error {
+			assert.Fail(t, "should not delete")
+			return nil
+		},
+	}
+
+	t.Run("EMQX is not found", func(t *testing.T) {
+		connector := &appsv2beta1.EMQXConnector{
+			ObjectMeta: metav1.ObjectMeta{Name: "webhook", Namespace: "emqx"},
+			Spec:       appsv2beta1.EMQXConnectorSpec{InstanceName: "emqx"},
+		}
+		fakeClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(connector).WithStatusSubresource(connector).Build()
+		recorder := record.NewFakeRecorder(10)
+		l := &apiResourceLifecycle{client: fakeClient, eventRecorder: recorder, kind: "Connector"}
+
+		// The failed resource is retried, the event is emitted once for the same message
+		for i := 0; i < 2; i++ {
+			got := &appsv2beta1.EMQXConnector{}
+			result, err := l.reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(connector)}, got, handlerNotCalled)
+			assert.NoError(t, err)
+			assert.Equal(t, time.Minute, result.RequeueAfter)
+			assert.Equal(t, appsv2beta1.ConnectorPhaseFailed, got.Status.Phase)
+			assert.Equal(t, "EMQX emqx is not found", got.Status.Message)
+		}
+		assert.Equal(t, "Warning ConnectorFailed EMQX emqx is not found", <-recorder.Events)
+		assert.Len(t, recorder.Events, 0)
+	})
+
+	t.Run("deleted with the EMQX cluster", func(t *testing.T) {
+		connector := &appsv2beta1.EMQXConnector{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              "webhook",
+				Namespace:         "emqx",
+				Finalizers:        []string{apiResourceFinalizer},
+				DeletionTimestamp: &metav1.Time{Time: time.Now()},
+			},
+			Spec: appsv2beta1.EMQXConnectorSpec{InstanceName: "emqx"},
+		}
+		fakeClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(connector).Build()
+		l := &apiResourceLifecycle{client: fakeClient, eventRecorder: record.NewFakeRecorder(10), kind: "Connector"}
+
+		_, err := l.reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(connector)}, &appsv2beta1.EMQXConnector{}, handlerNotCalled)
+		assert.NoError(t, err)
+		err = fakeClient.Get(ctx, client.ObjectKeyFromObject(connector), &appsv2beta1.EMQXConnector{})
+		assert.True(t, k8sErrors.IsNotFound(err))
+	})
+
+	t.Run("deleted while EMQX is not ready", func(t *testing.T) {
+		emqx := &appsv2beta1.EMQX{ObjectMeta: metav1.ObjectMeta{Name: "emqx", Namespace: "emqx"}}
+		newConnector := func(deletionTimestamp time.Time) *appsv2beta1.EMQXConnector {
+			return &appsv2beta1.EMQXConnector{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:              "webhook",
+					Namespace:         "emqx",
+					Finalizers:        []string{apiResourceFinalizer},
+					DeletionTimestamp: &metav1.Time{Time: deletionTimestamp},
+				},
+				Spec: appsv2beta1.EMQXConnectorSpec{InstanceName: "emqx"},
+			}
+		}
+
+		// The deletion is retried
+		connector := newConnector(time.Now())
+		fakeClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(emqx, connector).Build()
+		l := &apiResourceLifecycle{client: fakeClient, eventRecorder: record.NewFakeRecorder(10), kind: "Connector"}
+		result, err := l.reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(connector)}, &appsv2beta1.EMQXConnector{}, handlerNotCalled)
+		assert.NoError(t, err)
+		assert.Equal(t, 5*time.Second, result.RequeueAfter)
+		assert.NoError(t, fakeClient.Get(ctx, client.ObjectKeyFromObject(connector), &appsv2beta1.EMQXConnector{}))
+
+		// The finalizer is removed after the timeout
+		connector = newConnector(time.Now().Add(-10 * time.Minute))
+		fakeClient = fake.NewClientBuilder().WithScheme(scheme).WithObjects(emqx, connector).Build()
+		recorder := record.NewFakeRecorder(10)
+		l = &apiResourceLifecycle{client: fakeClient, eventRecorder: recorder, kind: "Connector"}
+		_, err = l.reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(connector)}, &appsv2beta1.EMQXConnector{}, handlerNotCalled)
+		assert.NoError(t, err)
+		err = fakeClient.Get(ctx, client.ObjectKeyFromObject(connector), &appsv2beta1.EMQXConnector{})
+		assert.True(t, k8sErrors.IsNotFound(err))
+		assert.Contains(t, <-recorder.Events, "Warning ConnectorDeleteFailed")
+
+		// The finalizer is removed when the EMQX cluster is being deleted
+		deleting := emqx.DeepCopy()
+		deleting.Finalizers = []string{"foregroundDeletion"}
+		deleting.DeletionTimestamp = &metav1.Time{Time: time.Now()}
+		connector = newConnector(time.Now())
+		fakeClient = fake.NewClientBuilder().WithScheme(scheme).WithObjects(deleting, connector).Build()
+		l = &apiResourceLifecycle{client: fakeClient, eventRecorder: record.NewFakeRecorder(10), kind: "Connector"}
+		_, err = l.reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(connector)}, &appsv2beta1.EMQXConnector{}, handlerNotCalled)
+		assert.NoError(t, err)
+		err = fakeClient.Get(ctx, client.ObjectKeyFromObject(connector), &appsv2beta1.EMQXConnector{})
+		assert.True(t, k8sErrors.IsNotFound(err))
+	})
+
+	t.Run("EMQX is not ready", func(t *testing.T) {
+		emqx := &appsv2beta1.EMQX{ObjectMeta: metav1.ObjectMeta{Name: "emqx", Namespace: "emqx"}}
+		connector := &appsv2beta1.EMQXConnector{
+			ObjectMeta: metav1.ObjectMeta{Name: "webhook", Namespace: "emqx"},
+			Spec:       appsv2beta1.EMQXConnectorSpec{InstanceName: "emqx"},
+		}
+		fakeClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(emqx, connector).Build()
+		l := &apiResourceLifecycle{client: fakeClient, eventRecorder: record.NewFakeRecorder(10), kind: "Connector"}
+
+		result, err := l.reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(connector)}, &appsv2beta1.EMQXConnector{}, handlerNotCalled)
+		assert.NoError(t, err)
+		assert.Equal(t, 5*time.Second, result.RequeueAfter)
+	})
+}
+
+func TestSetAPIResourceConfig(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = corev1.AddToScheme(scheme)
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "mysql", Namespace: "emqx", UID: "uid"},
+		Data:       map[string][]byte{"password": []byte("public")},
+	}
+	fakeClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(secret).Build()
+	config := runtime.RawExtension{Raw: []byte(`{"server": "mysql:3306"}`)}
+	secretConfig := map[string]appsv2beta1.KeyRef{"password": {SecretName: "mysql", SecretKey: "password"}}
+
+	res := apiResource{}
+	assert.NoError(t, setAPIResourceConfig(ctx, fakeClient, "emqx", &res, config, secretConfig))
+	assert.Equal(t, map[string]interface{}{"server": "mysql:3306", "password": "public"}, res.body)
+	// The secret value is not hashed
+	hash := res.hash()
+	assert.Equal(t, computeConfigHash(`{"password":"uid/`+secret.ResourceVersion+`/password","server":"mysql:3306"}`), hash)
+
+	// The hash is changed when the Secret is updated
+	secret.Data["password"] = []byte("new")
+	assert.NoError(t, fakeClient.Update(ctx, secret))
+	assert.NoError(t, setAPIResourceConfig(ctx, fakeClient, "emqx", &res, config, secretConfig))
+	assert.Equal(t, "new", res.body["password"])
+	assert.NotEqual(t, hash, res.hash())
+}
+
+func TestAPIResourceLifecycleRequests(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = appsv2beta1.AddToScheme(scheme)
+	fakeClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		&appsv2beta1.EMQXRule{
+			ObjectMeta: metav1.ObjectMeta{Name: "rule-1", Namespace: "emqx"},
+			Spec:       appsv2beta1.EMQXRuleSpec{InstanceName: "emqx"},
+		},
+		&appsv2beta1.EMQXRule{
+			ObjectMeta: metav1.ObjectMeta{Name: "rule-2", Namespace: "emqx"},
+			Spec:       appsv2beta1.EMQXRuleSpec{InstanceName: "other"},
+		},
+	).Build()
+	r := &EMQXRuleReconciler{Client: fakeClient}
+
+	emqx := &appsv2beta1.EMQX{ObjectMeta: metav1.ObjectMeta{Name: "emqx", Namespace: "emqx"}}
+	requests := r.requestsForEMQX(ctx, emqx)
+	assert.Len(t, requests, 1)
+	assert.Equal(t, "rule-1", requests[0].Name)
+}
+
+func TestGenerateAPIResourceConfig(t *testing.T) {
+	t.Run("with secret config", func(t *testing.T) {
+		got, err := generateAPIResourceConfig(
+			runtime.RawExtension{Raw: []byte(`{"server": "mysql:3306", "ssl": {"enable": true}}`)},
+			map[string]appsv2beta1.KeyRef{
+				"password":     {SecretName: "mysql", SecretKey: "password"},
+				"ssl.password": {SecretName: "mysql", SecretKey: "ssl"},
+			},
+			fakeReadSecret,
+		)
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]interface{}{
+			"server":   "mysql:3306",
+			"password": "mysql/password",
+			"ssl": map[string]interface{}{
+				"enable":   true,
+				"password": "mysql/ssl",
+			},
+		}, got)
+	})
+
+	t.Run("without config", func(t *testing.T) {
+		got, err := generateAPIResourceConfig(runtime.RawExtension{}, nil, fakeReadSecret)
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]interface{}{}, got)
+	})
+
+	t.Run("failed to read secret", func(t *testing.T) {
+		_, err := generateAPIResourceConfig(runtime.RawExtension{}, map[string]appsv2beta1.KeyRef{
+			"password": {SecretName: "not-found", SecretKey: "password"},
+		}, fakeReadSecret)
+		assert.ErrorContains(t, err, "failed to get secret")
+	})
+}
+
+func TestIsAPIResourceDependency(t *testing.T) {
+	secretConfig := map[string]appsv2beta1.KeyRef{
+		"password": {SecretName: "mysql", SecretKey: "password"},
+	}
+	assert.True(t, isAPIResourceDependency(&appsv2beta1.EMQX{ObjectMeta: metav1.ObjectMeta{Name: "emqx"}}, "emqx", secretConfig))
+	assert.False(t, isAPIResourceDependency(&appsv2beta1.EMQX{ObjectMeta: metav1.ObjectMeta{Name: "mysql"}}, "emqx", secretConfig))
+	assert.True(t, isAPIResourceDependency(&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "mysql"}}, "emqx", secretConfig))
+	assert.False(t, isAPIResourceDependency(&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "emqx"}}, "emqx", secretConfig))
+}
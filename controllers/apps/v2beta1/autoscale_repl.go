@@ -0,0 +1,124 @@
+package v2beta1
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	emperror "emperror.dev/errors"
+	appsv2beta1 "github.com/emqx/emqx-operator/apis/apps/v2beta1"
+	innerReq "github.com/emqx/emqx-operator/internal/requester"
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+)
+
+// The desired replicas will not be changed if the average usage is within this ratio of the target,
+// it avoids scaling up and down repeatedly around the target
+const autoscalingTolerance = 0.1
+
+type autoscaleRepl struct {
+	*EMQXReconciler
+}
+
+func (a *autoscaleRepl) reconcile(ctx context.Context, logger logr.Logger, instance *appsv2beta1.EMQX, r innerReq.RequesterInterface) subResult {
+	if !appsv2beta1.IsExistReplicant(instance) || instance.Spec.ReplicantTemplate.Autoscaling == nil {
+		return subResult{}
+	}
+	if r == nil {
+		return subResult{}
+	}
+	if !instance.Status.IsConditionTrue(appsv2beta1.Available) {
+		return subResult{}
+	}
+	// The replicas of the replicaSets are managed by the update strategy during the update
+	updateRs, currentRs, _ := getReplicaSetList(ctx, a.Client, instance)
+	if updateRs == nil || currentRs == nil || updateRs.UID != currentRs.UID {
+		return subResult{}
+	}
+
+	autoscaling := instance.Spec.ReplicantTemplate.Autoscaling
+	replicas := appsv2beta1.GetReplicantReplicas(instance)
+
+	status := &appsv2beta1.ReplicantAutoscalingStatus{}
+	if instance.Status.ReplicantAutoscalingStatus != nil {
+		status = instance.Status.ReplicantAutoscalingStatus.DeepCopy()
+	}
+	var runningNodes int32
+	status.CurrentConnections, status.CurrentSessions = 0, 0
+	for _, node := range instance.Status.ReplicantNodes {
+		if node.ControllerUID == updateRs.UID && node.NodeStatus == "running" {
+			runningNodes++
+			status.CurrentConnections += node.Connections
+			status.CurrentSessions += node.Session
+		}
+	}
+	status.DesiredReplicas = getAutoscalingDesiredReplicas(autoscaling, replicas, runningNodes, status.CurrentConnections, status.CurrentSessions)
+	// The replicaSet will be scaled by addRepl, and be scaled in by syncPods
+	status.Replicas = replicas
+
+	switch {
+	case status.DesiredReplicas > replicas:
+		status.Replicas = status.DesiredReplicas
+		status.LastScaleUpTime = ptr.To(metav1.Now())
+		logger.Info("autoscaling scale up replicant nodes", "replicas", status.DesiredReplicas)
+		a.EventRecorder.Event(instance, corev1.EventTypeNormal, "ScaleUp", fmt.Sprintf("Scale up replicant nodes from %d to %d", replicas, status.DesiredReplicas))
+	case status.DesiredReplicas < replicas:
+		if status.LastScaleUpTime != nil && time.Since(status.LastScaleUpTime.Time) < time.Duration(autoscaling.ScaleDownDelaySeconds)*time.Second {
+			break
+		}
+		status.Replicas = status.DesiredReplicas
+		status.LastScaleDownTime = ptr.To(metav1.Now())
+		logger.Info("autoscaling scale down replicant nodes", "replicas", status.DesiredReplicas)
+		a.EventRecorder.Event(instance, corev1.EventTypeNormal, "ScaleDown", fmt.Sprintf("Scale down replicant nodes from %d to %d", replicas, status.DesiredReplicas))
+	}
+
+	if !equality.Semantic.DeepEqual(instance.Status.ReplicantAutoscalingStatus, status) {
+		instance.Status.ReplicantAutoscalingStatus = status
+		if err := a.Client.Status().Update(ctx, instance); err != nil {
+			return subResult{err: emperror.Wrap(err, "failed to update status")}
+		}
+	}
+	return subResult{}
+}
+
+// getAutoscalingDesiredReplicas returns the number of replicant nodes which keeps the average connections
+// and sessions per node not greater than the targets, it is between minReplicas and maxReplicas.
+func getAutoscalingDesiredReplicas(autoscaling *appsv2beta1.ReplicantAutoscaling, replicas, runningNodes int32, connections, sessions int64) int32 {
+	desired := replicas
+	if runningNodes > 0 {
+		desired = 0
+		for _, metric := range []struct {
+			current int64
+			target  *int64
+		}{
+			{current: connections, target: autoscaling.TargetConnectionsPerNode},
+			{current: sessions, target: autoscaling.TargetSessionsPerNode},
+		} {
+			if metric.target == nil || *metric.target <= 0 {
+				continue
+			}
+			usageRatio := float64(metric.current) / float64(runningNodes) / float64(*metric.target)
+			if math.Abs(usageRatio-1.0) <= autoscalingTolerance {
+				desired = max(desired, replicas)
+				continue
+			}
+			desired = max(desired, int32(math.Ceil(float64(metric.current)/float64(*metric.target))))
+		}
+	}
+
+	minReplicas := int32(1)
+	if autoscaling.MinReplicas != nil {
+		minReplicas = *autoscaling.MinReplicas
+	}
+	if desired < minReplicas {
+		desired = minReplicas
+	}
+	if desired > autoscaling.MaxReplicas {
+		desired = autoscaling.MaxReplicas
+	}
+	return desired
+}
@@ -0,0 +1,111 @@
+package v2beta1
+
+import (
+	"testing"
+
+	appsv2beta1 "github.com/emqx/emqx-operator/apis/apps/v2beta1"
+	"github.com/emqx/emqx-operator/internal/handler"
+	innerReq "github.com/emqx/emqx-operator/internal/requester"
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestAutoscaleRepl(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = appsv2beta1.AddToScheme(scheme)
+	_ = appsv1.AddToScheme(scheme)
+
+	instance := &appsv2beta1.EMQX{
+		ObjectMeta: metav1.ObjectMeta{Name: "emqx", Namespace: "emqx"},
+		Spec: appsv2beta1.EMQXSpec{
+			ReplicantTemplate: &appsv2beta1.EMQXReplicantTemplate{
+				Spec: appsv2beta1.EMQXReplicantTemplateSpec{Replicas: ptr.To(int32(2))},
+				Autoscaling: &appsv2beta1.ReplicantAutoscaling{
+					MaxReplicas:              10,
+					TargetConnectionsPerNode: ptr.To(int64(1000)),
+				},
+			},
+		},
+		Status: appsv2beta1.EMQXStatus{
+			Conditions:           []metav1.Condition{{Type: appsv2beta1.Available, Status: metav1.ConditionTrue}},
+			ReplicantNodesStatus: appsv2beta1.EMQXNodesStatus{UpdateRevision: "fake", CurrentRevision: "fake"},
+		},
+	}
+	rs := &appsv1.ReplicaSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "emqx-replicant-fake",
+			Namespace: "emqx",
+			UID:       "fake-uid",
+			Labels:    appsv2beta1.CloneAndAddLabel(appsv2beta1.DefaultReplicantLabels(instance), appsv2beta1.LabelsPodTemplateHashKey, "fake"),
+		},
+		Spec: appsv1.ReplicaSetSpec{Replicas: ptr.To(int32(2))},
+	}
+	for _, node := range []string{"emqx@10.0.0.1", "emqx@10.0.0.2"} {
+		instance.Status.ReplicantNodes = append(instance.Status.ReplicantNodes, appsv2beta1.EMQXNode{
+			Node:          node,
+			NodeStatus:    "running",
+			ControllerUID: rs.UID,
+			Connections:   2000,
+		})
+	}
+
+	fakeClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(instance, rs).WithStatusSubresource(instance).Build()
+	a := &autoscaleRepl{&EMQXReconciler{
+		Handler:       &handler.Handler{Client: fakeClient},
+		EventRecorder: record.NewFakeRecorder(10),
+	}}
+
+	assert.Nil(t, a.reconcile(ctx, logger, instance, &innerReq.Requester{}).err)
+	assert.Equal(t, int32(4), instance.Status.ReplicantAutoscalingStatus.Replicas)
+	assert.Equal(t, int32(4), appsv2beta1.GetReplicantReplicas(instance))
+
+	// The EMQX custom resource is not changed by the autoscaling
+	got := &appsv2beta1.EMQX{}
+	assert.NoError(t, fakeClient.Get(ctx, client.ObjectKeyFromObject(instance), got))
+	assert.Equal(t, int32(2), *got.Spec.ReplicantTemplate.Spec.Replicas)
+	assert.Equal(t, int32(4), got.Status.ReplicantAutoscalingStatus.Replicas)
+	assert.Equal(t, int32(4), *generateReplicaSet(got, nil).Spec.Replicas)
+}
+
+func TestGetAutoscalingDesiredReplicas(t *testing.T) {
+	autoscaling := &appsv2beta1.ReplicantAutoscaling{
+		MinReplicas:              ptr.To(int32(2)),
+		MaxReplicas:              10,
+		TargetConnectionsPerNode: ptr.To(int64(1000)),
+	}
+
+	t.Run("no running nodes", func(t *testing.T) {
+		assert.Equal(t, int32(3), getAutoscalingDesiredReplicas(autoscaling, 3, 0, 0, 0))
+	})
+
+	t.Run("scale up by connections", func(t *testing.T) {
+		assert.Equal(t, int32(5), getAutoscalingDesiredReplicas(autoscaling, 3, 3, 4500, 0))
+	})
+
+	t.Run("scale down by connections", func(t *testing.T) {
+		assert.Equal(t, int32(2), getAutoscalingDesiredReplicas(autoscaling, 3, 3, 1500, 0))
+	})
+
+	t.Run("within tolerance", func(t *testing.T) {
+		assert.Equal(t, int32(3), getAutoscalingDesiredReplicas(autoscaling, 3, 3, 3200, 0))
+		assert.Equal(t, int32(3), getAutoscalingDesiredReplicas(autoscaling, 3, 3, 2800, 0))
+	})
+
+	t.Run("limited by min and max replicas", func(t *testing.T) {
+		assert.Equal(t, int32(2), getAutoscalingDesiredReplicas(autoscaling, 3, 3, 0, 0))
+		assert.Equal(t, int32(10), getAutoscalingDesiredReplicas(autoscaling, 3, 3, 99999, 0))
+	})
+
+	t.Run("the larger one of connections and sessions", func(t *testing.T) {
+		a := autoscaling.DeepCopy()
+		a.TargetSessionsPerNode = ptr.To(int64(500))
+		assert.Equal(t, int32(4), getAutoscalingDesiredReplicas(a, 3, 3, 1500, 2000))
+		assert.Equal(t, int32(6), getAutoscalingDesiredReplicas(a, 3, 3, 6000, 2000))
+	})
+}
@@ -18,6 +18,7 @@ package v2beta1
 
 import (
 	"context"
+	"fmt"
 	"net"
 	"sort"
 	"strconv"
@@ -26,21 +27,26 @@ import (
 
 	emperror "emperror.dev/errors"
 	innerErr "github.com/emqx/emqx-operator/internal/errors"
+	"github.com/emqx/emqx-operator/internal/metrics"
 	innerReq "github.com/emqx/emqx-operator/internal/requester"
 	"github.com/go-logr/logr"
 	"github.com/rory-z/go-hocon"
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/event"
+	ctrlHandler "sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	appsv2beta1 "github.com/emqx/emqx-operator/apis/apps/v2beta1"
 	"github.com/emqx/emqx-operator/internal/handler"
@@ -95,6 +101,8 @@ func (r *EMQXReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.
 	instance := &appsv2beta1.EMQX{}
 	if err := r.Client.Get(ctx, req.NamespacedName, instance); err != nil {
 		if k8sErrors.IsNotFound(err) {
+			metrics.DeleteEMQX(req.Namespace, req.Name)
+			innerReq.DeleteTransport(req.NamespacedName.String())
 			return ctrl.Result{}, nil
 		}
 		return ctrl.Result{}, err
@@ -118,21 +126,43 @@ func (r *EMQXReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.
 		}
 		return ctrl.Result{}, emperror.Wrap(err, "failed to get bootstrap user")
 	}
+	if requester != nil && requester.GetURL("").Scheme == "https" {
+		if isAPIClientInsecure(instance) {
+			r.EventRecorder.Event(instance, corev1.EventTypeWarning, "InsecureAPIClient", "The certificate of the EMQX nodes is not verified when calling the EMQX management API")
+		}
+		ca, err := getAPIClientCA(ctx, r.Client, instance)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		if r.setAPIClientCACondition(instance, len(ca) == 0 && !isAPIClientInsecure(instance)) {
+			if err := r.Client.Status().Update(ctx, instance); err != nil {
+				return ctrl.Result{}, emperror.Wrap(err, "failed to update status")
+			}
+		}
+	}
 
+	liveConfig := &emqxLiveConfig{}
 	for _, subReconciler := range []subReconciler{
 		&addBootstrap{r},
 		&updatePodConditions{r},
 		&updateStatus{r},
 		&addHeadlessSvc{r},
 		&addCore{r},
+		&syncPVCs{r},
 		&addRepl{r},
 		&addPdb{r},
-		&syncConfig{r},
-		&addSvc{r},
+		&syncConfig{r, liveConfig},
+		&syncTLS{r},
+		&syncListeners{r, liveConfig},
+		&addSvc{r, liveConfig},
+		&addMonitor{r},
 		&updatePodConditions{r},
 		&updateStatus{r},
+		&autoscaleRepl{r},
 		&syncPods{r},
 		&syncSets{r},
+		// syncAuth is the last one, it requeues the reconcile when the Secrets of the auth are not found
+		&syncAuth{r},
 	} {
 		subResult := subReconciler.reconcile(ctx, logger, instance, requester)
 		if !subResult.result.IsZero() {
@@ -157,16 +187,56 @@ func (r *EMQXReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.
 // SetupWithManager sets up the controller with the Manager.
 func (r *EMQXReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
-		For(&appsv2beta1.EMQX{}).
-		WithEventFilter(predicate.Funcs{
+		For(&appsv2beta1.EMQX{}, builder.WithPredicates(predicate.Funcs{
 			UpdateFunc: func(e event.UpdateEvent) bool {
 				// Ignore updates to CR status in which case metadata.Generation does not change
 				return e.ObjectNew.GetGeneration() != e.ObjectOld.GetGeneration()
 			},
-		}).
+		})).
+		// The config is re-applied when the ConfigMaps and Secrets referenced by ".spec.config" are changed,
+		// only their metadata is watched, so that the data of all the ConfigMaps and Secrets is not cached
+		Watches(&corev1.ConfigMap{}, ctrlHandler.EnqueueRequestsFromMapFunc(r.requestsForConfigSource(false)), builder.OnlyMetadata).
+		Watches(&corev1.Secret{}, ctrlHandler.EnqueueRequestsFromMapFunc(r.requestsForConfigSource(true)), builder.OnlyMetadata).
 		Complete(r)
 }
 
+func (r *EMQXReconciler) requestsForConfigSource(isSecret bool) ctrlHandler.MapFunc {
+	return func(ctx context.Context, obj client.Object) []reconcile.Request {
+		emqxList := &appsv2beta1.EMQXList{}
+		if err := r.Client.List(ctx, emqxList, client.InNamespace(obj.GetNamespace())); err != nil {
+			return nil
+		}
+		requests := []reconcile.Request{}
+		for i := range emqxList.Items {
+			if isConfigSource(&emqxList.Items[i], obj.GetName(), isSecret) {
+				requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&emqxList.Items[i])})
+			}
+		}
+		return requests
+	}
+}
+
+// isConfigSource returns true if the ConfigMap or Secret is referenced by ".spec.config.from",
+// or the Secret is referenced by the secret placeholders of ".spec.config.data"
+func isConfigSource(instance *appsv2beta1.EMQX, name string, isSecret bool) bool {
+	for _, source := range instance.Spec.Config.From {
+		if !isSecret && source.ConfigMapKeyRef != nil && source.ConfigMapKeyRef.Name == name {
+			return true
+		}
+		if isSecret && source.SecretKeyRef != nil && source.SecretKeyRef.Name == name {
+			return true
+		}
+	}
+	if isSecret {
+		for _, match := range secretPlaceholderRegexp.FindAllStringSubmatch(instance.Spec.Config.Data, -1) {
+			if match[1] == name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 func newRequester(ctx context.Context, k8sClient client.Client, instance *appsv2beta1.EMQX) (innerReq.RequesterInterface, error) {
 	username, password, err := getBootstrapAPIKey(ctx, k8sClient, instance)
 	if err != nil {
@@ -188,6 +258,17 @@ func newRequester(ctx context.Context, k8sClient client.Client, instance *appsv2
 		port = strconv.FormatInt(int64(dashboard), 10)
 	}
 
+	var tlsOptions *innerReq.TLSOptions
+	if schema == "https" {
+		if tlsOptions, err = getAPIClientTLSOptions(ctx, k8sClient, instance); err != nil {
+			return nil, err
+		}
+	}
+	transport, err := innerReq.GetTransport(client.ObjectKeyFromObject(instance).String(), tlsOptions)
+	if err != nil {
+		return nil, emperror.Wrap(err, "failed to get transport")
+	}
+
 	podList := &corev1.PodList{}
 	_ = k8sClient.List(ctx, podList,
 		client.InNamespace(instance.Namespace),
@@ -204,10 +285,11 @@ func newRequester(ctx context.Context, k8sClient client.Client, instance *appsv2
 			for _, cond := range pod.Status.Conditions {
 				if cond.Type == corev1.ContainersReady && cond.Status == corev1.ConditionTrue {
 					return &innerReq.Requester{
-						Schema:   schema,
-						Host:     net.JoinHostPort(pod.Status.PodIP, port),
-						Username: username,
-						Password: password,
+						Schema:    schema,
+						Host:      net.JoinHostPort(pod.Status.PodIP, port),
+						Username:  username,
+						Password:  password,
+						Transport: transport,
 					}, nil
 				}
 			}
@@ -217,6 +299,109 @@ func newRequester(ctx context.Context, k8sClient client.Client, instance *appsv2
 	return nil, nil
 }
 
+func isAPIClientInsecure(instance *appsv2beta1.EMQX) bool {
+	return instance.Spec.TLS != nil && instance.Spec.TLS.APIClient != nil && instance.Spec.TLS.APIClient.InsecureSkipVerify
+}
+
+// getAPIClientTLSOptions returns the TLS options of calling the EMQX management API by the dashboard HTTPS listener,
+// the certificate of the EMQX nodes is verified by the CA bundle provided by ".spec.tls", or by the system trust store
+// if no CA bundle is provided, unless it is skipped explicitly.
+func getAPIClientTLSOptions(ctx context.Context, k8sClient client.Client, instance *appsv2beta1.EMQX) (*innerReq.TLSOptions, error) {
+	ca, err := getAPIClientCA(ctx, k8sClient, instance)
+	if err != nil {
+		return nil, err
+	}
+	opts := &innerReq.TLSOptions{
+		ServerName:         getAPIClientServerName(instance),
+		CA:                 ca,
+		InsecureSkipVerify: isAPIClientInsecure(instance),
+	}
+
+	if instance.Spec.TLS != nil && instance.Spec.TLS.APIClient != nil && instance.Spec.TLS.APIClient.CertSecretName != "" {
+		secret, err := getAPIClientSecret(ctx, k8sClient, instance, instance.Spec.TLS.APIClient.CertSecretName)
+		if err != nil {
+			return nil, err
+		}
+		opts.Cert = secret.Data[corev1.TLSCertKey]
+		opts.Key = secret.Data[corev1.TLSPrivateKeyKey]
+	}
+	return opts, nil
+}
+
+// getAPIClientCA returns the CA bundle to verify the certificate of the EMQX nodes, it is empty if no CA bundle is provided,
+// e.g. the dashboard certificate is issued by a public CA, or it is not issued yet.
+func getAPIClientCA(ctx context.Context, k8sClient client.Client, instance *appsv2beta1.EMQX) ([]byte, error) {
+	if instance.Spec.TLS == nil {
+		return nil, nil
+	}
+	if instance.Spec.TLS.APIClient != nil && instance.Spec.TLS.APIClient.CASecretName != "" {
+		secret, err := getAPIClientSecret(ctx, k8sClient, instance, instance.Spec.TLS.APIClient.CASecretName)
+		if err != nil {
+			return nil, err
+		}
+		return secret.Data["ca.crt"], nil
+	}
+	if instance.Spec.TLS.Dashboard != nil {
+		secret := &corev1.Secret{}
+		if err := k8sClient.Get(ctx, instance.DashboardTLSNamespacedName(), secret); err != nil {
+			if k8sErrors.IsNotFound(err) {
+				return nil, nil
+			}
+			return nil, emperror.Wrap(err, "failed to get dashboard tls secret")
+		}
+		return secret.Data["ca.crt"], nil
+	}
+	return nil, nil
+}
+
+func getAPIClientSecret(ctx context.Context, k8sClient client.Client, instance *appsv2beta1.EMQX, name string) (*corev1.Secret, error) {
+	secret := &corev1.Secret{}
+	if err := k8sClient.Get(ctx, types.NamespacedName{Namespace: instance.Namespace, Name: name}, secret); err != nil {
+		// Don't return the NotFound error, it means the bootstrap API key is not found for the caller
+		return nil, emperror.Errorf("failed to get secret %s: %s", name, err.Error())
+	}
+	return secret, nil
+}
+
+// setAPIClientCACondition sets the APIClientCAMissing condition, and emits an event when it becomes true,
+// it returns true if the condition is changed
+func (r *EMQXReconciler) setAPIClientCACondition(instance *appsv2beta1.EMQX, missing bool) bool {
+	_, condition := instance.Status.GetCondition(appsv2beta1.APIClientCAMissing)
+	if !missing {
+		if condition == nil || condition.Status == metav1.ConditionFalse {
+			return false
+		}
+		instance.Status.SetCondition(metav1.Condition{
+			Type:    appsv2beta1.APIClientCAMissing,
+			Status:  metav1.ConditionFalse,
+			Reason:  "APIClientCAFound",
+			Message: "The certificate of the EMQX nodes is verified by the CA bundle provided by .spec.tls",
+		})
+		return true
+	}
+
+	if condition != nil && condition.Status == metav1.ConditionTrue {
+		return false
+	}
+	message := `No CA bundle is provided, the certificate of the EMQX nodes is verified by the system trust store, set ".spec.tls.apiClient.caSecretName" if it is not issued by a public CA`
+	r.EventRecorder.Event(instance, corev1.EventTypeWarning, "APIClientCAMissing", message)
+	instance.Status.SetCondition(metav1.Condition{
+		Type:    appsv2beta1.APIClientCAMissing,
+		Status:  metav1.ConditionTrue,
+		Reason:  "APIClientCAMissing",
+		Message: message,
+	})
+	return true
+}
+
+// getAPIClientServerName returns the name to verify the certificate of the EMQX nodes
+func getAPIClientServerName(instance *appsv2beta1.EMQX) string {
+	if instance.Spec.TLS != nil && instance.Spec.TLS.APIClient != nil && instance.Spec.TLS.APIClient.ServerName != "" {
+		return instance.Spec.TLS.APIClient.ServerName
+	}
+	return fmt.Sprintf("%s.%s.svc.%s", instance.DashboardServiceNamespacedName().Name, instance.Namespace, instance.Spec.ClusterDomain)
+}
+
 func getBootstrapAPIKey(ctx context.Context, client client.Client, instance *appsv2beta1.EMQX) (username, password string, err error) {
 	bootstrapAPIKey := &corev1.Secret{}
 	if err = client.Get(ctx, types.NamespacedName{
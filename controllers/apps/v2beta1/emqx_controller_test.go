@@ -0,0 +1,158 @@
+package v2beta1
+
+import (
+	"testing"
+
+	appsv2beta1 "github.com/emqx/emqx-operator/apis/apps/v2beta1"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestGetAPIClientTLSOptions(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := corev1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	k8sClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "emqx-dashboard-tls", Namespace: "emqx"},
+			Data:       map[string][]byte{"ca.crt": []byte("dashboard-ca")},
+		},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "emqx-ca", Namespace: "emqx"},
+			Data:       map[string][]byte{"ca.crt": []byte("ca")},
+		},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "emqx-client-tls", Namespace: "emqx"},
+			Data:       map[string][]byte{"tls.crt": []byte("cert"), "tls.key": []byte("key")},
+		},
+	).Build()
+
+	instance := &appsv2beta1.EMQX{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "emqx",
+			Namespace: "emqx",
+		},
+		Spec: appsv2beta1.EMQXSpec{
+			ClusterDomain: "cluster.local",
+		},
+	}
+
+	t.Run("no ca", func(t *testing.T) {
+		// Verified by the system trust store
+		got, err := getAPIClientTLSOptions(ctx, k8sClient, instance)
+		assert.NoError(t, err)
+		assert.Nil(t, got.CA)
+		assert.False(t, got.InsecureSkipVerify)
+
+		// The dashboard certificate is not issued yet
+		emqx := instance.DeepCopy()
+		emqx.Name = "not-issued"
+		emqx.Spec.TLS = &appsv2beta1.TLS{
+			Dashboard: &appsv2beta1.TLSCertificate{IssuerRef: &appsv2beta1.IssuerRef{Name: "ca-issuer"}},
+		}
+		got, err = getAPIClientTLSOptions(ctx, k8sClient, emqx)
+		assert.NoError(t, err)
+		assert.Nil(t, got.CA)
+		assert.False(t, got.InsecureSkipVerify)
+	})
+
+	t.Run("skip verify explicitly", func(t *testing.T) {
+		emqx := instance.DeepCopy()
+		emqx.Spec.TLS = &appsv2beta1.TLS{
+			APIClient: &appsv2beta1.APIClientTLS{InsecureSkipVerify: true},
+		}
+		got, err := getAPIClientTLSOptions(ctx, k8sClient, emqx)
+		assert.NoError(t, err)
+		assert.Equal(t, "emqx-dashboard.emqx.svc.cluster.local", got.ServerName)
+		assert.Nil(t, got.CA)
+		assert.True(t, got.InsecureSkipVerify)
+	})
+
+	t.Run("ca of dashboard certificate", func(t *testing.T) {
+		emqx := instance.DeepCopy()
+		emqx.Spec.TLS = &appsv2beta1.TLS{
+			Dashboard: &appsv2beta1.TLSCertificate{IssuerRef: &appsv2beta1.IssuerRef{Name: "ca-issuer"}},
+		}
+		got, err := getAPIClientTLSOptions(ctx, k8sClient, emqx)
+		assert.NoError(t, err)
+		assert.Equal(t, []byte("dashboard-ca"), got.CA)
+		assert.False(t, got.InsecureSkipVerify)
+	})
+
+	t.Run("api client", func(t *testing.T) {
+		emqx := instance.DeepCopy()
+		emqx.Spec.TLS = &appsv2beta1.TLS{
+			Dashboard: &appsv2beta1.TLSCertificate{IssuerRef: &appsv2beta1.IssuerRef{Name: "ca-issuer"}},
+			APIClient: &appsv2beta1.APIClientTLS{
+				CASecretName:   "emqx-ca",
+				CertSecretName: "emqx-client-tls",
+				ServerName:     "emqx.example.com",
+			},
+		}
+		got, err := getAPIClientTLSOptions(ctx, k8sClient, emqx)
+		assert.NoError(t, err)
+		assert.Equal(t, "emqx.example.com", got.ServerName)
+		assert.Equal(t, []byte("ca"), got.CA)
+		assert.Equal(t, []byte("cert"), got.Cert)
+		assert.Equal(t, []byte("key"), got.Key)
+		assert.False(t, got.InsecureSkipVerify)
+	})
+
+	t.Run("secret not found", func(t *testing.T) {
+		emqx := instance.DeepCopy()
+		emqx.Spec.TLS = &appsv2beta1.TLS{
+			APIClient: &appsv2beta1.APIClientTLS{CASecretName: "not-found"},
+		}
+		_, err := getAPIClientTLSOptions(ctx, k8sClient, emqx)
+		assert.ErrorContains(t, err, "failed to get secret not-found")
+	})
+}
+
+func TestSetAPIClientCACondition(t *testing.T) {
+	recorder := record.NewFakeRecorder(10)
+	r := &EMQXReconciler{EventRecorder: recorder}
+	instance := &appsv2beta1.EMQX{}
+
+	assert.False(t, r.setAPIClientCACondition(instance, false))
+	assert.Empty(t, instance.Status.Conditions)
+
+	assert.True(t, r.setAPIClientCACondition(instance, true))
+	_, condition := instance.Status.GetCondition(appsv2beta1.APIClientCAMissing)
+	assert.Equal(t, metav1.ConditionTrue, condition.Status)
+	assert.Len(t, recorder.Events, 1)
+
+	// The event is emitted only once
+	assert.False(t, r.setAPIClientCACondition(instance, true))
+	assert.Len(t, recorder.Events, 1)
+
+	assert.True(t, r.setAPIClientCACondition(instance, false))
+	_, condition = instance.Status.GetCondition(appsv2beta1.APIClientCAMissing)
+	assert.Equal(t, metav1.ConditionFalse, condition.Status)
+}
+
+func TestIsConfigSource(t *testing.T) {
+	instance := &appsv2beta1.EMQX{
+		Spec: appsv2beta1.EMQXSpec{
+			Config: appsv2beta1.Config{
+				Data: `authentication.password = "${secret:auth/password}"`,
+				From: []appsv2beta1.ConfigSource{
+					{ConfigMapKeyRef: &corev1.ConfigMapKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "emqx-config"}}},
+					{SecretKeyRef: &corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "emqx-secret-config"}}},
+				},
+			},
+		},
+	}
+
+	assert.True(t, isConfigSource(instance, "emqx-config", false))
+	assert.False(t, isConfigSource(instance, "emqx-config", true))
+	assert.True(t, isConfigSource(instance, "emqx-secret-config", true))
+	assert.False(t, isConfigSource(instance, "emqx-secret-config", false))
+	assert.True(t, isConfigSource(instance, "auth", true))
+	assert.False(t, isConfigSource(instance, "auth", false))
+	assert.False(t, isConfigSource(instance, "not-found", true))
+}
@@ -0,0 +1,113 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2beta1
+
+import (
+	"context"
+
+	"github.com/tidwall/gjson"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	appsv2beta1 "github.com/emqx/emqx-operator/apis/apps/v2beta1"
+	innerReq "github.com/emqx/emqx-operator/internal/requester"
+)
+
+const ApiActionsV5 = "api/v5/actions"
+
+// EMQXActionReconciler reconciles a EMQXAction object
+type EMQXActionReconciler struct {
+	Client        client.Client
+	Scheme        *runtime.Scheme
+	EventRecorder record.EventRecorder
+}
+
+func NewEMQXActionReconciler(mgr manager.Manager) *EMQXActionReconciler {
+	return &EMQXActionReconciler{
+		Client:        mgr.GetClient(),
+		Scheme:        mgr.GetScheme(),
+		EventRecorder: mgr.GetEventRecorderFor("emqxaction-controller"),
+	}
+}
+
+//+kubebuilder:rbac:groups=apps.emqx.io,resources=emqxactions,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=apps.emqx.io,resources=emqxactions/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=apps.emqx.io,resources=emqxactions/finalizers,verbs=update
+
+func (r *EMQXActionReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+	logger.V(1).Info("Reconcile EMQX action")
+
+	lifecycle := r.lifecycle()
+	action := &appsv2beta1.EMQXAction{}
+	return lifecycle.reconcile(ctx, req, action, apiResourceHandler{
+		// The action can not be deleted while it is used by the rules, so retry until they are deleted
+		delete: func(requester innerReq.RequesterInterface) error {
+			return deleteAPIResource(requester, newActionAPIResource(action))
+		},
+		sync: func(_ *appsv2beta1.EMQX, requester innerReq.RequesterInterface) (ctrl.Result, error) {
+			res := newActionAPIResource(action)
+			if err := setAPIResourceConfig(ctx, r.Client, action.Namespace, &res, action.Spec.Config, action.Spec.SecretConfig); err != nil {
+				return lifecycle.setFailed(ctx, action, err.Error())
+			}
+			res.body["connector"] = action.Spec.Connector
+			return lifecycle.syncAPIResource(ctx, action, requester, res, action.Status.ConfigHash, func(body []byte) {
+				action.Status.SetSynced(gjson.GetBytes(body, "status").String(), res.hash())
+			})
+		},
+	})
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *EMQXActionReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&appsv2beta1.EMQXAction{}).
+		Watches(&corev1.Secret{}, handler.EnqueueRequestsFromMapFunc(r.requestsForObject), builder.OnlyMetadata).
+		Watches(&appsv2beta1.EMQX{}, handler.EnqueueRequestsFromMapFunc(r.requestsForObject)).
+		Complete(r)
+}
+
+func (r *EMQXActionReconciler) lifecycle() *apiResourceLifecycle {
+	return &apiResourceLifecycle{client: r.Client, eventRecorder: r.EventRecorder, kind: "Action"}
+}
+
+// requestsForObject returns the actions which use the secret or the EMQX
+func (r *EMQXActionReconciler) requestsForObject(ctx context.Context, obj client.Object) []reconcile.Request {
+	return r.lifecycle().requests(ctx, &appsv2beta1.EMQXActionList{}, obj.GetNamespace(), func(o apiResourceObject) bool {
+		action := o.(*appsv2beta1.EMQXAction)
+		return isAPIResourceDependency(obj, action.Spec.InstanceName, action.Spec.SecretConfig)
+	})
+}
+
+func newActionAPIResource(action *appsv2beta1.EMQXAction) apiResource {
+	return apiResource{
+		path: ApiActionsV5,
+		id:   action.ID(),
+		keys: map[string]interface{}{
+			"type": action.Spec.Type,
+			"name": action.Name,
+		},
+	}
+}
@@ -0,0 +1,260 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2beta1
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	emperror "emperror.dev/errors"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	appsv2beta1 "github.com/emqx/emqx-operator/apis/apps/v2beta1"
+	innerReq "github.com/emqx/emqx-operator/internal/requester"
+)
+
+const ApiBuiltInDatabaseRulesV5 = "api/v5/authorization/sources/built_in_database/rules"
+
+// EMQXAuthorizationRuleReconciler reconciles a EMQXAuthorizationRule object
+type EMQXAuthorizationRuleReconciler struct {
+	Client        client.Client
+	Scheme        *runtime.Scheme
+	EventRecorder record.EventRecorder
+}
+
+func NewEMQXAuthorizationRuleReconciler(mgr manager.Manager) *EMQXAuthorizationRuleReconciler {
+	return &EMQXAuthorizationRuleReconciler{
+		Client:        mgr.GetClient(),
+		Scheme:        mgr.GetScheme(),
+		EventRecorder: mgr.GetEventRecorderFor("emqxauthorizationrule-controller"),
+	}
+}
+
+//+kubebuilder:rbac:groups=apps.emqx.io,resources=emqxauthorizationrules,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=apps.emqx.io,resources=emqxauthorizationrules/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=apps.emqx.io,resources=emqxauthorizationrules/finalizers,verbs=update
+
+func (r *EMQXAuthorizationRuleReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+	logger.V(1).Info("Reconcile EMQX authorization rule")
+
+	lifecycle := r.lifecycle()
+	rule := &appsv2beta1.EMQXAuthorizationRule{}
+	return lifecycle.reconcile(ctx, req, rule, apiResourceHandler{
+		delete: func(requester innerReq.RequesterInterface) error {
+			for _, target := range []string{rule.Status.Target, rule.Spec.Target()} {
+				if target == "" {
+					continue
+				}
+				// The rules of the target are managed by another EMQXAuthorizationRule
+				others, err := r.getOtherRulesOfTarget(ctx, rule, target)
+				if err != nil {
+					return err
+				}
+				if len(others) > 0 {
+					continue
+				}
+				if err := deleteAuthorizationRulesByAPI(requester, target); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+		sync: func(_ *appsv2beta1.EMQX, requester innerReq.RequesterInterface) (ctrl.Result, error) {
+			return r.syncAuthorizationRule(ctx, lifecycle, rule, requester)
+		},
+	})
+}
+
+func (r *EMQXAuthorizationRuleReconciler) syncAuthorizationRule(ctx context.Context, lifecycle *apiResourceLifecycle, rule *appsv2beta1.EMQXAuthorizationRule, requester innerReq.RequesterInterface) (ctrl.Result, error) {
+	// It is validated by the CRD, but the rule may be created before the validation is added
+	if rule.Spec.Username != "" && rule.Spec.ClientID != "" {
+		return lifecycle.setFailed(ctx, rule, "only one of username and clientId can be set")
+	}
+
+	// The rules of a target are replaced as a whole, so they are managed by the oldest EMQXAuthorizationRule of the target
+	target := rule.Spec.Target()
+	others, err := r.getOtherRulesOfTarget(ctx, rule, target)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if len(others) > 0 && isOlderAuthorizationRule(&others[0], rule) {
+		return lifecycle.setFailed(ctx, rule, fmt.Sprintf("the authorization rules of %s are managed by EMQXAuthorizationRule %s", target, others[0].Name))
+	}
+
+	// The rules are compared with the rules in EMQX on every reconcile,
+	// so the changes made through the EMQX Dashboard or the HTTP API are reverted
+	rules, err := getAuthorizationRulesByAPI(requester, target)
+	if err != nil {
+		return lifecycle.setFailed(ctx, rule, fmt.Sprintf("Failed to get the authorization rules: %s", err.Error()))
+	}
+	if !equality.Semantic.DeepEqual(rules, rule.Spec.Rules) {
+		if rule.Status.Phase == appsv2beta1.AuthorizationRulePhaseSynced && rule.Status.Target == target {
+			r.EventRecorder.Event(rule, corev1.EventTypeWarning, "DriftDetected", fmt.Sprintf("authorization rules of %s are changed in EMQX, revert them", target))
+		}
+		if err := putAuthorizationRulesByAPI(requester, target, rule.Spec.Rules); err != nil {
+			return lifecycle.setFailed(ctx, rule, fmt.Sprintf("Failed to apply the authorization rules: %s", err.Error()))
+		}
+	}
+	if rule.Status.Target != "" && rule.Status.Target != target {
+		if err := deleteAuthorizationRulesByAPI(requester, rule.Status.Target); err != nil {
+			return ctrl.Result{}, emperror.Wrap(err, "failed to delete the old authorization rules")
+		}
+	}
+
+	if rule.Status.Phase != appsv2beta1.AuthorizationRulePhaseSynced || rule.Status.Target != target {
+		rule.Status.SetSynced(target)
+		r.EventRecorder.Event(rule, corev1.EventTypeNormal, "AuthorizationRuleSynced", fmt.Sprintf("authorization rules of %s are synced", target))
+		if err := r.Client.Status().Update(ctx, rule); err != nil {
+			return ctrl.Result{}, emperror.Wrap(err, "failed to update status")
+		}
+	}
+	return ctrl.Result{RequeueAfter: apiResourceSyncInterval}, nil
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *EMQXAuthorizationRuleReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&appsv2beta1.EMQXAuthorizationRule{}).
+		// The rules are applied when the EMQX cluster is created after them
+		Watches(&appsv2beta1.EMQX{}, handler.EnqueueRequestsFromMapFunc(r.requestsForEMQX)).
+		Complete(r)
+}
+
+func (r *EMQXAuthorizationRuleReconciler) lifecycle() *apiResourceLifecycle {
+	return &apiResourceLifecycle{client: r.Client, eventRecorder: r.EventRecorder, kind: "AuthorizationRule"}
+}
+
+func (r *EMQXAuthorizationRuleReconciler) requestsForEMQX(ctx context.Context, obj client.Object) []reconcile.Request {
+	return r.lifecycle().requests(ctx, &appsv2beta1.EMQXAuthorizationRuleList{}, obj.GetNamespace(), func(o apiResourceObject) bool {
+		return o.GetInstanceName() == obj.GetName() && o.(*appsv2beta1.EMQXAuthorizationRule).Status.Phase != appsv2beta1.AuthorizationRulePhaseSynced
+	})
+}
+
+// getOtherRulesOfTarget returns the other EMQXAuthorizationRules of the same EMQX cluster and target, the oldest one is the first
+func (r *EMQXAuthorizationRuleReconciler) getOtherRulesOfTarget(ctx context.Context, rule *appsv2beta1.EMQXAuthorizationRule, target string) ([]appsv2beta1.EMQXAuthorizationRule, error) {
+	list := &appsv2beta1.EMQXAuthorizationRuleList{}
+	if err := r.Client.List(ctx, list, client.InNamespace(rule.Namespace)); err != nil {
+		return nil, emperror.Wrap(err, "failed to list authorization rules")
+	}
+	others := []appsv2beta1.EMQXAuthorizationRule{}
+	for _, other := range list.Items {
+		if other.UID == rule.UID || !other.DeletionTimestamp.IsZero() {
+			continue
+		}
+		if other.Spec.InstanceName == rule.Spec.InstanceName && other.Spec.Target() == target {
+			others = append(others, other)
+		}
+	}
+	sort.Slice(others, func(i, j int) bool {
+		return isOlderAuthorizationRule(&others[i], &others[j])
+	})
+	return others, nil
+}
+
+func isOlderAuthorizationRule(a, b *appsv2beta1.EMQXAuthorizationRule) bool {
+	if !a.CreationTimestamp.Equal(&b.CreationTimestamp) {
+		return a.CreationTimestamp.Before(&b.CreationTimestamp)
+	}
+	return a.Name < b.Name
+}
+
+// getAuthorizationRulesByAPI returns the rules of the target in the built-in database authorization source,
+// it returns nil if the target has no rules
+func getAuthorizationRulesByAPI(requester innerReq.RequesterInterface, target string) ([]appsv2beta1.AuthorizationRule, error) {
+	url := getAuthorizationRulesURL(requester, target)
+	resp, body, err := requester.Request("GET", url, nil, nil)
+	if err != nil {
+		return nil, emperror.Wrapf(err, "failed to get API %s", url.String())
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, emperror.Errorf("failed to get API %s, status : %s, body: %s", url.String(), resp.Status, body)
+	}
+
+	// EMQX returns the optional fields like "qos" and "retain" too, they are not managed by the operator
+	data := struct {
+		Rules []appsv2beta1.AuthorizationRule `json:"rules"`
+	}{}
+	if err := json.Unmarshal(body, &data); err != nil {
+		return nil, emperror.Wrap(err, "failed to unmarshal authorization rules")
+	}
+	if len(data.Rules) == 0 {
+		return nil, nil
+	}
+	return data.Rules, nil
+}
+
+// getAuthorizationRulesURL returns the URL of the rules of the target, the username and the client ID are escaped
+func getAuthorizationRulesURL(requester innerReq.RequesterInterface, target string) url.URL {
+	if kind, id, ok := strings.Cut(target, "/"); ok {
+		return getEscapedURL(requester, fmt.Sprintf("%s/%s", ApiBuiltInDatabaseRulesV5, kind), id)
+	}
+	return requester.GetURL(fmt.Sprintf("%s/%s", ApiBuiltInDatabaseRulesV5, target))
+}
+
+func putAuthorizationRulesByAPI(requester innerReq.RequesterInterface, target string, rules []appsv2beta1.AuthorizationRule) error {
+	method := "PUT"
+	body := map[string]interface{}{"rules": rules}
+	if username, ok := strings.CutPrefix(target, "users/"); ok {
+		body["username"] = username
+	} else if clientID, ok := strings.CutPrefix(target, "clients/"); ok {
+		body["clientid"] = clientID
+	} else {
+		// The rules for all clients are replaced by POST
+		method = "POST"
+	}
+	data, _ := json.Marshal(body)
+
+	url := getAuthorizationRulesURL(requester, target)
+	resp, respBody, err := requester.Request(method, url, data, nil)
+	if err != nil {
+		return emperror.Wrapf(err, "failed to request API %s", url.String())
+	}
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
+		return emperror.Errorf("failed to request API %s, status : %s, body: %s", url.String(), resp.Status, respBody)
+	}
+	return nil
+}
+
+func deleteAuthorizationRulesByAPI(requester innerReq.RequesterInterface, target string) error {
+	url := getAuthorizationRulesURL(requester, target)
+	resp, body, err := requester.Request("DELETE", url, nil, nil)
+	if err != nil {
+		return emperror.Wrapf(err, "failed to delete API %s", url.String())
+	}
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNotFound {
+		return emperror.Errorf("failed to delete API %s, status : %s, body: %s", url.String(), resp.Status, body)
+	}
+	return nil
+}
@@ -0,0 +1,117 @@
+package v2beta1
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	appsv2beta1 "github.com/emqx/emqx-operator/apis/apps/v2beta1"
+	innerReq "github.com/emqx/emqx-operator/internal/requester"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestGetAuthorizationRulesByAPI(t *testing.T) {
+	t.Run("rules found", func(t *testing.T) {
+		requester := &innerReq.FakeRequester{
+			ReqFunc: func(method string, url url.URL, body []byte, header http.Header) (*http.Response, []byte, error) {
+				assert.Equal(t, "GET", method)
+				assert.Equal(t, "api/v5/authorization/sources/built_in_database/rules/users/emqx", url.Path)
+				return &http.Response{StatusCode: 200}, []byte(`{"username":"emqx","rules":[{"permission":"allow","action":"publish","topic":"t/#","qos":[0,1,2],"retain":"all"}]}`), nil
+			},
+		}
+		got, err := getAuthorizationRulesByAPI(requester, "users/emqx")
+		assert.NoError(t, err)
+		assert.Equal(t, []appsv2beta1.AuthorizationRule{
+			{Permission: "allow", Action: "publish", Topic: "t/#"},
+		}, got)
+	})
+
+	t.Run("escape username", func(t *testing.T) {
+		requester := &innerReq.FakeRequester{
+			ReqFunc: func(method string, url url.URL, body []byte, header http.Header) (*http.Response, []byte, error) {
+				assert.Equal(t, "api/v5/authorization/sources/built_in_database/rules/users/a%2Fb%3Fc", url.EscapedPath())
+				return &http.Response{StatusCode: 404, Status: "404 Not Found"}, nil, nil
+			},
+		}
+		_, err := getAuthorizationRulesByAPI(requester, "users/a/b?c")
+		assert.NoError(t, err)
+	})
+
+	t.Run("rules not found", func(t *testing.T) {
+		requester := &innerReq.FakeRequester{
+			ReqFunc: func(method string, url url.URL, body []byte, header http.Header) (*http.Response, []byte, error) {
+				return &http.Response{StatusCode: 404, Status: "404 Not Found"}, nil, nil
+			},
+		}
+		got, err := getAuthorizationRulesByAPI(requester, "clients/emqx")
+		assert.NoError(t, err)
+		assert.Nil(t, got)
+	})
+}
+
+func TestPutAuthorizationRulesByAPI(t *testing.T) {
+	rules := []appsv2beta1.AuthorizationRule{
+		{Permission: "deny", Action: "all", Topic: "#"},
+	}
+	for _, tc := range []struct {
+		target string
+		want   string
+	}{
+		{"users/emqx", `PUT api/v5/authorization/sources/built_in_database/rules/users/emqx {"rules":[{"permission":"deny","action":"all","topic":"#"}],"username":"emqx"}`},
+		{"clients/emqx", `PUT api/v5/authorization/sources/built_in_database/rules/clients/emqx {"clientid":"emqx","rules":[{"permission":"deny","action":"all","topic":"#"}]}`},
+		{"all", `POST api/v5/authorization/sources/built_in_database/rules/all {"rules":[{"permission":"deny","action":"all","topic":"#"}]}`},
+	} {
+		t.Run(tc.target, func(t *testing.T) {
+			requests := []string{}
+			requester := &innerReq.FakeRequester{
+				ReqFunc: func(method string, url url.URL, body []byte, header http.Header) (*http.Response, []byte, error) {
+					requests = append(requests, method+" "+url.Path+" "+string(body))
+					return &http.Response{StatusCode: 204}, nil, nil
+				},
+			}
+			assert.NoError(t, putAuthorizationRulesByAPI(requester, tc.target, rules))
+			assert.Equal(t, []string{tc.want}, requests)
+		})
+	}
+}
+
+func TestDeleteAuthorizationRulesByAPI(t *testing.T) {
+	requester := &innerReq.FakeRequester{
+		ReqFunc: func(method string, url url.URL, body []byte, header http.Header) (*http.Response, []byte, error) {
+			assert.Equal(t, "DELETE", method)
+			assert.Equal(t, "api/v5/authorization/sources/built_in_database/rules/all", url.Path)
+			return &http.Response{StatusCode: 500, Status: "500 Internal Server Error"}, nil, nil
+		},
+	}
+	assert.ErrorContains(t, deleteAuthorizationRulesByAPI(requester, "all"), "500 Internal Server Error")
+}
+
+func TestGetOtherRulesOfTarget(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = appsv2beta1.AddToScheme(scheme)
+	now := metav1.Now()
+	newRule := func(name, username string, created metav1.Time) *appsv2beta1.EMQXAuthorizationRule {
+		return &appsv2beta1.EMQXAuthorizationRule{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "emqx", UID: types.UID(name), CreationTimestamp: created},
+			Spec:       appsv2beta1.EMQXAuthorizationRuleSpec{InstanceName: "emqx", Username: username},
+		}
+	}
+	rule := newRule("rule", "emqx", now)
+	older := newRule("older", "emqx", metav1.NewTime(now.Add(-time.Minute)))
+	newer := newRule("newer", "emqx", metav1.NewTime(now.Add(time.Minute)))
+	other := newRule("other", "other", metav1.NewTime(now.Add(-time.Minute)))
+	fakeClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(rule, older, newer, other).Build()
+	r := &EMQXAuthorizationRuleReconciler{Client: fakeClient}
+
+	others, err := r.getOtherRulesOfTarget(ctx, rule, "users/emqx")
+	assert.NoError(t, err)
+	assert.Len(t, others, 2)
+	assert.Equal(t, "older", others[0].Name)
+	assert.True(t, isOlderAuthorizationRule(&others[0], rule))
+	assert.False(t, isOlderAuthorizationRule(&others[1], rule))
+}
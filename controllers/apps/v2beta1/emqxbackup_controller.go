@@ -0,0 +1,553 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2beta1
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	emperror "emperror.dev/errors"
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+	"github.com/tidwall/gjson"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+
+	appsv2beta1 "github.com/emqx/emqx-operator/apis/apps/v2beta1"
+	innerReq "github.com/emqx/emqx-operator/internal/requester"
+)
+
+const (
+	ApiDataExportV5 = "api/v5/data/export"
+	ApiDataFilesV5  = "api/v5/data/files"
+)
+
+const backupMountPath = "/backup"
+
+// maxTransientRetries is the number of the retries before the backup or the restore is marked as failed
+const maxTransientRetries = 5
+
+// getRetryBackoff returns the delay before the next retry, it is doubled after each retry and is at most 5 minutes
+func getRetryBackoff(retries int32) time.Duration {
+	backoff := 5 * time.Second << retries
+	if backoff > 5*time.Minute || backoff <= 0 {
+		return 5 * time.Minute
+	}
+	return backoff
+}
+
+// EMQXBackupReconciler reconciles a EMQXBackup object
+type EMQXBackupReconciler struct {
+	Client        client.Client
+	Scheme        *runtime.Scheme
+	EventRecorder record.EventRecorder
+}
+
+func NewEMQXBackupReconciler(mgr manager.Manager) *EMQXBackupReconciler {
+	return &EMQXBackupReconciler{
+		Client:        mgr.GetClient(),
+		Scheme:        mgr.GetScheme(),
+		EventRecorder: mgr.GetEventRecorderFor("emqxbackup-controller"),
+	}
+}
+
+//+kubebuilder:rbac:groups=apps.emqx.io,resources=emqxbackups,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=apps.emqx.io,resources=emqxbackups/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=apps.emqx.io,resources=emqxbackups/finalizers,verbs=update
+//+kubebuilder:rbac:groups=batch,resources=jobs,verbs=get;list;watch;create;delete
+
+func (r *EMQXBackupReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	var finalizer string = "apps.emqx.io/finalizer"
+
+	logger := log.FromContext(ctx)
+	logger.V(1).Info("Reconcile EMQX backup")
+
+	backup := &appsv2beta1.EMQXBackup{}
+	if err := r.Client.Get(ctx, req.NamespacedName, backup); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	if !backup.DeletionTimestamp.IsZero() {
+		if !controllerutil.ContainsFinalizer(backup, finalizer) {
+			return ctrl.Result{}, nil
+		}
+		if done, err := r.deleteArchive(ctx, backup); err != nil || !done {
+			return ctrl.Result{RequeueAfter: 5 * time.Second}, err
+		}
+		controllerutil.RemoveFinalizer(backup, finalizer)
+		return ctrl.Result{}, r.Client.Update(ctx, backup)
+	}
+
+	if !controllerutil.ContainsFinalizer(backup, finalizer) {
+		controllerutil.AddFinalizer(backup, finalizer)
+		if err := r.Client.Update(ctx, backup); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	if backup.Status.IsFinished() {
+		return ctrl.Result{}, nil
+	}
+
+	if err := validateBackupStorage(backup.Spec.Storage); err != nil {
+		return r.setFailed(ctx, backup, err.Error())
+	}
+
+	emqx := &appsv2beta1.EMQX{}
+	if err := r.Client.Get(ctx, types.NamespacedName{
+		Name:      backup.Spec.InstanceName,
+		Namespace: backup.Namespace,
+	}, emqx); err != nil {
+		if k8sErrors.IsNotFound(err) {
+			return r.setFailed(ctx, backup, fmt.Sprintf("EMQX %s is not found", backup.Spec.InstanceName))
+		}
+		return ctrl.Result{}, emperror.Wrap(err, "failed to get EMQX")
+	}
+
+	if !emqx.Status.IsConditionTrue(appsv2beta1.Ready) {
+		logger.V(1).Info("EMQX is not ready, wait for it", "emqx", emqx.Name)
+		return ctrl.Result{RequeueAfter: 5 * time.Second}, nil
+	}
+
+	requester, err := newRequester(ctx, r.Client, emqx)
+	if err != nil {
+		return ctrl.Result{}, emperror.Wrap(err, "failed to create EMQX API requester")
+	}
+	if requester == nil {
+		return ctrl.Result{RequeueAfter: 5 * time.Second}, nil
+	}
+
+	switch backup.Status.Phase {
+	case "":
+		if err := exportData(requester, &backup.Status); err != nil {
+			return r.retryOrFail(ctx, backup, fmt.Sprintf("Failed to export data: %s", err.Error()))
+		}
+		backup.Status.Phase = appsv2beta1.BackupPhaseProcessing
+		r.EventRecorder.Event(backup, corev1.EventTypeNormal, "Exported", fmt.Sprintf("data of EMQX is exported to %s on %s", backup.Status.Filename, backup.Status.Node))
+		return ctrl.Result{Requeue: true}, r.Client.Status().Update(ctx, backup)
+	case appsv2beta1.BackupPhaseProcessing:
+		if backup.Spec.Storage.S3 != nil {
+			return r.uploadToS3(ctx, backup, requester)
+		}
+		return r.downloadToPVC(ctx, backup, emqx, requester)
+	default:
+		return ctrl.Result{}, nil
+	}
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *EMQXBackupReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&appsv2beta1.EMQXBackup{}).
+		Owns(&batchv1.Job{}).
+		Complete(r)
+}
+
+func (r *EMQXBackupReconciler) setFailed(ctx context.Context, backup *appsv2beta1.EMQXBackup, message string) (ctrl.Result, error) {
+	backup.Status.SetFailed(message)
+	r.EventRecorder.Event(backup, corev1.EventTypeWarning, "BackupFailed", message)
+	return ctrl.Result{}, r.Client.Status().Update(ctx, backup)
+}
+
+// retryOrFail requeues the backup with backoff after a transient failure, the backup is marked as failed after maxTransientRetries
+func (r *EMQXBackupReconciler) retryOrFail(ctx context.Context, backup *appsv2beta1.EMQXBackup, message string) (ctrl.Result, error) {
+	if backup.Status.Retries >= maxTransientRetries {
+		return r.setFailed(ctx, backup, message)
+	}
+	backoff := getRetryBackoff(backup.Status.Retries)
+	backup.Status.Retries++
+	backup.Status.Message = message
+	r.EventRecorder.Event(backup, corev1.EventTypeWarning, "BackupRetrying", fmt.Sprintf("%s, retry %d/%d after %s", message, backup.Status.Retries, maxTransientRetries, backoff))
+	return ctrl.Result{RequeueAfter: backoff}, r.Client.Status().Update(ctx, backup)
+}
+
+func (r *EMQXBackupReconciler) setCompleted(ctx context.Context, backup *appsv2beta1.EMQXBackup, requester innerReq.RequesterInterface, location string) (ctrl.Result, error) {
+	// The archive has been stored, so it is not needed on the EMQX node any more
+	if err := deleteExportedFile(requester, backup.Status.Filename, backup.Status.Node); err != nil {
+		log.FromContext(ctx).Info("failed to delete the exported file on EMQX node", "error", err.Error())
+	}
+	backup.Status.SetCompleted(location)
+	r.EventRecorder.Event(backup, corev1.EventTypeNormal, "BackupCompleted", fmt.Sprintf("backup is stored in %s", location))
+	return ctrl.Result{}, r.Client.Status().Update(ctx, backup)
+}
+
+func (r *EMQXBackupReconciler) uploadToS3(ctx context.Context, backup *appsv2beta1.EMQXBackup, requester innerReq.RequesterInterface) (ctrl.Result, error) {
+	archive, err := downloadExportedFile(requester, backup.Status.Filename, backup.Status.Node)
+	if err != nil {
+		return r.retryOrFail(ctx, backup, fmt.Sprintf("Failed to download the exported file: %s", err.Error()))
+	}
+
+	s3 := backup.Spec.Storage.S3
+	s3Client, err := newS3Client(ctx, r.Client, backup.Namespace, s3)
+	if err != nil {
+		return r.retryOrFail(ctx, backup, err.Error())
+	}
+	key := path.Join(s3.Prefix, backup.Status.Filename)
+	if _, err := s3Client.PutObject(ctx, s3.Bucket, key, bytes.NewReader(archive), int64(len(archive)), minio.PutObjectOptions{
+		ContentType: "application/gzip",
+	}); err != nil {
+		return r.retryOrFail(ctx, backup, fmt.Sprintf("Failed to upload the archive to S3: %s", err.Error()))
+	}
+	return r.setCompleted(ctx, backup, requester, fmt.Sprintf("s3://%s/%s", s3.Bucket, key))
+}
+
+func (r *EMQXBackupReconciler) downloadToPVC(ctx context.Context, backup *appsv2beta1.EMQXBackup, emqx *appsv2beta1.EMQX, requester innerReq.RequesterInterface) (ctrl.Result, error) {
+	fileURL, err := getExportedFileURL(emqx, backup.Status.Filename, backup.Status.Node)
+	if err != nil {
+		return r.setFailed(ctx, backup, err.Error())
+	}
+	job := generateBackupDownloadJob(backup, emqx, fileURL)
+
+	done, err := runJob(ctx, r.Client, r.Scheme, backup, job)
+	if err != nil {
+		if done {
+			return r.setFailed(ctx, backup, err.Error())
+		}
+		return ctrl.Result{}, err
+	}
+	if !done {
+		return ctrl.Result{RequeueAfter: 5 * time.Second}, nil
+	}
+	pvc := backup.Spec.Storage.PersistentVolumeClaim
+	return r.setCompleted(ctx, backup, requester, fmt.Sprintf("pvc://%s/%s", pvc.ClaimName, backup.Status.Filename))
+}
+
+// deleteArchive deletes the archive in the storage when the EMQXBackup is deleted, it returns true if the archive has been deleted
+func (r *EMQXBackupReconciler) deleteArchive(ctx context.Context, backup *appsv2beta1.EMQXBackup) (bool, error) {
+	if backup.Status.Phase != appsv2beta1.BackupPhaseCompleted || backup.Status.Filename == "" {
+		return true, nil
+	}
+
+	if s3 := backup.Spec.Storage.S3; s3 != nil {
+		s3Client, err := newS3Client(ctx, r.Client, backup.Namespace, s3)
+		if err != nil {
+			return false, err
+		}
+		if err := s3Client.RemoveObject(ctx, s3.Bucket, path.Join(s3.Prefix, backup.Status.Filename), minio.RemoveObjectOptions{}); err != nil {
+			return false, emperror.Wrap(err, "failed to remove the archive from S3")
+		}
+		return true, nil
+	}
+
+	if backup.Spec.Storage.PersistentVolumeClaim != nil {
+		done, err := runJob(ctx, r.Client, r.Scheme, backup, generateBackupCleanupJob(backup))
+		if err != nil && done {
+			// Don't block the deletion of the EMQXBackup, the PVC may have been deleted
+			r.EventRecorder.Event(backup, corev1.EventTypeWarning, "CleanupFailed", err.Error())
+			return true, nil
+		}
+		return done, err
+	}
+	return true, nil
+}
+
+// runJob creates the job if it does not exist, and returns true if the job is finished,
+// the returned error is not nil if the job is failed
+func runJob(ctx context.Context, k8sClient client.Client, scheme *runtime.Scheme, owner client.Object, job *batchv1.Job) (bool, error) {
+	current := &batchv1.Job{}
+	if err := k8sClient.Get(ctx, client.ObjectKeyFromObject(job), current); err != nil {
+		if !k8sErrors.IsNotFound(err) {
+			return false, emperror.Wrap(err, "failed to get job")
+		}
+		if err := ctrl.SetControllerReference(owner, job, scheme); err != nil {
+			return false, emperror.Wrap(err, "failed to set controller reference")
+		}
+		if err := k8sClient.Create(ctx, job); err != nil {
+			return false, emperror.Wrap(err, "failed to create job")
+		}
+		return false, nil
+	}
+
+	for _, c := range current.Status.Conditions {
+		if c.Status != corev1.ConditionTrue {
+			continue
+		}
+		switch c.Type {
+		case batchv1.JobComplete:
+			return true, nil
+		case batchv1.JobFailed:
+			return true, emperror.Errorf("job %s failed: %s", current.Name, c.Message)
+		}
+	}
+	return false, nil
+}
+
+func newS3Client(ctx context.Context, k8sClient client.Client, namespace string, s3 *appsv2beta1.S3BackupStorage) (*minio.Client, error) {
+	accessKey, err := readSecretValue(ctx, k8sClient, namespace, s3.CredentialsRef.Key)
+	if err != nil {
+		return nil, err
+	}
+	secretKey, err := readSecretValue(ctx, k8sClient, namespace, s3.CredentialsRef.Secret)
+	if err != nil {
+		return nil, err
+	}
+	s3Client, err := minio.New(s3.Endpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4(accessKey, secretKey, ""),
+		Secure: !s3.Insecure,
+		Region: s3.Region,
+	})
+	if err != nil {
+		return nil, emperror.Wrap(err, "failed to create S3 client")
+	}
+	return s3Client, nil
+}
+
+func readSecretValue(ctx context.Context, k8sClient client.Client, namespace string, ref appsv2beta1.KeyRef) (string, error) {
+	secret := &corev1.Secret{}
+	if err := k8sClient.Get(ctx, types.NamespacedName{Namespace: namespace, Name: ref.SecretName}, secret); err != nil {
+		return "", emperror.Wrap(err, "failed to get secret")
+	}
+	if _, ok := secret.Data[ref.SecretKey]; !ok {
+		return "", emperror.NewWithDetails("secret does not contain the key", "secret", secret.Name, "key", ref.SecretKey)
+	}
+	return string(secret.Data[ref.SecretKey]), nil
+}
+
+func validateBackupStorage(storage appsv2beta1.BackupStorage) error {
+	if (storage.PersistentVolumeClaim == nil) == (storage.S3 == nil) {
+		return emperror.New("only one of persistentVolumeClaim and s3 must be set in storage")
+	}
+	return nil
+}
+
+func exportData(requester innerReq.RequesterInterface, status *appsv2beta1.EMQXBackupStatus) error {
+	resp, body, err := requester.Request("POST", requester.GetURL(ApiDataExportV5), []byte("{}"), nil)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != 200 {
+		return emperror.Errorf("request api failed: %s, body: %s", resp.Status, string(body))
+	}
+
+	status.Filename = path.Base(gjson.GetBytes(body, "filename").String())
+	status.Node = gjson.GetBytes(body, "node").String()
+	status.Size = gjson.GetBytes(body, "size").Int()
+	status.CreatedAt = metav1.Now()
+	if createdAt, err := time.Parse(time.RFC3339, gjson.GetBytes(body, "created_at").String()); err == nil {
+		status.CreatedAt = metav1.NewTime(createdAt)
+	}
+	if status.Filename == "." || status.Node == "" {
+		return emperror.Errorf("unexpected response: %s", string(body))
+	}
+	return nil
+}
+
+func downloadExportedFile(requester innerReq.RequesterInterface, filename, node string) ([]byte, error) {
+	header := http.Header{}
+	header.Set("Accept", "application/octet-stream")
+	resp, body, err := requester.Request("GET", requester.GetURL(path.Join(ApiDataFilesV5, filename), "node="+url.QueryEscape(node)), nil, header)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != 200 {
+		return nil, emperror.Errorf("request api failed: %s", resp.Status)
+	}
+	return body, nil
+}
+
+// deleteExportedFile deletes the file on the EMQX node, if the node is empty, the file on the node which handles the request will be deleted
+func deleteExportedFile(requester innerReq.RequesterInterface, filename, node string) error {
+	query := []string{}
+	if node != "" {
+		query = append(query, "node="+url.QueryEscape(node))
+	}
+	resp, _, err := requester.Request("DELETE", requester.GetURL(path.Join(ApiDataFilesV5, filename), query...), nil, nil)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != 204 && resp.StatusCode != 200 {
+		return emperror.Errorf("request api failed: %s", resp.Status)
+	}
+	return nil
+}
+
+// getNodeAPIURL returns the URL of the EMQX HTTP API, the host is the host of the EMQX node,
+// so that the API can be requested on the specified node, like the exported file is only stored on the node which exported it
+func getNodeAPIURL(instance *appsv2beta1.EMQX, node, apiPath string) (url.URL, error) {
+	portMap, err := appsv2beta1.GetDashboardPortMap(instance.Spec.Config.Data)
+	if err != nil {
+		return url.URL{}, emperror.Wrap(err, "failed to get dashboard port")
+	}
+
+	var scheme, port string
+	if dashboardHttps, ok := portMap["dashboard-https"]; ok {
+		scheme = "https"
+		port = strconv.FormatInt(int64(dashboardHttps), 10)
+	}
+	if dashboard, ok := portMap["dashboard"]; ok {
+		scheme = "http"
+		port = strconv.FormatInt(int64(dashboard), 10)
+	}
+
+	host := node
+	if index := strings.Index(node, "@"); index >= 0 {
+		host = node[index+1:]
+	}
+
+	return url.URL{
+		Scheme: scheme,
+		Host:   net.JoinHostPort(host, port),
+		Path:   "/" + apiPath,
+	}, nil
+}
+
+func getExportedFileURL(instance *appsv2beta1.EMQX, filename, node string) (url.URL, error) {
+	fileURL, err := getNodeAPIURL(instance, node, path.Join(ApiDataFilesV5, filename))
+	if err != nil {
+		return url.URL{}, err
+	}
+	fileURL.RawQuery = "node=" + url.QueryEscape(node)
+	return fileURL, nil
+}
+
+func generateBackupDownloadJob(backup *appsv2beta1.EMQXBackup, emqx *appsv2beta1.EMQX, fileURL url.URL) *batchv1.Job {
+	job := generatePVCJob(backup.ObjectMeta, backup.Name+"-download", backup.Spec.Storage.PersistentVolumeClaim, []string{
+		"sh", "-c",
+		`curl -sSf ${TLS_OPTIONS} -u "$(grep "^${API_KEY}:" /etc/emqx/bootstrap_api_key)" -o "` + backupMountPath + `/${FILENAME}" "${URL}"`,
+	})
+	mountBootstrapAPIKey(job, emqx)
+	fileURL = mountNodeAPIClientTLS(job, emqx, fileURL)
+	container := &job.Spec.Template.Spec.Containers[0]
+	container.Env = append(container.Env,
+		corev1.EnvVar{Name: "API_KEY", Value: appsv2beta1.DefaultBootstrapAPIKey},
+		corev1.EnvVar{Name: "FILENAME", Value: backup.Status.Filename},
+		corev1.EnvVar{Name: "URL", Value: fileURL.String()},
+	)
+	return job
+}
+
+func generateBackupCleanupJob(backup *appsv2beta1.EMQXBackup) *batchv1.Job {
+	job := generatePVCJob(backup.ObjectMeta, backup.Name+"-cleanup", backup.Spec.Storage.PersistentVolumeClaim, []string{
+		"sh", "-c", `rm -f "` + backupMountPath + `/${FILENAME}"`,
+	})
+	job.Spec.Template.Spec.Containers[0].Env = []corev1.EnvVar{
+		{Name: "FILENAME", Value: backup.Status.Filename},
+	}
+	return job
+}
+
+// mountBootstrapAPIKey mounts the bootstrap API key of EMQX to /etc/emqx/bootstrap_api_key, the job uses it to request the EMQX HTTP API
+func mountBootstrapAPIKey(job *batchv1.Job, emqx *appsv2beta1.EMQX) {
+	container := &job.Spec.Template.Spec.Containers[0]
+	container.VolumeMounts = append(container.VolumeMounts, corev1.VolumeMount{
+		Name:      "bootstrap-api-key",
+		MountPath: "/etc/emqx/bootstrap_api_key",
+		SubPath:   "bootstrap_api_key",
+		ReadOnly:  true,
+	})
+	job.Spec.Template.Spec.Volumes = append(job.Spec.Template.Spec.Volumes, corev1.Volume{
+		Name: "bootstrap-api-key",
+		VolumeSource: corev1.VolumeSource{
+			Secret: &corev1.SecretVolumeSource{
+				SecretName: emqx.BootstrapAPIKeyNamespacedName().Name,
+			},
+		},
+	})
+}
+
+// mountNodeAPIClientTLS mounts the CA bundle and the client certificate to the job if the node API is served by HTTPS,
+// and passes the options of curl by the TLS_OPTIONS env. The node is connected by its address, but its certificate is verified
+// by the server name, the same as the EMQX management API client of EMQX Operator, so the returned URL is requested instead.
+func mountNodeAPIClientTLS(job *batchv1.Job, emqx *appsv2beta1.EMQX, nodeURL url.URL) url.URL {
+	if nodeURL.Scheme != "https" {
+		return nodeURL
+	}
+	options := append(mountAPIClientTLS(job, emqx), "--connect-to", "::"+nodeURL.Host)
+	container := &job.Spec.Template.Spec.Containers[0]
+	container.Env = append(container.Env, corev1.EnvVar{Name: "TLS_OPTIONS", Value: strings.Join(options, " ")})
+
+	apiURL := nodeURL
+	apiURL.Host = net.JoinHostPort(getAPIClientServerName(emqx), nodeURL.Port())
+	return apiURL
+}
+
+// generatePVCJob generates the job which mounts the PVC to /backup and runs the command
+func generatePVCJob(owner metav1.ObjectMeta, name string, pvc *appsv2beta1.PVCBackupStorage, command []string) *batchv1.Job {
+	labels := appsv2beta1.CloneAndMergeMap(map[string]string{
+		appsv2beta1.LabelsManagedByKey: "emqx-operator",
+	}, owner.Labels)
+	return &batchv1.Job{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "batch/v1",
+			Kind:       "Job",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: owner.Namespace,
+			Name:      name,
+			Labels:    labels,
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit: ptr.To(int32(3)),
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: labels,
+				},
+				Spec: corev1.PodSpec{
+					RestartPolicy: corev1.RestartPolicyNever,
+					// Keep the same owner as the EMQX container, so that the archive can be read by EMQX
+					SecurityContext: &corev1.PodSecurityContext{
+						RunAsUser:  ptr.To(int64(1000)),
+						RunAsGroup: ptr.To(int64(1000)),
+						FSGroup:    ptr.To(int64(1000)),
+					},
+					Containers: []corev1.Container{
+						{
+							Name:    "backup",
+							Image:   pvc.Image,
+							Command: command,
+							VolumeMounts: []corev1.VolumeMount{
+								{
+									Name:      "backup",
+									MountPath: backupMountPath,
+								},
+							},
+						},
+					},
+					Volumes: []corev1.Volume{
+						{
+							Name: "backup",
+							VolumeSource: corev1.VolumeSource{
+								PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+									ClaimName: pvc.ClaimName,
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
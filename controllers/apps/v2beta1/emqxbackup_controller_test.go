@@ -0,0 +1,210 @@
+package v2beta1
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	appsv2beta1 "github.com/emqx/emqx-operator/apis/apps/v2beta1"
+	innerReq "github.com/emqx/emqx-operator/internal/requester"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestValidateBackupStorage(t *testing.T) {
+	assert.Error(t, validateBackupStorage(appsv2beta1.BackupStorage{}))
+	assert.Error(t, validateBackupStorage(appsv2beta1.BackupStorage{
+		PersistentVolumeClaim: &appsv2beta1.PVCBackupStorage{ClaimName: "backup"},
+		S3:                    &appsv2beta1.S3BackupStorage{Bucket: "backup"},
+	}))
+	assert.NoError(t, validateBackupStorage(appsv2beta1.BackupStorage{
+		PersistentVolumeClaim: &appsv2beta1.PVCBackupStorage{ClaimName: "backup"},
+	}))
+	assert.NoError(t, validateBackupStorage(appsv2beta1.BackupStorage{
+		S3: &appsv2beta1.S3BackupStorage{Bucket: "backup"},
+	}))
+}
+
+func TestGetRetryBackoff(t *testing.T) {
+	assert.Equal(t, 5*time.Second, getRetryBackoff(0))
+	assert.Equal(t, 10*time.Second, getRetryBackoff(1))
+	assert.Equal(t, 80*time.Second, getRetryBackoff(4))
+	assert.Equal(t, 5*time.Minute, getRetryBackoff(10))
+	assert.Equal(t, 5*time.Minute, getRetryBackoff(100))
+}
+
+func TestBackupRetryOrFail(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = appsv2beta1.AddToScheme(scheme)
+	backup := &appsv2beta1.EMQXBackup{
+		ObjectMeta: metav1.ObjectMeta{Name: "emqx-backup", Namespace: "emqx"},
+	}
+	fakeClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(backup).WithStatusSubresource(backup).Build()
+	r := &EMQXBackupReconciler{Client: fakeClient, Scheme: scheme, EventRecorder: record.NewFakeRecorder(10)}
+
+	got := &appsv2beta1.EMQXBackup{}
+	for i := int32(0); i < maxTransientRetries; i++ {
+		assert.NoError(t, fakeClient.Get(ctx, client.ObjectKeyFromObject(backup), got))
+		result, err := r.retryOrFail(ctx, got, "connection refused")
+		assert.NoError(t, err)
+		assert.Equal(t, getRetryBackoff(i), result.RequeueAfter)
+		assert.Equal(t, i+1, got.Status.Retries)
+		assert.False(t, got.Status.IsFinished())
+	}
+
+	assert.NoError(t, fakeClient.Get(ctx, client.ObjectKeyFromObject(backup), got))
+	result, err := r.retryOrFail(ctx, got, "connection refused")
+	assert.NoError(t, err)
+	assert.Zero(t, result.RequeueAfter)
+	assert.Equal(t, appsv2beta1.BackupPhaseFailed, got.Status.Phase)
+	assert.Equal(t, "connection refused", got.Status.Message)
+}
+
+func TestExportData(t *testing.T) {
+	t.Run("export succeeded", func(t *testing.T) {
+		requester := &innerReq.FakeRequester{
+			ReqFunc: func(method string, url url.URL, body []byte, header http.Header) (*http.Response, []byte, error) {
+				assert.Equal(t, "POST", method)
+				assert.Equal(t, ApiDataExportV5, url.Path)
+				return &http.Response{StatusCode: 200}, []byte(`{
+					"filename": "/opt/emqx/data/backup/emqx-export-2024-01-01-00-00-00.000.tar.gz",
+					"node": "emqx@emqx-core-0.emqx-headless.default.svc.cluster.local",
+					"size": 1024,
+					"created_at": "2024-01-01T00:00:00Z"
+				}`), nil
+			},
+		}
+		status := &appsv2beta1.EMQXBackupStatus{}
+		assert.NoError(t, exportData(requester, status))
+		assert.Equal(t, "emqx-export-2024-01-01-00-00-00.000.tar.gz", status.Filename)
+		assert.Equal(t, "emqx@emqx-core-0.emqx-headless.default.svc.cluster.local", status.Node)
+		assert.Equal(t, int64(1024), status.Size)
+		assert.True(t, status.CreatedAt.Time.Equal(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)))
+	})
+
+	t.Run("export failed", func(t *testing.T) {
+		requester := &innerReq.FakeRequester{
+			ReqFunc: func(method string, url url.URL, body []byte, header http.Header) (*http.Response, []byte, error) {
+				return &http.Response{StatusCode: 500, Status: "500 Internal Server Error"}, nil, nil
+			},
+		}
+		assert.ErrorContains(t, exportData(requester, &appsv2beta1.EMQXBackupStatus{}), "500 Internal Server Error")
+
+		requester.ReqFunc = func(method string, url url.URL, body []byte, header http.Header) (*http.Response, []byte, error) {
+			return nil, nil, errors.New("fake error")
+		}
+		assert.ErrorContains(t, exportData(requester, &appsv2beta1.EMQXBackupStatus{}), "fake error")
+	})
+}
+
+func TestGetExportedFileURL(t *testing.T) {
+	instance := &appsv2beta1.EMQX{}
+	instance.Spec.Config.Data = `dashboard.listeners.http.bind = 18083`
+
+	got, err := getExportedFileURL(instance, "emqx-export.tar.gz", "emqx@emqx-core-0.emqx-headless.default.svc.cluster.local")
+	assert.NoError(t, err)
+	assert.Equal(t, "http://emqx-core-0.emqx-headless.default.svc.cluster.local:18083/api/v5/data/files/emqx-export.tar.gz?node=emqx%40emqx-core-0.emqx-headless.default.svc.cluster.local", got.String())
+
+	instance.Spec.Config.Data = `
+	dashboard.listeners.http.bind = 0
+	dashboard.listeners.https.bind = 18084
+	`
+	got, err = getExportedFileURL(instance, "emqx-export.tar.gz", "emqx@10.0.0.1")
+	assert.NoError(t, err)
+	assert.Equal(t, "https://10.0.0.1:18084/api/v5/data/files/emqx-export.tar.gz?node=emqx%4010.0.0.1", got.String())
+}
+
+func TestGenerateBackupDownloadJob(t *testing.T) {
+	instance := &appsv2beta1.EMQX{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "emqx",
+			Namespace: "default",
+		},
+	}
+	backup := &appsv2beta1.EMQXBackup{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "backup",
+			Namespace: "default",
+			Labels:    map[string]string{"foo": "bar"},
+		},
+		Spec: appsv2beta1.EMQXBackupSpec{
+			InstanceName: "emqx",
+			Storage: appsv2beta1.BackupStorage{
+				PersistentVolumeClaim: &appsv2beta1.PVCBackupStorage{
+					ClaimName: "emqx-backup",
+					Image:     "curlimages/curl:8.5.0",
+				},
+			},
+		},
+		Status: appsv2beta1.EMQXBackupStatus{
+			Filename: "emqx-export.tar.gz",
+		},
+	}
+	fileURL := url.URL{Scheme: "http", Host: "emqx-core-0:18083", Path: "/api/v5/data/files/emqx-export.tar.gz"}
+
+	got := generateBackupDownloadJob(backup, instance, fileURL)
+	assert.Equal(t, "backup-download", got.Name)
+	assert.Equal(t, "default", got.Namespace)
+	assert.Equal(t, map[string]string{
+		"foo":                          "bar",
+		appsv2beta1.LabelsManagedByKey: "emqx-operator",
+	}, got.Labels)
+
+	podSpec := got.Spec.Template.Spec
+	assert.Equal(t, corev1.RestartPolicyNever, podSpec.RestartPolicy)
+	assert.Len(t, podSpec.Containers, 1)
+	assert.Equal(t, "curlimages/curl:8.5.0", podSpec.Containers[0].Image)
+	assert.ElementsMatch(t, []corev1.EnvVar{
+		{Name: "API_KEY", Value: appsv2beta1.DefaultBootstrapAPIKey},
+		{Name: "FILENAME", Value: "emqx-export.tar.gz"},
+		{Name: "URL", Value: fileURL.String()},
+	}, podSpec.Containers[0].Env)
+	assert.ElementsMatch(t, []corev1.Volume{
+		{
+			Name: "backup",
+			VolumeSource: corev1.VolumeSource{
+				PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: "emqx-backup"},
+			},
+		},
+		{
+			Name: "bootstrap-api-key",
+			VolumeSource: corev1.VolumeSource{
+				Secret: &corev1.SecretVolumeSource{SecretName: "emqx-bootstrap-api-key"},
+			},
+		},
+	}, podSpec.Volumes)
+
+	t.Run("generate download job with https", func(t *testing.T) {
+		emqx := instance.DeepCopy()
+		emqx.Spec.ClusterDomain = "cluster.local"
+		fileURL := url.URL{Scheme: "https", Host: "emqx-core-0:18084", Path: "/api/v5/data/files/emqx-export.tar.gz"}
+
+		got := generateBackupDownloadJob(backup, emqx, fileURL)
+		container := got.Spec.Template.Spec.Containers[0]
+		assert.NotContains(t, container.Command[2], "-k")
+		assert.Contains(t, container.Command[2], "${TLS_OPTIONS}")
+		assert.Contains(t, container.Env, corev1.EnvVar{
+			Name:  "TLS_OPTIONS",
+			Value: "--cacert /etc/emqx/api-client/ca/ca.crt --connect-to ::emqx-core-0:18084",
+		})
+		assert.Contains(t, container.Env, corev1.EnvVar{
+			Name:  "URL",
+			Value: "https://emqx-dashboard.default.svc.cluster.local:18084/api/v5/data/files/emqx-export.tar.gz",
+		})
+		assert.Len(t, got.Spec.Template.Spec.Volumes, 3)
+	})
+
+	t.Run("generate cleanup job", func(t *testing.T) {
+		got := generateBackupCleanupJob(backup)
+		assert.Equal(t, "backup-cleanup", got.Name)
+		assert.Equal(t, []corev1.EnvVar{{Name: "FILENAME", Value: "emqx-export.tar.gz"}}, got.Spec.Template.Spec.Containers[0].Env)
+		assert.Len(t, got.Spec.Template.Spec.Volumes, 1)
+	})
+}
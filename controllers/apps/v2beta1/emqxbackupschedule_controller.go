@@ -0,0 +1,196 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2beta1
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	emperror "emperror.dev/errors"
+	"github.com/robfig/cron/v3"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+
+	appsv2beta1 "github.com/emqx/emqx-operator/apis/apps/v2beta1"
+)
+
+// EMQXBackupScheduleReconciler reconciles a EMQXBackupSchedule object
+type EMQXBackupScheduleReconciler struct {
+	Client        client.Client
+	Scheme        *runtime.Scheme
+	EventRecorder record.EventRecorder
+}
+
+func NewEMQXBackupScheduleReconciler(mgr manager.Manager) *EMQXBackupScheduleReconciler {
+	return &EMQXBackupScheduleReconciler{
+		Client:        mgr.GetClient(),
+		Scheme:        mgr.GetScheme(),
+		EventRecorder: mgr.GetEventRecorderFor("emqxbackupschedule-controller"),
+	}
+}
+
+//+kubebuilder:rbac:groups=apps.emqx.io,resources=emqxbackupschedules,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=apps.emqx.io,resources=emqxbackupschedules/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=apps.emqx.io,resources=emqxbackupschedules/finalizers,verbs=update
+
+func (r *EMQXBackupScheduleReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+	logger.V(1).Info("Reconcile EMQX backup schedule")
+
+	schedule := &appsv2beta1.EMQXBackupSchedule{}
+	if err := r.Client.Get(ctx, req.NamespacedName, schedule); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	if !schedule.DeletionTimestamp.IsZero() {
+		return ctrl.Result{}, nil
+	}
+
+	cronSchedule, err := cron.ParseStandard(schedule.Spec.Schedule)
+	if err != nil {
+		// Don't requeue, the schedule will be reconciled again when it is updated
+		r.EventRecorder.Event(schedule, corev1.EventTypeWarning, "InvalidSchedule", fmt.Sprintf("failed to parse schedule %q: %s", schedule.Spec.Schedule, err.Error()))
+		return ctrl.Result{}, nil
+	}
+
+	backupList := &appsv2beta1.EMQXBackupList{}
+	if err := r.Client.List(ctx, backupList,
+		client.InNamespace(schedule.Namespace),
+		client.MatchingLabels{appsv2beta1.LabelsBackupScheduleKey: schedule.Name},
+	); err != nil {
+		return ctrl.Result{}, emperror.Wrap(err, "failed to list EMQX backups")
+	}
+
+	now := time.Now()
+	for _, backup := range getExpiredBackups(backupList.Items, schedule.Spec.Retention, now) {
+		if err := r.Client.Delete(ctx, backup); client.IgnoreNotFound(err) != nil {
+			return ctrl.Result{}, emperror.Wrap(err, "failed to delete expired EMQX backup")
+		}
+		r.EventRecorder.Event(schedule, corev1.EventTypeNormal, "DeleteBackup", fmt.Sprintf("delete expired EMQX backup %s", backup.Name))
+	}
+
+	status := schedule.Status.DeepCopy()
+	for _, backup := range backupList.Items {
+		if backup.Status.Phase == appsv2beta1.BackupPhaseCompleted && status.LastSuccessfulTime.Before(&backup.Status.CompletedTime) {
+			status.LastSuccessfulTime = backup.Status.CompletedTime
+		}
+	}
+
+	var result ctrl.Result
+	if !schedule.Spec.Suspend {
+		earliest := schedule.CreationTimestamp.Time
+		if !schedule.Status.LastScheduleTime.IsZero() {
+			earliest = schedule.Status.LastScheduleTime.Time
+		}
+		missed, next := getScheduleTimes(cronSchedule, earliest, now)
+		if !missed.IsZero() {
+			backup := generateScheduledBackup(schedule, missed)
+			if err := ctrl.SetControllerReference(schedule, backup, r.Scheme); err != nil {
+				return ctrl.Result{}, emperror.Wrap(err, "failed to set controller reference")
+			}
+			if err := r.Client.Create(ctx, backup); err != nil && !k8sErrors.IsAlreadyExists(err) {
+				return ctrl.Result{}, emperror.Wrap(err, "failed to create EMQX backup")
+			}
+			r.EventRecorder.Event(schedule, corev1.EventTypeNormal, "CreateBackup", fmt.Sprintf("create EMQX backup %s", backup.Name))
+			status.LastScheduleTime = metav1.NewTime(missed)
+		}
+		result.RequeueAfter = next.Sub(now)
+	}
+
+	if !equality.Semantic.DeepEqual(*status, schedule.Status) {
+		schedule.Status = *status
+		if err := r.Client.Status().Update(ctx, schedule); err != nil {
+			return ctrl.Result{}, emperror.Wrap(err, "failed to update EMQX backup schedule status")
+		}
+	}
+	return result, nil
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *EMQXBackupScheduleReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&appsv2beta1.EMQXBackupSchedule{}).
+		Owns(&appsv2beta1.EMQXBackup{}).
+		Complete(r)
+}
+
+// getScheduleTimes returns the latest scheduled time which is after the earliest and not after now,
+// it is zero if there is no missed schedule, and returns the next scheduled time after now.
+// Like the CronJob, if more than one schedule is missed, only the latest one will be run.
+func getScheduleTimes(schedule cron.Schedule, earliest, now time.Time) (missed, next time.Time) {
+	for t := schedule.Next(earliest); !t.After(now); t = schedule.Next(t) {
+		missed = t
+	}
+	next = schedule.Next(now)
+	return
+}
+
+// getExpiredBackups returns the EMQXBackups which should be deleted by the retention policy,
+// the backups in processing will never be deleted
+func getExpiredBackups(backups []appsv2beta1.EMQXBackup, retention appsv2beta1.BackupRetention, now time.Time) []*appsv2beta1.EMQXBackup {
+	finished := []*appsv2beta1.EMQXBackup{}
+	for i := range backups {
+		if backups[i].Status.IsFinished() && backups[i].DeletionTimestamp.IsZero() {
+			finished = append(finished, &backups[i])
+		}
+	}
+	sort.Slice(finished, func(i, j int) bool {
+		return finished[j].CreationTimestamp.Before(&finished[i].CreationTimestamp)
+	})
+
+	expired := []*appsv2beta1.EMQXBackup{}
+	completed := int32(0)
+	for _, backup := range finished {
+		if retention.MaxAge != nil && backup.CreationTimestamp.Add(retention.MaxAge.Duration).Before(now) {
+			expired = append(expired, backup)
+			continue
+		}
+		if backup.Status.Phase == appsv2beta1.BackupPhaseCompleted {
+			completed++
+			if retention.MaxCount != nil && completed > *retention.MaxCount {
+				expired = append(expired, backup)
+			}
+		}
+	}
+	return expired
+}
+
+func generateScheduledBackup(schedule *appsv2beta1.EMQXBackupSchedule, scheduledTime time.Time) *appsv2beta1.EMQXBackup {
+	return &appsv2beta1.EMQXBackup{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: appsv2beta1.GroupVersion.String(),
+			Kind:       "EMQXBackup",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: schedule.Namespace,
+			Name:      fmt.Sprintf("%s-%d", schedule.Name, scheduledTime.Unix()),
+			Labels: appsv2beta1.CloneAndMergeMap(map[string]string{
+				appsv2beta1.LabelsBackupScheduleKey: schedule.Name,
+			}, schedule.Labels),
+		},
+		Spec: *schedule.Spec.BackupTemplate.DeepCopy(),
+	}
+}
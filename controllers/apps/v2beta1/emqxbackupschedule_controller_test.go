@@ -0,0 +1,104 @@
+package v2beta1
+
+import (
+	"testing"
+	"time"
+
+	appsv2beta1 "github.com/emqx/emqx-operator/apis/apps/v2beta1"
+	"github.com/robfig/cron/v3"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+)
+
+func TestGetScheduleTimes(t *testing.T) {
+	schedule, err := cron.ParseStandard("0 * * * *")
+	assert.NoError(t, err)
+
+	now := time.Date(2024, 1, 1, 10, 30, 0, 0, time.UTC)
+
+	t.Run("no missed schedule", func(t *testing.T) {
+		missed, next := getScheduleTimes(schedule, now.Add(-10*time.Minute), now)
+		assert.True(t, missed.IsZero())
+		assert.Equal(t, time.Date(2024, 1, 1, 11, 0, 0, 0, time.UTC), next)
+	})
+
+	t.Run("only the latest missed schedule", func(t *testing.T) {
+		missed, next := getScheduleTimes(schedule, now.Add(-3*time.Hour), now)
+		assert.Equal(t, time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC), missed)
+		assert.Equal(t, time.Date(2024, 1, 1, 11, 0, 0, 0, time.UTC), next)
+	})
+}
+
+func TestGetExpiredBackups(t *testing.T) {
+	now := time.Now()
+	newBackup := func(name string, age time.Duration, phase appsv2beta1.BackupPhase) appsv2beta1.EMQXBackup {
+		return appsv2beta1.EMQXBackup{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              name,
+				CreationTimestamp: metav1.NewTime(now.Add(-age)),
+			},
+			Status: appsv2beta1.EMQXBackupStatus{Phase: phase},
+		}
+	}
+	backups := []appsv2beta1.EMQXBackup{
+		newBackup("completed-3", 3*time.Hour, appsv2beta1.BackupPhaseCompleted),
+		newBackup("processing", 1*time.Minute, appsv2beta1.BackupPhaseProcessing),
+		newBackup("completed-1", 1*time.Hour, appsv2beta1.BackupPhaseCompleted),
+		newBackup("failed", 4*time.Hour, appsv2beta1.BackupPhaseFailed),
+		newBackup("completed-2", 2*time.Hour, appsv2beta1.BackupPhaseCompleted),
+		newBackup("processing-old", 48*time.Hour, appsv2beta1.BackupPhaseProcessing),
+	}
+	names := func(backups []*appsv2beta1.EMQXBackup) []string {
+		list := []string{}
+		for _, backup := range backups {
+			list = append(list, backup.Name)
+		}
+		return list
+	}
+
+	t.Run("no retention", func(t *testing.T) {
+		assert.Empty(t, getExpiredBackups(backups, appsv2beta1.BackupRetention{}, now))
+	})
+
+	t.Run("retention by count", func(t *testing.T) {
+		got := getExpiredBackups(backups, appsv2beta1.BackupRetention{MaxCount: ptr.To(int32(2))}, now)
+		assert.Equal(t, []string{"completed-3"}, names(got))
+	})
+
+	t.Run("retention by age", func(t *testing.T) {
+		got := getExpiredBackups(backups, appsv2beta1.BackupRetention{MaxAge: &metav1.Duration{Duration: 150 * time.Minute}}, now)
+		assert.Equal(t, []string{"completed-3", "failed"}, names(got))
+	})
+
+	t.Run("retention by count and age", func(t *testing.T) {
+		got := getExpiredBackups(backups, appsv2beta1.BackupRetention{
+			MaxCount: ptr.To(int32(1)),
+			MaxAge:   &metav1.Duration{Duration: 150 * time.Minute},
+		}, now)
+		assert.Equal(t, []string{"completed-2", "completed-3", "failed"}, names(got))
+	})
+}
+
+func TestGenerateScheduledBackup(t *testing.T) {
+	schedule := &appsv2beta1.EMQXBackupSchedule{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "daily",
+			Namespace: "default",
+			Labels:    map[string]string{"foo": "bar"},
+		},
+		Spec: appsv2beta1.EMQXBackupScheduleSpec{
+			BackupTemplate: appsv2beta1.EMQXBackupSpec{
+				InstanceName: "emqx",
+			},
+		},
+	}
+	got := generateScheduledBackup(schedule, time.Unix(1704067200, 0))
+	assert.Equal(t, "daily-1704067200", got.Name)
+	assert.Equal(t, "default", got.Namespace)
+	assert.Equal(t, map[string]string{
+		"foo":                               "bar",
+		appsv2beta1.LabelsBackupScheduleKey: "daily",
+	}, got.Labels)
+	assert.Equal(t, "emqx", got.Spec.InstanceName)
+}
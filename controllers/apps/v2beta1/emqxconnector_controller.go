@@ -0,0 +1,112 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2beta1
+
+import (
+	"context"
+
+	"github.com/tidwall/gjson"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	appsv2beta1 "github.com/emqx/emqx-operator/apis/apps/v2beta1"
+	innerReq "github.com/emqx/emqx-operator/internal/requester"
+)
+
+const ApiConnectorsV5 = "api/v5/connectors"
+
+// EMQXConnectorReconciler reconciles a EMQXConnector object
+type EMQXConnectorReconciler struct {
+	Client        client.Client
+	Scheme        *runtime.Scheme
+	EventRecorder record.EventRecorder
+}
+
+func NewEMQXConnectorReconciler(mgr manager.Manager) *EMQXConnectorReconciler {
+	return &EMQXConnectorReconciler{
+		Client:        mgr.GetClient(),
+		Scheme:        mgr.GetScheme(),
+		EventRecorder: mgr.GetEventRecorderFor("emqxconnector-controller"),
+	}
+}
+
+//+kubebuilder:rbac:groups=apps.emqx.io,resources=emqxconnectors,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=apps.emqx.io,resources=emqxconnectors/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=apps.emqx.io,resources=emqxconnectors/finalizers,verbs=update
+
+func (r *EMQXConnectorReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+	logger.V(1).Info("Reconcile EMQX connector")
+
+	lifecycle := r.lifecycle()
+	connector := &appsv2beta1.EMQXConnector{}
+	return lifecycle.reconcile(ctx, req, connector, apiResourceHandler{
+		// The connector can not be deleted while it is used by the actions, so retry until they are deleted
+		delete: func(requester innerReq.RequesterInterface) error {
+			return deleteAPIResource(requester, newConnectorAPIResource(connector))
+		},
+		sync: func(_ *appsv2beta1.EMQX, requester innerReq.RequesterInterface) (ctrl.Result, error) {
+			res := newConnectorAPIResource(connector)
+			if err := setAPIResourceConfig(ctx, r.Client, connector.Namespace, &res, connector.Spec.Config, connector.Spec.SecretConfig); err != nil {
+				return lifecycle.setFailed(ctx, connector, err.Error())
+			}
+			return lifecycle.syncAPIResource(ctx, connector, requester, res, connector.Status.ConfigHash, func(body []byte) {
+				connector.Status.SetSynced(gjson.GetBytes(body, "status").String(), res.hash())
+			})
+		},
+	})
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *EMQXConnectorReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&appsv2beta1.EMQXConnector{}).
+		Watches(&corev1.Secret{}, handler.EnqueueRequestsFromMapFunc(r.requestsForObject), builder.OnlyMetadata).
+		Watches(&appsv2beta1.EMQX{}, handler.EnqueueRequestsFromMapFunc(r.requestsForObject)).
+		Complete(r)
+}
+
+func (r *EMQXConnectorReconciler) lifecycle() *apiResourceLifecycle {
+	return &apiResourceLifecycle{client: r.Client, eventRecorder: r.EventRecorder, kind: "Connector"}
+}
+
+// requestsForObject returns the connectors which use the secret or the EMQX
+func (r *EMQXConnectorReconciler) requestsForObject(ctx context.Context, obj client.Object) []reconcile.Request {
+	return r.lifecycle().requests(ctx, &appsv2beta1.EMQXConnectorList{}, obj.GetNamespace(), func(o apiResourceObject) bool {
+		connector := o.(*appsv2beta1.EMQXConnector)
+		return isAPIResourceDependency(obj, connector.Spec.InstanceName, connector.Spec.SecretConfig)
+	})
+}
+
+func newConnectorAPIResource(connector *appsv2beta1.EMQXConnector) apiResource {
+	return apiResource{
+		path: ApiConnectorsV5,
+		id:   connector.ID(),
+		keys: map[string]interface{}{
+			"type": connector.Spec.Type,
+			"name": connector.Name,
+		},
+	}
+}
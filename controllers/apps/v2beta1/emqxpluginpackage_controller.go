@@ -0,0 +1,503 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2beta1
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"mime/multipart"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	emperror "emperror.dev/errors"
+	"github.com/tidwall/gjson"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	appsv2beta1 "github.com/emqx/emqx-operator/apis/apps/v2beta1"
+	innerReq "github.com/emqx/emqx-operator/internal/requester"
+)
+
+const (
+	ApiPluginsV5        = "api/v5/plugins"
+	ApiPluginsInstallV5 = "api/v5/plugins/install"
+)
+
+// defaultPluginUploaderImage downloads the package of the url source, and uploads the package to EMQX
+const defaultPluginUploaderImage = "curlimages/curl:8.5.0"
+
+// EMQXPluginPackageReconciler reconciles a EMQXPluginPackage object
+type EMQXPluginPackageReconciler struct {
+	Client        client.Client
+	Scheme        *runtime.Scheme
+	EventRecorder record.EventRecorder
+}
+
+func NewEMQXPluginPackageReconciler(mgr manager.Manager) *EMQXPluginPackageReconciler {
+	return &EMQXPluginPackageReconciler{
+		Client:        mgr.GetClient(),
+		Scheme:        mgr.GetScheme(),
+		EventRecorder: mgr.GetEventRecorderFor("emqxpluginpackage-controller"),
+	}
+}
+
+//+kubebuilder:rbac:groups=apps.emqx.io,resources=emqxpluginpackages,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=apps.emqx.io,resources=emqxpluginpackages/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=apps.emqx.io,resources=emqxpluginpackages/finalizers,verbs=update
+
+func (r *EMQXPluginPackageReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+	logger.V(1).Info("Reconcile EMQX plugin package")
+
+	lifecycle := r.lifecycle()
+	pkg := &appsv2beta1.EMQXPluginPackage{}
+	return lifecycle.reconcile(ctx, req, pkg, apiResourceHandler{
+		delete: func(requester innerReq.RequesterInterface) error {
+			for _, nameVsn := range []string{pkg.Status.NameVsn, pkg.Spec.NameVsn} {
+				if nameVsn == "" {
+					continue
+				}
+				if err := deletePluginByAPI(requester, nameVsn); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+		sync: func(emqx *appsv2beta1.EMQX, requester innerReq.RequesterInterface) (ctrl.Result, error) {
+			return r.syncPluginPackage(ctx, lifecycle, pkg, emqx, requester)
+		},
+	})
+}
+
+func (r *EMQXPluginPackageReconciler) syncPluginPackage(ctx context.Context, lifecycle *apiResourceLifecycle, pkg *appsv2beta1.EMQXPluginPackage, emqx *appsv2beta1.EMQX, requester innerReq.RequesterInterface) (ctrl.Result, error) {
+	if err := validatePluginSource(pkg.Spec.Source); err != nil {
+		return lifecycle.setFailed(ctx, pkg, err.Error())
+	}
+
+	nameVsn := pkg.Spec.NameVsn
+	if pkg.Status.NameVsn != "" && pkg.Status.NameVsn != nameVsn {
+		if err := deletePluginByAPI(requester, pkg.Status.NameVsn); err != nil {
+			return ctrl.Result{}, emperror.Wrap(err, "failed to uninstall the old plugin")
+		}
+		r.EventRecorder.Event(pkg, corev1.EventTypeNormal, "PluginUninstalled", fmt.Sprintf("plugin %s is uninstalled", pkg.Status.NameVsn))
+		pkg.Status.SetInstalling(nameVsn)
+		return ctrl.Result{Requeue: true}, r.Client.Status().Update(ctx, pkg)
+	}
+
+	nodes, err := getPluginByAPI(requester, nameVsn)
+	if err != nil {
+		return ctrl.Result{}, emperror.Wrap(err, "failed to get plugin")
+	}
+
+	// EMQX installs the plugin on all nodes in the cluster, but the nodes which
+	// failed to install it or joined the cluster later need to install it again
+	if missing := getMissingPluginNodes(emqx, nodes); len(missing) > 0 {
+		return r.installPlugin(ctx, lifecycle, pkg, emqx, requester, missing)
+	}
+
+	enable := pkg.Spec.Enable == nil || *pkg.Spec.Enable
+	for _, node := range nodes {
+		if enable == (node.Status == "running") {
+			continue
+		}
+		action := "stop"
+		if enable {
+			action = "start"
+		}
+		if err := requestPluginAPI(requester, "PUT", fmt.Sprintf("%s/%s/%s", ApiPluginsV5, nameVsn, action), nil); err != nil {
+			return lifecycle.setFailed(ctx, pkg, fmt.Sprintf("Failed to %s the plugin: %s", action, err.Error()))
+		}
+		if nodes, err = getPluginByAPI(requester, nameVsn); err != nil {
+			return ctrl.Result{}, emperror.Wrap(err, "failed to get plugin")
+		}
+		break
+	}
+
+	data, _ := json.Marshal(map[string]interface{}{
+		"position": pkg.Spec.Position,
+		"config":   pkg.Spec.Config,
+	})
+	configHash := computeConfigHash(string(data))
+	if configHash != pkg.Status.ConfigHash || pkg.Status.Phase != appsv2beta1.PluginPackagePhaseSynced {
+		if pkg.Spec.Position != "" {
+			body, _ := json.Marshal(map[string]string{"position": pkg.Spec.Position})
+			if err := requestPluginAPI(requester, "POST", fmt.Sprintf("%s/%s/move", ApiPluginsV5, nameVsn), body); err != nil {
+				return lifecycle.setFailed(ctx, pkg, fmt.Sprintf("Failed to move the plugin: %s", err.Error()))
+			}
+		}
+		if len(pkg.Spec.Config.Raw) > 0 {
+			if err := requestPluginAPI(requester, "PUT", fmt.Sprintf("%s/%s/config", ApiPluginsV5, nameVsn), pkg.Spec.Config.Raw); err != nil {
+				return lifecycle.setFailed(ctx, pkg, fmt.Sprintf("Failed to update the config of the plugin: %s", err.Error()))
+			}
+		}
+	}
+
+	status := pkg.Status.DeepCopy()
+	pkg.Status.SetSynced(nameVsn, configHash, nodes)
+	if !equality.Semantic.DeepEqual(status, &pkg.Status) {
+		if status.Phase != appsv2beta1.PluginPackagePhaseSynced || status.ConfigHash != configHash {
+			r.EventRecorder.Event(pkg, corev1.EventTypeNormal, "PluginSynced", fmt.Sprintf("plugin %s is synced", nameVsn))
+		}
+		if err := r.Client.Status().Update(ctx, pkg); err != nil {
+			return ctrl.Result{}, emperror.Wrap(err, "failed to update status")
+		}
+	}
+	// Check the plugin in EMQX periodically, it is installed on the new nodes and the recreated EMQX cluster
+	return ctrl.Result{RequeueAfter: apiResourceSyncInterval}, nil
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *EMQXPluginPackageReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&appsv2beta1.EMQXPluginPackage{}).
+		Owns(&batchv1.Job{}).
+		Watches(&appsv2beta1.EMQX{}, handler.EnqueueRequestsFromMapFunc(r.requestsForEMQX)).
+		Complete(r)
+}
+
+func (r *EMQXPluginPackageReconciler) lifecycle() *apiResourceLifecycle {
+	return &apiResourceLifecycle{client: r.Client, eventRecorder: r.EventRecorder, kind: "Plugin"}
+}
+
+func (r *EMQXPluginPackageReconciler) requestsForEMQX(ctx context.Context, obj client.Object) []reconcile.Request {
+	return r.lifecycle().requests(ctx, &appsv2beta1.EMQXPluginPackageList{}, obj.GetNamespace(), func(pkg apiResourceObject) bool {
+		return pkg.GetInstanceName() == obj.GetName()
+	})
+}
+
+// installPlugin uploads the package to the install API of each node, the package in the image or from the url is uploaded by a Job
+func (r *EMQXPluginPackageReconciler) installPlugin(ctx context.Context, lifecycle *apiResourceLifecycle, pkg *appsv2beta1.EMQXPluginPackage, emqx *appsv2beta1.EMQX, requester innerReq.RequesterInterface, nodes []string) (ctrl.Result, error) {
+	urls := []url.URL{}
+	for _, node := range nodes {
+		u, err := getNodeAPIURL(emqx, node, ApiPluginsInstallV5)
+		if err != nil {
+			return lifecycle.setFailed(ctx, pkg, err.Error())
+		}
+		urls = append(urls, u)
+	}
+
+	if pkg.Status.Phase != appsv2beta1.PluginPackagePhaseInstalling {
+		pkg.Status.SetInstalling(pkg.Spec.NameVsn)
+		if err := r.Client.Status().Update(ctx, pkg); err != nil {
+			return ctrl.Result{}, emperror.Wrap(err, "failed to update status")
+		}
+	}
+
+	if pkg.Spec.Source.Image != nil || pkg.Spec.Source.URL != "" {
+		job := generatePluginInstallJob(pkg, emqx, urls)
+		done, err := runJob(ctx, r.Client, r.Scheme, pkg, job)
+		if err != nil && !done {
+			return ctrl.Result{}, err
+		}
+		if !done {
+			return ctrl.Result{RequeueAfter: 5 * time.Second}, nil
+		}
+		// The Job is created again if any node still misses the plugin
+		if err := r.Client.Delete(ctx, job, client.PropagationPolicy(metav1.DeletePropagationBackground)); client.IgnoreNotFound(err) != nil {
+			return ctrl.Result{}, emperror.Wrap(err, "failed to delete job")
+		}
+		if err != nil {
+			return lifecycle.setFailed(ctx, pkg, fmt.Sprintf("Failed to install the plugin: %s", err.Error()))
+		}
+		return ctrl.Result{RequeueAfter: 5 * time.Second}, nil
+	}
+
+	archive, err := r.loadPluginPackage(ctx, pkg)
+	if err != nil {
+		return lifecycle.setFailed(ctx, pkg, err.Error())
+	}
+	for _, u := range urls {
+		if err := installPluginByAPI(requester, u, pkg.Spec.NameVsn, archive); err != nil {
+			return lifecycle.setFailed(ctx, pkg, fmt.Sprintf("Failed to install the plugin: %s", err.Error()))
+		}
+	}
+	r.EventRecorder.Event(pkg, corev1.EventTypeNormal, "PluginInstalled", fmt.Sprintf("plugin %s is installed on %s", pkg.Spec.NameVsn, strings.Join(nodes, ", ")))
+	return ctrl.Result{RequeueAfter: 5 * time.Second}, nil
+}
+
+// loadPluginPackage returns the package in the ConfigMap
+func (r *EMQXPluginPackageReconciler) loadPluginPackage(ctx context.Context, pkg *appsv2beta1.EMQXPluginPackage) ([]byte, error) {
+	source := pkg.Spec.Source.ConfigMap
+	configMap := &corev1.ConfigMap{}
+	if err := r.Client.Get(ctx, types.NamespacedName{Namespace: pkg.Namespace, Name: source.Name}, configMap); err != nil {
+		return nil, emperror.Wrap(err, "failed to get configMap")
+	}
+	if archive, ok := configMap.BinaryData[source.Key]; ok {
+		return archive, nil
+	}
+	if archive, ok := configMap.Data[source.Key]; ok {
+		return []byte(archive), nil
+	}
+	return nil, emperror.NewWithDetails("configMap does not contain the key", "configMap", source.Name, "key", source.Key)
+}
+
+func validatePluginSource(source appsv2beta1.PluginSource) error {
+	count := 0
+	if source.URL != "" {
+		count++
+	}
+	if source.ConfigMap != nil {
+		count++
+	}
+	if source.Image != nil {
+		count++
+	}
+	if count != 1 {
+		return emperror.New("only one of url, configMap and image must be set in source")
+	}
+	return nil
+}
+
+// getMissingPluginNodes returns the running nodes which have not installed the plugin
+func getMissingPluginNodes(emqx *appsv2beta1.EMQX, installed []appsv2beta1.PluginNodeStatus) []string {
+	missing := []string{}
+	for _, node := range append(slices.Clone(emqx.Status.CoreNodes), emqx.Status.ReplicantNodes...) {
+		if node.NodeStatus != "running" {
+			continue
+		}
+		if !slices.ContainsFunc(installed, func(s appsv2beta1.PluginNodeStatus) bool { return s.Node == node.Node }) {
+			missing = append(missing, node.Node)
+		}
+	}
+	return missing
+}
+
+// generatePluginInstallJob generates the job which copies the package from the image and uploads it to each node
+func generatePluginInstallJob(pkg *appsv2beta1.EMQXPluginPackage, emqx *appsv2beta1.EMQX, urls []url.URL) *batchv1.Job {
+	labels := appsv2beta1.CloneAndMergeMap(map[string]string{
+		appsv2beta1.LabelsManagedByKey: "emqx-operator",
+	}, pkg.Labels)
+	// The nodes are connected by their addresses, but their certificates are verified by the server name,
+	// the same as the EMQX management API client of EMQX Operator
+	addresses := []string{}
+	for _, u := range urls {
+		addresses = append(addresses, u.Host)
+	}
+	installURL := url.URL{
+		Scheme: urls[0].Scheme,
+		Host:   net.JoinHostPort(getAPIClientServerName(emqx), urls[0].Port()),
+		Path:   urls[0].Path,
+	}
+
+	job := &batchv1.Job{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "batch/v1",
+			Kind:       "Job",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: pkg.Namespace,
+			Name:      pkg.Name + "-install",
+			Labels:    labels,
+		},
+	}
+	packagePath := "/plugin/" + pkg.Spec.NameVsn + ".tar.gz"
+	uploaderImage := defaultPluginUploaderImage
+	var loader corev1.Container
+	if source := pkg.Spec.Source.Image; source != nil {
+		if source.UploaderImage != "" {
+			uploaderImage = source.UploaderImage
+		}
+		loader = corev1.Container{Image: source.Image, Command: []string{"cp", source.Path, packagePath}}
+	} else {
+		// The package is downloaded by the Job, so it is not loaded into the memory of EMQX Operator
+		loader = corev1.Container{Image: uploaderImage, Command: []string{"curl", "-sSfL", "-o", packagePath, pkg.Spec.Source.URL}}
+	}
+	loader.Name = "package"
+	loader.VolumeMounts = []corev1.VolumeMount{{Name: "plugin", MountPath: "/plugin"}}
+
+	job.Spec = batchv1.JobSpec{
+		BackoffLimit: ptr.To(int32(3)),
+		Template: corev1.PodTemplateSpec{
+			ObjectMeta: metav1.ObjectMeta{
+				Labels: labels,
+			},
+			Spec: corev1.PodSpec{
+				RestartPolicy:  corev1.RestartPolicyNever,
+				InitContainers: []corev1.Container{loader},
+				Containers: []corev1.Container{
+					{
+						Name:  "upload",
+						Image: uploaderImage,
+						Command: []string{
+							"sh", "-c",
+							`for address in ${ADDRESSES}; do ` +
+								`code=$(curl -sS ${TLS_OPTIONS} --connect-to "::${address}" -u "$(grep "^${API_KEY}:" /etc/emqx/bootstrap_api_key)" -o /tmp/response -w "%{http_code}" -F "plugin=@` + packagePath + `" "${URL}") || exit 1; ` +
+								`case "${code}" in 200|204) ;; *) grep -q ALREADY_INSTALLED /tmp/response || { cat /tmp/response; exit 1; } ;; esac; ` +
+								`done`,
+						},
+						Env: []corev1.EnvVar{
+							{Name: "API_KEY", Value: appsv2beta1.DefaultBootstrapAPIKey},
+							{Name: "URL", Value: installURL.String()},
+							{Name: "ADDRESSES", Value: strings.Join(addresses, " ")},
+						},
+						VolumeMounts: []corev1.VolumeMount{{Name: "plugin", MountPath: "/plugin", ReadOnly: true}},
+					},
+				},
+				Volumes: []corev1.Volume{
+					{
+						Name:         "plugin",
+						VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}},
+					},
+				},
+			},
+		},
+	}
+	mountBootstrapAPIKey(job, emqx)
+	if installURL.Scheme == "https" {
+		container := &job.Spec.Template.Spec.Containers[0]
+		container.Env = append(container.Env, corev1.EnvVar{Name: "TLS_OPTIONS", Value: strings.Join(mountAPIClientTLS(job, emqx), " ")})
+	}
+	return job
+}
+
+// mountAPIClientTLS mounts the CA bundle and the client certificate of ".spec.tls.apiClient" to the first container of the Job,
+// it returns the options of curl to verify the certificate of the EMQX nodes
+func mountAPIClientTLS(job *batchv1.Job, emqx *appsv2beta1.EMQX) []string {
+	if isAPIClientInsecure(emqx) {
+		return []string{"--insecure"}
+	}
+
+	mount := func(name, secretName, mountPath string) {
+		container := &job.Spec.Template.Spec.Containers[0]
+		container.VolumeMounts = append(container.VolumeMounts, corev1.VolumeMount{
+			Name:      name,
+			MountPath: mountPath,
+			ReadOnly:  true,
+		})
+		job.Spec.Template.Spec.Volumes = append(job.Spec.Template.Spec.Volumes, corev1.Volume{
+			Name: name,
+			VolumeSource: corev1.VolumeSource{
+				Secret: &corev1.SecretVolumeSource{SecretName: secretName},
+			},
+		})
+	}
+
+	var apiClient *appsv2beta1.APIClientTLS
+	if emqx.Spec.TLS != nil {
+		apiClient = emqx.Spec.TLS.APIClient
+	}
+	caSecretName := emqx.DashboardTLSNamespacedName().Name
+	if apiClient != nil && apiClient.CASecretName != "" {
+		caSecretName = apiClient.CASecretName
+	}
+	mount("api-client-ca", caSecretName, "/etc/emqx/api-client/ca")
+	options := []string{"--cacert", "/etc/emqx/api-client/ca/ca.crt"}
+	if apiClient != nil && apiClient.CertSecretName != "" {
+		mount("api-client-cert", apiClient.CertSecretName, "/etc/emqx/api-client/cert")
+		options = append(options,
+			"--cert", "/etc/emqx/api-client/cert/"+corev1.TLSCertKey,
+			"--key", "/etc/emqx/api-client/cert/"+corev1.TLSPrivateKeyKey,
+		)
+	}
+	return options
+}
+
+// getPluginByAPI returns the status of the plugin on each node, it returns nil if the plugin is not installed
+func getPluginByAPI(requester innerReq.RequesterInterface, nameVsn string) ([]appsv2beta1.PluginNodeStatus, error) {
+	url := requester.GetURL(fmt.Sprintf("%s/%s", ApiPluginsV5, nameVsn))
+	resp, body, err := requester.Request("GET", url, nil, nil)
+	if err != nil {
+		return nil, emperror.Wrapf(err, "failed to get API %s", url.String())
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, emperror.Errorf("failed to get API %s, status : %s, body: %s", url.String(), resp.Status, body)
+	}
+
+	nodes := []appsv2beta1.PluginNodeStatus{}
+	for _, s := range gjson.GetBytes(body, "running_status").Array() {
+		nodes = append(nodes, appsv2beta1.PluginNodeStatus{
+			Node:   s.Get("node").String(),
+			Status: s.Get("status").String(),
+		})
+	}
+	return nodes, nil
+}
+
+// installPluginByAPI uploads the package to the node, the plugin which has been installed on the node is skipped
+func installPluginByAPI(requester innerReq.RequesterInterface, url url.URL, nameVsn string, archive []byte) error {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	part, err := writer.CreateFormFile("plugin", nameVsn+".tar.gz")
+	if err != nil {
+		return emperror.Wrap(err, "failed to create form file")
+	}
+	if _, err := part.Write(archive); err != nil {
+		return emperror.Wrap(err, "failed to write form file")
+	}
+	if err := writer.Close(); err != nil {
+		return emperror.Wrap(err, "failed to close multipart writer")
+	}
+
+	header := http.Header{}
+	header.Set("Content-Type", writer.FormDataContentType())
+	resp, respBody, err := requester.Request("POST", url, body.Bytes(), header)
+	if err != nil {
+		return emperror.Wrapf(err, "failed to post API %s", url.String())
+	}
+	if resp.StatusCode == http.StatusBadRequest && gjson.GetBytes(respBody, "code").String() == "ALREADY_INSTALLED" {
+		return nil
+	}
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
+		return emperror.Errorf("failed to post API %s, status : %s, body: %s", url.String(), resp.Status, respBody)
+	}
+	return nil
+}
+
+func deletePluginByAPI(requester innerReq.RequesterInterface, nameVsn string) error {
+	url := requester.GetURL(fmt.Sprintf("%s/%s", ApiPluginsV5, nameVsn))
+	resp, body, err := requester.Request("DELETE", url, nil, nil)
+	if err != nil {
+		return emperror.Wrapf(err, "failed to delete API %s", url.String())
+	}
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNotFound {
+		return emperror.Errorf("failed to delete API %s, status : %s, body: %s", url.String(), resp.Status, body)
+	}
+	return nil
+}
+
+func requestPluginAPI(requester innerReq.RequesterInterface, method, path string, body []byte) error {
+	url := requester.GetURL(path)
+	resp, respBody, err := requester.Request(method, url, body, nil)
+	if err != nil {
+		return emperror.Wrapf(err, "failed to request API %s", url.String())
+	}
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
+		return emperror.Errorf("failed to request API %s, status : %s, body: %s", url.String(), resp.Status, respBody)
+	}
+	return nil
+}
@@ -0,0 +1,192 @@
+package v2beta1
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+
+	appsv2beta1 "github.com/emqx/emqx-operator/apis/apps/v2beta1"
+	innerReq "github.com/emqx/emqx-operator/internal/requester"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestValidatePluginSource(t *testing.T) {
+	assert.NoError(t, validatePluginSource(appsv2beta1.PluginSource{URL: "https://example.com/plugin.tar.gz"}))
+	assert.ErrorContains(t, validatePluginSource(appsv2beta1.PluginSource{}), "only one of url, configMap and image must be set in source")
+	assert.ErrorContains(t, validatePluginSource(appsv2beta1.PluginSource{
+		URL:       "https://example.com/plugin.tar.gz",
+		ConfigMap: &appsv2beta1.PluginConfigMapSource{Name: "plugin", Key: "plugin.tar.gz"},
+	}), "only one of url, configMap and image must be set in source")
+}
+
+func TestGetMissingPluginNodes(t *testing.T) {
+	emqx := &appsv2beta1.EMQX{
+		Status: appsv2beta1.EMQXStatus{
+			CoreNodes: []appsv2beta1.EMQXNode{
+				{Node: "emqx@core-0", NodeStatus: "running"},
+				{Node: "emqx@core-1", NodeStatus: "running"},
+			},
+			ReplicantNodes: []appsv2beta1.EMQXNode{
+				{Node: "emqx@replicant-0", NodeStatus: "running"},
+				{Node: "emqx@replicant-1", NodeStatus: "stopped"},
+			},
+		},
+	}
+	assert.Equal(t, []string{"emqx@core-1", "emqx@replicant-0"}, getMissingPluginNodes(emqx, []appsv2beta1.PluginNodeStatus{
+		{Node: "emqx@core-0", Status: "running"},
+	}))
+	assert.Empty(t, getMissingPluginNodes(emqx, []appsv2beta1.PluginNodeStatus{
+		{Node: "emqx@core-0", Status: "running"},
+		{Node: "emqx@core-1", Status: "stopped"},
+		{Node: "emqx@replicant-0", Status: "running"},
+	}))
+}
+
+func TestGeneratePluginInstallJob(t *testing.T) {
+	emqx := &appsv2beta1.EMQX{
+		ObjectMeta: metav1.ObjectMeta{Name: "emqx", Namespace: "default"},
+		Spec:       appsv2beta1.EMQXSpec{ClusterDomain: "cluster.local"},
+	}
+	pkg := &appsv2beta1.EMQXPluginPackage{
+		ObjectMeta: metav1.ObjectMeta{Name: "plugin", Namespace: "default"},
+		Spec: appsv2beta1.EMQXPluginPackageSpec{
+			NameVsn: "emqx_plugin_template-5.0.0",
+			Source: appsv2beta1.PluginSource{
+				Image: &appsv2beta1.PluginImageSource{
+					Image:         "example.com/emqx-plugin-template:5.0.0",
+					Path:          "/emqx_plugin_template-5.0.0.tar.gz",
+					UploaderImage: "curlimages/curl:8.5.0",
+				},
+			},
+		},
+	}
+
+	t.Run("image", func(t *testing.T) {
+		got := generatePluginInstallJob(pkg, emqx, []url.URL{
+			{Scheme: "http", Host: "emqx-core-0:18083", Path: "/api/v5/plugins/install"},
+			{Scheme: "http", Host: "emqx-core-1:18083", Path: "/api/v5/plugins/install"},
+		})
+		assert.Equal(t, "plugin-install", got.Name)
+		assert.Equal(t, "default", got.Namespace)
+		initContainer := got.Spec.Template.Spec.InitContainers[0]
+		assert.Equal(t, "example.com/emqx-plugin-template:5.0.0", initContainer.Image)
+		assert.Equal(t, []string{"cp", "/emqx_plugin_template-5.0.0.tar.gz", "/plugin/emqx_plugin_template-5.0.0.tar.gz"}, initContainer.Command)
+		container := got.Spec.Template.Spec.Containers[0]
+		assert.Equal(t, "curlimages/curl:8.5.0", container.Image)
+		assert.Contains(t, container.Command[2], `-F "plugin=@/plugin/emqx_plugin_template-5.0.0.tar.gz"`)
+		assert.NotContains(t, container.Command[2], "-sSk")
+		assert.ElementsMatch(t, []corev1.EnvVar{
+			{Name: "API_KEY", Value: appsv2beta1.DefaultBootstrapAPIKey},
+			{Name: "URL", Value: "http://emqx-dashboard.default.svc.cluster.local:18083/api/v5/plugins/install"},
+			{Name: "ADDRESSES", Value: "emqx-core-0:18083 emqx-core-1:18083"},
+		}, container.Env)
+		assert.Len(t, got.Spec.Template.Spec.Volumes, 2)
+	})
+
+	t.Run("url", func(t *testing.T) {
+		pkg := pkg.DeepCopy()
+		pkg.Spec.Source = appsv2beta1.PluginSource{URL: "https://example.com/emqx_plugin_template-5.0.0.tar.gz"}
+		got := generatePluginInstallJob(pkg, emqx, []url.URL{
+			{Scheme: "http", Host: "emqx-core-0:18083", Path: "/api/v5/plugins/install"},
+		})
+		initContainer := got.Spec.Template.Spec.InitContainers[0]
+		assert.Equal(t, defaultPluginUploaderImage, initContainer.Image)
+		assert.Equal(t, []string{"curl", "-sSfL", "-o", "/plugin/emqx_plugin_template-5.0.0.tar.gz", "https://example.com/emqx_plugin_template-5.0.0.tar.gz"}, initContainer.Command)
+		assert.Equal(t, defaultPluginUploaderImage, got.Spec.Template.Spec.Containers[0].Image)
+	})
+
+	t.Run("https", func(t *testing.T) {
+		emqx := emqx.DeepCopy()
+		emqx.Spec.TLS = &appsv2beta1.TLS{
+			APIClient: &appsv2beta1.APIClientTLS{CASecretName: "emqx-ca", CertSecretName: "emqx-client"},
+		}
+		got := generatePluginInstallJob(pkg, emqx, []url.URL{
+			{Scheme: "https", Host: "emqx-core-0:18084", Path: "/api/v5/plugins/install"},
+		})
+		container := got.Spec.Template.Spec.Containers[0]
+		assert.Contains(t, container.Env, corev1.EnvVar{Name: "URL", Value: "https://emqx-dashboard.default.svc.cluster.local:18084/api/v5/plugins/install"})
+		assert.Contains(t, container.Env, corev1.EnvVar{
+			Name:  "TLS_OPTIONS",
+			Value: "--cacert /etc/emqx/api-client/ca/ca.crt --cert /etc/emqx/api-client/cert/tls.crt --key /etc/emqx/api-client/cert/tls.key",
+		})
+		secrets := []string{}
+		for _, volume := range got.Spec.Template.Spec.Volumes {
+			if volume.Secret != nil {
+				secrets = append(secrets, volume.Secret.SecretName)
+			}
+		}
+		assert.ElementsMatch(t, []string{"emqx-bootstrap-api-key", "emqx-ca", "emqx-client"}, secrets)
+
+		// The certificate is only skipped by the explicit opt-in
+		emqx.Spec.TLS.APIClient = &appsv2beta1.APIClientTLS{InsecureSkipVerify: true}
+		got = generatePluginInstallJob(pkg, emqx, []url.URL{
+			{Scheme: "https", Host: "emqx-core-0:18084", Path: "/api/v5/plugins/install"},
+		})
+		assert.Contains(t, got.Spec.Template.Spec.Containers[0].Env, corev1.EnvVar{Name: "TLS_OPTIONS", Value: "--insecure"})
+	})
+}
+
+func TestGetPluginByAPI(t *testing.T) {
+	t.Run("installed", func(t *testing.T) {
+		requester := &innerReq.FakeRequester{
+			ReqFunc: func(method string, url url.URL, body []byte, header http.Header) (*http.Response, []byte, error) {
+				assert.Equal(t, "api/v5/plugins/emqx_plugin_template-5.0.0", url.Path)
+				return &http.Response{StatusCode: 200}, []byte(`{"name":"emqx_plugin_template","rel_vsn":"5.0.0","running_status":[{"node":"emqx@core-0","status":"running"},{"node":"emqx@core-1","status":"stopped"}]}`), nil
+			},
+		}
+		got, err := getPluginByAPI(requester, "emqx_plugin_template-5.0.0")
+		assert.NoError(t, err)
+		assert.Equal(t, []appsv2beta1.PluginNodeStatus{
+			{Node: "emqx@core-0", Status: "running"},
+			{Node: "emqx@core-1", Status: "stopped"},
+		}, got)
+	})
+
+	t.Run("not installed", func(t *testing.T) {
+		requester := &innerReq.FakeRequester{
+			ReqFunc: func(method string, url url.URL, body []byte, header http.Header) (*http.Response, []byte, error) {
+				return &http.Response{StatusCode: 404, Status: "404 Not Found"}, nil, nil
+			},
+		}
+		got, err := getPluginByAPI(requester, "emqx_plugin_template-5.0.0")
+		assert.NoError(t, err)
+		assert.Nil(t, got)
+	})
+}
+
+func TestInstallPluginByAPI(t *testing.T) {
+	installURL := url.URL{Scheme: "http", Host: "emqx-core-0:18083", Path: "/api/v5/plugins/install"}
+
+	t.Run("installed", func(t *testing.T) {
+		requester := &innerReq.FakeRequester{
+			ReqFunc: func(method string, url url.URL, body []byte, header http.Header) (*http.Response, []byte, error) {
+				assert.Equal(t, "POST", method)
+				assert.Equal(t, installURL, url)
+				assert.Contains(t, header.Get("Content-Type"), "multipart/form-data")
+				assert.Contains(t, string(body), `name="plugin"; filename="emqx_plugin_template-5.0.0.tar.gz"`)
+				return &http.Response{StatusCode: 204}, nil, nil
+			},
+		}
+		assert.NoError(t, installPluginByAPI(requester, installURL, "emqx_plugin_template-5.0.0", []byte("package")))
+	})
+
+	t.Run("already installed", func(t *testing.T) {
+		requester := &innerReq.FakeRequester{
+			ReqFunc: func(method string, url url.URL, body []byte, header http.Header) (*http.Response, []byte, error) {
+				return &http.Response{StatusCode: 400, Status: "400 Bad Request"}, []byte(`{"code":"ALREADY_INSTALLED"}`), nil
+			},
+		}
+		assert.NoError(t, installPluginByAPI(requester, installURL, "emqx_plugin_template-5.0.0", []byte("package")))
+	})
+
+	t.Run("bad package", func(t *testing.T) {
+		requester := &innerReq.FakeRequester{
+			ReqFunc: func(method string, url url.URL, body []byte, header http.Header) (*http.Response, []byte, error) {
+				return &http.Response{StatusCode: 400, Status: "400 Bad Request"}, []byte(`{"code":"BAD_REQUEST"}`), nil
+			},
+		}
+		assert.ErrorContains(t, installPluginByAPI(requester, installURL, "emqx_plugin_template-5.0.0", []byte("package")), "400 Bad Request")
+	})
+}
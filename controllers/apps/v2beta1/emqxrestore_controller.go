@@ -0,0 +1,392 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2beta1
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	emperror "emperror.dev/errors"
+	"github.com/minio/minio-go/v7"
+	"github.com/tidwall/gjson"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+
+	appsv2beta1 "github.com/emqx/emqx-operator/apis/apps/v2beta1"
+	innerReq "github.com/emqx/emqx-operator/internal/requester"
+)
+
+const ApiDataImportV5 = "api/v5/data/import"
+
+// EMQXRestoreReconciler reconciles a EMQXRestore object
+type EMQXRestoreReconciler struct {
+	Client        client.Client
+	Scheme        *runtime.Scheme
+	EventRecorder record.EventRecorder
+}
+
+func NewEMQXRestoreReconciler(mgr manager.Manager) *EMQXRestoreReconciler {
+	return &EMQXRestoreReconciler{
+		Client:        mgr.GetClient(),
+		Scheme:        mgr.GetScheme(),
+		EventRecorder: mgr.GetEventRecorderFor("emqxrestore-controller"),
+	}
+}
+
+//+kubebuilder:rbac:groups=apps.emqx.io,resources=emqxrestores,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=apps.emqx.io,resources=emqxrestores/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=apps.emqx.io,resources=emqxrestores/finalizers,verbs=update
+
+func (r *EMQXRestoreReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+	logger.V(1).Info("Reconcile EMQX restore")
+
+	restore := &appsv2beta1.EMQXRestore{}
+	if err := r.Client.Get(ctx, req.NamespacedName, restore); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	if !restore.DeletionTimestamp.IsZero() || restore.Status.IsFinished() {
+		return ctrl.Result{}, nil
+	}
+
+	source, err := r.resolveRestoreSource(ctx, restore)
+	if err != nil {
+		return r.setFailed(ctx, restore, err.Error(), nil)
+	}
+
+	emqx := &appsv2beta1.EMQX{}
+	if err := r.Client.Get(ctx, types.NamespacedName{
+		Name:      restore.Spec.InstanceName,
+		Namespace: restore.Namespace,
+	}, emqx); err != nil {
+		if k8sErrors.IsNotFound(err) {
+			return r.setFailed(ctx, restore, fmt.Sprintf("EMQX %s is not found", restore.Spec.InstanceName), nil)
+		}
+		return ctrl.Result{}, emperror.Wrap(err, "failed to get EMQX")
+	}
+
+	if !emqx.Status.IsConditionTrue(appsv2beta1.Ready) {
+		logger.V(1).Info("EMQX is not ready, wait for it", "emqx", emqx.Name)
+		return ctrl.Result{RequeueAfter: 5 * time.Second}, nil
+	}
+
+	requester, err := newRequester(ctx, r.Client, emqx)
+	if err != nil {
+		return ctrl.Result{}, emperror.Wrap(err, "failed to create EMQX API requester")
+	}
+	if requester == nil {
+		return ctrl.Result{RequeueAfter: 5 * time.Second}, nil
+	}
+
+	if source.PersistentVolumeClaim != nil {
+		return r.restoreFromPVC(ctx, restore, emqx, requester, source.PersistentVolumeClaim)
+	}
+
+	archive, filename, err := r.loadArchive(ctx, restore.Namespace, source)
+	if err != nil {
+		return r.retryOrFail(ctx, restore, fmt.Sprintf("Failed to load the archive: %s", err.Error()))
+	}
+	defer archive.Close()
+	restore.Status.SetProcessing()
+	restore.Status.Filename = filename
+	// The archive is uploaded to the node which handles the request,
+	// so it must be uploaded and imported by the same requester
+	if err := uploadDataFile(requester, filename, archive); err != nil {
+		return r.retryOrFail(ctx, restore, fmt.Sprintf("Failed to upload the archive: %s", err.Error()))
+	}
+	return r.importData(ctx, restore, requester)
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *EMQXRestoreReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&appsv2beta1.EMQXRestore{}).
+		Owns(&batchv1.Job{}).
+		Complete(r)
+}
+
+func (r *EMQXRestoreReconciler) setFailed(ctx context.Context, restore *appsv2beta1.EMQXRestore, message string, failures []appsv2beta1.RestoreFailure) (ctrl.Result, error) {
+	restore.Status.SetFailed(message, failures)
+	r.EventRecorder.Event(restore, corev1.EventTypeWarning, "RestoreFailed", message)
+	return ctrl.Result{}, r.Client.Status().Update(ctx, restore)
+}
+
+// retryOrFail requeues the restore with backoff after a transient failure, the restore is marked as failed after maxTransientRetries
+func (r *EMQXRestoreReconciler) retryOrFail(ctx context.Context, restore *appsv2beta1.EMQXRestore, message string) (ctrl.Result, error) {
+	if restore.Status.Retries >= maxTransientRetries {
+		return r.setFailed(ctx, restore, message, nil)
+	}
+	backoff := getRetryBackoff(restore.Status.Retries)
+	restore.Status.Retries++
+	restore.Status.Message = message
+	r.EventRecorder.Event(restore, corev1.EventTypeWarning, "RestoreRetrying", fmt.Sprintf("%s, retry %d/%d after %s", message, restore.Status.Retries, maxTransientRetries, backoff))
+	return ctrl.Result{RequeueAfter: backoff}, r.Client.Status().Update(ctx, restore)
+}
+
+func (r *EMQXRestoreReconciler) importData(ctx context.Context, restore *appsv2beta1.EMQXRestore, requester innerReq.RequesterInterface) (ctrl.Result, error) {
+	failures, err := importDataFile(requester, restore.Status.Filename, restore.Status.Node)
+	// The import is not started, keep the archive on the EMQX node and retry it
+	if err != nil && len(failures) == 0 && restore.Status.Retries < maxTransientRetries {
+		return r.retryOrFail(ctx, restore, fmt.Sprintf("Failed to import the archive: %s", err.Error()))
+	}
+	// The archive has been imported, so it is not needed on the EMQX node any more
+	if err := deleteExportedFile(requester, restore.Status.Filename, restore.Status.Node); err != nil {
+		log.FromContext(ctx).Info("failed to delete the uploaded file on EMQX node", "error", err.Error())
+	}
+	if err != nil {
+		return r.setFailed(ctx, restore, fmt.Sprintf("Failed to import the archive: %s", err.Error()), failures)
+	}
+	restore.Status.SetCompleted()
+	r.EventRecorder.Event(restore, corev1.EventTypeNormal, "RestoreCompleted", fmt.Sprintf("archive %s is imported", restore.Status.Filename))
+	return ctrl.Result{}, r.Client.Status().Update(ctx, restore)
+}
+
+func (r *EMQXRestoreReconciler) restoreFromPVC(ctx context.Context, restore *appsv2beta1.EMQXRestore, emqx *appsv2beta1.EMQX, requester innerReq.RequesterInterface, pvc *appsv2beta1.PVCRestoreSource) (ctrl.Result, error) {
+	if restore.Status.Phase == "" {
+		// The Job uploads the archive to the specified node, and the archive will be imported on it
+		node := ""
+		for _, n := range emqx.Status.CoreNodes {
+			if n.NodeStatus == "running" {
+				node = n.Node
+				break
+			}
+		}
+		if node == "" {
+			return ctrl.Result{RequeueAfter: 5 * time.Second}, nil
+		}
+		restore.Status.SetProcessing()
+		restore.Status.Node = node
+		restore.Status.Filename = path.Base(pvc.Filename)
+		return ctrl.Result{Requeue: true}, r.Client.Status().Update(ctx, restore)
+	}
+
+	uploadURL, err := getNodeAPIURL(emqx, restore.Status.Node, ApiDataFilesV5)
+	if err != nil {
+		return r.setFailed(ctx, restore, err.Error(), nil)
+	}
+	done, err := runJob(ctx, r.Client, r.Scheme, restore, generateRestoreUploadJob(restore, emqx, pvc, uploadURL))
+	if err != nil {
+		if done {
+			return r.setFailed(ctx, restore, err.Error(), nil)
+		}
+		return ctrl.Result{}, err
+	}
+	if !done {
+		return ctrl.Result{RequeueAfter: 5 * time.Second}, nil
+	}
+	return r.importData(ctx, restore, requester)
+}
+
+// resolveRestoreSource validates the source, and returns the source of the EMQXBackup if the backupName is set
+func (r *EMQXRestoreReconciler) resolveRestoreSource(ctx context.Context, restore *appsv2beta1.EMQXRestore) (*appsv2beta1.RestoreSource, error) {
+	source := restore.Spec.Source
+	count := 0
+	if source.BackupName != "" {
+		count++
+	}
+	if source.PersistentVolumeClaim != nil {
+		count++
+	}
+	if source.Secret != nil {
+		count++
+	}
+	if source.S3 != nil {
+		count++
+	}
+	if count != 1 {
+		return nil, emperror.New("only one of backupName, persistentVolumeClaim, secret and s3 must be set in source")
+	}
+	if source.BackupName == "" {
+		return &source, nil
+	}
+
+	backup := &appsv2beta1.EMQXBackup{}
+	if err := r.Client.Get(ctx, types.NamespacedName{Namespace: restore.Namespace, Name: source.BackupName}, backup); err != nil {
+		return nil, emperror.Wrapf(err, "failed to get EMQX backup %s", source.BackupName)
+	}
+	return getBackupRestoreSource(backup)
+}
+
+// loadArchive returns the reader of the archive, the archive in S3 is streamed to EMQX rather than loaded into the memory
+func (r *EMQXRestoreReconciler) loadArchive(ctx context.Context, namespace string, source *appsv2beta1.RestoreSource) (io.ReadCloser, string, error) {
+	if source.Secret != nil {
+		archive, err := readSecretValue(ctx, r.Client, namespace, appsv2beta1.KeyRef{
+			SecretName: source.Secret.SecretName,
+			SecretKey:  source.Secret.Key,
+		})
+		if err != nil {
+			return nil, "", err
+		}
+		return io.NopCloser(strings.NewReader(archive)), source.Secret.Key, nil
+	}
+
+	s3 := source.S3
+	s3Client, err := newS3Client(ctx, r.Client, namespace, &s3.S3BackupStorage)
+	if err != nil {
+		return nil, "", err
+	}
+	object, err := s3Client.GetObject(ctx, s3.Bucket, path.Join(s3.Prefix, s3.Key), minio.GetObjectOptions{})
+	if err != nil {
+		return nil, "", emperror.Wrap(err, "failed to get the archive from S3")
+	}
+	return object, path.Base(s3.Key), nil
+}
+
+func getBackupRestoreSource(backup *appsv2beta1.EMQXBackup) (*appsv2beta1.RestoreSource, error) {
+	if backup.Status.Phase != appsv2beta1.BackupPhaseCompleted {
+		return nil, emperror.Errorf("EMQX backup %s is not completed", backup.Name)
+	}
+	if pvc := backup.Spec.Storage.PersistentVolumeClaim; pvc != nil {
+		return &appsv2beta1.RestoreSource{
+			PersistentVolumeClaim: &appsv2beta1.PVCRestoreSource{
+				PVCBackupStorage: *pvc,
+				Filename:         backup.Status.Filename,
+			},
+		}, nil
+	}
+	if s3 := backup.Spec.Storage.S3; s3 != nil {
+		return &appsv2beta1.RestoreSource{
+			S3: &appsv2beta1.S3RestoreSource{
+				S3BackupStorage: *s3,
+				Key:             backup.Status.Filename,
+			},
+		}, nil
+	}
+	return nil, emperror.Errorf("EMQX backup %s has no storage", backup.Name)
+}
+
+func uploadDataFile(requester innerReq.RequesterInterface, filename string, archive io.Reader) error {
+	body, writer := io.Pipe()
+	form := multipart.NewWriter(writer)
+	go func() {
+		part, err := form.CreateFormFile("filename", filename)
+		if err != nil {
+			_ = writer.CloseWithError(emperror.Wrap(err, "failed to create form file"))
+			return
+		}
+		if _, err := io.Copy(part, archive); err != nil {
+			_ = writer.CloseWithError(emperror.Wrap(err, "failed to read the archive"))
+			return
+		}
+		_ = writer.CloseWithError(form.Close())
+	}()
+	// Unblock the writer if the request is finished before the archive is sent
+	defer body.Close()
+
+	header := http.Header{}
+	header.Set("Content-Type", form.FormDataContentType())
+	resp, respBody, err := requester.RequestStream("POST", requester.GetURL(ApiDataFilesV5), body, header)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != 204 && resp.StatusCode != 200 {
+		return emperror.Errorf("request api failed: %s, body: %s", resp.Status, string(respBody))
+	}
+	return nil
+}
+
+// importDataFile imports the uploaded archive, the node is the EMQX node where the archive is,
+// if it is empty, the archive is on the node which handles the request
+func importDataFile(requester innerReq.RequesterInterface, filename, node string) ([]appsv2beta1.RestoreFailure, error) {
+	reqBody := map[string]string{"filename": filename}
+	if node != "" {
+		reqBody["node"] = node
+	}
+	body, _ := json.Marshal(reqBody)
+
+	resp, respBody, err := requester.Request("POST", requester.GetURL(ApiDataImportV5), body, nil)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == 204 || resp.StatusCode == 200 {
+		// Some EMQX versions return the errors of each database table and config root key with 200
+		failures := []appsv2beta1.RestoreFailure{}
+		for _, key := range []string{"db_errors", "config_errors"} {
+			gjson.GetBytes(respBody, key).ForEach(func(name, reason gjson.Result) bool {
+				failures = append(failures, appsv2beta1.RestoreFailure{Name: name.String(), Reason: reason.String()})
+				return true
+			})
+		}
+		if len(failures) > 0 {
+			return failures, emperror.Errorf("%d database tables or config root keys failed to import", len(failures))
+		}
+		return nil, nil
+	}
+	// The EMQX node is not available, the import can be retried
+	if resp.StatusCode >= 500 {
+		return nil, emperror.Errorf("request api failed: %s", resp.Status)
+	}
+	message := gjson.GetBytes(respBody, "message").String()
+	if message == "" {
+		message = string(respBody)
+	}
+	return parseImportFailures(message), emperror.Errorf("request api failed: %s", resp.Status)
+}
+
+// parseImportFailures parses the message of the failed import, EMQX returns one failure per line,
+// like "emqx_authn_mnesia: {aborted,...}" for the database table, or "authentication: ..." for the config root key
+func parseImportFailures(message string) []appsv2beta1.RestoreFailure {
+	failures := []appsv2beta1.RestoreFailure{}
+	for _, line := range strings.Split(message, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if index := strings.Index(line, ": "); index > 0 && !strings.Contains(line[:index], " ") {
+			failures = append(failures, appsv2beta1.RestoreFailure{
+				Name:   line[:index],
+				Reason: strings.TrimSpace(line[index+2:]),
+			})
+			continue
+		}
+		failures = append(failures, appsv2beta1.RestoreFailure{Reason: line})
+	}
+	return failures
+}
+
+func generateRestoreUploadJob(restore *appsv2beta1.EMQXRestore, emqx *appsv2beta1.EMQX, pvc *appsv2beta1.PVCRestoreSource, uploadURL url.URL) *batchv1.Job {
+	job := generatePVCJob(restore.ObjectMeta, restore.Name+"-upload", &pvc.PVCBackupStorage, []string{
+		"sh", "-c",
+		`curl -sSf ${TLS_OPTIONS} -u "$(grep "^${API_KEY}:" /etc/emqx/bootstrap_api_key)" -F "filename=@` + backupMountPath + `/${FILENAME}" "${URL}"`,
+	})
+	mountBootstrapAPIKey(job, emqx)
+	uploadURL = mountNodeAPIClientTLS(job, emqx, uploadURL)
+	container := &job.Spec.Template.Spec.Containers[0]
+	container.Env = append(container.Env,
+		corev1.EnvVar{Name: "API_KEY", Value: appsv2beta1.DefaultBootstrapAPIKey},
+		corev1.EnvVar{Name: "FILENAME", Value: pvc.Filename},
+		corev1.EnvVar{Name: "URL", Value: uploadURL.String()},
+	)
+	return job
+}
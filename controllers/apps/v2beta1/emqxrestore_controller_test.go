@@ -0,0 +1,223 @@
+package v2beta1
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	appsv2beta1 "github.com/emqx/emqx-operator/apis/apps/v2beta1"
+	innerReq "github.com/emqx/emqx-operator/internal/requester"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestGetBackupRestoreSource(t *testing.T) {
+	backup := &appsv2beta1.EMQXBackup{
+		ObjectMeta: metav1.ObjectMeta{Name: "backup"},
+		Spec: appsv2beta1.EMQXBackupSpec{
+			Storage: appsv2beta1.BackupStorage{
+				PersistentVolumeClaim: &appsv2beta1.PVCBackupStorage{ClaimName: "emqx-backup", Image: "curl"},
+			},
+		},
+		Status: appsv2beta1.EMQXBackupStatus{
+			Phase:    appsv2beta1.BackupPhaseProcessing,
+			Filename: "emqx-export.tar.gz",
+		},
+	}
+
+	_, err := getBackupRestoreSource(backup)
+	assert.ErrorContains(t, err, "not completed")
+
+	backup.Status.Phase = appsv2beta1.BackupPhaseCompleted
+	got, err := getBackupRestoreSource(backup)
+	assert.NoError(t, err)
+	assert.Equal(t, &appsv2beta1.RestoreSource{
+		PersistentVolumeClaim: &appsv2beta1.PVCRestoreSource{
+			PVCBackupStorage: appsv2beta1.PVCBackupStorage{ClaimName: "emqx-backup", Image: "curl"},
+			Filename:         "emqx-export.tar.gz",
+		},
+	}, got)
+
+	backup.Spec.Storage = appsv2beta1.BackupStorage{
+		S3: &appsv2beta1.S3BackupStorage{Bucket: "emqx", Prefix: "backup"},
+	}
+	got, err = getBackupRestoreSource(backup)
+	assert.NoError(t, err)
+	assert.Equal(t, &appsv2beta1.RestoreSource{
+		S3: &appsv2beta1.S3RestoreSource{
+			S3BackupStorage: appsv2beta1.S3BackupStorage{Bucket: "emqx", Prefix: "backup"},
+			Key:             "emqx-export.tar.gz",
+		},
+	}, got)
+}
+
+func TestUploadDataFile(t *testing.T) {
+	requester := &innerReq.FakeRequester{
+		ReqFunc: func(method string, url url.URL, body []byte, header http.Header) (*http.Response, []byte, error) {
+			assert.Equal(t, "POST", method)
+			assert.Equal(t, ApiDataFilesV5, url.Path)
+
+			mediaType, params, err := mime.ParseMediaType(header.Get("Content-Type"))
+			assert.NoError(t, err)
+			assert.Equal(t, "multipart/form-data", mediaType)
+			part, err := multipart.NewReader(bytes.NewReader(body), params["boundary"]).NextPart()
+			assert.NoError(t, err)
+			assert.Equal(t, "filename", part.FormName())
+			assert.Equal(t, "emqx-export.tar.gz", part.FileName())
+			content, _ := io.ReadAll(part)
+			assert.Equal(t, "fake archive", string(content))
+			return &http.Response{StatusCode: 204}, nil, nil
+		},
+	}
+	assert.NoError(t, uploadDataFile(requester, "emqx-export.tar.gz", strings.NewReader("fake archive")))
+}
+
+func TestImportDataFile(t *testing.T) {
+	t.Run("import succeeded", func(t *testing.T) {
+		requester := &innerReq.FakeRequester{
+			ReqFunc: func(method string, url url.URL, body []byte, header http.Header) (*http.Response, []byte, error) {
+				assert.Equal(t, "POST", method)
+				assert.Equal(t, ApiDataImportV5, url.Path)
+				reqBody := map[string]string{}
+				_ = json.Unmarshal(body, &reqBody)
+				assert.Equal(t, map[string]string{"filename": "emqx-export.tar.gz", "node": "emqx@127.0.0.1"}, reqBody)
+				return &http.Response{StatusCode: 204}, nil, nil
+			},
+		}
+		failures, err := importDataFile(requester, "emqx-export.tar.gz", "emqx@127.0.0.1")
+		assert.NoError(t, err)
+		assert.Empty(t, failures)
+	})
+
+	t.Run("import failed", func(t *testing.T) {
+		requester := &innerReq.FakeRequester{
+			ReqFunc: func(method string, url url.URL, body []byte, header http.Header) (*http.Response, []byte, error) {
+				assert.NotContains(t, string(body), "node")
+				return &http.Response{StatusCode: 400, Status: "400 Bad Request"}, []byte(`{
+					"code": "BAD_REQUEST",
+					"message": "emqx_authn_mnesia: {aborted,{no_exists,emqx_authn_mnesia}}\nauthentication: invalid config\n"
+				}`), nil
+			},
+		}
+		failures, err := importDataFile(requester, "emqx-export.tar.gz", "")
+		assert.ErrorContains(t, err, "400 Bad Request")
+		assert.Equal(t, []appsv2beta1.RestoreFailure{
+			{Name: "emqx_authn_mnesia", Reason: "{aborted,{no_exists,emqx_authn_mnesia}}"},
+			{Name: "authentication", Reason: "invalid config"},
+		}, failures)
+	})
+
+	t.Run("import partially failed", func(t *testing.T) {
+		requester := &innerReq.FakeRequester{
+			ReqFunc: func(method string, url url.URL, body []byte, header http.Header) (*http.Response, []byte, error) {
+				return &http.Response{StatusCode: 200, Status: "200 OK"}, []byte(`{
+					"db_errors": {"emqx_authn_mnesia": "{aborted,{no_exists,emqx_authn_mnesia}}"},
+					"config_errors": {}
+				}`), nil
+			},
+		}
+		failures, err := importDataFile(requester, "emqx-export.tar.gz", "")
+		assert.Error(t, err)
+		assert.Equal(t, []appsv2beta1.RestoreFailure{
+			{Name: "emqx_authn_mnesia", Reason: "{aborted,{no_exists,emqx_authn_mnesia}}"},
+		}, failures)
+	})
+
+	t.Run("EMQX is unavailable", func(t *testing.T) {
+		requester := &innerReq.FakeRequester{
+			ReqFunc: func(method string, url url.URL, body []byte, header http.Header) (*http.Response, []byte, error) {
+				return &http.Response{StatusCode: 503, Status: "503 Service Unavailable"}, []byte(`{"message": "not ready"}`), nil
+			},
+		}
+		failures, err := importDataFile(requester, "emqx-export.tar.gz", "")
+		assert.ErrorContains(t, err, "503 Service Unavailable")
+		assert.Empty(t, failures)
+	})
+}
+
+func TestImportData(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = appsv2beta1.AddToScheme(scheme)
+	restore := &appsv2beta1.EMQXRestore{
+		ObjectMeta: metav1.ObjectMeta{Name: "emqx-restore", Namespace: "emqx"},
+		Status: appsv2beta1.EMQXRestoreStatus{
+			Phase:    appsv2beta1.RestorePhaseProcessing,
+			Filename: "emqx-export.tar.gz",
+		},
+	}
+	fakeClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(restore).WithStatusSubresource(restore).Build()
+	r := &EMQXRestoreReconciler{Client: fakeClient, Scheme: scheme, EventRecorder: record.NewFakeRecorder(10)}
+
+	deleted := false
+	statusCode := 503
+	requester := &innerReq.FakeRequester{
+		ReqFunc: func(method string, url url.URL, body []byte, header http.Header) (*http.Response, []byte, error) {
+			if method == "DELETE" {
+				deleted = true
+				return &http.Response{StatusCode: 204}, nil, nil
+			}
+			return &http.Response{StatusCode: statusCode, Status: http.StatusText(statusCode)}, nil, nil
+		},
+	}
+
+	t.Run("keep the archive when EMQX is unavailable", func(t *testing.T) {
+		result, err := r.importData(ctx, restore, requester)
+		assert.NoError(t, err)
+		assert.Equal(t, getRetryBackoff(0), result.RequeueAfter)
+		assert.False(t, deleted)
+		assert.Equal(t, int32(1), restore.Status.Retries)
+		assert.Equal(t, appsv2beta1.RestorePhaseProcessing, restore.Status.Phase)
+	})
+
+	t.Run("delete the archive after the import", func(t *testing.T) {
+		statusCode = 204
+		_, err := r.importData(ctx, restore, requester)
+		assert.NoError(t, err)
+		assert.True(t, deleted)
+		assert.Equal(t, appsv2beta1.RestorePhaseCompleted, restore.Status.Phase)
+	})
+}
+
+func TestParseImportFailures(t *testing.T) {
+	assert.Empty(t, parseImportFailures(""))
+	assert.Equal(t, []appsv2beta1.RestoreFailure{
+		{Reason: "Backup file not found: emqx-export.tar.gz"},
+	}, parseImportFailures("Backup file not found: emqx-export.tar.gz"))
+}
+
+func TestGenerateRestoreUploadJob(t *testing.T) {
+	instance := &appsv2beta1.EMQX{
+		ObjectMeta: metav1.ObjectMeta{Name: "emqx", Namespace: "default"},
+	}
+	restore := &appsv2beta1.EMQXRestore{
+		ObjectMeta: metav1.ObjectMeta{Name: "restore", Namespace: "default"},
+	}
+	pvc := &appsv2beta1.PVCRestoreSource{
+		PVCBackupStorage: appsv2beta1.PVCBackupStorage{ClaimName: "emqx-backup", Image: "curlimages/curl:8.5.0"},
+		Filename:         "emqx-export.tar.gz",
+	}
+	uploadURL := url.URL{Scheme: "http", Host: "emqx-core-0:18083", Path: "/api/v5/data/files"}
+
+	got := generateRestoreUploadJob(restore, instance, pvc, uploadURL)
+	assert.Equal(t, "restore-upload", got.Name)
+	assert.Equal(t, "default", got.Namespace)
+	container := got.Spec.Template.Spec.Containers[0]
+	assert.Equal(t, "curlimages/curl:8.5.0", container.Image)
+	assert.Contains(t, container.Command[2], `-F "filename=@/backup/${FILENAME}"`)
+	assert.ElementsMatch(t, []corev1.EnvVar{
+		{Name: "API_KEY", Value: appsv2beta1.DefaultBootstrapAPIKey},
+		{Name: "FILENAME", Value: "emqx-export.tar.gz"},
+		{Name: "URL", Value: "http://emqx-core-0:18083/api/v5/data/files"},
+	}, container.Env)
+	assert.Len(t, got.Spec.Template.Spec.Volumes, 2)
+}
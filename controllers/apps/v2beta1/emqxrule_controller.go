@@ -0,0 +1,139 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2beta1
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	emperror "emperror.dev/errors"
+	"github.com/tidwall/gjson"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	appsv2beta1 "github.com/emqx/emqx-operator/apis/apps/v2beta1"
+	innerReq "github.com/emqx/emqx-operator/internal/requester"
+)
+
+const ApiRulesV5 = "api/v5/rules"
+
+// EMQXRuleReconciler reconciles a EMQXRule object
+type EMQXRuleReconciler struct {
+	Client        client.Client
+	Scheme        *runtime.Scheme
+	EventRecorder record.EventRecorder
+}
+
+func NewEMQXRuleReconciler(mgr manager.Manager) *EMQXRuleReconciler {
+	return &EMQXRuleReconciler{
+		Client:        mgr.GetClient(),
+		Scheme:        mgr.GetScheme(),
+		EventRecorder: mgr.GetEventRecorderFor("emqxrule-controller"),
+	}
+}
+
+//+kubebuilder:rbac:groups=apps.emqx.io,resources=emqxrules,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=apps.emqx.io,resources=emqxrules/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=apps.emqx.io,resources=emqxrules/finalizers,verbs=update
+
+func (r *EMQXRuleReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+	logger.V(1).Info("Reconcile EMQX rule")
+
+	lifecycle := r.lifecycle()
+	rule := &appsv2beta1.EMQXRule{}
+	return lifecycle.reconcile(ctx, req, rule, apiResourceHandler{
+		delete: func(requester innerReq.RequesterInterface) error {
+			return deleteAPIResource(requester, newRuleAPIResource(rule))
+		},
+		sync: func(_ *appsv2beta1.EMQX, requester innerReq.RequesterInterface) (ctrl.Result, error) {
+			res := newRuleAPIResource(rule)
+			// The metrics are refreshed on every reconcile
+			return lifecycle.syncAPIResource(ctx, rule, requester, res, rule.Status.ConfigHash, func([]byte) {
+				metrics, err := getRuleMetricsByAPI(requester, rule.Name)
+				if err != nil {
+					logger.V(1).Info("failed to get rule metrics", "error", err)
+					metrics = rule.Status.Metrics
+				}
+				rule.Status.SetSynced(metrics, res.hash())
+			})
+		},
+	})
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *EMQXRuleReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&appsv2beta1.EMQXRule{}).
+		Watches(&appsv2beta1.EMQX{}, handler.EnqueueRequestsFromMapFunc(r.requestsForEMQX)).
+		Complete(r)
+}
+
+func (r *EMQXRuleReconciler) lifecycle() *apiResourceLifecycle {
+	return &apiResourceLifecycle{client: r.Client, eventRecorder: r.EventRecorder, kind: "Rule"}
+}
+
+func (r *EMQXRuleReconciler) requestsForEMQX(ctx context.Context, obj client.Object) []reconcile.Request {
+	return r.lifecycle().requests(ctx, &appsv2beta1.EMQXRuleList{}, obj.GetNamespace(), func(rule apiResourceObject) bool {
+		return rule.GetInstanceName() == obj.GetName()
+	})
+}
+
+// newRuleAPIResource returns the rule whose ID is the name of the EMQXRule
+func newRuleAPIResource(rule *appsv2beta1.EMQXRule) apiResource {
+	actions := rule.Spec.Actions
+	if actions == nil {
+		actions = []string{}
+	}
+	return apiResource{
+		path: ApiRulesV5,
+		id:   rule.Name,
+		keys: map[string]interface{}{
+			"id": rule.Name,
+		},
+		body: map[string]interface{}{
+			"sql":         rule.Spec.SQL,
+			"actions":     actions,
+			"enable":      rule.Spec.Enable == nil || *rule.Spec.Enable,
+			"description": rule.Spec.Description,
+		},
+	}
+}
+
+// getRuleMetricsByAPI returns the metrics of the rule, they are aggregated from all nodes by EMQX
+func getRuleMetricsByAPI(r innerReq.RequesterInterface, id string) (*appsv2beta1.RuleMetrics, error) {
+	url := r.GetURL(fmt.Sprintf("%s/%s/metrics", ApiRulesV5, id))
+	resp, body, err := r.Request("GET", url, nil, nil)
+	if err != nil {
+		return nil, emperror.Wrapf(err, "failed to get API %s", url.String())
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, emperror.Errorf("failed to get API %s, status : %s, body: %s", url.String(), resp.Status, body)
+	}
+	return &appsv2beta1.RuleMetrics{
+		Matched: gjson.GetBytes(body, "metrics.matched").Int(),
+		Passed:  gjson.GetBytes(body, "metrics.passed").Int(),
+		Failed:  gjson.GetBytes(body, "metrics.failed").Int(),
+	}, nil
+}
@@ -0,0 +1,23 @@
+package v2beta1
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+
+	appsv2beta1 "github.com/emqx/emqx-operator/apis/apps/v2beta1"
+	innerReq "github.com/emqx/emqx-operator/internal/requester"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetRuleMetricsByAPI(t *testing.T) {
+	requester := &innerReq.FakeRequester{
+		ReqFunc: func(method string, url url.URL, body []byte, header http.Header) (*http.Response, []byte, error) {
+			assert.Equal(t, "api/v5/rules/emqx/metrics", url.Path)
+			return &http.Response{StatusCode: 200}, []byte(`{"id":"emqx","metrics":{"matched":10,"passed":8,"failed":2,"matched.rate":0.5},"node_metrics":[]}`), nil
+		},
+	}
+	got, err := getRuleMetricsByAPI(requester, "emqx")
+	assert.NoError(t, err)
+	assert.Equal(t, &appsv2beta1.RuleMetrics{Matched: 10, Passed: 8, Failed: 2}, got)
+}
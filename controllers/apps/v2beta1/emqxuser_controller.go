@@ -0,0 +1,316 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2beta1
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"strings"
+
+	emperror "emperror.dev/errors"
+	"github.com/tidwall/gjson"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	appsv2beta1 "github.com/emqx/emqx-operator/apis/apps/v2beta1"
+	innerReq "github.com/emqx/emqx-operator/internal/requester"
+)
+
+// The users are managed in the built-in database authenticator
+const (
+	ApiBuiltInDatabaseUsersV5 = "api/v5/authentication/password_based:built_in_database/users"
+	ApiImportUsersV5          = "api/v5/authentication/password_based:built_in_database/import_users"
+)
+
+// EMQXUserReconciler reconciles a EMQXUser object
+type EMQXUserReconciler struct {
+	Client        client.Client
+	Scheme        *runtime.Scheme
+	EventRecorder record.EventRecorder
+}
+
+func NewEMQXUserReconciler(mgr manager.Manager) *EMQXUserReconciler {
+	return &EMQXUserReconciler{
+		Client:        mgr.GetClient(),
+		Scheme:        mgr.GetScheme(),
+		EventRecorder: mgr.GetEventRecorderFor("emqxuser-controller"),
+	}
+}
+
+//+kubebuilder:rbac:groups=apps.emqx.io,resources=emqxusers,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=apps.emqx.io,resources=emqxusers/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=apps.emqx.io,resources=emqxusers/finalizers,verbs=update
+
+func (r *EMQXUserReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+	logger.V(1).Info("Reconcile EMQX user")
+
+	lifecycle := r.lifecycle()
+	user := &appsv2beta1.EMQXUser{}
+	return lifecycle.reconcile(ctx, req, user, apiResourceHandler{
+		delete: func(requester innerReq.RequesterInterface) error {
+			for _, userID := range []string{user.Status.UserID, user.Spec.UserID} {
+				if userID == "" {
+					continue
+				}
+				if err := deleteUserByAPI(requester, userID); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+		sync: func(_ *appsv2beta1.EMQX, requester innerReq.RequesterInterface) (ctrl.Result, error) {
+			return r.syncUser(ctx, lifecycle, user, requester)
+		},
+	})
+}
+
+func (r *EMQXUserReconciler) syncUser(ctx context.Context, lifecycle *apiResourceLifecycle, user *appsv2beta1.EMQXUser, requester innerReq.RequesterInterface) (ctrl.Result, error) {
+	credentialHash, err := r.getCredentialHash(ctx, user)
+	if err != nil {
+		return lifecycle.setFailed(ctx, user, err.Error())
+	}
+	// The synced user is checked on every reconcile,
+	// so the user deleted or changed through the EMQX Dashboard or the HTTP API is applied again
+	if user.Status.Phase == appsv2beta1.UserPhaseSynced && user.Status.UserID == user.Spec.UserID && user.Status.CredentialHash == credentialHash {
+		emqxUser, err := getUserByAPI(requester, user.Spec.UserID)
+		if err != nil {
+			return lifecycle.setFailed(ctx, user, fmt.Sprintf("Failed to get the user: %s", err.Error()))
+		}
+		if emqxUser != nil && gjson.GetBytes(emqxUser, "is_superuser").Bool() == user.Spec.IsSuperuser {
+			return ctrl.Result{RequeueAfter: apiResourceSyncInterval}, nil
+		}
+		r.EventRecorder.Event(user, corev1.EventTypeWarning, "DriftDetected", fmt.Sprintf("user %s is deleted or changed in EMQX, apply it again", user.Spec.UserID))
+	}
+
+	body, err := r.getUserBody(ctx, user)
+	if err != nil {
+		return lifecycle.setFailed(ctx, user, err.Error())
+	}
+	if user.Spec.PasswordHash != nil {
+		err = importUserByAPI(requester, body)
+	} else {
+		err = putUserByAPI(requester, user.Spec.UserID, body)
+	}
+	if err != nil {
+		return lifecycle.setFailed(ctx, user, fmt.Sprintf("Failed to apply the user: %s", err.Error()))
+	}
+	if user.Status.UserID != "" && user.Status.UserID != user.Spec.UserID {
+		if err := deleteUserByAPI(requester, user.Status.UserID); err != nil {
+			return ctrl.Result{}, emperror.Wrap(err, "failed to delete the old user")
+		}
+	}
+
+	user.Status.SetSynced(user.Spec.UserID, credentialHash)
+	r.EventRecorder.Event(user, corev1.EventTypeNormal, "UserSynced", fmt.Sprintf("user %s is synced", user.Spec.UserID))
+	return ctrl.Result{RequeueAfter: apiResourceSyncInterval}, r.Client.Status().Update(ctx, user)
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *EMQXUserReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&appsv2beta1.EMQXUser{}).
+		// The users are updated when the passwords in the secrets are changed,
+		// and are created when the EMQX cluster is created after them
+		Watches(&corev1.Secret{}, handler.EnqueueRequestsFromMapFunc(r.requestsForSecret), builder.OnlyMetadata).
+		Watches(&appsv2beta1.EMQX{}, handler.EnqueueRequestsFromMapFunc(r.requestsForEMQX)).
+		Complete(r)
+}
+
+func (r *EMQXUserReconciler) lifecycle() *apiResourceLifecycle {
+	return &apiResourceLifecycle{client: r.Client, eventRecorder: r.EventRecorder, kind: "User"}
+}
+
+func (r *EMQXUserReconciler) requestsForSecret(ctx context.Context, obj client.Object) []reconcile.Request {
+	return r.lifecycle().requests(ctx, &appsv2beta1.EMQXUserList{}, obj.GetNamespace(), func(o apiResourceObject) bool {
+		for _, ref := range getUserSecretRefs(o.(*appsv2beta1.EMQXUser)) {
+			if ref.SecretName == obj.GetName() {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+func (r *EMQXUserReconciler) requestsForEMQX(ctx context.Context, obj client.Object) []reconcile.Request {
+	return r.lifecycle().requests(ctx, &appsv2beta1.EMQXUserList{}, obj.GetNamespace(), func(o apiResourceObject) bool {
+		return o.GetInstanceName() == obj.GetName() && o.(*appsv2beta1.EMQXUser).Status.Phase != appsv2beta1.UserPhaseSynced
+	})
+}
+
+// getCredentialHash returns the hash of the spec and the versions of the referenced Secrets,
+// the password is not hashed, so it can not be guessed from the status
+func (r *EMQXUserReconciler) getCredentialHash(ctx context.Context, user *appsv2beta1.EMQXUser) (string, error) {
+	versions := []string{}
+	for _, ref := range getUserSecretRefs(user) {
+		secret := &corev1.Secret{}
+		if err := r.Client.Get(ctx, types.NamespacedName{Namespace: user.Namespace, Name: ref.SecretName}, secret); err != nil {
+			return "", emperror.Wrap(err, "failed to get secret")
+		}
+		versions = append(versions, fmt.Sprintf("%s/%s/%s", secret.UID, secret.ResourceVersion, ref.SecretKey))
+	}
+	spec, _ := json.Marshal(user.Spec)
+	return computeConfigHash(string(spec) + strings.Join(versions, ",")), nil
+}
+
+// getUserBody returns the body of the EMQX users API, or the user of the EMQX import users API if the password hash is set
+func (r *EMQXUserReconciler) getUserBody(ctx context.Context, user *appsv2beta1.EMQXUser) (map[string]interface{}, error) {
+	if (user.Spec.Password == nil) == (user.Spec.PasswordHash == nil) {
+		return nil, emperror.New("one and only one of password and passwordHash must be set")
+	}
+
+	body := map[string]interface{}{
+		"user_id":      user.Spec.UserID,
+		"is_superuser": user.Spec.IsSuperuser,
+	}
+	if user.Spec.Password != nil {
+		password, err := readSecretValue(ctx, r.Client, user.Namespace, *user.Spec.Password)
+		if err != nil {
+			return nil, emperror.Wrap(err, "failed to read password")
+		}
+		body["password"] = password
+		return body, nil
+	}
+
+	hash, err := readSecretValue(ctx, r.Client, user.Namespace, user.Spec.PasswordHash.Hash)
+	if err != nil {
+		return nil, emperror.Wrap(err, "failed to read password hash")
+	}
+	body["password_hash"] = hash
+	body["salt"] = ""
+	if user.Spec.PasswordHash.Salt != nil {
+		salt, err := readSecretValue(ctx, r.Client, user.Namespace, *user.Spec.PasswordHash.Salt)
+		if err != nil {
+			return nil, emperror.Wrap(err, "failed to read salt")
+		}
+		body["salt"] = salt
+	}
+	return body, nil
+}
+
+func getUserSecretRefs(user *appsv2beta1.EMQXUser) []appsv2beta1.KeyRef {
+	refs := []appsv2beta1.KeyRef{}
+	if user.Spec.Password != nil {
+		refs = append(refs, *user.Spec.Password)
+	}
+	if user.Spec.PasswordHash != nil {
+		refs = append(refs, user.Spec.PasswordHash.Hash)
+		if user.Spec.PasswordHash.Salt != nil {
+			refs = append(refs, *user.Spec.PasswordHash.Salt)
+		}
+	}
+	return refs
+}
+
+// putUserByAPI updates the password and the superuser flag of the user, it creates the user if it does not exist
+func putUserByAPI(requester innerReq.RequesterInterface, userID string, body map[string]interface{}) error {
+	data, _ := json.Marshal(map[string]interface{}{
+		"password":     body["password"],
+		"is_superuser": body["is_superuser"],
+	})
+	url := getEscapedURL(requester, ApiBuiltInDatabaseUsersV5, userID)
+	resp, respBody, err := requester.Request("PUT", url, data, nil)
+	if err != nil {
+		return emperror.Wrapf(err, "failed to put API %s", url.String())
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		data, _ = json.Marshal(body)
+		url = requester.GetURL(ApiBuiltInDatabaseUsersV5)
+		resp, respBody, err = requester.Request("POST", url, data, nil)
+		if err != nil {
+			return emperror.Wrapf(err, "failed to post API %s", url.String())
+		}
+	}
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		return emperror.Errorf("failed to request API %s, status : %s, body: %s", url.String(), resp.Status, respBody)
+	}
+	return nil
+}
+
+// importUserByAPI imports the user with the password hash, the existing user is overridden
+func importUserByAPI(requester innerReq.RequesterInterface, user map[string]interface{}) error {
+	data, _ := json.Marshal([]map[string]interface{}{user})
+
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	part, err := writer.CreateFormFile("filename", "users.json")
+	if err != nil {
+		return emperror.Wrap(err, "failed to create form file")
+	}
+	if _, err := part.Write(data); err != nil {
+		return emperror.Wrap(err, "failed to write form file")
+	}
+	if err := writer.Close(); err != nil {
+		return emperror.Wrap(err, "failed to close multipart writer")
+	}
+
+	header := http.Header{}
+	header.Set("Content-Type", writer.FormDataContentType())
+	url := requester.GetURL(ApiImportUsersV5, "type=hash")
+	resp, respBody, err := requester.Request("POST", url, body.Bytes(), header)
+	if err != nil {
+		return emperror.Wrapf(err, "failed to post API %s", url.String())
+	}
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
+		return emperror.Errorf("failed to post API %s, status : %s, body: %s", url.String(), resp.Status, respBody)
+	}
+	if failed := gjson.GetBytes(respBody, "failed").Int(); failed > 0 {
+		return emperror.Errorf("failed to import user, body: %s", respBody)
+	}
+	return nil
+}
+
+// getUserByAPI returns the user in EMQX, it returns nil if the user does not exist
+func getUserByAPI(requester innerReq.RequesterInterface, userID string) ([]byte, error) {
+	url := getEscapedURL(requester, ApiBuiltInDatabaseUsersV5, userID)
+	resp, body, err := requester.Request("GET", url, nil, nil)
+	if err != nil {
+		return nil, emperror.Wrapf(err, "failed to get API %s", url.String())
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, emperror.Errorf("failed to get API %s, status : %s, body: %s", url.String(), resp.Status, body)
+	}
+	return body, nil
+}
+
+func deleteUserByAPI(requester innerReq.RequesterInterface, userID string) error {
+	url := getEscapedURL(requester, ApiBuiltInDatabaseUsersV5, userID)
+	resp, body, err := requester.Request("DELETE", url, nil, nil)
+	if err != nil {
+		return emperror.Wrapf(err, "failed to delete API %s", url.String())
+	}
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNotFound {
+		return emperror.Errorf("failed to delete API %s, status : %s, body: %s", url.String(), resp.Status, body)
+	}
+	return nil
+}
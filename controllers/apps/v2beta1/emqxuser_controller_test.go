@@ -0,0 +1,205 @@
+package v2beta1
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+
+	appsv2beta1 "github.com/emqx/emqx-operator/apis/apps/v2beta1"
+	innerReq "github.com/emqx/emqx-operator/internal/requester"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestPutUserByAPI(t *testing.T) {
+	body := map[string]interface{}{
+		"user_id":      "emqx",
+		"password":     "public",
+		"is_superuser": true,
+	}
+
+	t.Run("update user", func(t *testing.T) {
+		requests := []string{}
+		requester := &innerReq.FakeRequester{
+			ReqFunc: func(method string, url url.URL, body []byte, header http.Header) (*http.Response, []byte, error) {
+				requests = append(requests, method+" "+url.Path+" "+string(body))
+				return &http.Response{StatusCode: 200}, nil, nil
+			},
+		}
+		assert.NoError(t, putUserByAPI(requester, "emqx", body))
+		assert.Equal(t, []string{
+			`PUT api/v5/authentication/password_based:built_in_database/users/emqx {"is_superuser":true,"password":"public"}`,
+		}, requests)
+	})
+
+	t.Run("create user", func(t *testing.T) {
+		requests := []string{}
+		requester := &innerReq.FakeRequester{
+			ReqFunc: func(method string, url url.URL, body []byte, header http.Header) (*http.Response, []byte, error) {
+				requests = append(requests, method+" "+url.Path+" "+string(body))
+				if method == "PUT" {
+					return &http.Response{StatusCode: 404, Status: "404 Not Found"}, nil, nil
+				}
+				return &http.Response{StatusCode: 201}, nil, nil
+			},
+		}
+		assert.NoError(t, putUserByAPI(requester, "emqx", body))
+		assert.Equal(t, []string{
+			`PUT api/v5/authentication/password_based:built_in_database/users/emqx {"is_superuser":true,"password":"public"}`,
+			`POST api/v5/authentication/password_based:built_in_database/users {"is_superuser":true,"password":"public","user_id":"emqx"}`,
+		}, requests)
+	})
+
+	t.Run("authenticator not found", func(t *testing.T) {
+		requester := &innerReq.FakeRequester{
+			ReqFunc: func(method string, url url.URL, body []byte, header http.Header) (*http.Response, []byte, error) {
+				return &http.Response{StatusCode: 404, Status: "404 Not Found"}, []byte(`{"code":"NOT_FOUND"}`), nil
+			},
+		}
+		assert.ErrorContains(t, putUserByAPI(requester, "emqx", body), "404 Not Found")
+	})
+}
+
+func TestImportUserByAPI(t *testing.T) {
+	user := map[string]interface{}{
+		"user_id":       "emqx",
+		"password_hash": "hash",
+		"salt":          "salt",
+		"is_superuser":  false,
+	}
+
+	t.Run("imported", func(t *testing.T) {
+		requester := &innerReq.FakeRequester{
+			ReqFunc: func(method string, url url.URL, body []byte, header http.Header) (*http.Response, []byte, error) {
+				assert.Equal(t, "POST", method)
+				assert.Equal(t, "api/v5/authentication/password_based:built_in_database/import_users", url.Path)
+				assert.Contains(t, header.Get("Content-Type"), "multipart/form-data")
+				assert.Contains(t, string(body), `filename="users.json"`)
+				assert.Contains(t, string(body), `[{"is_superuser":false,"password_hash":"hash","salt":"salt","user_id":"emqx"}]`)
+				return &http.Response{StatusCode: 200}, []byte(`{"total":1,"success":1,"failed":0}`), nil
+			},
+		}
+		assert.NoError(t, importUserByAPI(requester, user))
+	})
+
+	t.Run("failed to import", func(t *testing.T) {
+		requester := &innerReq.FakeRequester{
+			ReqFunc: func(method string, url url.URL, body []byte, header http.Header) (*http.Response, []byte, error) {
+				return &http.Response{StatusCode: 200}, []byte(`{"total":1,"success":0,"failed":1}`), nil
+			},
+		}
+		assert.ErrorContains(t, importUserByAPI(requester, user), "failed to import user")
+	})
+}
+
+func TestGetUserByAPI(t *testing.T) {
+	requester := &innerReq.FakeRequester{
+		ReqFunc: func(method string, url url.URL, body []byte, header http.Header) (*http.Response, []byte, error) {
+			assert.Equal(t, "GET", method)
+			switch url.Path {
+			case "api/v5/authentication/password_based:built_in_database/users/emqx":
+				return &http.Response{StatusCode: 200}, []byte(`{"user_id":"emqx","is_superuser":true}`), nil
+			case "api/v5/authentication/password_based:built_in_database/users/not-found":
+				return &http.Response{StatusCode: 404}, nil, nil
+			}
+			return &http.Response{StatusCode: 500, Status: "500 Internal Server Error"}, nil, nil
+		},
+	}
+
+	got, err := getUserByAPI(requester, "emqx")
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"user_id":"emqx","is_superuser":true}`, string(got))
+
+	got, err = getUserByAPI(requester, "not-found")
+	assert.NoError(t, err)
+	assert.Nil(t, got)
+
+	_, err = getUserByAPI(requester, "error")
+	assert.ErrorContains(t, err, "500 Internal Server Error")
+}
+
+func TestGetCredentialHash(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = corev1.AddToScheme(scheme)
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "emqx-user", Namespace: "emqx"},
+		Data:       map[string][]byte{"password": []byte("public")},
+	}
+	fakeClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(secret).Build()
+	r := &EMQXUserReconciler{Client: fakeClient}
+	user := &appsv2beta1.EMQXUser{
+		ObjectMeta: metav1.ObjectMeta{Name: "emqx-user", Namespace: "emqx"},
+		Spec: appsv2beta1.EMQXUserSpec{
+			InstanceName: "emqx",
+			UserID:       "emqx-user",
+			Password:     &appsv2beta1.KeyRef{SecretName: "emqx-user", SecretKey: "password"},
+		},
+	}
+
+	hash, err := r.getCredentialHash(ctx, user)
+	assert.NoError(t, err)
+	got, _ := r.getCredentialHash(ctx, user)
+	assert.Equal(t, hash, got)
+
+	// The hash is changed with the Secret
+	secret.Data["password"] = []byte("private")
+	assert.NoError(t, fakeClient.Update(ctx, secret))
+	got, _ = r.getCredentialHash(ctx, user)
+	assert.NotEqual(t, hash, got)
+
+	// The hash is changed with the spec
+	hash = got
+	user.Spec.IsSuperuser = true
+	got, _ = r.getCredentialHash(ctx, user)
+	assert.NotEqual(t, hash, got)
+
+	user.Spec.Password.SecretName = "not-found"
+	_, err = r.getCredentialHash(ctx, user)
+	assert.ErrorContains(t, err, "failed to get secret")
+}
+
+func TestDeleteUserByAPI(t *testing.T) {
+	for _, code := range []int{200, 204, 404} {
+		requester := &innerReq.FakeRequester{
+			ReqFunc: func(method string, url url.URL, body []byte, header http.Header) (*http.Response, []byte, error) {
+				assert.Equal(t, "DELETE", method)
+				assert.Equal(t, "api/v5/authentication/password_based:built_in_database/users/emqx", url.Path)
+				return &http.Response{StatusCode: code}, nil, nil
+			},
+		}
+		assert.NoError(t, deleteUserByAPI(requester, "emqx"))
+	}
+
+	requester := &innerReq.FakeRequester{
+		ReqFunc: func(method string, url url.URL, body []byte, header http.Header) (*http.Response, []byte, error) {
+			return &http.Response{StatusCode: 500, Status: "500 Internal Server Error"}, nil, nil
+		},
+	}
+	assert.ErrorContains(t, deleteUserByAPI(requester, "emqx"), "500 Internal Server Error")
+
+	// The user ID is escaped
+	requester = &innerReq.FakeRequester{
+		ReqFunc: func(method string, url url.URL, body []byte, header http.Header) (*http.Response, []byte, error) {
+			assert.Equal(t, "api/v5/authentication/password_based:built_in_database/users/a%2Fb", url.EscapedPath())
+			return &http.Response{StatusCode: 204}, nil, nil
+		},
+	}
+	assert.NoError(t, deleteUserByAPI(requester, "a/b"))
+}
+
+func TestGetUserSecretRefs(t *testing.T) {
+	assert.Equal(t, []appsv2beta1.KeyRef{
+		{SecretName: "user", SecretKey: "hash"},
+		{SecretName: "user", SecretKey: "salt"},
+	}, getUserSecretRefs(&appsv2beta1.EMQXUser{
+		Spec: appsv2beta1.EMQXUserSpec{
+			PasswordHash: &appsv2beta1.PasswordHashRef{
+				Hash: appsv2beta1.KeyRef{SecretName: "user", SecretKey: "hash"},
+				Salt: &appsv2beta1.KeyRef{SecretName: "user", SecretKey: "salt"},
+			},
+		},
+	}))
+}
@@ -40,6 +40,7 @@ import (
 	controllerv1beta4 "github.com/emqx/emqx-operator/controllers/apps/v1beta4"
 
 	// controllerv2beta1 "github.com/emqx/emqx-operator/controllers/apps/v2beta1"
+	"github.com/emqx/emqx-operator/internal/metrics"
 	innerReq "github.com/emqx/emqx-operator/internal/requester"
 	"github.com/tidwall/gjson"
 )
@@ -92,6 +93,7 @@ func (r *RebalanceReconciler) Reconcile(ctx context.Context, req ctrl.Request) (
 	rebalance := &appsv2beta1.Rebalance{}
 	if err := r.Client.Get(ctx, req.NamespacedName, rebalance); err != nil {
 		if k8sErrors.IsNotFound(err) {
+			metrics.DeleteRebalance(req.Namespace, req.Name)
 			return ctrl.Result{}, nil
 		}
 		return ctrl.Result{}, err
@@ -201,6 +203,7 @@ func (r *RebalanceReconciler) Reconcile(ctx context.Context, req ctrl.Request) (
 	if err := r.Client.Status().Update(ctx, rebalance); err != nil {
 		return ctrl.Result{}, err
 	}
+	metrics.ObserveRebalance(rebalance)
 
 	switch rebalance.Status.Phase {
 	case "Failed":
@@ -18,9 +18,13 @@ package v2beta1
 
 import (
 	"context"
+	"fmt"
 
 	appsv2beta1 "github.com/emqx/emqx-operator/apis/apps/v2beta1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
@@ -108,6 +112,43 @@ func (s *emqxStatusMachine) GetEMQX() *appsv2beta1.EMQX {
 	return s.emqx
 }
 
+// countOnServingPods returns the number of pods which have joined the EMQX cluster, and are controlled by the owner
+func (s *emqxStatusMachine) countOnServingPods(ctx context.Context, owner types.UID) int32 {
+	pods := &corev1.PodList{}
+	_ = s.client.List(ctx, pods,
+		client.InNamespace(s.emqx.Namespace),
+		client.MatchingLabels(appsv2beta1.DefaultLabels(s.emqx)),
+	)
+
+	var count int32
+	for _, pod := range pods.Items {
+		controllerRef := metav1.GetControllerOf(&pod)
+		if controllerRef == nil || controllerRef.UID != owner {
+			continue
+		}
+		for _, condition := range pod.Status.Conditions {
+			if condition.Type == appsv2beta1.PodOnServing && condition.Status == corev1.ConditionTrue {
+				count++
+				break
+			}
+		}
+	}
+	return count
+}
+
+// rollback marks the update is rolling back, the syncPods will bring the current revision back and delete the update revision
+func (s *emqxStatusMachine) rollback(message string) {
+	if isRollingBack(s.emqx) {
+		return
+	}
+	s.emqx.Status.SetCondition(metav1.Condition{
+		Type:    appsv2beta1.RollingBack,
+		Status:  metav1.ConditionTrue,
+		Reason:  "ProgressDeadlineExceeded",
+		Message: message,
+	})
+}
+
 type initializedStatus struct {
 	emqxStatusMachine *emqxStatusMachine
 }
@@ -138,14 +179,21 @@ type coreNodesProgressingStatus struct {
 func (s *coreNodesProgressingStatus) nextStatus(ctx context.Context) {
 	emqx := s.emqxStatusMachine.GetEMQX()
 
-	updateSts, _, _ := getStateFulSetList(ctx, s.emqxStatusMachine.client, emqx)
-	if updateSts != nil && updateSts.Status.ReadyReplicas != 0 && updateSts.Status.ReadyReplicas == *emqx.Spec.CoreTemplate.Spec.Replicas {
+	updateSts, currentSts, _ := getStateFulSetList(ctx, s.emqxStatusMachine.client, emqx)
+	if updateSts != nil && updateSts.Status.ReadyReplicas != 0 && updateSts.Status.ReadyReplicas >= *emqx.Spec.CoreTemplate.Spec.Replicas {
 		emqx.Status.SetCondition(metav1.Condition{
 			Type:    appsv2beta1.CoreNodesReady,
 			Status:  metav1.ConditionTrue,
 			Reason:  "CoreNodesReady",
 			Message: "Core nodes is ready",
 		})
+	} else if updateSts != nil && currentSts != nil && updateSts.UID != currentSts.UID &&
+		checkProgressDeadlineExceeded(emqx, appsv2beta1.CoreNodesProgressing) &&
+		s.emqxStatusMachine.countOnServingPods(ctx, updateSts.UID) < *updateSts.Spec.Replicas {
+		s.emqxStatusMachine.rollback(fmt.Sprintf(
+			"Core nodes of revision %s have not joined the cluster in %d seconds, roll back to revision %s",
+			emqx.Status.CoreNodesStatus.UpdateRevision, emqx.Spec.UpdateStrategy.ProgressDeadlineSeconds, emqx.Status.CoreNodesStatus.CurrentRevision,
+		))
 	}
 
 	s.emqxStatusMachine.setCurrentStatus(emqx)
@@ -190,14 +238,21 @@ func (s *replicantNodesProgressingStatus) nextStatus(ctx context.Context) {
 		return
 	}
 
-	updateRs, _, _ := getReplicaSetList(ctx, s.emqxStatusMachine.client, emqx)
-	if updateRs != nil && updateRs.Status.ReadyReplicas != 0 && updateRs.Status.ReadyReplicas == *emqx.Spec.ReplicantTemplate.Spec.Replicas {
+	updateRs, currentRs, _ := getReplicaSetList(ctx, s.emqxStatusMachine.client, emqx)
+	if updateRs != nil && isReplicaSetReady(emqx, updateRs) {
 		emqx.Status.SetCondition(metav1.Condition{
 			Type:    appsv2beta1.ReplicantNodesReady,
 			Status:  metav1.ConditionTrue,
 			Reason:  appsv2beta1.ReplicantNodesReady,
 			Message: "Replicant nodes ready",
 		})
+	} else if updateRs != nil && currentRs != nil && updateRs.UID != currentRs.UID &&
+		checkProgressDeadlineExceeded(emqx, appsv2beta1.ReplicantNodesProgressing) &&
+		s.emqxStatusMachine.countOnServingPods(ctx, updateRs.UID) < *updateRs.Spec.Replicas {
+		s.emqxStatusMachine.rollback(fmt.Sprintf(
+			"Replicant nodes of revision %s have not joined the cluster in %d seconds, roll back to revision %s",
+			emqx.Status.ReplicantNodesStatus.UpdateRevision, emqx.Spec.UpdateStrategy.ProgressDeadlineSeconds, emqx.Status.ReplicantNodesStatus.CurrentRevision,
+		))
 	}
 
 	s.emqxStatusMachine.setCurrentStatus(emqx)
@@ -229,13 +284,14 @@ type availableStatus struct {
 func (s *availableStatus) nextStatus(ctx context.Context) {
 	emqx := s.emqxStatusMachine.GetEMQX()
 
-	if emqx.Status.CoreNodesStatus.ReadyReplicas != emqx.Status.CoreNodesStatus.Replicas ||
+	// The ready replicas are more than the replicas when the nodes are being scaled in
+	if emqx.Status.CoreNodesStatus.ReadyReplicas < emqx.Status.CoreNodesStatus.Replicas ||
 		emqx.Status.CoreNodesStatus.UpdateRevision != emqx.Status.CoreNodesStatus.CurrentRevision {
 		return
 	}
 
 	if appsv2beta1.IsExistReplicant(emqx) {
-		if emqx.Status.ReplicantNodesStatus.ReadyReplicas != emqx.Status.ReplicantNodesStatus.Replicas ||
+		if emqx.Status.ReplicantNodesStatus.ReadyReplicas < emqx.Status.ReplicantNodesStatus.Replicas ||
 			emqx.Status.ReplicantNodesStatus.UpdateRevision != emqx.Status.ReplicantNodesStatus.CurrentRevision {
 			return
 		}
@@ -257,14 +313,14 @@ type readyStatus struct {
 func (s *readyStatus) nextStatus(ctx context.Context) {
 	emqx := s.emqxStatusMachine.GetEMQX()
 	updateSts, _, _ := getStateFulSetList(ctx, s.emqxStatusMachine.client, emqx)
-	if updateSts != nil && updateSts.Status.ReadyReplicas != emqx.Status.CoreNodesStatus.Replicas {
+	if updateSts != nil && updateSts.Status.ReadyReplicas < emqx.Status.CoreNodesStatus.Replicas {
 		s.emqxStatusMachine.initialized.nextStatus(ctx)
 		return
 	}
 
 	if appsv2beta1.IsExistReplicant(emqx) {
 		updateRs, _, _ := getReplicaSetList(ctx, s.emqxStatusMachine.client, emqx)
-		if updateRs != nil && updateRs.Status.ReadyReplicas != emqx.Status.ReplicantNodesStatus.Replicas {
+		if updateRs != nil && updateRs.Status.ReadyReplicas < emqx.Status.ReplicantNodesStatus.Replicas {
 			s.emqxStatusMachine.emqx.Status.RemoveCondition(appsv2beta1.Ready)
 			s.emqxStatusMachine.emqx.Status.RemoveCondition(appsv2beta1.Available)
 			s.emqxStatusMachine.emqx.Status.RemoveCondition(appsv2beta1.ReplicantNodesReady)
@@ -280,3 +336,13 @@ func (s *readyStatus) nextStatus(ctx context.Context) {
 		}
 	}
 }
+
+func isReplicaSetReady(emqx *appsv2beta1.EMQX, rs *appsv1.ReplicaSet) bool {
+	switch emqx.Spec.UpdateStrategy.Type {
+	case appsv2beta1.UpdateStrategyRollingUpdate, appsv2beta1.UpdateStrategyCanary:
+		// The update replicaSet is scaled up step by step, so just check the replicas of itself
+		return rs.Status.ObservedGeneration == rs.Generation && rs.Status.ReadyReplicas == *rs.Spec.Replicas
+	default:
+		return rs.Status.ReadyReplicas != 0 && rs.Status.ReadyReplicas >= appsv2beta1.GetReplicantReplicas(emqx)
+	}
+}
@@ -0,0 +1,532 @@
+package v2beta1
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	emperror "emperror.dev/errors"
+	appsv2beta1 "github.com/emqx/emqx-operator/apis/apps/v2beta1"
+	innerReq "github.com/emqx/emqx-operator/internal/requester"
+	"github.com/go-logr/logr"
+	"github.com/tidwall/gjson"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+type syncAuth struct {
+	*EMQXReconciler
+}
+
+// authItem is an authenticator or an authorization source, the id is the path parameter of the EMQX API
+type authItem struct {
+	id   string
+	body map[string]interface{}
+	// hash of the spec and the versions of the referenced Secrets, the body is not hashed because it contains the secrets
+	hash string
+}
+
+// authAPI describes the EMQX API of the authenticators or the authorization sources
+type authAPI struct {
+	// path of the collection, like "api/v5/authentication"
+	path string
+	// move puts the item at the position, which is "front", "rear", "before:<id>" or "after:<id>"
+	move func(r innerReq.RequesterInterface, id, position string) error
+}
+
+var authenticationAPI = authAPI{
+	path: "api/v5/authentication",
+	move: func(r innerReq.RequesterInterface, id, position string) error {
+		return requestAuthAPI(r, "PUT", fmt.Sprintf("api/v5/authentication/%s/position/%s", id, position), nil)
+	},
+}
+
+var authorizationAPI = authAPI{
+	path: "api/v5/authorization/sources",
+	move: func(r innerReq.RequesterInterface, id, position string) error {
+		body, _ := json.Marshal(map[string]string{"position": position})
+		return requestAuthAPI(r, "POST", fmt.Sprintf("api/v5/authorization/sources/%s/move", id), body)
+	},
+}
+
+// syncAuth applies ".spec.authentication" and ".spec.authorization" by the EMQX authentication and authorization API,
+// the hashes of the applied items are saved in the annotations, so they are only requested when they are changed
+func (s *syncAuth) reconcile(ctx context.Context, logger logr.Logger, instance *appsv2beta1.EMQX, r innerReq.RequesterInterface) subResult {
+	if r == nil || !instance.Status.IsConditionTrue(appsv2beta1.CoreNodesReady) {
+		return subResult{}
+	}
+
+	// missingAuthenticators and missingSources are the items whose Secrets are not found
+	missingAuthenticators := []string{}
+	authenticators := []authItem{}
+	for _, authenticator := range instance.Spec.Authentication {
+		reader := &secretVersionReader{ctx: ctx, client: s.Client, namespace: instance.Namespace}
+		body, err := generateAuthenticatorBody(authenticator, reader.read)
+		if err != nil {
+			if k8sErrors.IsNotFound(err) {
+				missingAuthenticators = append(missingAuthenticators, authenticator.ID())
+				continue
+			}
+			return subResult{err: emperror.Wrapf(err, "failed to generate authenticator %s", authenticator.ID())}
+		}
+		spec, _ := json.Marshal(authenticator)
+		authenticators = append(authenticators, authItem{id: authenticator.ID(), body: body, hash: reader.hash(spec)})
+	}
+
+	missingSources := []string{}
+	sources := []authItem{}
+	settings := map[string]interface{}{}
+	if instance.Spec.Authorization != nil {
+		for _, source := range instance.Spec.Authorization.Sources {
+			reader := &secretVersionReader{ctx: ctx, client: s.Client, namespace: instance.Namespace}
+			body, err := generateAuthorizationSourceBody(source, reader.read)
+			if err != nil {
+				if k8sErrors.IsNotFound(err) {
+					missingSources = append(missingSources, source.Type)
+					continue
+				}
+				return subResult{err: emperror.Wrapf(err, "failed to generate authorization source %s", source.Type)}
+			}
+			spec, _ := json.Marshal(source)
+			sources = append(sources, authItem{id: source.Type, body: body, hash: reader.hash(spec)})
+		}
+		settings = generateAuthorizationSettings(instance.Spec.Authorization)
+	}
+
+	for _, sync := range []struct {
+		annotation string
+		api        authAPI
+		items      []authItem
+		settings   map[string]interface{}
+		missing    []string
+	}{
+		{appsv2beta1.AnnotationsLastAuthenticationKey, authenticationAPI, authenticators, nil, missingAuthenticators},
+		{appsv2beta1.AnnotationsLastAuthorizationKey, authorizationAPI, sources, settings, missingSources},
+	} {
+		// The items are kept as they are until all the Secrets are found,
+		// otherwise the items whose Secrets are missing would be deleted from EMQX
+		if len(sync.missing) > 0 {
+			continue
+		}
+		last := map[string]string{}
+		if v, ok := instance.Annotations[sync.annotation]; ok {
+			_ = json.Unmarshal([]byte(v), &last)
+		}
+		if len(sync.items) == 0 && len(sync.settings) == 0 && len(last) == 0 {
+			continue
+		}
+
+		hashes, err := applyAuthItems(r, sync.api, last, sync.items)
+		if err != nil {
+			return subResult{err: emperror.Wrap(err, "failed to apply auth by api")}
+		}
+		// The settings are saved with the items, there is no authorization source named "settings"
+		if len(sync.settings) > 0 {
+			body, _ := json.Marshal(sync.settings)
+			hashes["settings"] = computeConfigHash(string(body))
+			if last["settings"] != hashes["settings"] {
+				if err := requestAuthAPI(r, "PUT", "api/v5/authorization/settings", body); err != nil {
+					return subResult{err: emperror.Wrap(err, "failed to update authorization settings")}
+				}
+			}
+		}
+		if maps.Equal(last, hashes) {
+			continue
+		}
+
+		logger.Info("synced emqx auth", "annotation", sync.annotation)
+		data, _ := json.Marshal(hashes)
+		if instance.Annotations == nil {
+			instance.Annotations = map[string]string{}
+		}
+		instance.Annotations[sync.annotation] = string(data)
+		if err := s.Client.Update(ctx, instance); err != nil {
+			return subResult{err: emperror.Wrap(err, "failed to update emqx instance annotation")}
+		}
+	}
+
+	authenticationStatus := instance.Status.AuthenticationStatus
+	if len(missingAuthenticators) == 0 {
+		authenticationStatus = getAuthStatus(r, "api/v5/authentication", authenticators)
+	}
+	authorizationStatus := instance.Status.AuthorizationStatus
+	if len(missingSources) == 0 {
+		authorizationStatus = getAuthStatus(r, "api/v5/authorization/sources", sources)
+	}
+	conditionChanged := s.setAuthSecretsCondition(instance, missingAuthenticators, missingSources)
+	if conditionChanged ||
+		!equality.Semantic.DeepEqual(instance.Status.AuthenticationStatus, authenticationStatus) ||
+		!equality.Semantic.DeepEqual(instance.Status.AuthorizationStatus, authorizationStatus) {
+		instance.Status.AuthenticationStatus = authenticationStatus
+		instance.Status.AuthorizationStatus = authorizationStatus
+		if err := s.Client.Status().Update(ctx, instance); err != nil {
+			return subResult{err: emperror.Wrap(err, "failed to update status")}
+		}
+	}
+	// Wait for the Secrets to be created, the Secrets are not watched
+	if len(missingAuthenticators) > 0 || len(missingSources) > 0 {
+		return subResult{result: ctrl.Result{RequeueAfter: 10 * time.Second}}
+	}
+	return subResult{}
+}
+
+// setAuthSecretsCondition sets the AuthSecretsMissing condition, and emits an event when the missing Secrets are changed,
+// it returns true if the condition is changed
+func (s *syncAuth) setAuthSecretsCondition(instance *appsv2beta1.EMQX, missingAuthenticators, missingSources []string) bool {
+	_, condition := instance.Status.GetCondition(appsv2beta1.AuthSecretsMissing)
+	if len(missingAuthenticators) == 0 && len(missingSources) == 0 {
+		if condition == nil || condition.Status == metav1.ConditionFalse {
+			return false
+		}
+		instance.Status.SetCondition(metav1.Condition{
+			Type:    appsv2beta1.AuthSecretsMissing,
+			Status:  metav1.ConditionFalse,
+			Reason:  "AuthSecretsFound",
+			Message: "The Secrets of the authenticators and the authorization sources are found",
+		})
+		return true
+	}
+
+	missing := []string{}
+	for _, id := range missingAuthenticators {
+		missing = append(missing, "authenticator "+id)
+	}
+	for _, id := range missingSources {
+		missing = append(missing, "authorization source "+id)
+	}
+	message := fmt.Sprintf("The Secrets of %s are not found, they are not synced to EMQX", strings.Join(missing, ", "))
+	if condition != nil && condition.Status == metav1.ConditionTrue && condition.Message == message {
+		return false
+	}
+	s.EventRecorder.Event(instance, corev1.EventTypeWarning, "AuthSecretsMissing", message)
+	instance.Status.SetCondition(metav1.Condition{
+		Type:    appsv2beta1.AuthSecretsMissing,
+		Status:  metav1.ConditionTrue,
+		Reason:  "AuthSecretsMissing",
+		Message: message,
+	})
+	return true
+}
+
+// applyAuthItems creates or updates the changed items, deletes the removed items and puts the items in order,
+// it returns the hashes of the items
+func applyAuthItems(r innerReq.RequesterInterface, api authAPI, last map[string]string, items []authItem) (map[string]string, error) {
+	hashes := map[string]string{}
+	changed := false
+	for _, item := range items {
+		hashes[item.id] = item.hash
+		if last[item.id] == hashes[item.id] {
+			continue
+		}
+		changed = true
+		body, _ := json.Marshal(item.body)
+		if err := putAuthItemByAPI(r, api.path, item.id, body); err != nil {
+			return nil, emperror.Wrapf(err, "failed to update %s", item.id)
+		}
+	}
+
+	for id := range last {
+		if _, ok := hashes[id]; ok || id == "settings" {
+			continue
+		}
+		changed = true
+		if err := deleteAuthItemByAPI(r, api.path, id); err != nil {
+			return nil, emperror.Wrapf(err, "failed to delete %s", id)
+		}
+	}
+
+	if !changed {
+		return hashes, nil
+	}
+	for i, item := range items {
+		position := "front"
+		if i > 0 {
+			position = "after:" + items[i-1].id
+		}
+		if err := api.move(r, item.id, position); err != nil {
+			return nil, emperror.Wrapf(err, "failed to move %s", item.id)
+		}
+	}
+	return hashes, nil
+}
+
+func getAuthStatus(r innerReq.RequesterInterface, path string, items []authItem) []appsv2beta1.AuthSourceStatus {
+	if len(items) == 0 {
+		return nil
+	}
+	status := []appsv2beta1.AuthSourceStatus{}
+	for _, item := range items {
+		url := r.GetURL(fmt.Sprintf("%s/%s/status", path, item.id))
+		resp, body, err := r.Request("GET", url, nil, nil)
+		if err != nil {
+			status = append(status, appsv2beta1.AuthSourceStatus{ID: item.id, Message: err.Error()})
+			continue
+		}
+		if resp.StatusCode != http.StatusOK {
+			status = append(status, appsv2beta1.AuthSourceStatus{ID: item.id, Message: fmt.Sprintf("status: %s, body: %s", resp.Status, body)})
+			continue
+		}
+		status = append(status, appsv2beta1.AuthSourceStatus{ID: item.id, Status: gjson.GetBytes(body, "status").String()})
+	}
+	return status
+}
+
+func generateAuthenticatorBody(authenticator appsv2beta1.Authenticator, readSecret func(appsv2beta1.KeyRef) (string, error)) (map[string]interface{}, error) {
+	body := map[string]interface{}{
+		"mechanism": "password_based",
+		"enable":    authenticator.Enable == nil || *authenticator.Enable,
+	}
+
+	if authenticator.Mechanism == "jwt" {
+		body["mechanism"] = "jwt"
+		if jwt := authenticator.JWT; jwt != nil {
+			body["use_jwks"] = jwt.UseJWKS
+			if jwt.From != "" {
+				body["from"] = jwt.From
+			}
+			if jwt.UseJWKS {
+				body["endpoint"] = jwt.Endpoint
+			} else {
+				body["algorithm"] = jwt.Algorithm
+				switch jwt.Algorithm {
+				case "hmac-based":
+					secret, err := readOptionalSecret(jwt.Secret, readSecret)
+					if err != nil {
+						return nil, err
+					}
+					body["secret"] = secret
+					body["secret_base64_encoded"] = false
+				case "public-key":
+					body["public_key"] = jwt.PublicKey
+				}
+			}
+		}
+		return body, nil
+	}
+
+	body["backend"] = authenticator.Backend
+	if authenticator.Backend == "built_in_database" && authenticator.UserIDType != "" {
+		body["user_id_type"] = authenticator.UserIDType
+	}
+	if algorithm := authenticator.PasswordHashAlgorithm; algorithm != nil && authenticator.Backend != "http" {
+		passwordHashAlgorithm := map[string]interface{}{"name": algorithm.Name}
+		if algorithm.SaltPosition != "" && algorithm.Name != "bcrypt" {
+			passwordHashAlgorithm["salt_position"] = algorithm.SaltPosition
+		}
+		body["password_hash_algorithm"] = passwordHashAlgorithm
+	}
+	config, err := generateAuthSourceConfig(authenticator.HTTP, authenticator.Redis, authenticator.PostgreSQL, readSecret)
+	if err != nil {
+		return nil, err
+	}
+	maps.Copy(body, config)
+	return body, nil
+}
+
+func generateAuthorizationSourceBody(source appsv2beta1.AuthorizationSource, readSecret func(appsv2beta1.KeyRef) (string, error)) (map[string]interface{}, error) {
+	body := map[string]interface{}{
+		"type":   source.Type,
+		"enable": source.Enable == nil || *source.Enable,
+	}
+	if source.Type == "file" {
+		body["rules"] = source.Rules
+	}
+	config, err := generateAuthSourceConfig(source.HTTP, source.Redis, source.PostgreSQL, readSecret)
+	if err != nil {
+		return nil, err
+	}
+	maps.Copy(body, config)
+	return body, nil
+}
+
+func generateAuthorizationSettings(authorization *appsv2beta1.Authorization) map[string]interface{} {
+	settings := map[string]interface{}{
+		"no_match":    "allow",
+		"deny_action": "ignore",
+		"cache": map[string]interface{}{
+			"enable": authorization.CacheEnabled == nil || *authorization.CacheEnabled,
+		},
+	}
+	if authorization.NoMatch != "" {
+		settings["no_match"] = authorization.NoMatch
+	}
+	if authorization.DenyAction != "" {
+		settings["deny_action"] = authorization.DenyAction
+	}
+	return settings
+}
+
+// generateAuthSourceConfig returns the config of the http, redis or postgresql backend,
+// which are shared by the authenticators and the authorization sources
+func generateAuthSourceConfig(
+	httpSource *appsv2beta1.HTTPAuthSource,
+	redis *appsv2beta1.RedisAuthSource,
+	postgresql *appsv2beta1.PostgreSQLAuthSource,
+	readSecret func(appsv2beta1.KeyRef) (string, error),
+) (map[string]interface{}, error) {
+	config := map[string]interface{}{}
+	switch {
+	case httpSource != nil:
+		method := httpSource.Method
+		if method == "" {
+			method = "post"
+		}
+		headers := map[string]interface{}{}
+		for k, v := range httpSource.Headers {
+			headers[k] = v
+		}
+		for k, ref := range httpSource.SecretHeaders {
+			v, err := readSecret(ref)
+			if err != nil {
+				return nil, err
+			}
+			headers[k] = v
+		}
+		config["method"] = method
+		config["url"] = httpSource.URL
+		config["headers"] = headers
+		if len(httpSource.Body) > 0 {
+			body := map[string]interface{}{}
+			for k, v := range httpSource.Body {
+				body[k] = v
+			}
+			config["body"] = body
+		}
+	case redis != nil:
+		redisType := redis.RedisType
+		if redisType == "" {
+			redisType = "single"
+		}
+		config["redis_type"] = redisType
+		config["cmd"] = redis.Cmd
+		switch redisType {
+		case "single":
+			config["server"] = redis.Server
+			config["database"] = redis.Database
+		case "sentinel":
+			config["servers"] = redis.Server
+			config["sentinel"] = redis.Sentinel
+			config["database"] = redis.Database
+		case "cluster":
+			config["servers"] = redis.Server
+		}
+		if redis.Username != "" {
+			config["username"] = redis.Username
+		}
+		password, err := readOptionalSecret(redis.Password, readSecret)
+		if err != nil {
+			return nil, err
+		}
+		if password != "" {
+			config["password"] = password
+		}
+	case postgresql != nil:
+		config["server"] = postgresql.Server
+		config["database"] = postgresql.Database
+		config["query"] = postgresql.Query
+		if postgresql.Username != "" {
+			config["username"] = postgresql.Username
+		}
+		password, err := readOptionalSecret(postgresql.Password, readSecret)
+		if err != nil {
+			return nil, err
+		}
+		if password != "" {
+			config["password"] = password
+		}
+	}
+	return config, nil
+}
+
+// secretVersionReader reads the values of the Secrets, and records the versions of them to hash the auth items
+type secretVersionReader struct {
+	ctx       context.Context
+	client    client.Client
+	namespace string
+	versions  map[appsv2beta1.KeyRef]string
+}
+
+func (s *secretVersionReader) read(ref appsv2beta1.KeyRef) (string, error) {
+	secret := &corev1.Secret{}
+	if err := s.client.Get(s.ctx, types.NamespacedName{Namespace: s.namespace, Name: ref.SecretName}, secret); err != nil {
+		return "", emperror.Wrap(err, "failed to get secret")
+	}
+	if _, ok := secret.Data[ref.SecretKey]; !ok {
+		return "", emperror.NewWithDetails("secret does not contain the key", "secret", secret.Name, "key", ref.SecretKey)
+	}
+	if s.versions == nil {
+		s.versions = map[appsv2beta1.KeyRef]string{}
+	}
+	s.versions[ref] = fmt.Sprintf("%s/%s/%s", secret.UID, secret.ResourceVersion, ref.SecretKey)
+	return string(secret.Data[ref.SecretKey]), nil
+}
+
+func (s *secretVersionReader) hash(spec []byte) string {
+	versions := []string{}
+	for _, version := range s.versions {
+		versions = append(versions, version)
+	}
+	sort.Strings(versions)
+	return computeConfigHash(string(spec) + strings.Join(versions, ","))
+}
+
+func readOptionalSecret(ref *appsv2beta1.KeyRef, readSecret func(appsv2beta1.KeyRef) (string, error)) (string, error) {
+	if ref == nil {
+		return "", nil
+	}
+	return readSecret(*ref)
+}
+
+// putAuthItemByAPI updates the item, it creates the item if it does not exist
+func putAuthItemByAPI(r innerReq.RequesterInterface, path, id string, body []byte) error {
+	url := r.GetURL(fmt.Sprintf("%s/%s", path, id))
+	resp, respBody, err := r.Request("PUT", url, body, nil)
+	if err != nil {
+		return emperror.Wrapf(err, "failed to put API %s", url.String())
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		url = r.GetURL(path)
+		resp, respBody, err = r.Request("POST", url, body, nil)
+		if err != nil {
+			return emperror.Wrapf(err, "failed to post API %s", url.String())
+		}
+	}
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusNoContent {
+		return emperror.Errorf("failed to request API %s, status : %s, body: %s", url.String(), resp.Status, respBody)
+	}
+	return nil
+}
+
+func deleteAuthItemByAPI(r innerReq.RequesterInterface, path, id string) error {
+	url := r.GetURL(fmt.Sprintf("%s/%s", path, id))
+	resp, body, err := r.Request("DELETE", url, nil, nil)
+	if err != nil {
+		return emperror.Wrapf(err, "failed to delete API %s", url.String())
+	}
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNotFound {
+		return emperror.Errorf("failed to delete API %s, status : %s, body: %s", url.String(), resp.Status, body)
+	}
+	return nil
+}
+
+func requestAuthAPI(r innerReq.RequesterInterface, method, path string, body []byte) error {
+	url := r.GetURL(path)
+	resp, respBody, err := r.Request(method, url, body, nil)
+	if err != nil {
+		return emperror.Wrapf(err, "failed to request API %s", url.String())
+	}
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
+		return emperror.Errorf("failed to request API %s, status : %s, body: %s", url.String(), resp.Status, respBody)
+	}
+	return nil
+}
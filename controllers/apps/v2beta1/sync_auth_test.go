@@ -0,0 +1,350 @@
+package v2beta1
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	emperror "emperror.dev/errors"
+	appsv2beta1 "github.com/emqx/emqx-operator/apis/apps/v2beta1"
+	"github.com/emqx/emqx-operator/internal/handler"
+	innerReq "github.com/emqx/emqx-operator/internal/requester"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func fakeReadSecret(ref appsv2beta1.KeyRef) (string, error) {
+	if ref.SecretName == "not-found" {
+		return "", emperror.New("failed to get secret")
+	}
+	return ref.SecretName + "/" + ref.SecretKey, nil
+}
+
+func TestGenerateAuthenticatorBody(t *testing.T) {
+	t.Run("built in database", func(t *testing.T) {
+		got, err := generateAuthenticatorBody(appsv2beta1.Authenticator{
+			Backend:    "built_in_database",
+			UserIDType: "clientid",
+			PasswordHashAlgorithm: &appsv2beta1.PasswordHashAlgorithm{
+				Name:         "sha256",
+				SaltPosition: "suffix",
+			},
+		}, fakeReadSecret)
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]interface{}{
+			"mechanism":    "password_based",
+			"backend":      "built_in_database",
+			"enable":       true,
+			"user_id_type": "clientid",
+			"password_hash_algorithm": map[string]interface{}{
+				"name":          "sha256",
+				"salt_position": "suffix",
+			},
+		}, got)
+	})
+
+	t.Run("jwt", func(t *testing.T) {
+		got, err := generateAuthenticatorBody(appsv2beta1.Authenticator{
+			Mechanism: "jwt",
+			Enable:    ptr.To(false),
+			JWT: &appsv2beta1.JWTAuthenticator{
+				Algorithm: "hmac-based",
+				Secret:    &appsv2beta1.KeyRef{SecretName: "jwt", SecretKey: "secret"},
+				From:      "password",
+			},
+		}, fakeReadSecret)
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]interface{}{
+			"mechanism":             "jwt",
+			"enable":                false,
+			"use_jwks":              false,
+			"from":                  "password",
+			"algorithm":             "hmac-based",
+			"secret":                "jwt/secret",
+			"secret_base64_encoded": false,
+		}, got)
+	})
+
+	t.Run("redis", func(t *testing.T) {
+		got, err := generateAuthenticatorBody(appsv2beta1.Authenticator{
+			Backend: "redis",
+			Redis: &appsv2beta1.RedisAuthSource{
+				RedisType: "sentinel",
+				Server:    "redis-0:26379,redis-1:26379",
+				Sentinel:  "mymaster",
+				Password:  &appsv2beta1.KeyRef{SecretName: "redis", SecretKey: "password"},
+				Cmd:       "HMGET mqtt_user:${username} password_hash salt",
+			},
+		}, fakeReadSecret)
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]interface{}{
+			"mechanism":  "password_based",
+			"backend":    "redis",
+			"enable":     true,
+			"redis_type": "sentinel",
+			"servers":    "redis-0:26379,redis-1:26379",
+			"sentinel":   "mymaster",
+			"database":   int32(0),
+			"password":   "redis/password",
+			"cmd":        "HMGET mqtt_user:${username} password_hash salt",
+		}, got)
+	})
+
+	t.Run("failed to read secret", func(t *testing.T) {
+		_, err := generateAuthenticatorBody(appsv2beta1.Authenticator{
+			Backend: "postgresql",
+			PostgreSQL: &appsv2beta1.PostgreSQLAuthSource{
+				Server:   "postgresql:5432",
+				Database: "mqtt",
+				Password: &appsv2beta1.KeyRef{SecretName: "not-found", SecretKey: "password"},
+				Query:    "SELECT password_hash, salt FROM mqtt_user where username = ${username} LIMIT 1",
+			},
+		}, fakeReadSecret)
+		assert.ErrorContains(t, err, "failed to get secret")
+	})
+}
+
+func TestGenerateAuthorizationSourceBody(t *testing.T) {
+	t.Run("file", func(t *testing.T) {
+		got, err := generateAuthorizationSourceBody(appsv2beta1.AuthorizationSource{
+			Type:  "file",
+			Rules: "{allow, all}.",
+		}, fakeReadSecret)
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]interface{}{
+			"type":   "file",
+			"enable": true,
+			"rules":  "{allow, all}.",
+		}, got)
+	})
+
+	t.Run("http", func(t *testing.T) {
+		got, err := generateAuthorizationSourceBody(appsv2beta1.AuthorizationSource{
+			Type: "http",
+			HTTP: &appsv2beta1.HTTPAuthSource{
+				URL:           "http://authz:8080/acl",
+				Headers:       map[string]string{"content-type": "application/json"},
+				SecretHeaders: map[string]appsv2beta1.KeyRef{"authorization": {SecretName: "authz", SecretKey: "token"}},
+				Body:          map[string]string{"username": "${username}"},
+			},
+		}, fakeReadSecret)
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]interface{}{
+			"type":   "http",
+			"enable": true,
+			"method": "post",
+			"url":    "http://authz:8080/acl",
+			"headers": map[string]interface{}{
+				"content-type":  "application/json",
+				"authorization": "authz/token",
+			},
+			"body": map[string]interface{}{
+				"username": "${username}",
+			},
+		}, got)
+	})
+}
+
+func TestGenerateAuthorizationSettings(t *testing.T) {
+	assert.Equal(t, map[string]interface{}{
+		"no_match":    "deny",
+		"deny_action": "ignore",
+		"cache": map[string]interface{}{
+			"enable": false,
+		},
+	}, generateAuthorizationSettings(&appsv2beta1.Authorization{
+		NoMatch:      "deny",
+		CacheEnabled: ptr.To(false),
+	}))
+}
+
+func TestApplyAuthItems(t *testing.T) {
+	items := []authItem{
+		{id: "password_based:built_in_database", body: map[string]interface{}{"backend": "built_in_database"}, hash: "built-in-database"},
+		{id: "jwt", body: map[string]interface{}{"mechanism": "jwt"}, hash: "jwt"},
+	}
+	hashes := map[string]string{}
+	for _, item := range items {
+		hashes[item.id] = item.hash
+	}
+
+	t.Run("nothing changed", func(t *testing.T) {
+		requester := &innerReq.FakeRequester{
+			ReqFunc: func(method string, url url.URL, body []byte, header http.Header) (*http.Response, []byte, error) {
+				assert.Fail(t, "should not request API")
+				return nil, nil, nil
+			},
+		}
+		got, err := applyAuthItems(requester, authenticationAPI, hashes, items)
+		assert.NoError(t, err)
+		assert.Equal(t, hashes, got)
+	})
+
+	t.Run("create, delete and move", func(t *testing.T) {
+		requests := []string{}
+		requester := &innerReq.FakeRequester{
+			ReqFunc: func(method string, url url.URL, body []byte, header http.Header) (*http.Response, []byte, error) {
+				requests = append(requests, method+" "+url.Path)
+				if method == "PUT" && !strings.Contains(url.Path, "/position/") {
+					return &http.Response{StatusCode: 404, Status: "404 Not Found"}, nil, nil
+				}
+				if method == "DELETE" {
+					return &http.Response{StatusCode: 204}, nil, nil
+				}
+				return &http.Response{StatusCode: 200}, nil, nil
+			},
+		}
+		last := map[string]string{
+			"password_based:built_in_database": hashes["password_based:built_in_database"],
+			"password_based:http":              "fake",
+		}
+		got, err := applyAuthItems(requester, authenticationAPI, last, items)
+		assert.NoError(t, err)
+		assert.Equal(t, hashes, got)
+		assert.Equal(t, []string{
+			"PUT api/v5/authentication/jwt",
+			"POST api/v5/authentication",
+			"DELETE api/v5/authentication/password_based:http",
+			"PUT api/v5/authentication/password_based:built_in_database/position/front",
+			"PUT api/v5/authentication/jwt/position/after:password_based:built_in_database",
+		}, requests)
+	})
+
+	t.Run("move authorization sources", func(t *testing.T) {
+		moves := []string{}
+		requester := &innerReq.FakeRequester{
+			ReqFunc: func(method string, url url.URL, body []byte, header http.Header) (*http.Response, []byte, error) {
+				if strings.HasSuffix(url.Path, "/move") {
+					moves = append(moves, method+" "+url.Path+" "+string(body))
+				}
+				return &http.Response{StatusCode: 204}, nil, nil
+			},
+		}
+		_, err := applyAuthItems(requester, authorizationAPI, map[string]string{}, []authItem{
+			{id: "http", body: map[string]interface{}{"type": "http"}, hash: "http"},
+			{id: "file", body: map[string]interface{}{"type": "file"}, hash: "file"},
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{
+			`POST api/v5/authorization/sources/http/move {"position":"front"}`,
+			`POST api/v5/authorization/sources/file/move {"position":"after:http"}`,
+		}, moves)
+	})
+}
+
+func TestSyncAuthSecretsMissing(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = corev1.AddToScheme(scheme)
+	_ = appsv2beta1.AddToScheme(scheme)
+	instance := &appsv2beta1.EMQX{
+		ObjectMeta: metav1.ObjectMeta{Name: "emqx", Namespace: "emqx"},
+		Spec: appsv2beta1.EMQXSpec{
+			Authentication: []appsv2beta1.Authenticator{
+				{Mechanism: "password_based", Backend: "built_in_database"},
+			},
+			Authorization: &appsv2beta1.Authorization{
+				Sources: []appsv2beta1.AuthorizationSource{
+					{Type: "http", HTTP: &appsv2beta1.HTTPAuthSource{
+						URL:           "http://auth:8080",
+						SecretHeaders: map[string]appsv2beta1.KeyRef{"Authorization": {SecretName: "http-auth", SecretKey: "token"}},
+					}},
+				},
+			},
+		},
+		Status: appsv2beta1.EMQXStatus{
+			Conditions: []metav1.Condition{{Type: appsv2beta1.CoreNodesReady, Status: metav1.ConditionTrue}},
+		},
+	}
+	fakeClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(instance).WithStatusSubresource(instance).Build()
+	recorder := record.NewFakeRecorder(10)
+	s := &syncAuth{&EMQXReconciler{
+		Handler:       &handler.Handler{Client: fakeClient},
+		EventRecorder: recorder,
+	}}
+
+	requests := []string{}
+	requester := &innerReq.FakeRequester{
+		ReqFunc: func(method string, url url.URL, body []byte, header http.Header) (*http.Response, []byte, error) {
+			if method == "GET" {
+				return &http.Response{StatusCode: 200}, []byte(`{"status":"connected"}`), nil
+			}
+			requests = append(requests, method+" "+url.Path)
+			return &http.Response{StatusCode: 200}, nil, nil
+		},
+	}
+
+	// The authenticators are synced, the authorization sources wait for the Secret
+	result := s.reconcile(ctx, logger, instance, requester)
+	assert.Nil(t, result.err)
+	assert.NotZero(t, result.result.RequeueAfter)
+	assert.Contains(t, requests, "PUT api/v5/authentication/password_based:built_in_database")
+	for _, request := range requests {
+		assert.NotContains(t, request, "api/v5/authorization")
+	}
+	assert.True(t, instance.Status.IsConditionTrue(appsv2beta1.AuthSecretsMissing))
+	assert.Equal(t, "Warning AuthSecretsMissing The Secrets of authorization source http are not found, they are not synced to EMQX", <-recorder.Events)
+
+	// The authorization sources are synced after the Secret is created
+	_ = fakeClient.Create(ctx, &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "http-auth", Namespace: "emqx"},
+		Data:       map[string][]byte{"token": []byte("Bearer token")},
+	})
+	requests = []string{}
+	result = s.reconcile(ctx, logger, instance, requester)
+	assert.Nil(t, result.err)
+	assert.Zero(t, result.result)
+	assert.Contains(t, requests, "PUT api/v5/authorization/sources/http")
+	assert.False(t, instance.Status.IsConditionTrue(appsv2beta1.AuthSecretsMissing))
+	assert.Len(t, recorder.Events, 0)
+}
+
+func TestSecretVersionReader(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = corev1.AddToScheme(scheme)
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "redis", Namespace: "emqx", UID: "uid"},
+		Data:       map[string][]byte{"password": []byte("public")},
+	}
+	fakeClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(secret).Build()
+
+	reader := &secretVersionReader{ctx: ctx, client: fakeClient, namespace: "emqx"}
+	got, err := reader.read(appsv2beta1.KeyRef{SecretName: "redis", SecretKey: "password"})
+	assert.NoError(t, err)
+	assert.Equal(t, "public", got)
+	hash := reader.hash([]byte("spec"))
+	assert.Equal(t, computeConfigHash("spec"+"uid/"+secret.ResourceVersion+"/password"), hash)
+
+	// The hash is changed when the Secret is updated
+	secret.Data["password"] = []byte("new")
+	assert.NoError(t, fakeClient.Update(ctx, secret))
+	reader = &secretVersionReader{ctx: ctx, client: fakeClient, namespace: "emqx"}
+	_, err = reader.read(appsv2beta1.KeyRef{SecretName: "redis", SecretKey: "password"})
+	assert.NoError(t, err)
+	assert.NotEqual(t, hash, reader.hash([]byte("spec")))
+
+	_, err = reader.read(appsv2beta1.KeyRef{SecretName: "not-found", SecretKey: "password"})
+	assert.True(t, k8sErrors.IsNotFound(err))
+}
+
+func TestGetAuthStatus(t *testing.T) {
+	requester := &innerReq.FakeRequester{
+		ReqFunc: func(method string, url url.URL, body []byte, header http.Header) (*http.Response, []byte, error) {
+			if url.Path == "api/v5/authorization/sources/http/status" {
+				return &http.Response{StatusCode: 200}, []byte(`{"status": "disconnected"}`), nil
+			}
+			return &http.Response{StatusCode: 404, Status: "404 Not Found"}, []byte("not found"), nil
+		},
+	}
+	assert.Equal(t, []appsv2beta1.AuthSourceStatus{
+		{ID: "http", Status: "disconnected"},
+		{ID: "redis", Message: "status: 404 Not Found, body: not found"},
+	}, getAuthStatus(requester, "api/v5/authorization/sources", []authItem{{id: "http"}, {id: "redis"}}))
+	assert.Nil(t, getAuthStatus(requester, "api/v5/authorization/sources", nil))
+}
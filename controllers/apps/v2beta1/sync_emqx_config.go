@@ -1,30 +1,73 @@
 package v2beta1
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
+	"hash/fnv"
 	"net/http"
+	"reflect"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
 
 	emperror "emperror.dev/errors"
 	appsv2beta1 "github.com/emqx/emqx-operator/apis/apps/v2beta1"
+	"github.com/emqx/emqx-operator/internal/metrics"
 	innerReq "github.com/emqx/emqx-operator/internal/requester"
 	"github.com/go-logr/logr"
 	"github.com/rory-z/go-hocon"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/rand"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
+// The configs can not be updated by the API of EMQX
+var readOnlyConfigs = []string{"node", "cluster", "dashboard", "rpc"}
+
+const maxConfigDriftItems = 10
+
+const redactedConfigValue = "******"
+
+var secretPlaceholderRegexp = regexp.MustCompile(`\$\{secret:([^/}]+)/([^}]+)\}`)
+
+var (
+	hoconSizeRegexp         = regexp.MustCompile(`^(\d+(?:\.\d+)?)\s*(b|kb|mb|gb|tb)$`)
+	hoconDurationRegexp     = regexp.MustCompile(`^(?:\d+(?:\.\d+)?(?:ms|s|m|h|d))+$`)
+	hoconDurationPartRegexp = regexp.MustCompile(`(\d+(?:\.\d+)?)(ms|s|m|h|d)`)
+)
+
+var hoconSizeUnits = map[string]float64{"b": 1, "kb": 1 << 10, "mb": 1 << 20, "gb": 1 << 30, "tb": 1 << 40}
+
+var hoconDurationUnits = map[string]float64{"ms": 1, "s": 1000, "m": 60 * 1000, "h": 60 * 60 * 1000, "d": 24 * 60 * 60 * 1000}
+
 type syncConfig struct {
 	*EMQXReconciler
+	liveConfig *emqxLiveConfig
 }
 
 func (s *syncConfig) reconcile(ctx context.Context, logger logr.Logger, instance *appsv2beta1.EMQX, r innerReq.RequesterInterface) subResult {
-	hoconConfig := mergeDefaultConfig(instance.Spec.Config.Data)
+	apiConfig, fileConfig, sourcesHash, err := s.renderConfig(ctx, instance)
+	if err != nil {
+		return subResult{err: emperror.Wrap(err, "failed to render config")}
+	}
+	hoconConfig := mergeDefaultConfig(apiConfig + "\n" + generateListenersConfig(instance))
 	confStr := hoconConfig.String()
+	// The configs from Secrets are not written to the config map
+	fileConfStr := mergeDefaultConfig(fileConfig + "\n" + generateListenersConfig(instance)).String()
+
+	// The config with the values of Secrets is mounted by the EMQX nodes from a Secret, see configVolumeSource
+	if err := s.syncConfigSecret(ctx, instance, confStr); err != nil {
+		return subResult{err: emperror.Wrap(err, "failed to sync config secret")}
+	}
 
 	// Make sure the config map exists
 	configMap := &corev1.ConfigMap{}
@@ -33,7 +76,7 @@ func (s *syncConfig) reconcile(ctx context.Context, logger logr.Logger, instance
 		Namespace: instance.Namespace,
 	}, configMap); err != nil {
 		if k8sErrors.IsNotFound(err) {
-			configMap = generateConfigMap(instance, confStr)
+			configMap = generateConfigMap(instance, fileConfStr)
 			if err := ctrl.SetControllerReference(instance, configMap, s.Scheme); err != nil {
 				return subResult{err: emperror.Wrap(err, "failed to set controller reference for configMap")}
 			}
@@ -57,7 +100,7 @@ func (s *syncConfig) reconcile(ctx context.Context, logger logr.Logger, instance
 		return subResult{}
 	}
 
-	if lastConfigStr != instance.Spec.Config.Data {
+	if lastConfigStr != instance.Spec.Config.Data || instance.Annotations[appsv2beta1.AnnotationsLastConfigSourcesKey] != sourcesHash {
 		_, coreReady := instance.Status.GetCondition(appsv2beta1.CoreNodesReady)
 		if coreReady == nil || !instance.Status.IsConditionTrue(appsv2beta1.CoreNodesReady) {
 			return subResult{}
@@ -65,32 +108,36 @@ func (s *syncConfig) reconcile(ctx context.Context, logger logr.Logger, instance
 
 		// Delete readonly configs
 		hoconConfigObj := hoconConfig.GetRoot().(hocon.Object)
-		if _, ok := hoconConfigObj["node"]; ok {
-			s.EventRecorder.Event(instance, corev1.EventTypeNormal, "WontUpdateReadOnlyConfig", "Won't update `node` config, because it's readonly config")
-			delete(hoconConfigObj, "node")
-		}
-		if _, ok := hoconConfigObj["cluster"]; ok {
-			s.EventRecorder.Event(instance, corev1.EventTypeNormal, "WontUpdateReadOnlyConfig", "Won't update `cluster` config, because it's readonly config")
-			delete(hoconConfigObj, "cluster")
-		}
-		if _, ok := hoconConfigObj["dashboard"]; ok {
-			s.EventRecorder.Event(instance, corev1.EventTypeNormal, "WontUpdateReadOnlyConfig", "Won't update `dashboard` config, because it's readonly config")
-			delete(hoconConfigObj, "dashboard")
-		}
-		if _, ok := hoconConfigObj["rpc"]; ok {
-			s.EventRecorder.Event(instance, corev1.EventTypeNormal, "WontUpdateReadOnlyConfig", "Won't update `rpc` config, because it's readonly config")
-			delete(hoconConfigObj, "rpc")
+		for _, key := range readOnlyConfigs {
+			if _, ok := hoconConfigObj[key]; ok {
+				if instance.Spec.Config.RestartOnReadOnlyConfigChange {
+					// Applied by replacing the EMQX nodes, see readOnlyConfigHash
+					delete(hoconConfigObj, key)
+					continue
+				}
+				s.EventRecorder.Event(instance, corev1.EventTypeNormal, "WontUpdateReadOnlyConfig", fmt.Sprintf("Won't update `%s` config, because it's readonly config", key))
+				delete(hoconConfigObj, key)
+			}
 		}
 
 		if err := putEMQXConfigsByAPI(r, instance.Spec.Config.Mode, hoconConfigObj.String()); err != nil {
+			metrics.EMQXConfigSyncFailures.WithLabelValues(instance.Namespace, instance.Name).Inc()
 			return subResult{err: emperror.Wrap(err, "failed to put emqx config")}
 		}
+		s.liveConfig.reset()
 
-		if err := s.Client.Update(ctx, generateConfigMap(instance, confStr)); err != nil {
+		if err := s.Client.Update(ctx, generateConfigMap(instance, fileConfStr)); err != nil {
 			return subResult{err: emperror.Wrap(err, "failed to update configMap")}
 		}
 
 		instance.Annotations[appsv2beta1.AnnotationsLastEMQXConfigKey] = instance.Spec.Config.Data
+		if sourcesHash != "" {
+			instance.Annotations[appsv2beta1.AnnotationsLastConfigSourcesKey] = sourcesHash
+		} else {
+			delete(instance.Annotations, appsv2beta1.AnnotationsLastConfigSourcesKey)
+		}
+		// The certificates of the listeners may be overwritten by the config, reload them by syncTLS
+		delete(instance.Annotations, appsv2beta1.AnnotationsLastTLSCertificateKey)
 		if err := s.Client.Update(ctx, instance); err != nil {
 			return subResult{err: emperror.Wrap(err, "failed to update emqx instance annotation")}
 		}
@@ -98,9 +145,467 @@ func (s *syncConfig) reconcile(ctx context.Context, logger logr.Logger, instance
 		return subResult{}
 	}
 
+	// The config map is also changed by ".spec.listeners", they are applied to the running EMQX nodes by syncListeners
+	if !isSameHoconConfig(configMap.Data["emqx.conf"], fileConfStr) {
+		if err := s.Client.Update(ctx, generateConfigMap(instance, fileConfStr)); err != nil {
+			return subResult{err: emperror.Wrap(err, "failed to update configMap")}
+		}
+	}
+
+	// The read-only configs are applied by replacing the EMQX nodes, see readOnlyConfigHash
+	if setReadOnlyConfigHash(instance, computeReadOnlyConfigHashWithSources(confStr, fileConfStr, sourcesHash)) {
+		if err := s.Client.Update(ctx, instance); err != nil {
+			return subResult{err: emperror.Wrap(err, "failed to update emqx instance annotation")}
+		}
+	}
+
+	if r != nil && instance.Status.IsConditionTrue(appsv2beta1.CoreNodesReady) {
+		if err := s.syncConfigDrift(ctx, instance, r, confStr, fileConfStr); err != nil {
+			return subResult{err: emperror.Wrap(err, "failed to sync config drift")}
+		}
+	}
+
 	return subResult{}
 }
 
+// syncConfigDrift compares the live config of EMQX with the desired config, records the drift in status,
+// and re-applies the desired config if ".spec.config.autoCorrectDrift" is true
+func (s *syncConfig) syncConfigDrift(ctx context.Context, instance *appsv2beta1.EMQX, r innerReq.RequesterInterface, confStr, fileConfStr string) error {
+	desired, err := hocon.ParseString(confStr)
+	if err != nil {
+		return emperror.Wrap(err, "failed to parse desired config")
+	}
+	file, err := hocon.ParseString(fileConfStr)
+	if err != nil {
+		return emperror.Wrap(err, "failed to parse desired config")
+	}
+	liveStr, err := s.liveConfig.get(r)
+	if err != nil {
+		return err
+	}
+	live, err := hocon.ParseString(liveStr)
+	if err != nil {
+		return emperror.Wrap(err, "failed to parse live config")
+	}
+	desiredObj, ok := desired.GetRoot().(hocon.Object)
+	if !ok {
+		return nil
+	}
+	liveObj, ok := live.GetRoot().(hocon.Object)
+	if !ok {
+		return nil
+	}
+	for _, key := range readOnlyConfigs {
+		delete(desiredObj, key)
+	}
+
+	drift := diffHoconConfig(desiredObj, liveObj, "")
+	// The values from Secrets are not recorded in status
+	for i := range drift {
+		value := lookupHoconValue(file.GetRoot(), drift[i].Path)
+		if value == nil || normalizeHoconValue("", value.String()) != drift[i].Desired {
+			drift[i].Desired = redactedConfigValue
+			drift[i].Live = redactedConfigValue
+		}
+	}
+	if len(drift) > 0 && instance.Spec.Config.AutoCorrectDrift {
+		if err := putEMQXConfigsByAPI(r, instance.Spec.Config.Mode, desiredObj.String()); err != nil {
+			metrics.EMQXConfigSyncFailures.WithLabelValues(instance.Namespace, instance.Name).Inc()
+			return emperror.Wrap(err, "failed to put emqx config")
+		}
+		s.liveConfig.reset()
+		s.EventRecorder.Event(instance, corev1.EventTypeNormal, "ConfigDriftCorrected", fmt.Sprintf("Re-apply the config, because %d config items are changed in EMQX", len(drift)))
+		drift = nil
+	}
+
+	_, condition := instance.Status.GetCondition(appsv2beta1.ConfigDrifted)
+	drifted := len(drift) > 0
+	if condition == nil && !drifted {
+		return nil
+	}
+	if len(drift) > maxConfigDriftItems {
+		drift = drift[:maxConfigDriftItems]
+	}
+	if condition != nil && (condition.Status == metav1.ConditionTrue) == drifted && equality.Semantic.DeepEqual(instance.Status.ConfigDrift, drift) {
+		return nil
+	}
+
+	if drifted {
+		if condition == nil || condition.Status != metav1.ConditionTrue {
+			s.EventRecorder.Event(instance, corev1.EventTypeWarning, "ConfigDrifted", "The config of EMQX is different from `.spec.config.data`")
+		}
+		instance.Status.SetCondition(metav1.Condition{
+			Type:    appsv2beta1.ConfigDrifted,
+			Status:  metav1.ConditionTrue,
+			Reason:  "ConfigDrifted",
+			Message: "The config of EMQX is different from `.spec.config.data`",
+		})
+	} else {
+		instance.Status.SetCondition(metav1.Condition{
+			Type:    appsv2beta1.ConfigDrifted,
+			Status:  metav1.ConditionFalse,
+			Reason:  "ConfigInSync",
+			Message: "The config of EMQX is the same as `.spec.config.data`",
+		})
+	}
+	instance.Status.ConfigDrift = drift
+	return s.Client.Status().Update(ctx, instance)
+}
+
+// diffHoconConfig returns the config items whose values in the live config are different from the desired config,
+// the items which are not in the live config are ignored, because EMQX doesn't return the unknown or hidden configs
+func diffHoconConfig(desired, live hocon.Object, prefix string) []appsv2beta1.ConfigDriftItem {
+	keys := make([]string, 0, len(desired))
+	for key := range desired {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var drift []appsv2beta1.ConfigDriftItem
+	for _, key := range keys {
+		path := key
+		if prefix != "" {
+			path = prefix + "." + key
+		}
+		liveValue, ok := live[key]
+		if !ok || liveValue == nil || desired[key] == nil {
+			continue
+		}
+		switch desiredValue := desired[key].(type) {
+		case hocon.Object:
+			if liveObj, ok := liveValue.(hocon.Object); ok {
+				drift = append(drift, diffHoconConfig(desiredValue, liveObj, path)...)
+			}
+		case hocon.Array:
+			// The objects in array are filled with the default values by EMQX, like authentication, so skip them
+			if slices.ContainsFunc(desiredValue, func(v hocon.Value) bool { return v.Type() == hocon.ObjectType }) {
+				continue
+			}
+			if d, l := desiredValue.String(), liveValue.String(); d != l {
+				drift = append(drift, appsv2beta1.ConfigDriftItem{Path: path, Desired: d, Live: l})
+			}
+		default:
+			// The sensitive configs are redacted by EMQX, like the passwords
+			d, l := normalizeHoconValue(key, desiredValue.String()), normalizeHoconValue(key, liveValue.String())
+			if canonicalHoconValue(d) != canonicalHoconValue(l) && l != redactedConfigValue {
+				drift = append(drift, appsv2beta1.ConfigDriftItem{Path: path, Desired: d, Live: l})
+			}
+		}
+	}
+	return drift
+}
+
+func lookupHoconValue(value hocon.Value, path string) hocon.Value {
+	for _, key := range strings.Split(path, ".") {
+		obj, ok := value.(hocon.Object)
+		if !ok {
+			return nil
+		}
+		if value, ok = obj[key]; !ok {
+			return nil
+		}
+	}
+	return value
+}
+
+func normalizeHoconValue(key, value string) string {
+	// The concatenation of go-hocon is quoted by each part, like 1""MB, so remove all of the quotes
+	value = strings.ReplaceAll(value, `"`, "")
+	// EMQX returns the bind of listeners with the address, like "0.0.0.0:1883"
+	if key == "bind" {
+		value = strings.TrimPrefix(value, "0.0.0.0")
+		value = strings.TrimPrefix(value, ":")
+	}
+	return value
+}
+
+// canonicalHoconValue converts the sizes to bytes and the durations to milliseconds, because EMQX returns them
+// in its own format, like "1024KB" is returned as "1MB" and "60s" is returned as "1m"
+func canonicalHoconValue(value string) string {
+	lower := strings.ToLower(value)
+	if match := hoconSizeRegexp.FindStringSubmatch(lower); match != nil {
+		number, _ := strconv.ParseFloat(match[1], 64)
+		return strconv.FormatFloat(number*hoconSizeUnits[match[2]], 'f', -1, 64) + "B"
+	}
+	if hoconDurationRegexp.MatchString(lower) {
+		total := float64(0)
+		for _, match := range hoconDurationPartRegexp.FindAllStringSubmatch(lower, -1) {
+			number, _ := strconv.ParseFloat(match[1], 64)
+			total += number * hoconDurationUnits[match[2]]
+		}
+		return strconv.FormatFloat(total, 'f', -1, 64) + "ms"
+	}
+	return value
+}
+
+// isSameHoconConfig compares the parsed configs, the keys order of the HOCON string is not stable
+func isSameHoconConfig(configStr1, configStr2 string) bool {
+	c1, err := hocon.ParseString(configStr1)
+	if err != nil {
+		return false
+	}
+	c2, err := hocon.ParseString(configStr2)
+	if err != nil {
+		return false
+	}
+	return reflect.DeepEqual(c1.GetRoot(), c2.GetRoot())
+}
+
+// renderConfig returns the config applied through the API of EMQX, and the config written to the config map,
+// the latter doesn't contain the configs from Secrets and the configs with secret placeholders.
+// The configs from ".spec.config.from" and Secrets can be changed without changing ".spec.config.data",
+// so it also returns the hash of the versions of the referenced ConfigMaps and Secrets, it is empty if nothing is referenced
+func (s *syncConfig) renderConfig(ctx context.Context, instance *appsv2beta1.EMQX) (apiConfig, fileConfig, sourcesHash string, err error) {
+	versions := []string{}
+	readSecret := func(ref appsv2beta1.KeyRef) (string, error) {
+		secret := &corev1.Secret{}
+		if err := s.Client.Get(ctx, types.NamespacedName{Namespace: instance.Namespace, Name: ref.SecretName}, secret); err != nil {
+			return "", emperror.Wrap(err, "failed to get secret")
+		}
+		if _, ok := secret.Data[ref.SecretKey]; !ok {
+			return "", emperror.NewWithDetails("secret does not contain the key", "secret", secret.Name, "key", ref.SecretKey)
+		}
+		versions = append(versions, fmt.Sprintf("Secret/%s/%s/%s", secret.UID, secret.ResourceVersion, ref.SecretKey))
+		return string(secret.Data[ref.SecretKey]), nil
+	}
+
+	for _, source := range instance.Spec.Config.From {
+		switch {
+		case source.ConfigMapKeyRef != nil:
+			ref := source.ConfigMapKeyRef
+			configMap := &corev1.ConfigMap{}
+			if err := s.Client.Get(ctx, types.NamespacedName{Namespace: instance.Namespace, Name: ref.Name}, configMap); err != nil {
+				if k8sErrors.IsNotFound(err) && ref.Optional != nil && *ref.Optional {
+					continue
+				}
+				return "", "", "", emperror.Wrapf(err, "failed to get configMap %s", ref.Name)
+			}
+			data, ok := configMap.Data[ref.Key]
+			if !ok {
+				if ref.Optional != nil && *ref.Optional {
+					continue
+				}
+				return "", "", "", emperror.NewWithDetails("configMap does not contain the key", "configMap", ref.Name, "key", ref.Key)
+			}
+			versions = append(versions, fmt.Sprintf("ConfigMap/%s/%s/%s", configMap.UID, configMap.ResourceVersion, ref.Key))
+			apiConfig += data + "\n"
+			fileConfig += data + "\n"
+		case source.SecretKeyRef != nil:
+			ref := source.SecretKeyRef
+			data, err := readSecret(appsv2beta1.KeyRef{SecretName: ref.Name, SecretKey: ref.Key})
+			if err != nil {
+				if ref.Optional != nil && *ref.Optional {
+					continue
+				}
+				return "", "", "", err
+			}
+			apiConfig += data + "\n"
+		}
+	}
+	if len(instance.Spec.Config.From) == 0 {
+		// Keep the config as it is, so that it is the same as ".spec.config.data" if there are no placeholders
+		apiConfig, fileConfig = instance.Spec.Config.Data, instance.Spec.Config.Data
+	} else {
+		apiConfig += instance.Spec.Config.Data
+		fileConfig += instance.Spec.Config.Data
+	}
+
+	apiConfig, err = resolveSecretPlaceholders(apiConfig, readSecret)
+	if err != nil {
+		return "", "", "", err
+	}
+	if _, err := hocon.ParseString(apiConfig); err != nil {
+		return "", "", "", emperror.Wrap(err, "the config is not a valid HOCON config")
+	}
+	if len(versions) > 0 {
+		sourcesHash = computeConfigHash(strings.Join(versions, ","))
+	}
+	return apiConfig, redactSecretPlaceholders(fileConfig), sourcesHash, nil
+}
+
+// resolveSecretPlaceholders replaces the placeholders like "${secret:<name>/<key>}" in the quoted strings with the escaped values of the Secrets
+func resolveSecretPlaceholders(config string, readSecret func(appsv2beta1.KeyRef) (string, error)) (string, error) {
+	var err error
+	resolved := secretPlaceholderRegexp.ReplaceAllStringFunc(config, func(placeholder string) string {
+		match := secretPlaceholderRegexp.FindStringSubmatch(placeholder)
+		value, e := readSecret(appsv2beta1.KeyRef{SecretName: match[1], SecretKey: match[2]})
+		if e != nil {
+			err = e
+			return placeholder
+		}
+		buf := &bytes.Buffer{}
+		encoder := json.NewEncoder(buf)
+		encoder.SetEscapeHTML(false)
+		_ = encoder.Encode(value)
+		quoted := strings.TrimSpace(buf.String())
+		return quoted[1 : len(quoted)-1]
+	})
+	return resolved, err
+}
+
+// redactSecretPlaceholders removes the configs with secret placeholders
+func redactSecretPlaceholders(config string) string {
+	if !secretPlaceholderRegexp.MatchString(config) {
+		return config
+	}
+	hoconConfig, err := hocon.ParseString(config)
+	if err != nil {
+		return config
+	}
+	var redact func(obj hocon.Object)
+	redact = func(obj hocon.Object) {
+		for key, value := range obj {
+			if o, ok := value.(hocon.Object); ok {
+				redact(o)
+				continue
+			}
+			if value != nil && secretPlaceholderRegexp.MatchString(value.String()) {
+				delete(obj, key)
+			}
+		}
+	}
+	root, ok := hoconConfig.GetRoot().(hocon.Object)
+	if !ok {
+		return config
+	}
+	redact(root)
+	return root.String()
+}
+
+// syncConfigSecret writes the rendered config to the Secret mounted by the EMQX nodes if the config contains the values of Secrets,
+// so that the new and restarted EMQX nodes boot with them. The Secret is deleted if no Secret is referenced by the config.
+func (s *syncConfig) syncConfigSecret(ctx context.Context, instance *appsv2beta1.EMQX, confStr string) error {
+	secret := &corev1.Secret{}
+	if err := s.Client.Get(ctx, instance.SecretConfigsNamespacedName(), secret); err != nil {
+		if !k8sErrors.IsNotFound(err) {
+			return emperror.Wrap(err, "failed to get secret")
+		}
+		if !usesSecretConfig(instance) {
+			return nil
+		}
+		secret = generateConfigSecret(instance, confStr)
+		if err := ctrl.SetControllerReference(instance, secret, s.Scheme); err != nil {
+			return emperror.Wrap(err, "failed to set controller reference for secret")
+		}
+		return emperror.Wrap(s.Client.Create(ctx, secret), "failed to create secret")
+	}
+
+	if !usesSecretConfig(instance) {
+		return emperror.Wrap(client.IgnoreNotFound(s.Client.Delete(ctx, secret)), "failed to delete secret")
+	}
+	if isSameHoconConfig(string(secret.Data["emqx.conf"]), confStr) {
+		return nil
+	}
+	newSecret := generateConfigSecret(instance, confStr)
+	if err := ctrl.SetControllerReference(instance, newSecret, s.Scheme); err != nil {
+		return emperror.Wrap(err, "failed to set controller reference for secret")
+	}
+	newSecret.ResourceVersion = secret.ResourceVersion
+	return emperror.Wrap(s.Client.Update(ctx, newSecret), "failed to update secret")
+}
+
+// usesSecretConfig returns true if the config of EMQX contains the values of Secrets,
+// from ".spec.config.from[].secretKeyRef" or the placeholders in ".spec.config.data"
+func usesSecretConfig(instance *appsv2beta1.EMQX) bool {
+	for _, source := range instance.Spec.Config.From {
+		if source.SecretKeyRef != nil {
+			return true
+		}
+	}
+	return secretPlaceholderRegexp.MatchString(instance.Spec.Config.Data)
+}
+
+// configVolumeSource returns the volume of the config file of the EMQX nodes. The config map doesn't contain the values of Secrets,
+// so the config is mounted from the Secret written by syncConfig if the config contains them.
+func configVolumeSource(instance *appsv2beta1.EMQX) corev1.VolumeSource {
+	if usesSecretConfig(instance) {
+		return corev1.VolumeSource{
+			Secret: &corev1.SecretVolumeSource{
+				SecretName: instance.SecretConfigsNamespacedName().Name,
+			},
+		}
+	}
+	return corev1.VolumeSource{
+		ConfigMap: &corev1.ConfigMapVolumeSource{
+			LocalObjectReference: corev1.LocalObjectReference{
+				Name: instance.ConfigsNamespacedName().Name,
+			},
+		},
+	}
+}
+
+// readOnlyConfigHash returns the hash of the read-only configs that have been written to the mounted config, it is added to the pod template
+// so that a new revision of the EMQX nodes is created when they are changed. The annotation of the hash is updated by syncConfig
+// after the config map, so the new EMQX nodes always mount the new config. It is empty until the read-only configs are changed
+// after ".spec.config.restartOnReadOnlyConfigChange" is enabled, so enabling it doesn't create a new revision.
+func readOnlyConfigHash(instance *appsv2beta1.EMQX) string {
+	if !instance.Spec.Config.RestartOnReadOnlyConfigChange {
+		return ""
+	}
+	hash := instance.Annotations[appsv2beta1.AnnotationsLastReadOnlyConfigHashKey]
+	if hash == instance.Annotations[appsv2beta1.AnnotationsInitialReadOnlyConfigHashKey] {
+		return ""
+	}
+	return hash
+}
+
+// setReadOnlyConfigHash records the hash of the read-only configs in the annotations of EMQX, the first hash after
+// ".spec.config.restartOnReadOnlyConfigChange" is enabled is also recorded as the initial one. It returns true if the annotations are changed.
+func setReadOnlyConfigHash(instance *appsv2beta1.EMQX, hash string) bool {
+	if !instance.Spec.Config.RestartOnReadOnlyConfigChange {
+		_, ok1 := instance.Annotations[appsv2beta1.AnnotationsLastReadOnlyConfigHashKey]
+		_, ok2 := instance.Annotations[appsv2beta1.AnnotationsInitialReadOnlyConfigHashKey]
+		delete(instance.Annotations, appsv2beta1.AnnotationsLastReadOnlyConfigHashKey)
+		delete(instance.Annotations, appsv2beta1.AnnotationsInitialReadOnlyConfigHashKey)
+		return ok1 || ok2
+	}
+
+	changed := false
+	if _, ok := instance.Annotations[appsv2beta1.AnnotationsInitialReadOnlyConfigHashKey]; !ok {
+		instance.Annotations[appsv2beta1.AnnotationsInitialReadOnlyConfigHashKey] = hash
+		changed = true
+	}
+	if last, ok := instance.Annotations[appsv2beta1.AnnotationsLastReadOnlyConfigHashKey]; !ok || last != hash {
+		instance.Annotations[appsv2beta1.AnnotationsLastReadOnlyConfigHashKey] = hash
+		changed = true
+	}
+	return changed
+}
+
+// computeReadOnlyConfigHashWithSources returns the hash of the read-only configs of the rendered config. The values from Secrets
+// are not hashed, if they contain read-only configs, the hash of the versions of the config sources is mixed in instead.
+func computeReadOnlyConfigHashWithSources(confStr, fileConfStr, sourcesHash string) string {
+	hash := computeReadOnlyConfigHash(fileConfStr)
+	if computeReadOnlyConfigHash(confStr) != hash {
+		return computeConfigHash(hash + "," + sourcesHash)
+	}
+	return hash
+}
+
+// computeReadOnlyConfigHash returns the hash of the read-only configs of the rendered config, it is empty if there are no read-only configs
+func computeReadOnlyConfigHash(config string) string {
+	hoconConfig, err := hocon.ParseString(config)
+	if err != nil {
+		return ""
+	}
+	root, ok := hoconConfig.GetRoot().(hocon.Object)
+	if !ok {
+		return ""
+	}
+	readOnly := map[string]hocon.Value{}
+	for _, key := range readOnlyConfigs {
+		if value, ok := root[key]; ok {
+			readOnly[key] = value
+		}
+	}
+	if len(readOnly) == 0 {
+		return ""
+	}
+	hasher := fnv.New32a()
+	deepHashObject(hasher, readOnly)
+	return rand.SafeEncodeString(fmt.Sprint(hasher.Sum32()))
+}
+
 func generateConfigMap(instance *appsv2beta1.EMQX, data string) *corev1.ConfigMap {
 	return &corev1.ConfigMap{
 		TypeMeta: metav1.TypeMeta{
@@ -118,6 +623,23 @@ func generateConfigMap(instance *appsv2beta1.EMQX, data string) *corev1.ConfigMa
 	}
 }
 
+func generateConfigSecret(instance *appsv2beta1.EMQX, data string) *corev1.Secret {
+	return &corev1.Secret{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "Secret",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      instance.SecretConfigsNamespacedName().Name,
+			Namespace: instance.Namespace,
+			Labels:    appsv2beta1.CloneAndMergeMap(appsv2beta1.DefaultLabels(instance), instance.Labels),
+		},
+		Data: map[string][]byte{
+			"emqx.conf": []byte(data),
+		},
+	}
+}
+
 func putEMQXConfigsByAPI(r innerReq.RequesterInterface, mode, config string) error {
 	url := r.GetURL("api/v5/configs", "mode="+strings.ToLower(mode))
 
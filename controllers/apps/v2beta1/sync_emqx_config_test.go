@@ -2,9 +2,24 @@ package v2beta1
 
 import (
 	"fmt"
+	"net/http"
+	"net/url"
 	"testing"
 
+	appsv2beta1 "github.com/emqx/emqx-operator/apis/apps/v2beta1"
+	"github.com/emqx/emqx-operator/internal/handler"
+	innerReq "github.com/emqx/emqx-operator/internal/requester"
+	"github.com/rory-z/go-hocon"
 	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 )
 
 func TestMergeDefaultConfig(t *testing.T) {
@@ -31,3 +46,452 @@ func TestMergeDefaultConfig(t *testing.T) {
 		assert.Equal(t, "38084", got.GetString("listeners.wss.default.bind"))
 	})
 }
+
+func TestIsSameHoconConfig(t *testing.T) {
+	config := mergeDefaultConfig("listeners.tcp.internal.bind = 11883\nnode.cookie = emqx")
+	assert.True(t, isSameHoconConfig(config.String(), config.String()))
+	assert.True(t, isSameHoconConfig(config.String(), mergeDefaultConfig("node.cookie = emqx\nlisteners.tcp.internal.bind = 11883").String()))
+	assert.False(t, isSameHoconConfig(config.String(), mergeDefaultConfig("node.cookie = emqx").String()))
+	assert.False(t, isSameHoconConfig("", config.String()))
+}
+
+func TestDiffHoconConfig(t *testing.T) {
+	desired, _ := hocon.ParseString(`
+listeners.tcp.default.bind = 1883
+mqtt.max_packet_size = 1MB
+mqtt.retry_interval = 30s
+log.console.level = info
+authentication = [{mechanism = password_based, backend = built_in_database}]
+unknown.key = value
+`)
+	live, _ := hocon.ParseString(`
+listeners.tcp.default.bind = "0.0.0.0:1883"
+listeners.tcp.default.max_connections = infinity
+mqtt.max_packet_size = 2MB
+mqtt.retry_interval = 30s
+log.console.level = "info"
+authentication = [{mechanism = password_based, backend = built_in_database, enable = true}]
+`)
+
+	got := diffHoconConfig(desired.GetRoot().(hocon.Object), live.GetRoot().(hocon.Object), "")
+	assert.Equal(t, []appsv2beta1.ConfigDriftItem{
+		{Path: "mqtt.max_packet_size", Desired: "1MB", Live: "2MB"},
+	}, got)
+}
+
+func TestSyncConfigDrift(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = appsv2beta1.AddToScheme(scheme)
+
+	instance := &appsv2beta1.EMQX{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "emqx",
+			Namespace: "emqx",
+		},
+		Spec: appsv2beta1.EMQXSpec{
+			Config: appsv2beta1.Config{
+				Mode: "Merge",
+			},
+		},
+	}
+	confStr := mergeDefaultConfig("mqtt.max_packet_size = 1MB\nnode.cookie = emqx").String()
+
+	liveConfig := "mqtt.max_packet_size = 2MB\nnode.cookie = changed"
+	var putBody string
+	var getCount int
+	requester := &innerReq.FakeRequester{
+		ReqFunc: func(method string, url url.URL, body []byte, header http.Header) (*http.Response, []byte, error) {
+			if method == "PUT" {
+				putBody = string(body)
+				return &http.Response{StatusCode: 200}, nil, nil
+			}
+			getCount++
+			return &http.Response{StatusCode: 200}, []byte(liveConfig), nil
+		},
+	}
+
+	t.Run("detect drift", func(t *testing.T) {
+		emqx := instance.DeepCopy()
+		s := &syncConfig{&EMQXReconciler{
+			Handler:       &handler.Handler{Client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(emqx).WithStatusSubresource(emqx).Build()},
+			EventRecorder: record.NewFakeRecorder(10),
+		}, &emqxLiveConfig{}}
+		assert.NoError(t, s.syncConfigDrift(ctx, emqx, requester, confStr, confStr))
+		assert.True(t, emqx.Status.IsConditionTrue(appsv2beta1.ConfigDrifted))
+		assert.Equal(t, []appsv2beta1.ConfigDriftItem{
+			{Path: "mqtt.max_packet_size", Desired: "1MB", Live: "2MB"},
+		}, emqx.Status.ConfigDrift)
+		assert.Empty(t, putBody)
+
+		liveConfig = "mqtt.max_packet_size = 1MB"
+		s.liveConfig.reset()
+		assert.NoError(t, s.syncConfigDrift(ctx, emqx, requester, confStr, confStr))
+		_, condition := emqx.Status.GetCondition(appsv2beta1.ConfigDrifted)
+		assert.Equal(t, metav1.ConditionFalse, condition.Status)
+		assert.Nil(t, emqx.Status.ConfigDrift)
+	})
+
+	t.Run("redact the values from secrets", func(t *testing.T) {
+		liveConfig = "mqtt.max_packet_size = 2MB"
+		emqx := instance.DeepCopy()
+		s := &syncConfig{&EMQXReconciler{
+			Handler:       &handler.Handler{Client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(emqx).WithStatusSubresource(emqx).Build()},
+			EventRecorder: record.NewFakeRecorder(10),
+		}, &emqxLiveConfig{}}
+		assert.NoError(t, s.syncConfigDrift(ctx, emqx, requester, confStr, ""))
+		assert.Equal(t, []appsv2beta1.ConfigDriftItem{
+			{Path: "mqtt.max_packet_size", Desired: "******", Live: "******"},
+		}, emqx.Status.ConfigDrift)
+	})
+
+	t.Run("correct drift", func(t *testing.T) {
+		liveConfig = "mqtt.max_packet_size = 2MB"
+		emqx := instance.DeepCopy()
+		emqx.Spec.Config.AutoCorrectDrift = true
+		s := &syncConfig{&EMQXReconciler{
+			Handler:       &handler.Handler{Client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(emqx).WithStatusSubresource(emqx).Build()},
+			EventRecorder: record.NewFakeRecorder(10),
+		}, &emqxLiveConfig{}}
+		assert.NoError(t, s.syncConfigDrift(ctx, emqx, requester, confStr, confStr))
+		assert.Contains(t, putBody, "max_packet_size")
+		assert.NotContains(t, putBody, "cookie")
+		assert.Nil(t, emqx.Status.GetLastTrueCondition())
+		assert.False(t, emqx.Status.IsConditionTrue(appsv2beta1.ConfigDrifted))
+	})
+
+	t.Run("values canonicalized by EMQX", func(t *testing.T) {
+		confStr := mergeDefaultConfig("mqtt.max_packet_size = 1024KB\nmqtt.idle_timeout = 60s\nmqtt.retry_interval = 90m").String()
+		liveConfig = "mqtt.max_packet_size = 1MB\nmqtt.idle_timeout = 1m\nmqtt.retry_interval = 5400s"
+		putBody, getCount = "", 0
+		emqx := instance.DeepCopy()
+		emqx.Spec.Config.AutoCorrectDrift = true
+		s := &syncConfig{&EMQXReconciler{
+			Handler:       &handler.Handler{Client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(emqx).WithStatusSubresource(emqx).Build()},
+			EventRecorder: record.NewFakeRecorder(10),
+		}, &emqxLiveConfig{}}
+		assert.NoError(t, s.syncConfigDrift(ctx, emqx, requester, confStr, confStr))
+		assert.Empty(t, putBody)
+		assert.Nil(t, emqx.Status.ConfigDrift)
+
+		// The live config is fetched once in a reconcile, it is shared with addSvc
+		configStr, err := s.liveConfig.get(requester)
+		assert.NoError(t, err)
+		assert.Equal(t, liveConfig, configStr)
+		assert.Equal(t, 1, getCount)
+	})
+}
+
+func TestCanonicalHoconValue(t *testing.T) {
+	assert.Equal(t, canonicalHoconValue("1MB"), canonicalHoconValue("1024KB"))
+	assert.Equal(t, canonicalHoconValue("1mb"), canonicalHoconValue("1048576B"))
+	assert.NotEqual(t, canonicalHoconValue("1MB"), canonicalHoconValue("1GB"))
+	assert.Equal(t, canonicalHoconValue("1m"), canonicalHoconValue("60s"))
+	// The durations are formatted by go-hocon like time.Duration
+	assert.Equal(t, canonicalHoconValue("1h30m0s"), canonicalHoconValue("5400s"))
+	assert.Equal(t, canonicalHoconValue("1d"), canonicalHoconValue("24h"))
+	assert.Equal(t, canonicalHoconValue("1.5s"), canonicalHoconValue("1500ms"))
+	assert.NotEqual(t, canonicalHoconValue("1m"), canonicalHoconValue("1mb"))
+	assert.Equal(t, "1883", canonicalHoconValue("1883"))
+	assert.Equal(t, "info", canonicalHoconValue("info"))
+}
+
+func TestResolveSecretPlaceholders(t *testing.T) {
+	got, err := resolveSecretPlaceholders(`password = "${secret:mysql/password}"
+server = "mysql://${secret:mysql/username}@mysql:3306"`, func(ref appsv2beta1.KeyRef) (string, error) {
+		if ref.SecretKey == "password" {
+			return `pa"ss<>`, nil
+		}
+		return "emqx", nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, `password = "pa\"ss<>"
+server = "mysql://emqx@mysql:3306"`, got)
+
+	_, err = resolveSecretPlaceholders(`password = "${secret:not-found/password}"`, fakeReadSecret)
+	assert.ErrorContains(t, err, "failed to get secret")
+}
+
+func TestRedactSecretPlaceholders(t *testing.T) {
+	got := redactSecretPlaceholders(`
+mqtt.max_packet_size = "1MB"
+authorization.sources.mysql.password = "${secret:mysql/password}"
+`)
+	assert.True(t, isSameHoconConfig(`mqtt.max_packet_size = "1MB", authorization.sources.mysql {}`, got))
+	assert.NotContains(t, got, "secret")
+
+	got = redactSecretPlaceholders(`
+mqtt.max_packet_size = "1MB"
+authentication = [{backend = "mysql", password = "${secret:mysql/password}"}]
+`)
+	assert.True(t, isSameHoconConfig(`mqtt.max_packet_size = "1MB"`, got))
+
+	assert.Equal(t, "mqtt.max_packet_size = 1MB", redactSecretPlaceholders("mqtt.max_packet_size = 1MB"))
+}
+
+func TestRenderConfig(t *testing.T) {
+	instance := &appsv2beta1.EMQX{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "emqx",
+			Namespace: "emqx",
+		},
+		Spec: appsv2beta1.EMQXSpec{
+			Config: appsv2beta1.Config{
+				Data: `mqtt.max_packet_size = "1MB"
+authentication.password = "${secret:auth/password}"`,
+				From: []appsv2beta1.ConfigSource{
+					{ConfigMapKeyRef: &corev1.ConfigMapKeySelector{
+						LocalObjectReference: corev1.LocalObjectReference{Name: "emqx-config"},
+						Key:                  "emqx.conf",
+					}},
+					{SecretKeyRef: &corev1.SecretKeySelector{
+						LocalObjectReference: corev1.LocalObjectReference{Name: "emqx-secret-config"},
+						Key:                  "emqx.conf",
+					}},
+					{SecretKeyRef: &corev1.SecretKeySelector{
+						LocalObjectReference: corev1.LocalObjectReference{Name: "not-found"},
+						Key:                  "emqx.conf",
+						Optional:             ptr.To(true),
+					}},
+				},
+			},
+		},
+	}
+	s := &syncConfig{&EMQXReconciler{
+		Handler: &handler.Handler{Client: fake.NewClientBuilder().WithObjects(
+			&corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: "emqx-config", Namespace: "emqx"},
+				Data:       map[string]string{"emqx.conf": "log.console.level = debug"},
+			},
+			&corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "emqx-secret-config", Namespace: "emqx"},
+				Data:       map[string][]byte{"emqx.conf": []byte(`bridges.mqtt.test.password = "secret"`)},
+			},
+			&corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "auth", Namespace: "emqx"},
+				Data:       map[string][]byte{"password": []byte("public")},
+			},
+		).Build()},
+	}, &emqxLiveConfig{}}
+
+	apiConfig, fileConfig, sourcesHash, err := s.renderConfig(ctx, instance)
+	assert.NoError(t, err)
+	assert.True(t, isSameHoconConfig(`
+log.console.level = debug
+bridges.mqtt.test.password = "secret"
+mqtt.max_packet_size = "1MB"
+authentication.password = "public"
+`, apiConfig))
+	assert.True(t, isSameHoconConfig(`
+log.console.level = debug
+mqtt.max_packet_size = "1MB"
+authentication {}
+`, fileConfig))
+
+	assert.NotEmpty(t, sourcesHash)
+
+	// The hash is changed by the versions of the referenced objects, not their data
+	secret := &corev1.Secret{}
+	assert.NoError(t, s.Client.Get(ctx, types.NamespacedName{Namespace: "emqx", Name: "auth"}, secret))
+	secret.Labels = map[string]string{"foo": "bar"}
+	assert.NoError(t, s.Client.Update(ctx, secret))
+	_, _, got, err := s.renderConfig(ctx, instance)
+	assert.NoError(t, err)
+	assert.NotEqual(t, sourcesHash, got)
+	assert.NotEqual(t, computeConfigHash(apiConfig), got)
+
+	// Nothing is referenced
+	_, _, got, err = s.renderConfig(ctx, &appsv2beta1.EMQX{
+		ObjectMeta: metav1.ObjectMeta{Name: "emqx", Namespace: "emqx"},
+		Spec:       appsv2beta1.EMQXSpec{Config: appsv2beta1.Config{Data: "mqtt.max_packet_size = 1MB"}},
+	})
+	assert.NoError(t, err)
+	assert.Empty(t, got)
+
+	instance.Spec.Config.From[0].ConfigMapKeyRef.Name = "not-found"
+	_, _, _, err = s.renderConfig(ctx, instance)
+	assert.ErrorContains(t, err, "failed to get configMap not-found")
+}
+
+func TestReadOnlyConfigHash(t *testing.T) {
+	hash := computeReadOnlyConfigHash(`
+node.cookie = "emqx"
+rpc.port_discovery = manual
+mqtt.max_packet_size = "1MB"
+`)
+	assert.NotEmpty(t, hash)
+	for i := 0; i < 10; i++ {
+		assert.Equal(t, hash, computeReadOnlyConfigHash(`node.cookie = "emqx", rpc.port_discovery = manual`))
+	}
+	// Not changed by the writable configs
+	assert.Equal(t, hash, computeReadOnlyConfigHash(`
+rpc.port_discovery = manual
+node.cookie = "emqx"
+mqtt.max_packet_size = "2MB"
+`))
+	assert.NotEqual(t, hash, computeReadOnlyConfigHash(`node.cookie = "emqx", rpc.port_discovery = stateless`))
+	assert.Empty(t, computeReadOnlyConfigHash(`mqtt.max_packet_size = "1MB"`))
+
+	instance := &appsv2beta1.EMQX{
+		ObjectMeta: metav1.ObjectMeta{
+			Annotations: map[string]string{appsv2beta1.AnnotationsLastReadOnlyConfigHashKey: hash},
+		},
+	}
+	assert.Empty(t, readOnlyConfigHash(instance))
+	instance.Spec.Config.RestartOnReadOnlyConfigChange = true
+	assert.Equal(t, hash, readOnlyConfigHash(instance))
+	// Not added to the pod template until the read-only configs are changed
+	instance.Annotations[appsv2beta1.AnnotationsInitialReadOnlyConfigHashKey] = hash
+	assert.Empty(t, readOnlyConfigHash(instance))
+
+	// The read-only configs from Secrets are hashed by the versions of the sources
+	fileConfStr := `rpc.port_discovery = manual`
+	confStr := `node.cookie = "secret", rpc.port_discovery = manual`
+	assert.Equal(t, computeReadOnlyConfigHash(fileConfStr), computeReadOnlyConfigHashWithSources(fileConfStr, fileConfStr, "v1"))
+	got := computeReadOnlyConfigHashWithSources(confStr, fileConfStr, "v1")
+	assert.NotEqual(t, computeReadOnlyConfigHash(fileConfStr), got)
+	assert.NotEqual(t, computeReadOnlyConfigHash(confStr), got)
+	assert.Equal(t, got, computeReadOnlyConfigHashWithSources(`node.cookie = "changed", rpc.port_discovery = manual`, fileConfStr, "v1"))
+	assert.NotEqual(t, got, computeReadOnlyConfigHashWithSources(confStr, fileConfStr, "v2"))
+}
+
+func TestSetReadOnlyConfigHash(t *testing.T) {
+	instance := &appsv2beta1.EMQX{
+		ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{}},
+		Spec: appsv2beta1.EMQXSpec{
+			Config: appsv2beta1.Config{RestartOnReadOnlyConfigChange: true},
+		},
+	}
+	// Seeded with the current hash
+	assert.True(t, setReadOnlyConfigHash(instance, "hash"))
+	assert.False(t, setReadOnlyConfigHash(instance, "hash"))
+	assert.Empty(t, readOnlyConfigHash(instance))
+
+	assert.True(t, setReadOnlyConfigHash(instance, "changed"))
+	assert.Equal(t, "changed", readOnlyConfigHash(instance))
+	assert.Equal(t, "hash", instance.Annotations[appsv2beta1.AnnotationsInitialReadOnlyConfigHashKey])
+
+	// The read-only configs are removed
+	assert.True(t, setReadOnlyConfigHash(instance, ""))
+	assert.Empty(t, readOnlyConfigHash(instance))
+	assert.Contains(t, instance.Annotations, appsv2beta1.AnnotationsLastReadOnlyConfigHashKey)
+
+	instance.Spec.Config.RestartOnReadOnlyConfigChange = false
+	assert.True(t, setReadOnlyConfigHash(instance, "changed"))
+	assert.False(t, setReadOnlyConfigHash(instance, "changed"))
+	assert.Empty(t, instance.Annotations)
+}
+
+func TestSyncConfigReadOnlyConfigHash(t *testing.T) {
+	instance := &appsv2beta1.EMQX{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "emqx",
+			Namespace: "emqx",
+		},
+		Spec: appsv2beta1.EMQXSpec{
+			Config: appsv2beta1.Config{
+				Data: `mqtt.max_packet_size = "1MB"`,
+				From: []appsv2beta1.ConfigSource{
+					{ConfigMapKeyRef: &corev1.ConfigMapKeySelector{
+						LocalObjectReference: corev1.LocalObjectReference{Name: "emqx-config"},
+						Key:                  "emqx.conf",
+					}},
+				},
+				RestartOnReadOnlyConfigChange: true,
+			},
+		},
+	}
+	source := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "emqx-config", Namespace: "emqx"},
+		Data:       map[string]string{"emqx.conf": `node.cookie = "emqx"`},
+	}
+	scheme := runtime.NewScheme()
+	_ = appsv2beta1.AddToScheme(scheme)
+	_ = corev1.AddToScheme(scheme)
+	s := &syncConfig{&EMQXReconciler{
+		Handler:       &handler.Handler{Client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(instance, source).Build()},
+		EventRecorder: record.NewFakeRecorder(10),
+	}, &emqxLiveConfig{}}
+
+	render := func(emqx *appsv2beta1.EMQX) *appsv2beta1.EMQX {
+		_, fileConfig, sourcesHash, err := s.renderConfig(ctx, emqx)
+		assert.NoError(t, err)
+		emqx.Annotations = appsv2beta1.CloneAndMergeMap(map[string]string{
+			appsv2beta1.AnnotationsLastEMQXConfigKey:    emqx.Spec.Config.Data,
+			appsv2beta1.AnnotationsLastConfigSourcesKey: sourcesHash,
+		}, emqx.Annotations)
+		assert.NoError(t, s.Client.Update(ctx, emqx))
+		assert.NoError(t, s.Client.Create(ctx, generateConfigMap(emqx, mergeDefaultConfig(fileConfig+"\n"+generateListenersConfig(emqx)).String())))
+		return emqx
+	}
+
+	emqx := &appsv2beta1.EMQX{}
+	assert.NoError(t, s.Client.Get(ctx, client.ObjectKeyFromObject(instance), emqx))
+	emqx = render(emqx)
+	assert.Equal(t, subResult{}, s.reconcile(ctx, logger, emqx, nil))
+	// Enabling it doesn't create a new revision
+	assert.Empty(t, readOnlyConfigHash(emqx))
+	assert.Equal(t, computeReadOnlyConfigHash(`node.cookie = "emqx"`), emqx.Annotations[appsv2beta1.AnnotationsInitialReadOnlyConfigHashKey])
+	assert.Equal(t, computeReadOnlyConfigHash(`node.cookie = "emqx"`), emqx.Annotations[appsv2beta1.AnnotationsLastReadOnlyConfigHashKey])
+
+	// The read-only configs from ".spec.config.from" create a new revision after the config map is updated
+	source.Data["emqx.conf"] = `node.cookie = "changed"`
+	assert.NoError(t, s.Client.Update(ctx, source))
+	assert.Equal(t, subResult{}, s.reconcile(ctx, logger, emqx, nil))
+	assert.Empty(t, readOnlyConfigHash(emqx))
+
+	configMap := &corev1.ConfigMap{}
+	assert.NoError(t, s.Client.Get(ctx, instance.ConfigsNamespacedName(), configMap))
+	assert.NoError(t, s.Client.Delete(ctx, configMap))
+	emqx = render(emqx)
+	assert.Equal(t, subResult{}, s.reconcile(ctx, logger, emqx, nil))
+	assert.Equal(t, computeReadOnlyConfigHash(`node.cookie = "changed"`), readOnlyConfigHash(emqx))
+
+	// The hash is removed when it is turned off
+	emqx.Spec.Config.RestartOnReadOnlyConfigChange = false
+	assert.Equal(t, subResult{}, s.reconcile(ctx, logger, emqx, nil))
+	assert.NotContains(t, emqx.Annotations, appsv2beta1.AnnotationsLastReadOnlyConfigHashKey)
+	assert.NotContains(t, emqx.Annotations, appsv2beta1.AnnotationsInitialReadOnlyConfigHashKey)
+}
+
+func TestSyncConfigSecret(t *testing.T) {
+	instance := &appsv2beta1.EMQX{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "emqx",
+			Namespace: "emqx",
+			UID:       "fake-uid",
+		},
+		Spec: appsv2beta1.EMQXSpec{
+			Config: appsv2beta1.Config{
+				Data: `node.cookie = "${secret:cookie/cookie}"`,
+			},
+		},
+	}
+	scheme := runtime.NewScheme()
+	_ = appsv2beta1.AddToScheme(scheme)
+	_ = corev1.AddToScheme(scheme)
+	s := &syncConfig{&EMQXReconciler{
+		Handler: &handler.Handler{Client: fake.NewClientBuilder().WithScheme(scheme).Build()},
+		Scheme:  scheme,
+	}, &emqxLiveConfig{}}
+
+	assert.NotNil(t, configVolumeSource(instance).Secret)
+	assert.Equal(t, "emqx-secret-configs", configVolumeSource(instance).Secret.SecretName)
+
+	assert.NoError(t, s.syncConfigSecret(ctx, instance, `node.cookie = "secret"`))
+	secret := &corev1.Secret{}
+	assert.NoError(t, s.Client.Get(ctx, instance.SecretConfigsNamespacedName(), secret))
+	assert.Equal(t, `node.cookie = "secret"`, string(secret.Data["emqx.conf"]))
+	assert.True(t, metav1.IsControlledBy(secret, instance))
+
+	assert.NoError(t, s.syncConfigSecret(ctx, instance, `node.cookie = "changed"`))
+	assert.NoError(t, s.Client.Get(ctx, instance.SecretConfigsNamespacedName(), secret))
+	assert.Equal(t, `node.cookie = "changed"`, string(secret.Data["emqx.conf"]))
+	assert.True(t, metav1.IsControlledBy(secret, instance))
+
+	// The config map is mounted and the Secret is deleted if no Secret is referenced
+	instance.Spec.Config.Data = `node.cookie = "emqx"`
+	assert.Nil(t, configVolumeSource(instance).Secret)
+	assert.Equal(t, "emqx-configs", configVolumeSource(instance).ConfigMap.Name)
+	assert.NoError(t, s.syncConfigSecret(ctx, instance, `node.cookie = "emqx"`))
+	assert.True(t, k8sErrors.IsNotFound(s.Client.Get(ctx, instance.SecretConfigsNamespacedName(), secret)))
+}
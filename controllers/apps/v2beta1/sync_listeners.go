@@ -0,0 +1,282 @@
+package v2beta1
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"sort"
+	"strings"
+
+	emperror "emperror.dev/errors"
+	appsv2beta1 "github.com/emqx/emqx-operator/apis/apps/v2beta1"
+	innerReq "github.com/emqx/emqx-operator/internal/requester"
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+type syncListeners struct {
+	*EMQXReconciler
+	liveConfig *emqxLiveConfig
+}
+
+// syncListeners applies ".spec.listeners" to the running EMQX nodes by the EMQX listeners API,
+// the new EMQX nodes load them from the config map which is rendered by generateListenersConfig
+func (s *syncListeners) reconcile(ctx context.Context, logger logr.Logger, instance *appsv2beta1.EMQX, r innerReq.RequesterInterface) subResult {
+	if r == nil || !instance.Status.IsConditionTrue(appsv2beta1.CoreNodesReady) {
+		return subResult{}
+	}
+
+	lastListeners := map[string]string{}
+	if v, ok := instance.Annotations[appsv2beta1.AnnotationsLastListenersKey]; ok {
+		_ = json.Unmarshal([]byte(v), &lastListeners)
+	}
+	if len(instance.Spec.Listeners) == 0 && len(lastListeners) == 0 {
+		return subResult{}
+	}
+	lastOverridden := map[string]string{}
+	if v, ok := instance.Annotations[appsv2beta1.AnnotationsOverriddenListenersKey]; ok {
+		_ = json.Unmarshal([]byte(v), &lastOverridden)
+	}
+	overridden := maps.Clone(lastOverridden)
+
+	podList := &corev1.PodList{}
+	if err := s.Client.List(ctx, podList,
+		client.InNamespace(instance.Namespace),
+		client.MatchingLabels(appsv2beta1.DefaultLabels(instance)),
+	); err != nil {
+		return subResult{err: emperror.Wrap(err, "failed to list pods")}
+	}
+
+	listeners := map[string]string{}
+	for _, listener := range instance.Spec.Listeners {
+		id := fmt.Sprintf("%s:%s", listener.Type, listener.Name)
+		body, _ := json.Marshal(generateListenerBody(listener))
+		hash := string(body)
+		if listener.TLSSecretName != "" {
+			secret := &corev1.Secret{}
+			if err := s.Client.Get(ctx, types.NamespacedName{Namespace: instance.Namespace, Name: listener.TLSSecretName}, secret); err != nil {
+				if k8sErrors.IsNotFound(err) {
+					return subResult{}
+				}
+				return subResult{err: emperror.Wrapf(err, "failed to get tls secret of listener %s", id)}
+			}
+			// The certificates are loaded from the mounted secret, wait for the EMQX nodes which mount it,
+			// the new EMQX nodes load the listener from the config map
+			if !isListenerTLSMounted(podList.Items, listener) {
+				if last, ok := lastListeners[id]; ok {
+					listeners[id] = last
+				}
+				continue
+			}
+			hash += fmt.Sprintf("%s/%s", secret.UID, secret.ResourceVersion)
+		}
+		listeners[id] = computeConfigHash(hash)
+		if lastListeners[id] == listeners[id] {
+			continue
+		}
+		// The listener is not created by EMQX Operator, like "tcp:default", keep its config to restore it later
+		if _, ok := lastListeners[id]; !ok {
+			original, err := getListenerByAPI(r, id)
+			if err != nil {
+				return subResult{err: emperror.Wrapf(err, "failed to get listener %s", id)}
+			}
+			if original != nil {
+				overridden[id] = string(original)
+			}
+		}
+		if err := putListenerByAPI(r, id, body); err != nil {
+			return subResult{err: emperror.Wrapf(err, "failed to update listener %s", id)}
+		}
+		// EMQX doesn't reload the certificates when the mounted secret is updated
+		if _, ok := lastListeners[id]; ok && listener.TLSSecretName != "" {
+			if err := restartListenerByAPI(r, id); err != nil {
+				return subResult{err: emperror.Wrapf(err, "failed to restart listener %s", id)}
+			}
+		}
+		logger.Info("updated emqx listener", "listener", id)
+	}
+
+	for id := range lastListeners {
+		if _, ok := listeners[id]; ok {
+			continue
+		}
+		if original, ok := overridden[id]; ok {
+			if err := putListenerByAPI(r, id, []byte(original)); err != nil {
+				return subResult{err: emperror.Wrapf(err, "failed to restore listener %s", id)}
+			}
+			delete(overridden, id)
+			logger.Info("restored emqx listener", "listener", id)
+			continue
+		}
+		// The built-in listeners overridden before their configs were kept, don't delete them
+		if strings.HasSuffix(id, ":default") {
+			continue
+		}
+		if err := deleteListenerByAPI(r, id); err != nil {
+			return subResult{err: emperror.Wrapf(err, "failed to delete listener %s", id)}
+		}
+		logger.Info("deleted emqx listener", "listener", id)
+	}
+
+	if maps.Equal(lastListeners, listeners) && maps.Equal(lastOverridden, overridden) {
+		return subResult{}
+	}
+	s.liveConfig.reset()
+	data, _ := json.Marshal(listeners)
+	if instance.Annotations == nil {
+		instance.Annotations = map[string]string{}
+	}
+	instance.Annotations[appsv2beta1.AnnotationsLastListenersKey] = string(data)
+	if len(overridden) > 0 {
+		data, _ = json.Marshal(overridden)
+		instance.Annotations[appsv2beta1.AnnotationsOverriddenListenersKey] = string(data)
+	} else {
+		delete(instance.Annotations, appsv2beta1.AnnotationsOverriddenListenersKey)
+	}
+	if err := s.Client.Update(ctx, instance); err != nil {
+		return subResult{err: emperror.Wrap(err, "failed to update emqx instance annotation")}
+	}
+	return subResult{}
+}
+
+// generateListenerBody returns the body of the EMQX listeners API, the certificates are loaded from the secrets
+// mounted by generateTLSPodSpec, the same as generateListenersConfig
+func generateListenerBody(listener appsv2beta1.Listener) map[string]interface{} {
+	body := map[string]interface{}{
+		"type":   listener.Type,
+		"name":   listener.Name,
+		"bind":   fmt.Sprintf("%d", listener.Port),
+		"enable": listener.IsEnabled(),
+	}
+	if listener.MaxConnections != nil {
+		body["max_connections"] = *listener.MaxConnections
+	} else {
+		body["max_connections"] = "infinity"
+	}
+	if listener.Type != "quic" {
+		body["proxy_protocol"] = listener.ProxyProtocol
+	}
+	if listener.TLSSecretName != "" {
+		body["ssl_options"] = map[string]interface{}{
+			"certfile": fmt.Sprintf("%s/%s", getListenerTLSMountPath(listener), corev1.TLSCertKey),
+			"keyfile":  fmt.Sprintf("%s/%s", getListenerTLSMountPath(listener), corev1.TLSPrivateKeyKey),
+		}
+	}
+	return body
+}
+
+// isListenerTLSMounted returns true if all of the EMQX pods mount the tls secret of the listener
+func isListenerTLSMounted(pods []corev1.Pod, listener appsv2beta1.Listener) bool {
+	for _, pod := range pods {
+		mounted := false
+		for _, volume := range pod.Spec.Volumes {
+			if volume.Name == getListenerTLSVolumeName(listener) && volume.Secret != nil && volume.Secret.SecretName == listener.TLSSecretName {
+				mounted = true
+				break
+			}
+		}
+		if !mounted {
+			return false
+		}
+	}
+	return true
+}
+
+// generateListenersConfig returns the EMQX config of ".spec.listeners", the certificates are loaded from the secrets mounted by generateTLSPodSpec
+func generateListenersConfig(instance *appsv2beta1.EMQX) string {
+	listeners := append([]appsv2beta1.Listener{}, instance.Spec.Listeners...)
+	sort.Slice(listeners, func(i, j int) bool {
+		if listeners[i].Type == listeners[j].Type {
+			return listeners[i].Name < listeners[j].Name
+		}
+		return listeners[i].Type < listeners[j].Type
+	})
+
+	config := ""
+	for _, listener := range listeners {
+		prefix := fmt.Sprintf("listeners.%s.%s", listener.Type, listener.Name)
+		config += fmt.Sprintf("%s.bind = %d\n", prefix, listener.Port)
+		config += fmt.Sprintf("%s.enable = %t\n", prefix, listener.IsEnabled())
+		if listener.MaxConnections != nil {
+			config += fmt.Sprintf("%s.max_connections = %d\n", prefix, *listener.MaxConnections)
+		} else {
+			config += fmt.Sprintf("%s.max_connections = infinity\n", prefix)
+		}
+		if listener.Type != "quic" {
+			config += fmt.Sprintf("%s.proxy_protocol = %t\n", prefix, listener.ProxyProtocol)
+		}
+		if listener.TLSSecretName != "" {
+			config += fmt.Sprintf("%s.ssl_options.certfile = \"%s/%s\"\n", prefix, getListenerTLSMountPath(listener), corev1.TLSCertKey)
+			config += fmt.Sprintf("%s.ssl_options.keyfile = \"%s/%s\"\n", prefix, getListenerTLSMountPath(listener), corev1.TLSPrivateKeyKey)
+		}
+	}
+	return config
+}
+
+func getListenerTLSVolumeName(listener appsv2beta1.Listener) string {
+	return fmt.Sprintf("listener-%s-%s-tls", listener.Type, listener.Name)
+}
+
+func getListenerTLSMountPath(listener appsv2beta1.Listener) string {
+	return fmt.Sprintf("/mounted/listeners/%s-%s", listener.Type, listener.Name)
+}
+
+// getListenerByAPI returns the config of the listener, it returns nil if the listener is not found
+func getListenerByAPI(r innerReq.RequesterInterface, id string) ([]byte, error) {
+	url := r.GetURL("api/v5/listeners/" + id)
+	resp, body, err := r.Request("GET", url, nil, nil)
+	if err != nil {
+		return nil, emperror.Wrapf(err, "failed to get API %s", url.String())
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, emperror.Errorf("failed to get API %s, status : %s, body: %s", url.String(), resp.Status, body)
+	}
+	config := map[string]interface{}{}
+	if err := json.Unmarshal(body, &config); err != nil {
+		return nil, emperror.Wrapf(err, "failed to unmarshal listener %s", id)
+	}
+	// The runtime fields are not accepted by the update API
+	delete(config, "id")
+	delete(config, "status")
+	delete(config, "node_status")
+	return json.Marshal(config)
+}
+
+func putListenerByAPI(r innerReq.RequesterInterface, id string, body []byte) error {
+	url := r.GetURL("api/v5/listeners/" + id)
+	resp, respBody, err := r.Request("PUT", url, body, nil)
+	if err != nil {
+		return emperror.Wrapf(err, "failed to put API %s", url.String())
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		url = r.GetURL("api/v5/listeners")
+		resp, respBody, err = r.Request("POST", url, body, nil)
+		if err != nil {
+			return emperror.Wrapf(err, "failed to post API %s", url.String())
+		}
+	}
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		return emperror.Errorf("failed to request API %s, status : %s, body: %s", url.String(), resp.Status, respBody)
+	}
+	return nil
+}
+
+func deleteListenerByAPI(r innerReq.RequesterInterface, id string) error {
+	url := r.GetURL("api/v5/listeners/" + id)
+	resp, body, err := r.Request("DELETE", url, nil, nil)
+	if err != nil {
+		return emperror.Wrapf(err, "failed to delete API %s", url.String())
+	}
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNotFound {
+		return emperror.Errorf("failed to delete API %s, status : %s, body: %s", url.String(), resp.Status, body)
+	}
+	return nil
+}
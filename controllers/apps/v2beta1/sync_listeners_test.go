@@ -0,0 +1,242 @@
+package v2beta1
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	appsv2beta1 "github.com/emqx/emqx-operator/apis/apps/v2beta1"
+	"github.com/emqx/emqx-operator/internal/handler"
+	innerReq "github.com/emqx/emqx-operator/internal/requester"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestSyncListeners(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = appsv2beta1.AddToScheme(scheme)
+	_ = corev1.AddToScheme(scheme)
+	instance := &appsv2beta1.EMQX{
+		ObjectMeta: metav1.ObjectMeta{Name: "emqx", Namespace: "emqx"},
+		Spec: appsv2beta1.EMQXSpec{
+			Listeners: []appsv2beta1.Listener{
+				{Name: "default", Type: "tcp", Port: 11883},
+				{Name: "internal", Type: "tcp", Port: 21883},
+			},
+		},
+		Status: appsv2beta1.EMQXStatus{
+			Conditions: []metav1.Condition{{Type: appsv2beta1.CoreNodesReady, Status: metav1.ConditionTrue}},
+		},
+	}
+	fakeClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(instance).Build()
+	s := &syncListeners{&EMQXReconciler{
+		Handler:       &handler.Handler{Client: fakeClient},
+		EventRecorder: record.NewFakeRecorder(10),
+	}, &emqxLiveConfig{}}
+
+	// The listeners of the running EMQX nodes
+	emqxListeners := map[string]map[string]interface{}{
+		"tcp:default": {"id": "tcp:default", "type": "tcp", "name": "default", "bind": "0.0.0.0:1883"},
+	}
+	requester := &innerReq.FakeRequester{
+		ReqFunc: func(method string, url url.URL, body []byte, header http.Header) (*http.Response, []byte, error) {
+			id := strings.TrimPrefix(url.Path, "api/v5/listeners/")
+			switch method {
+			case "GET":
+				if listener, ok := emqxListeners[id]; ok {
+					body, _ := json.Marshal(listener)
+					return &http.Response{StatusCode: 200}, body, nil
+				}
+				return &http.Response{StatusCode: 404}, nil, nil
+			case "PUT":
+				listener := map[string]interface{}{}
+				_ = json.Unmarshal(body, &listener)
+				emqxListeners[id] = listener
+				return &http.Response{StatusCode: 200}, nil, nil
+			case "DELETE":
+				delete(emqxListeners, id)
+				return &http.Response{StatusCode: 204}, nil, nil
+			}
+			return &http.Response{StatusCode: 405}, nil, nil
+		},
+	}
+
+	assert.Nil(t, s.reconcile(ctx, logger, instance, requester).err)
+	assert.Equal(t, "11883", emqxListeners["tcp:default"]["bind"])
+	assert.Equal(t, "21883", emqxListeners["tcp:internal"]["bind"])
+	assert.Contains(t, instance.Annotations, appsv2beta1.AnnotationsOverriddenListenersKey)
+
+	// The listener created by EMQX Operator is deleted, and the overridden one is restored
+	instance.Spec.Listeners = nil
+	assert.Nil(t, s.reconcile(ctx, logger, instance, requester).err)
+	assert.NotContains(t, emqxListeners, "tcp:internal")
+	assert.Equal(t, map[string]interface{}{"type": "tcp", "name": "default", "bind": "0.0.0.0:1883"}, emqxListeners["tcp:default"])
+	assert.NotContains(t, instance.Annotations, appsv2beta1.AnnotationsOverriddenListenersKey)
+}
+
+func TestSyncListenersWithTLS(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = appsv2beta1.AddToScheme(scheme)
+	_ = corev1.AddToScheme(scheme)
+	instance := &appsv2beta1.EMQX{
+		ObjectMeta: metav1.ObjectMeta{Name: "emqx", Namespace: "emqx"},
+		Spec: appsv2beta1.EMQXSpec{
+			Listeners: []appsv2beta1.Listener{
+				{Name: "internal", Type: "ssl", Port: 18883, TLSSecretName: "emqx-tls"},
+			},
+		},
+		Status: appsv2beta1.EMQXStatus{
+			Conditions: []metav1.Condition{{Type: appsv2beta1.CoreNodesReady, Status: metav1.ConditionTrue}},
+		},
+	}
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "emqx-tls", Namespace: "emqx"},
+		Data:       map[string][]byte{"tls.crt": []byte("cert"), "tls.key": []byte("key")},
+	}
+	// The pod of the current revision doesn't mount the secret yet
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "emqx-core-0", Namespace: "emqx", Labels: appsv2beta1.DefaultLabels(instance)},
+	}
+	fakeClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(instance, secret, pod).Build()
+	s := &syncListeners{&EMQXReconciler{
+		Handler:       &handler.Handler{Client: fakeClient},
+		EventRecorder: record.NewFakeRecorder(10),
+	}, &emqxLiveConfig{}}
+
+	requests := []string{}
+	requester := &innerReq.FakeRequester{
+		ReqFunc: func(method string, url url.URL, body []byte, header http.Header) (*http.Response, []byte, error) {
+			requests = append(requests, method+" "+url.Path)
+			if method == "GET" {
+				return &http.Response{StatusCode: 404}, nil, nil
+			}
+			if method == "PUT" {
+				listener := map[string]interface{}{}
+				_ = json.Unmarshal(body, &listener)
+				assert.Equal(t, map[string]interface{}{
+					"certfile": "/mounted/listeners/ssl-internal/tls.crt",
+					"keyfile":  "/mounted/listeners/ssl-internal/tls.key",
+				}, listener["ssl_options"])
+			}
+			return &http.Response{StatusCode: 200}, nil, nil
+		},
+	}
+
+	assert.Nil(t, s.reconcile(ctx, logger, instance, requester).err)
+	assert.Empty(t, requests)
+
+	pod.Spec.Volumes = []corev1.Volume{{
+		Name:         "listener-ssl-internal-tls",
+		VolumeSource: corev1.VolumeSource{Secret: &corev1.SecretVolumeSource{SecretName: "emqx-tls"}},
+	}}
+	assert.NoError(t, fakeClient.Update(ctx, pod))
+	assert.Nil(t, s.reconcile(ctx, logger, instance, requester).err)
+	assert.Equal(t, []string{"GET api/v5/listeners/ssl:internal", "PUT api/v5/listeners/ssl:internal"}, requests)
+
+	// The listener is restarted when the certificates are rotated
+	requests = []string{}
+	secret.Data["tls.crt"] = []byte("new-cert")
+	assert.NoError(t, fakeClient.Update(ctx, secret))
+	assert.Nil(t, s.reconcile(ctx, logger, instance, requester).err)
+	assert.Equal(t, []string{"PUT api/v5/listeners/ssl:internal", "POST api/v5/listeners/ssl:internal/restart"}, requests)
+}
+
+func TestGenerateListenersConfig(t *testing.T) {
+	instance := &appsv2beta1.EMQX{}
+	assert.Equal(t, "", generateListenersConfig(instance))
+
+	instance.Spec.Listeners = []appsv2beta1.Listener{
+		{Name: "internal", Type: "tcp", Port: 11883, MaxConnections: ptr.To(int32(1000)), ProxyProtocol: true},
+		{Name: "default", Type: "quic", Port: 14567, Enable: ptr.To(false), TLSSecretName: "emqx-tls"},
+	}
+	assert.Equal(t, ""+
+		"listeners.quic.default.bind = 14567\n"+
+		"listeners.quic.default.enable = false\n"+
+		"listeners.quic.default.max_connections = infinity\n"+
+		"listeners.quic.default.ssl_options.certfile = \"/mounted/listeners/quic-default/tls.crt\"\n"+
+		"listeners.quic.default.ssl_options.keyfile = \"/mounted/listeners/quic-default/tls.key\"\n"+
+		"listeners.tcp.internal.bind = 11883\n"+
+		"listeners.tcp.internal.enable = true\n"+
+		"listeners.tcp.internal.max_connections = 1000\n"+
+		"listeners.tcp.internal.proxy_protocol = true\n",
+		generateListenersConfig(instance),
+	)
+
+	got := mergeDefaultConfig(instance.Spec.Config.Data + "\n" + generateListenersConfig(instance))
+	assert.Equal(t, "1883", got.GetString("listeners.tcp.default.bind"))
+	assert.Equal(t, "11883", got.GetString("listeners.tcp.internal.bind"))
+}
+
+func TestGenerateListenerBody(t *testing.T) {
+	t.Run("tcp listener", func(t *testing.T) {
+		got := generateListenerBody(appsv2beta1.Listener{Name: "internal", Type: "tcp", Port: 11883})
+		assert.Equal(t, map[string]interface{}{
+			"type":            "tcp",
+			"name":            "internal",
+			"bind":            "11883",
+			"enable":          true,
+			"max_connections": "infinity",
+			"proxy_protocol":  false,
+		}, got)
+	})
+
+	t.Run("quic listener", func(t *testing.T) {
+		got := generateListenerBody(appsv2beta1.Listener{Name: "default", Type: "quic", Port: 14567, MaxConnections: ptr.To(int32(1000)), TLSSecretName: "emqx-tls"})
+		assert.Equal(t, map[string]interface{}{
+			"type":            "quic",
+			"name":            "default",
+			"bind":            "14567",
+			"enable":          true,
+			"max_connections": int32(1000),
+			"ssl_options": map[string]interface{}{
+				"certfile": "/mounted/listeners/quic-default/tls.crt",
+				"keyfile":  "/mounted/listeners/quic-default/tls.key",
+			},
+		}, got)
+	})
+}
+
+func TestPutListenerByAPI(t *testing.T) {
+	t.Run("update listener", func(t *testing.T) {
+		requester := &innerReq.FakeRequester{
+			ReqFunc: func(method string, url url.URL, body []byte, header http.Header) (*http.Response, []byte, error) {
+				assert.Equal(t, "PUT", method)
+				assert.Equal(t, "api/v5/listeners/tcp:internal", url.Path)
+				return &http.Response{StatusCode: 200}, nil, nil
+			},
+		}
+		assert.NoError(t, putListenerByAPI(requester, "tcp:internal", nil))
+	})
+
+	t.Run("create listener", func(t *testing.T) {
+		methods := []string{}
+		requester := &innerReq.FakeRequester{
+			ReqFunc: func(method string, url url.URL, body []byte, header http.Header) (*http.Response, []byte, error) {
+				methods = append(methods, method)
+				if method == "PUT" {
+					return &http.Response{StatusCode: 404, Status: "404 Not Found"}, nil, nil
+				}
+				assert.Equal(t, "api/v5/listeners", url.Path)
+				return &http.Response{StatusCode: 200}, nil, nil
+			},
+		}
+		assert.NoError(t, putListenerByAPI(requester, "tcp:internal", nil))
+		assert.Equal(t, []string{"PUT", "POST"}, methods)
+	})
+
+	t.Run("failed to update listener", func(t *testing.T) {
+		requester := &innerReq.FakeRequester{
+			ReqFunc: func(method string, url url.URL, body []byte, header http.Header) (*http.Response, []byte, error) {
+				return &http.Response{StatusCode: 400, Status: "400 Bad Request"}, []byte("bad request"), nil
+			},
+		}
+		assert.ErrorContains(t, putListenerByAPI(requester, "tcp:internal", nil), "400 Bad Request")
+	})
+}
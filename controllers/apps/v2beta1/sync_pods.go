@@ -14,7 +14,9 @@ import (
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
 	"k8s.io/utils/ptr"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
@@ -28,12 +30,23 @@ func (s *syncPods) reconcile(ctx context.Context, logger logr.Logger, instance *
 		return subResult{}
 	}
 
+	updateSts, currentSts, _ := getStateFulSetList(ctx, s.Client, instance)
+	updateRs, currentRs, _ := getReplicaSetList(ctx, s.Client, instance)
+
+	// The update can be aborted or rolled back even if the update revision is never available
+	if isAborted(instance) || isRollingBack(instance) {
+		return s.abortUpdate(ctx, logger, instance, updateSts, currentSts, updateRs, currentRs)
+	}
+
 	if !instance.Status.IsConditionTrue(appsv2beta1.Available) {
 		return subResult{}
 	}
 
-	updateSts, currentSts, _ := getStateFulSetList(ctx, s.Client, instance)
-	updateRs, currentRs, _ := getReplicaSetList(ctx, s.Client, instance)
+	updating := (updateRs != nil && currentRs != nil && updateRs.UID != currentRs.UID) ||
+		(updateSts != nil && currentSts != nil && updateSts.UID != currentSts.UID)
+	if err := s.syncUpdateConditions(ctx, instance, updating); err != nil {
+		return subResult{err: emperror.Wrap(err, "failed to update status")}
+	}
 
 	targetedEMQXNodesName := []string{}
 	if appsv2beta1.IsExistReplicant(instance) {
@@ -55,6 +68,13 @@ func (s *syncPods) reconcile(ctx context.Context, logger logr.Logger, instance *
 	}
 
 	if updateRs != nil && currentRs != nil && updateRs.UID != currentRs.UID {
+		if isUpdatePaused(instance) {
+			return subResult{}
+		}
+		if instance.Spec.UpdateStrategy.Type == appsv2beta1.UpdateStrategyRollingUpdate || instance.Spec.UpdateStrategy.Type == appsv2beta1.UpdateStrategyCanary {
+			return s.rollingScaleDownRs(ctx, instance, r, updateRs, currentRs, targetedEMQXNodesName)
+		}
+
 		shouldDeletePod, err := s.canBeScaleDownRs(ctx, instance, r, currentRs, targetedEMQXNodesName)
 		if err != nil {
 			return subResult{err: emperror.Wrap(err, "failed to check if pod can be scale down")}
@@ -87,6 +107,9 @@ func (s *syncPods) reconcile(ctx context.Context, logger logr.Logger, instance *
 	}
 
 	if updateSts != nil && currentSts != nil && updateSts.UID != currentSts.UID {
+		if isUpdatePaused(instance) {
+			return subResult{}
+		}
 		canBeScaledDown, err := s.canBeScaleDownSts(ctx, instance, r, currentSts, targetedEMQXNodesName)
 		if err != nil {
 			return subResult{err: emperror.Wrap(err, "failed to check if sts can be scale down")}
@@ -99,9 +122,499 @@ func (s *syncPods) reconcile(ctx context.Context, logger logr.Logger, instance *
 		}
 		return subResult{}
 	}
+
+	// The update is finished, the promote annotation is useless
+	if err := s.removeAnnotation(ctx, instance, appsv2beta1.AnnotationsPromoteKey); err != nil {
+		return subResult{err: emperror.Wrap(err, "failed to remove promote annotation")}
+	}
+
+	// Scale in the replicant nodes first, and then the core nodes, only one node is evacuated at a time
+	if instance.Spec.ReplicantTemplate != nil && updateRs != nil {
+		if err := s.scaleInRs(ctx, instance, r, updateRs); err != nil {
+			return subResult{err: emperror.Wrap(err, "failed to scale in replicaSet")}
+		}
+		if appsv2beta1.GetReplicantReplicas(instance) < *updateRs.Spec.Replicas {
+			return subResult{}
+		}
+	}
+	if updateSts != nil {
+		if err := s.scaleInSts(ctx, instance, r, updateSts); err != nil {
+			return subResult{err: emperror.Wrap(err, "failed to scale in statefulSet")}
+		}
+	}
 	return subResult{}
 }
 
+// scaleInRs scales in the replicaSet one pod at a time, the replicant node with the fewest connections
+// is selected and evacuated first, the replicaSet is scaled down after the node is empty.
+func (s *syncPods) scaleInRs(ctx context.Context, instance *appsv2beta1.EMQX, r innerReq.RequesterInterface, rs *appsv1.ReplicaSet) error {
+	pods := getRsPodMap(ctx, s.Client, instance)[rs.UID]
+	// Wait for the last scaled in pod to be deleted
+	if int32(len(pods)) > *rs.Spec.Replicas {
+		return nil
+	}
+	for _, pod := range pods {
+		if pod.DeletionTimestamp != nil {
+			return nil
+		}
+	}
+
+	nodes := []appsv2beta1.EMQXNode{}
+	for _, node := range instance.Status.ReplicantNodes {
+		if node.ControllerUID == rs.UID {
+			nodes = append(nodes, node)
+		}
+	}
+
+	if appsv2beta1.GetReplicantReplicas(instance) >= *rs.Spec.Replicas {
+		return s.cancelScaleIn(ctx, instance, r, pods, nodes)
+	}
+
+	var shouldDeletePod *corev1.Pod
+	for _, pod := range pods {
+		if _, ok := pod.Annotations["controller.kubernetes.io/pod-deletion-cost"]; ok {
+			shouldDeletePod = pod.DeepCopy()
+			break
+		}
+	}
+	if shouldDeletePod == nil {
+		node := getScaleInReplicantNode(nodes)
+		if node == nil {
+			return nil
+		}
+		for _, pod := range pods {
+			if pod.UID == node.PodUID {
+				shouldDeletePod = pod.DeepCopy()
+				break
+			}
+		}
+		if shouldDeletePod == nil {
+			return nil
+		}
+		if err := s.markScaleInPod(ctx, shouldDeletePod); err != nil {
+			return err
+		}
+	}
+
+	var shouldDeleteNode *appsv2beta1.EMQXNode
+	migrateTo := []string{}
+	for i, node := range nodes {
+		if node.PodUID == shouldDeletePod.UID {
+			shouldDeleteNode = &nodes[i]
+		} else if node.NodeStatus == "running" {
+			migrateTo = append(migrateTo, node.Node)
+		}
+	}
+	// The last replicant node is scaled in, the sessions are migrated to the core nodes
+	if len(migrateTo) == 0 {
+		for _, node := range instance.Status.CoreNodes {
+			if node.NodeStatus == "running" {
+				migrateTo = append(migrateTo, node.Node)
+			}
+		}
+	}
+	isEmpty, err := s.evacuateScaleInNode(instance, r, shouldDeleteNode, migrateTo)
+	if err != nil || !isEmpty {
+		return err
+	}
+
+	rs.Spec.Replicas = ptr.To(*rs.Spec.Replicas - 1)
+	if err := s.Client.Update(ctx, rs); err != nil {
+		return emperror.Wrap(err, "failed to scale down replicaSet")
+	}
+	s.EventRecorder.Event(instance, corev1.EventTypeNormal, "ScaleIn", fmt.Sprintf("Scale in replicaSet %s to %d, pod %s will be deleted", rs.Name, *rs.Spec.Replicas, shouldDeletePod.Name))
+	return nil
+}
+
+// scaleInSts scales in the statefulSet one pod at a time, the core node with the highest ordinal
+// is evacuated first, the statefulSet is scaled down after the node is empty.
+func (s *syncPods) scaleInSts(ctx context.Context, instance *appsv2beta1.EMQX, r innerReq.RequesterInterface, sts *appsv1.StatefulSet) error {
+	// Wait for the last scaled in pod to be deleted
+	if sts.Status.Replicas > *sts.Spec.Replicas {
+		return nil
+	}
+	if *sts.Spec.Replicas == 0 {
+		return nil
+	}
+	replicas := *instance.Spec.CoreTemplate.Spec.Replicas
+
+	shouldDeletePod := &corev1.Pod{}
+	if err := s.Client.Get(ctx, types.NamespacedName{
+		Namespace: sts.Namespace,
+		Name:      fmt.Sprintf("%s-%d", sts.Name, *sts.Spec.Replicas-1),
+	}, shouldDeletePod); err != nil {
+		if k8sErrors.IsNotFound(err) {
+			return nil
+		}
+		return emperror.Wrap(err, "failed to get pod")
+	}
+	if shouldDeletePod.DeletionTimestamp != nil {
+		return nil
+	}
+
+	nodes := []appsv2beta1.EMQXNode{}
+	for _, node := range instance.Status.CoreNodes {
+		if node.ControllerUID == sts.UID {
+			nodes = append(nodes, node)
+		}
+	}
+
+	if replicas >= *sts.Spec.Replicas {
+		return s.cancelScaleIn(ctx, instance, r, []*corev1.Pod{shouldDeletePod}, nodes)
+	}
+
+	if _, ok := shouldDeletePod.Annotations["controller.kubernetes.io/pod-deletion-cost"]; !ok {
+		if err := s.markScaleInPod(ctx, shouldDeletePod); err != nil {
+			return err
+		}
+	}
+
+	// The sessions are migrated to the replicant nodes if they exist, otherwise to the core nodes which will be retained
+	retainedNodes := map[string]bool{}
+	for i := int32(0); i < replicas; i++ {
+		retainedNodes[fmt.Sprintf("emqx@%s-%d.%s.%s.svc.%s", sts.Name, i, sts.Spec.ServiceName, sts.Namespace, instance.Spec.ClusterDomain)] = true
+	}
+	var shouldDeleteNode *appsv2beta1.EMQXNode
+	migrateTo := []string{}
+	for i, node := range nodes {
+		if node.PodUID == shouldDeletePod.UID {
+			shouldDeleteNode = &nodes[i]
+		} else if !appsv2beta1.IsExistReplicant(instance) && node.NodeStatus == "running" && retainedNodes[node.Node] {
+			migrateTo = append(migrateTo, node.Node)
+		}
+	}
+	if appsv2beta1.IsExistReplicant(instance) {
+		for _, node := range instance.Status.ReplicantNodes {
+			if node.NodeStatus == "running" {
+				migrateTo = append(migrateTo, node.Node)
+			}
+		}
+	}
+	isEmpty, err := s.evacuateScaleInNode(instance, r, shouldDeleteNode, migrateTo)
+	if err != nil || !isEmpty {
+		return err
+	}
+
+	sts.Spec.Replicas = ptr.To(*sts.Spec.Replicas - 1)
+	if err := s.Client.Update(ctx, sts); err != nil {
+		return emperror.Wrap(err, "failed to scale down statefulSet")
+	}
+	s.EventRecorder.Event(instance, corev1.EventTypeNormal, "ScaleIn", fmt.Sprintf("Scale in statefulSet %s to %d, pod %s will be deleted", sts.Name, *sts.Spec.Replicas, shouldDeletePod.Name))
+	return nil
+}
+
+// markScaleInPod marks the pod selected to be scaled in by the pod deletion cost,
+// so the replicaSet will delete it first, and the selection survives across the reconciles.
+func (s *syncPods) markScaleInPod(ctx context.Context, pod *corev1.Pod) error {
+	patch := client.MergeFrom(pod.DeepCopy())
+	if pod.Annotations == nil {
+		pod.Annotations = make(map[string]string)
+	}
+	// https://kubernetes.io/docs/concepts/workloads/controllers/replicaset/#pod-deletion-cost
+	pod.Annotations["controller.kubernetes.io/pod-deletion-cost"] = "-99999"
+	if err := s.Client.Patch(ctx, pod, patch); err != nil {
+		return emperror.Wrap(err, "failed update pod deletion cost")
+	}
+	return nil
+}
+
+// cancelScaleIn stops the evacuation of the nodes selected to be scaled in, when they don't need to be scaled in anymore
+func (s *syncPods) cancelScaleIn(ctx context.Context, instance *appsv2beta1.EMQX, r innerReq.RequesterInterface, pods []*corev1.Pod, nodes []appsv2beta1.EMQXNode) error {
+	for _, pod := range pods {
+		if _, ok := pod.Annotations["controller.kubernetes.io/pod-deletion-cost"]; !ok {
+			continue
+		}
+		for _, node := range nodes {
+			if node.PodUID != pod.UID {
+				continue
+			}
+			for _, evacuation := range instance.Status.NodeEvacuationsStatus {
+				if evacuation.Node == node.Node {
+					if err := stopEvacuationByAPI(r, node.Node); err != nil {
+						return emperror.Wrap(err, "failed to stop node evacuation")
+					}
+					s.EventRecorder.Event(instance, corev1.EventTypeNormal, "NodeEvacuation", fmt.Sprintf("Node %s evacuation is stopped", node.Node))
+				}
+			}
+		}
+
+		patch := client.MergeFrom(pod.DeepCopy())
+		delete(pod.Annotations, "controller.kubernetes.io/pod-deletion-cost")
+		if err := s.Client.Patch(ctx, pod, patch); err != nil {
+			return emperror.Wrap(err, "failed to remove pod deletion cost")
+		}
+	}
+	return nil
+}
+
+// evacuateScaleInNode evacuates the sessions of the EMQX Enterprise node selected to be scaled in,
+// it returns true when the node is empty and can be deleted.
+func (s *syncPods) evacuateScaleInNode(instance *appsv2beta1.EMQX, r innerReq.RequesterInterface, node *appsv2beta1.EMQXNode, migrateTo []string) (bool, error) {
+	if node == nil || node.NodeStatus == "stopped" {
+		return true, nil
+	}
+	for _, evacuation := range instance.Status.NodeEvacuationsStatus {
+		if evacuation.Node == node.Node {
+			return evacuation.State == "prohibiting", nil
+		}
+	}
+	// Open Source or Enterprise with no session
+	if node.Edition != "Enterprise" || node.Session == 0 {
+		return true, nil
+	}
+	// Wait for the other node to be evacuated
+	if len(instance.Status.NodeEvacuationsStatus) > 0 {
+		return false, nil
+	}
+	if err := startEvacuationByAPI(r, instance, migrateTo, node.Node); err != nil {
+		return false, emperror.Wrap(err, "failed to start node evacuation")
+	}
+	s.EventRecorder.Event(instance, corev1.EventTypeNormal, "NodeEvacuation", fmt.Sprintf("Node %s is being evacuated", node.Node))
+	return false, nil
+}
+
+// syncUpdateConditions records the pause and the promote of the update in the status conditions
+func (s *syncPods) syncUpdateConditions(ctx context.Context, instance *appsv2beta1.EMQX, updating bool) error {
+	changed := false
+	if updating && isPromoted(instance) {
+		changed = setConditionIfChanged(instance, metav1.Condition{
+			Type:   appsv2beta1.UpdatePromoted,
+			Status: metav1.ConditionTrue,
+			Reason: appsv2beta1.UpdatePromoted,
+			Message: fmt.Sprintf("Update is promoted, core nodes revision: %s, replicant nodes revision: %s",
+				instance.Status.CoreNodesStatus.UpdateRevision, instance.Status.ReplicantNodesStatus.UpdateRevision),
+		}) || changed
+	}
+
+	if updating && isUpdatePaused(instance) {
+		changed = setConditionIfChanged(instance, metav1.Condition{
+			Type:    appsv2beta1.UpdatePaused,
+			Status:  metav1.ConditionTrue,
+			Reason:  appsv2beta1.UpdatePaused,
+			Message: "Update is paused, the old nodes will not be scaled down",
+		}) || changed
+	} else if instance.Status.IsConditionTrue(appsv2beta1.UpdatePaused) {
+		changed = setConditionIfChanged(instance, metav1.Condition{
+			Type:    appsv2beta1.UpdatePaused,
+			Status:  metav1.ConditionFalse,
+			Reason:  "UpdateResumed",
+			Message: "Update is resumed",
+		}) || changed
+	}
+
+	if !changed {
+		return nil
+	}
+	return s.Client.Status().Update(ctx, instance)
+}
+
+// abortUpdate brings the current revision back to full size, and then deletes the update revision,
+// it works for both the update aborted by user and the update rolled back by progress deadline exceeded
+func (s *syncPods) abortUpdate(
+	ctx context.Context,
+	logger logr.Logger,
+	instance *appsv2beta1.EMQX,
+	updateSts, currentSts *appsv1.StatefulSet,
+	updateRs, currentRs *appsv1.ReplicaSet,
+) subResult {
+	reason := appsv2beta1.UpdateAborted
+	if isRollingBack(instance) {
+		reason = appsv2beta1.RolledBack
+	}
+
+	if appsv2beta1.IsExistReplicant(instance) && updateRs != nil && currentRs != nil && updateRs.UID != currentRs.UID {
+		if replicas := appsv2beta1.GetReplicantReplicas(instance); *currentRs.Spec.Replicas != replicas {
+			currentRs.Spec.Replicas = ptr.To(replicas)
+			if err := s.Client.Update(ctx, currentRs); err != nil {
+				return subResult{err: emperror.Wrap(err, "failed to scale up current replicaSet")}
+			}
+			return subResult{}
+		}
+		if currentRs.Status.ReadyReplicas != *currentRs.Spec.Replicas {
+			return subResult{}
+		}
+
+		logger.Info("abort update, trying to delete update replicaSet", "replicaSet", klog.KObj(updateRs))
+		if err := s.Client.Delete(ctx, updateRs); err != nil && !k8sErrors.IsNotFound(err) {
+			return subResult{err: emperror.Wrap(err, "failed to delete update replicaSet")}
+		}
+		instance.Status.ReplicantNodesStatus.AbortedRevision = instance.Status.ReplicantNodesStatus.UpdateRevision
+		instance.Status.ReplicantNodesStatus.UpdateRevision = instance.Status.ReplicantNodesStatus.CurrentRevision
+		if err := s.Client.Status().Update(ctx, instance); err != nil {
+			return subResult{err: emperror.Wrap(err, "failed to update status")}
+		}
+		s.EventRecorder.Event(instance, corev1.EventTypeNormal, reason, fmt.Sprintf("Delete replicaSet %s", updateRs.Name))
+	}
+
+	if updateSts != nil && currentSts != nil && updateSts.UID != currentSts.UID {
+		if *currentSts.Spec.Replicas != *instance.Spec.CoreTemplate.Spec.Replicas {
+			currentSts.Spec.Replicas = instance.Spec.CoreTemplate.Spec.Replicas
+			if err := s.Client.Update(ctx, currentSts); err != nil {
+				return subResult{err: emperror.Wrap(err, "failed to scale up current statefulSet")}
+			}
+			return subResult{}
+		}
+		if currentSts.Status.ReadyReplicas != *currentSts.Spec.Replicas {
+			return subResult{}
+		}
+
+		if err := deleteStatefulSet(ctx, s.Client, logger, instance, updateSts); err != nil {
+			return subResult{err: emperror.Wrap(err, "failed to delete update statefulSet")}
+		}
+		instance.Status.CoreNodesStatus.AbortedRevision = instance.Status.CoreNodesStatus.UpdateRevision
+		instance.Status.CoreNodesStatus.UpdateRevision = instance.Status.CoreNodesStatus.CurrentRevision
+		if err := s.Client.Status().Update(ctx, instance); err != nil {
+			return subResult{err: emperror.Wrap(err, "failed to update status")}
+		}
+		s.EventRecorder.Event(instance, corev1.EventTypeNormal, reason, fmt.Sprintf("Delete statefulSet %s", updateSts.Name))
+	}
+
+	// All of the update revisions have been aborted, or there is nothing to abort
+	message := fmt.Sprintf("Core nodes revert to revision: %s", instance.Status.CoreNodesStatus.CurrentRevision)
+	if appsv2beta1.IsExistReplicant(instance) {
+		message += fmt.Sprintf(", replicant nodes revert to revision: %s", instance.Status.ReplicantNodesStatus.CurrentRevision)
+	}
+	instance.Status.SetCondition(metav1.Condition{
+		Type:    reason,
+		Status:  metav1.ConditionTrue,
+		Reason:  reason,
+		Message: message,
+	})
+	if reason == appsv2beta1.RolledBack {
+		instance.Status.SetCondition(metav1.Condition{
+			Type:    appsv2beta1.RollingBack,
+			Status:  metav1.ConditionFalse,
+			Reason:  appsv2beta1.RolledBack,
+			Message: message,
+		})
+	}
+	if err := s.Client.Status().Update(ctx, instance); err != nil {
+		return subResult{err: emperror.Wrap(err, "failed to update status")}
+	}
+	if reason == appsv2beta1.RolledBack {
+		s.EventRecorder.Event(instance, corev1.EventTypeWarning, appsv2beta1.RolledBack, message)
+	}
+
+	if err := s.removeAnnotation(ctx, instance, appsv2beta1.AnnotationsAbortKey); err != nil {
+		return subResult{err: emperror.Wrap(err, "failed to remove abort annotation")}
+	}
+	return subResult{}
+}
+
+func (s *syncPods) removeAnnotation(ctx context.Context, instance *appsv2beta1.EMQX, key string) error {
+	if _, ok := instance.Annotations[key]; !ok {
+		return nil
+	}
+	patch := client.MergeFrom(instance.DeepCopy())
+	delete(instance.Annotations, key)
+	return s.Client.Patch(ctx, instance, patch)
+}
+
+func (s *syncPods) rollingScaleDownRs(
+	ctx context.Context,
+	instance *appsv2beta1.EMQX,
+	r innerReq.RequesterInterface,
+	updateRs, currentRs *appsv1.ReplicaSet,
+	targetedEMQXNodesName []string,
+) subResult {
+	count := getCurrentRsScaleDownReplicas(instance, updateRs, currentRs)
+	if count == 0 {
+		return subResult{}
+	}
+
+	shouldDeletePods, err := s.canBeScaleDownRsPods(ctx, instance, r, currentRs, targetedEMQXNodesName, count)
+	if err != nil {
+		return subResult{err: emperror.Wrap(err, "failed to check if pods can be scale down")}
+	}
+	if len(shouldDeletePods) == 0 {
+		return subResult{}
+	}
+
+	for _, pod := range shouldDeletePods {
+		if err := s.markScaleInPod(ctx, pod); err != nil {
+			return subResult{err: err}
+		}
+	}
+
+	currentRs.Spec.Replicas = ptr.To(*currentRs.Spec.Replicas - int32(len(shouldDeletePods)))
+	if err := s.Client.Update(ctx, currentRs); err != nil {
+		return subResult{err: emperror.Wrap(err, "failed to scale down old replicaSet")}
+	}
+	return subResult{}
+}
+
+// canBeScaleDownRsPods returns at most count pods of the old replicaSet can be scaled down in one batch,
+// the EMQX Enterprise nodes that still have sessions will be evacuated one by one before they are scaled down.
+func (s *syncPods) canBeScaleDownRsPods(
+	ctx context.Context,
+	instance *appsv2beta1.EMQX,
+	r innerReq.RequesterInterface,
+	oldRs *appsv1.ReplicaSet,
+	targetedEMQXNodesName []string,
+	count int32,
+) ([]*corev1.Pod, error) {
+	if !checkInitialDelaySecondsReady(instance) {
+		return nil, nil
+	}
+
+	oldRsPods := getRsPodMap(ctx, s.Client, instance)[oldRs.UID]
+	if len(oldRsPods) == 0 {
+		return nil, nil
+	}
+	for _, pod := range oldRsPods {
+		// Wait for the last batch to be deleted
+		if pod.DeletionTimestamp != nil {
+			return nil, nil
+		}
+	}
+
+	if !checkWaitTakeoverReady(instance, getEventList(ctx, s.Clientset, oldRs)) {
+		return nil, nil
+	}
+
+	if len(instance.Status.NodeEvacuationsStatus) > 0 {
+		if instance.Status.NodeEvacuationsStatus[0].State != "prohibiting" {
+			return nil, nil
+		}
+		emqxNode := instance.Status.NodeEvacuationsStatus[0].Node
+		for _, node := range instance.Status.ReplicantNodes {
+			if node.Node == emqxNode {
+				for _, pod := range oldRsPods {
+					if pod.UID == node.PodUID {
+						return []*corev1.Pod{pod.DeepCopy()}, nil
+					}
+				}
+			}
+		}
+	}
+
+	shouldDeletePods := []*corev1.Pod{}
+	sort.Sort(PodsByNameOlder(oldRsPods))
+	for _, pod := range oldRsPods {
+		if int32(len(shouldDeletePods)) >= count {
+			break
+		}
+
+		shouldDeletePodInfo, err := getEMQXNodeInfoByAPI(r, fmt.Sprintf("emqx@%s", pod.Status.PodIP))
+		if err != nil {
+			return nil, emperror.Wrap(err, "failed to get node info by API")
+		}
+		if shouldDeletePodInfo.NodeStatus != "stopped" && shouldDeletePodInfo.Edition == "Enterprise" && shouldDeletePodInfo.Session > 0 {
+			if len(shouldDeletePods) > 0 {
+				// Scale down the pods have been selected first, and evacuate this node in the next batch
+				break
+			}
+			if err := startEvacuationByAPI(r, instance, targetedEMQXNodesName, shouldDeletePodInfo.Node); err != nil {
+				return nil, emperror.Wrap(err, "failed to start node evacuation")
+			}
+			s.EventRecorder.Event(instance, corev1.EventTypeNormal, "NodeEvacuation", fmt.Sprintf("Node %s is being evacuated", shouldDeletePodInfo.Node))
+			return nil, nil
+		}
+		shouldDeletePods = append(shouldDeletePods, pod.DeepCopy())
+	}
+	return shouldDeletePods, nil
+}
+
 func (s *syncPods) canBeScaleDownRs(
 	ctx context.Context,
 	instance *appsv2beta1.EMQX,
@@ -261,6 +774,19 @@ func getEMQXNodeInfoByAPI(r innerReq.RequesterInterface, nodeName string) (*apps
 	return nodeInfo, nil
 }
 
+// getScaleInReplicantNode returns the replicant node with the fewest connections, and then the fewest sessions
+func getScaleInReplicantNode(nodes []appsv2beta1.EMQXNode) *appsv2beta1.EMQXNode {
+	var shouldDeleteNode *appsv2beta1.EMQXNode
+	for i, node := range nodes {
+		if shouldDeleteNode == nil ||
+			node.Connections < shouldDeleteNode.Connections ||
+			(node.Connections == shouldDeleteNode.Connections && node.Session < shouldDeleteNode.Session) {
+			shouldDeleteNode = &nodes[i]
+		}
+	}
+	return shouldDeleteNode
+}
+
 func startEvacuationByAPI(r innerReq.RequesterInterface, instance *appsv2beta1.EMQX, migrateTo []string, nodeName string) error {
 	body := map[string]interface{}{
 		"conn_evict_rate": instance.Spec.UpdateStrategy.EvacuationStrategy.ConnEvictRate,
@@ -292,3 +818,18 @@ func startEvacuationByAPI(r innerReq.RequesterInterface, instance *appsv2beta1.E
 	}
 	return nil
 }
+
+func stopEvacuationByAPI(r innerReq.RequesterInterface, nodeName string) error {
+	url := r.GetURL("api/v5/load_rebalance/" + nodeName + "/evacuation/stop")
+	resp, respBody, err := r.Request("POST", url, nil, nil)
+	if err != nil {
+		return emperror.Wrap(err, "failed to request API api/v5/load_rebalance/"+nodeName+"/evacuation/stop")
+	}
+	if resp.StatusCode == 400 && strings.Contains(string(respBody), "not_started") {
+		return nil
+	}
+	if resp.StatusCode != 200 {
+		return emperror.Errorf("failed to request API %s, status : %s, body: %s", url.String(), resp.Status, respBody)
+	}
+	return nil
+}
@@ -0,0 +1,192 @@
+package v2beta1
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+
+	appsv2beta1 "github.com/emqx/emqx-operator/apis/apps/v2beta1"
+	"github.com/emqx/emqx-operator/internal/handler"
+	innerReq "github.com/emqx/emqx-operator/internal/requester"
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestScaleInSts(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = appsv2beta1.AddToScheme(scheme)
+	_ = appsv1.AddToScheme(scheme)
+	_ = corev1.AddToScheme(scheme)
+
+	instance := &appsv2beta1.EMQX{
+		ObjectMeta: metav1.ObjectMeta{Name: "emqx", Namespace: "emqx"},
+		Spec: appsv2beta1.EMQXSpec{
+			ClusterDomain: "cluster.local",
+			CoreTemplate: appsv2beta1.EMQXCoreTemplate{
+				Spec: appsv2beta1.EMQXCoreTemplateSpec{
+					EMQXReplicantTemplateSpec: appsv2beta1.EMQXReplicantTemplateSpec{
+						Replicas: ptr.To(int32(2)),
+					},
+				},
+			},
+		},
+	}
+	sts := &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "emqx-core-abc", Namespace: "emqx", UID: "sts-uid"},
+		Spec: appsv1.StatefulSetSpec{
+			Replicas:    ptr.To(int32(3)),
+			ServiceName: "emqx-headless",
+		},
+		Status: appsv1.StatefulSetStatus{Replicas: 3},
+	}
+	pods := []client.Object{}
+	for i := 0; i < 3; i++ {
+		name := fmt.Sprintf("emqx-core-abc-%d", i)
+		pods = append(pods, &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "emqx", UID: types.UID(name)}})
+		instance.Status.CoreNodes = append(instance.Status.CoreNodes, appsv2beta1.EMQXNode{
+			Node:          fmt.Sprintf("emqx@%s.emqx-headless.emqx.svc.cluster.local", name),
+			NodeStatus:    "running",
+			Edition:       "Enterprise",
+			ControllerUID: sts.UID,
+			PodUID:        types.UID(name),
+		})
+	}
+	victim := instance.Status.CoreNodes[2].Node
+
+	newSyncPods := func(instance *appsv2beta1.EMQX) (*syncPods, client.Client) {
+		fakeClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(sts.DeepCopy()).WithObjects(pods...).Build()
+		return &syncPods{&EMQXReconciler{
+			Handler:       &handler.Handler{Client: fakeClient},
+			EventRecorder: record.NewFakeRecorder(10),
+		}}, fakeClient
+	}
+	getReplicas := func(c client.Client) int32 {
+		got := &appsv1.StatefulSet{}
+		assert.NoError(t, c.Get(ctx, client.ObjectKeyFromObject(sts), got))
+		return *got.Spec.Replicas
+	}
+	getPod := func(c client.Client, name string) *corev1.Pod {
+		got := &corev1.Pod{}
+		assert.NoError(t, c.Get(ctx, types.NamespacedName{Namespace: "emqx", Name: name}, got))
+		return got
+	}
+
+	t.Run("scale in the empty node with the highest ordinal", func(t *testing.T) {
+		emqx := instance.DeepCopy()
+		emqx.Status.CoreNodes[2].Edition = "Opensource"
+		s, c := newSyncPods(emqx)
+		requester := &innerReq.FakeRequester{
+			ReqFunc: func(method string, url url.URL, body []byte, header http.Header) (*http.Response, []byte, error) {
+				t.Errorf("unexpected request %s %s", method, url.Path)
+				return &http.Response{StatusCode: 200}, nil, nil
+			},
+		}
+		assert.NoError(t, s.scaleInSts(ctx, emqx, requester, sts.DeepCopy()))
+		assert.Equal(t, int32(2), getReplicas(c))
+		assert.Equal(t, "-99999", getPod(c, "emqx-core-abc-2").Annotations["controller.kubernetes.io/pod-deletion-cost"])
+		assert.NotContains(t, getPod(c, "emqx-core-abc-1").Annotations, "controller.kubernetes.io/pod-deletion-cost")
+	})
+
+	t.Run("evacuate the node before scaling in", func(t *testing.T) {
+		emqx := instance.DeepCopy()
+		emqx.Status.CoreNodes[2].Session = 10
+		s, c := newSyncPods(emqx)
+		var migrateTo []string
+		requester := &innerReq.FakeRequester{
+			ReqFunc: func(method string, url url.URL, body []byte, header http.Header) (*http.Response, []byte, error) {
+				assert.Equal(t, "api/v5/load_rebalance/"+victim+"/evacuation/start", url.Path)
+				reqBody := map[string][]string{}
+				_ = json.Unmarshal(body, &reqBody)
+				migrateTo = reqBody["migrate_to"]
+				return &http.Response{StatusCode: 200}, nil, nil
+			},
+		}
+		assert.NoError(t, s.scaleInSts(ctx, emqx, requester, sts.DeepCopy()))
+		assert.ElementsMatch(t, []string{emqx.Status.CoreNodes[0].Node, emqx.Status.CoreNodes[1].Node}, migrateTo)
+		assert.Equal(t, int32(3), getReplicas(c))
+
+		// The replicas are not decreased until the node is empty
+		emqx.Status.NodeEvacuationsStatus = []appsv2beta1.NodeEvacuationStatus{{Node: victim, State: "evacuating"}}
+		assert.NoError(t, s.scaleInSts(ctx, emqx, requester, sts.DeepCopy()))
+		assert.Equal(t, int32(3), getReplicas(c))
+
+		emqx.Status.NodeEvacuationsStatus = []appsv2beta1.NodeEvacuationStatus{{Node: victim, State: "prohibiting"}}
+		assert.NoError(t, s.scaleInSts(ctx, emqx, requester, sts.DeepCopy()))
+		assert.Equal(t, int32(2), getReplicas(c))
+	})
+
+	t.Run("cancel the scale in when the replicas are raised again", func(t *testing.T) {
+		emqx := instance.DeepCopy()
+		emqx.Spec.CoreTemplate.Spec.Replicas = ptr.To(int32(3))
+		emqx.Status.NodeEvacuationsStatus = []appsv2beta1.NodeEvacuationStatus{{Node: victim, State: "evacuating"}}
+		s, c := newSyncPods(emqx)
+		pod := getPod(c, "emqx-core-abc-2")
+		assert.NoError(t, s.markScaleInPod(ctx, pod))
+
+		stopped := false
+		requester := &innerReq.FakeRequester{
+			ReqFunc: func(method string, url url.URL, body []byte, header http.Header) (*http.Response, []byte, error) {
+				assert.Equal(t, "api/v5/load_rebalance/"+victim+"/evacuation/stop", url.Path)
+				stopped = true
+				return &http.Response{StatusCode: 200}, nil, nil
+			},
+		}
+		assert.NoError(t, s.scaleInSts(ctx, emqx, requester, sts.DeepCopy()))
+		assert.True(t, stopped)
+		assert.Equal(t, int32(3), getReplicas(c))
+		assert.NotContains(t, getPod(c, "emqx-core-abc-2").Annotations, "controller.kubernetes.io/pod-deletion-cost")
+	})
+}
+
+func TestGetScaleInReplicantNode(t *testing.T) {
+	assert.Nil(t, getScaleInReplicantNode(nil))
+
+	nodes := []appsv2beta1.EMQXNode{
+		{Node: "emqx@10.0.0.1", Connections: 100, Session: 100},
+		{Node: "emqx@10.0.0.2", Connections: 50, Session: 200},
+		{Node: "emqx@10.0.0.3", Connections: 50, Session: 80},
+		{Node: "emqx@10.0.0.4", Connections: 300, Session: 10},
+	}
+	assert.Equal(t, "emqx@10.0.0.3", getScaleInReplicantNode(nodes).Node)
+}
+
+func TestStopEvacuationByAPI(t *testing.T) {
+	t.Run("stop evacuation", func(t *testing.T) {
+		requester := &innerReq.FakeRequester{
+			ReqFunc: func(method string, url url.URL, body []byte, header http.Header) (*http.Response, []byte, error) {
+				assert.Equal(t, "POST", method)
+				assert.Equal(t, "api/v5/load_rebalance/emqx@10.0.0.1/evacuation/stop", url.Path)
+				return &http.Response{StatusCode: 200}, nil, nil
+			},
+		}
+		assert.NoError(t, stopEvacuationByAPI(requester, "emqx@10.0.0.1"))
+	})
+
+	t.Run("evacuation is not started", func(t *testing.T) {
+		requester := &innerReq.FakeRequester{
+			ReqFunc: func(method string, url url.URL, body []byte, header http.Header) (*http.Response, []byte, error) {
+				return &http.Response{StatusCode: 400, Status: "400 Bad Request"}, []byte(`{"code":"BAD_REQUEST","message":"not_started"}`), nil
+			},
+		}
+		assert.NoError(t, stopEvacuationByAPI(requester, "emqx@10.0.0.1"))
+	})
+
+	t.Run("failed to stop evacuation", func(t *testing.T) {
+		requester := &innerReq.FakeRequester{
+			ReqFunc: func(method string, url url.URL, body []byte, header http.Header) (*http.Response, []byte, error) {
+				return &http.Response{StatusCode: 500, Status: "500 Internal Server Error"}, nil, nil
+			},
+		}
+		assert.ErrorContains(t, stopEvacuationByAPI(requester, "emqx@10.0.0.1"), "500 Internal Server Error")
+	})
+}
@@ -0,0 +1,156 @@
+package v2beta1
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	emperror "emperror.dev/errors"
+	appsv2beta1 "github.com/emqx/emqx-operator/apis/apps/v2beta1"
+	innerReq "github.com/emqx/emqx-operator/internal/requester"
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+type syncPVCs struct {
+	*EMQXReconciler
+}
+
+// syncPVCs expands the persistentVolumeClaims of the core nodes when the storage size of
+// ".spec.coreTemplate.spec.volumeClaimTemplates" is increased, the volumeClaimTemplates
+// of the statefulSet are immutable, so the persistentVolumeClaims are patched one by one.
+func (s *syncPVCs) reconcile(ctx context.Context, logger logr.Logger, instance *appsv2beta1.EMQX, _ innerReq.RequesterInterface) subResult {
+	desired, ok := instance.Spec.CoreTemplate.Spec.VolumeClaimTemplates.Resources.Requests[corev1.ResourceStorage]
+	if !ok {
+		return subResult{}
+	}
+	updateSts, _, _ := getStateFulSetList(ctx, s.Client, instance)
+	if updateSts == nil {
+		return subResult{}
+	}
+
+	pvcList := &corev1.PersistentVolumeClaimList{}
+	if err := s.Client.List(ctx, pvcList,
+		client.InNamespace(instance.Namespace),
+		client.MatchingLabels(updateSts.Spec.Selector.MatchLabels),
+	); err != nil {
+		return subResult{err: emperror.Wrap(err, "failed to list persistentVolumeClaims")}
+	}
+	sort.Slice(pvcList.Items, func(i, j int) bool {
+		return pvcList.Items[i].Name < pvcList.Items[j].Name
+	})
+
+	status := &appsv2beta1.VolumeExpansionStatus{TargetSize: desired}
+	for _, p := range pvcList.Items {
+		pvc := p.DeepCopy()
+		if pvc.DeletionTimestamp != nil {
+			continue
+		}
+		claim := appsv2beta1.VolumeClaimStatus{
+			Name:     pvc.Name,
+			Capacity: pvc.Status.Capacity[corev1.ResourceStorage],
+		}
+
+		requested := pvc.Spec.Resources.Requests[corev1.ResourceStorage]
+		if requested.Cmp(desired) < 0 {
+			allowed, err := s.allowVolumeExpansion(ctx, pvc)
+			if err != nil {
+				return subResult{err: err}
+			}
+			if !allowed {
+				claim.Phase = appsv2beta1.VolumeExpansionUnsupported
+				claim.Message = "the storageClass of the persistentVolumeClaim does not allow volume expansion"
+				if getVolumeClaimPhase(instance.Status.CoreVolumeExpansionStatus, pvc.Name) != appsv2beta1.VolumeExpansionUnsupported {
+					s.EventRecorder.Event(instance, corev1.EventTypeWarning, "VolumeExpansionUnsupported", fmt.Sprintf("Can not expand persistentVolumeClaim %s, %s", pvc.Name, claim.Message))
+				}
+				status.Claims = append(status.Claims, claim)
+				continue
+			}
+
+			logger.Info("trying to expand persistentVolumeClaim for EMQX", "persistentVolumeClaim", klog.KObj(pvc), "from", requested.String(), "to", desired.String())
+			patch := client.MergeFrom(pvc.DeepCopy())
+			pvc.Spec.Resources.Requests[corev1.ResourceStorage] = desired
+			if err := s.Client.Patch(ctx, pvc, patch); err != nil {
+				return subResult{err: emperror.Wrapf(err, "failed to expand persistentVolumeClaim %s", pvc.Name)}
+			}
+			s.EventRecorder.Event(instance, corev1.EventTypeNormal, "ExpandVolume", fmt.Sprintf("Expand persistentVolumeClaim %s from %s to %s", pvc.Name, requested.String(), desired.String()))
+		}
+		claim.Phase = getVolumeExpansionPhase(pvc, desired)
+		status.Claims = append(status.Claims, claim)
+	}
+
+	inProgress := false
+	for _, claim := range status.Claims {
+		if claim.Phase == appsv2beta1.VolumeExpansionResizing || claim.Phase == appsv2beta1.VolumeExpansionFileSystemResizePending {
+			inProgress = true
+		}
+	}
+	// Nothing has been expanded, don't record it in status
+	if instance.Status.CoreVolumeExpansionStatus == nil && !inProgress && !hasUnsupportedVolumeClaim(status) {
+		return subResult{}
+	}
+
+	// The progress of the resizing is refreshed by the periodic reconciliation of EMQX
+	if !equality.Semantic.DeepEqual(instance.Status.CoreVolumeExpansionStatus, status) {
+		instance.Status.CoreVolumeExpansionStatus = status
+		if err := s.Client.Status().Update(ctx, instance); err != nil {
+			return subResult{err: emperror.Wrap(err, "failed to update status")}
+		}
+	}
+	return subResult{}
+}
+
+func (s *syncPVCs) allowVolumeExpansion(ctx context.Context, pvc *corev1.PersistentVolumeClaim) (bool, error) {
+	if pvc.Spec.StorageClassName == nil || *pvc.Spec.StorageClassName == "" {
+		return false, nil
+	}
+	sc := &storagev1.StorageClass{}
+	if err := s.Client.Get(ctx, types.NamespacedName{Name: *pvc.Spec.StorageClassName}, sc); err != nil {
+		if k8sErrors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, emperror.Wrapf(err, "failed to get storageClass %s", *pvc.Spec.StorageClassName)
+	}
+	return sc.AllowVolumeExpansion != nil && *sc.AllowVolumeExpansion, nil
+}
+
+func getVolumeExpansionPhase(pvc *corev1.PersistentVolumeClaim, desired resource.Quantity) appsv2beta1.VolumeExpansionPhase {
+	capacity := pvc.Status.Capacity[corev1.ResourceStorage]
+	if capacity.Cmp(desired) >= 0 {
+		return appsv2beta1.VolumeExpansionCompleted
+	}
+	for _, c := range pvc.Status.Conditions {
+		if c.Type == corev1.PersistentVolumeClaimFileSystemResizePending && c.Status == corev1.ConditionTrue {
+			return appsv2beta1.VolumeExpansionFileSystemResizePending
+		}
+	}
+	return appsv2beta1.VolumeExpansionResizing
+}
+
+func getVolumeClaimPhase(status *appsv2beta1.VolumeExpansionStatus, name string) appsv2beta1.VolumeExpansionPhase {
+	if status == nil {
+		return ""
+	}
+	for _, claim := range status.Claims {
+		if claim.Name == name {
+			return claim.Phase
+		}
+	}
+	return ""
+}
+
+func hasUnsupportedVolumeClaim(status *appsv2beta1.VolumeExpansionStatus) bool {
+	for _, claim := range status.Claims {
+		if claim.Phase == appsv2beta1.VolumeExpansionUnsupported {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,183 @@
+package v2beta1
+
+import (
+	"testing"
+
+	appsv2beta1 "github.com/emqx/emqx-operator/apis/apps/v2beta1"
+	"github.com/emqx/emqx-operator/internal/handler"
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestGetVolumeExpansionPhase(t *testing.T) {
+	pvc := &corev1.PersistentVolumeClaim{
+		Status: corev1.PersistentVolumeClaimStatus{
+			Capacity: corev1.ResourceList{
+				corev1.ResourceStorage: resource.MustParse("1Gi"),
+			},
+		},
+	}
+
+	t.Run("completed", func(t *testing.T) {
+		assert.Equal(t, appsv2beta1.VolumeExpansionCompleted, getVolumeExpansionPhase(pvc, resource.MustParse("1Gi")))
+	})
+
+	t.Run("resizing", func(t *testing.T) {
+		assert.Equal(t, appsv2beta1.VolumeExpansionResizing, getVolumeExpansionPhase(pvc, resource.MustParse("2Gi")))
+	})
+
+	t.Run("file system resize pending", func(t *testing.T) {
+		pending := pvc.DeepCopy()
+		pending.Status.Conditions = []corev1.PersistentVolumeClaimCondition{
+			{Type: corev1.PersistentVolumeClaimFileSystemResizePending, Status: corev1.ConditionTrue},
+		}
+		assert.Equal(t, appsv2beta1.VolumeExpansionFileSystemResizePending, getVolumeExpansionPhase(pending, resource.MustParse("2Gi")))
+	})
+}
+
+func TestSyncPVCs(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = appsv2beta1.AddToScheme(scheme)
+	_ = appsv1.AddToScheme(scheme)
+	_ = corev1.AddToScheme(scheme)
+	_ = storagev1.AddToScheme(scheme)
+
+	instance := &appsv2beta1.EMQX{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "emqx",
+			Namespace: "emqx",
+		},
+		Spec: appsv2beta1.EMQXSpec{
+			CoreTemplate: appsv2beta1.EMQXCoreTemplate{
+				Spec: appsv2beta1.EMQXCoreTemplateSpec{
+					VolumeClaimTemplates: corev1.PersistentVolumeClaimSpec{
+						Resources: corev1.VolumeResourceRequirements{
+							Requests: corev1.ResourceList{
+								corev1.ResourceStorage: resource.MustParse("2Gi"),
+							},
+						},
+					},
+				},
+			},
+		},
+		Status: appsv2beta1.EMQXStatus{
+			CoreNodesStatus: appsv2beta1.EMQXNodesStatus{
+				UpdateRevision: "fake",
+			},
+		},
+	}
+	labels := appsv2beta1.CloneAndAddLabel(appsv2beta1.DefaultCoreLabels(instance), appsv2beta1.LabelsPodTemplateHashKey, "fake")
+
+	sts := &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "emqx-core-fake",
+			Namespace: "emqx",
+			Labels:    labels,
+		},
+		Spec: appsv1.StatefulSetSpec{
+			Selector: &metav1.LabelSelector{MatchLabels: labels},
+		},
+	}
+
+	newPVC := func(name, storageClassName string) *corev1.PersistentVolumeClaim {
+		return &corev1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "emqx",
+				Labels:    labels,
+			},
+			Spec: corev1.PersistentVolumeClaimSpec{
+				StorageClassName: ptr.To(storageClassName),
+				Resources: corev1.VolumeResourceRequirements{
+					Requests: corev1.ResourceList{
+						corev1.ResourceStorage: resource.MustParse("1Gi"),
+					},
+				},
+			},
+			Status: corev1.PersistentVolumeClaimStatus{
+				Capacity: corev1.ResourceList{
+					corev1.ResourceStorage: resource.MustParse("1Gi"),
+				},
+			},
+		}
+	}
+
+	t.Run("expand volume", func(t *testing.T) {
+		emqx := instance.DeepCopy()
+		fakeClient := fake.NewClientBuilder().WithScheme(scheme).
+			WithObjects(emqx, sts, newPVC("emqx-core-data-emqx-core-fake-0", "standard")).
+			WithObjects(&storagev1.StorageClass{
+				ObjectMeta:           metav1.ObjectMeta{Name: "standard"},
+				AllowVolumeExpansion: ptr.To(true),
+			}).
+			WithStatusSubresource(emqx).
+			Build()
+		s := &syncPVCs{&EMQXReconciler{
+			Handler:       &handler.Handler{Client: fakeClient},
+			EventRecorder: record.NewFakeRecorder(10),
+		}}
+
+		assert.Nil(t, s.reconcile(ctx, logger, emqx, nil).err)
+
+		pvc := &corev1.PersistentVolumeClaim{}
+		assert.NoError(t, fakeClient.Get(ctx, client.ObjectKey{Namespace: "emqx", Name: "emqx-core-data-emqx-core-fake-0"}, pvc))
+		assert.Equal(t, "2Gi", pvc.Spec.Resources.Requests.Storage().String())
+
+		assert.NotNil(t, emqx.Status.CoreVolumeExpansionStatus)
+		assert.Equal(t, "2Gi", emqx.Status.CoreVolumeExpansionStatus.TargetSize.String())
+		assert.Len(t, emqx.Status.CoreVolumeExpansionStatus.Claims, 1)
+		assert.Equal(t, "emqx-core-data-emqx-core-fake-0", emqx.Status.CoreVolumeExpansionStatus.Claims[0].Name)
+		assert.Equal(t, appsv2beta1.VolumeExpansionResizing, emqx.Status.CoreVolumeExpansionStatus.Claims[0].Phase)
+	})
+
+	t.Run("storage class does not allow volume expansion", func(t *testing.T) {
+		emqx := instance.DeepCopy()
+		fakeClient := fake.NewClientBuilder().WithScheme(scheme).
+			WithObjects(emqx, sts, newPVC("emqx-core-data-emqx-core-fake-0", "standard")).
+			WithObjects(&storagev1.StorageClass{
+				ObjectMeta: metav1.ObjectMeta{Name: "standard"},
+			}).
+			WithStatusSubresource(emqx).
+			Build()
+		recorder := record.NewFakeRecorder(10)
+		s := &syncPVCs{&EMQXReconciler{
+			Handler:       &handler.Handler{Client: fakeClient},
+			EventRecorder: recorder,
+		}}
+
+		assert.Nil(t, s.reconcile(ctx, logger, emqx, nil).err)
+
+		pvc := &corev1.PersistentVolumeClaim{}
+		assert.NoError(t, fakeClient.Get(ctx, client.ObjectKey{Namespace: "emqx", Name: "emqx-core-data-emqx-core-fake-0"}, pvc))
+		assert.Equal(t, "1Gi", pvc.Spec.Resources.Requests.Storage().String())
+
+		assert.NotNil(t, emqx.Status.CoreVolumeExpansionStatus)
+		assert.Equal(t, appsv2beta1.VolumeExpansionUnsupported, emqx.Status.CoreVolumeExpansionStatus.Claims[0].Phase)
+		assert.Len(t, recorder.Events, 1)
+	})
+
+	t.Run("nothing to expand", func(t *testing.T) {
+		emqx := instance.DeepCopy()
+		emqx.Spec.CoreTemplate.Spec.VolumeClaimTemplates.Resources.Requests[corev1.ResourceStorage] = resource.MustParse("1Gi")
+		fakeClient := fake.NewClientBuilder().WithScheme(scheme).
+			WithObjects(emqx, sts, newPVC("emqx-core-data-emqx-core-fake-0", "standard")).
+			WithStatusSubresource(emqx).
+			Build()
+		s := &syncPVCs{&EMQXReconciler{
+			Handler:       &handler.Handler{Client: fakeClient},
+			EventRecorder: record.NewFakeRecorder(10),
+		}}
+
+		assert.Nil(t, s.reconcile(ctx, logger, emqx, nil).err)
+		assert.Nil(t, emqx.Status.CoreVolumeExpansionStatus)
+	})
+}
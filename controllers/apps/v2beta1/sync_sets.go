@@ -2,13 +2,23 @@ package v2beta1
 
 import (
 	"context"
+	"fmt"
+	"sort"
+	"time"
 
+	emperror "emperror.dev/errors"
 	appsv2beta1 "github.com/emqx/emqx-operator/apis/apps/v2beta1"
 	innerReq "github.com/emqx/emqx-operator/internal/requester"
 	"github.com/go-logr/logr"
+	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/klog/v2"
+	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
@@ -46,30 +56,184 @@ func (s *syncSets) reconcile(ctx context.Context, logger logr.Logger, instance *
 			if sts.Status.Replicas != 0 || *(sts.Spec.Replicas) != 0 || sts.Generation > sts.Status.ObservedGeneration || sts.DeletionTimestamp != nil {
 				continue
 			}
-			logger.Info("trying to cleanup statefulSet for EMQX", "statefulSet", klog.KObj(sts), "EMQX", klog.KObj(instance))
-			if err := s.Client.Delete(ctx, sts); err != nil && !k8sErrors.IsNotFound(err) {
+			if err := deleteStatefulSet(ctx, s.Client, logger, instance, sts); err != nil {
 				return subResult{err: err}
 			}
+		}
+	}
 
-			// Delete PVCs
-			pvcList := &corev1.PersistentVolumeClaimList{}
-			_ = s.Client.List(ctx, pvcList,
-				client.InNamespace(instance.Namespace),
-				client.MatchingLabels(sts.Spec.Selector.MatchLabels),
-			)
-
-			for _, p := range pvcList.Items {
-				pvc := p.DeepCopy()
-				if pvc.DeletionTimestamp != nil {
-					continue
-				}
-				logger.Info("trying to cleanup persistentVolumeClaim for EMQX", "persistentVolumeClaim", klog.KObj(pvc), "EMQX", klog.KObj(instance))
-				if err := s.Client.Delete(ctx, pvc); err != nil && !k8sErrors.IsNotFound(err) {
-					return subResult{err: err}
+	if err := s.syncRetiredPVCs(ctx, logger, instance); err != nil {
+		return subResult{err: err}
+	}
+
+	return subResult{}
+}
+
+// syncRetiredPVCs applies the pvcRetentionPolicy to the persistentVolumeClaims retired by deleteStatefulSet,
+// and records the ones which are retained or waiting for deletion in status
+func (s *syncSets) syncRetiredPVCs(ctx context.Context, logger logr.Logger, instance *appsv2beta1.EMQX) error {
+	pvcList := &corev1.PersistentVolumeClaimList{}
+	if err := s.Client.List(ctx, pvcList,
+		client.InNamespace(instance.Namespace),
+		client.MatchingLabels(appsv2beta1.DefaultCoreLabels(instance)),
+	); err != nil {
+		return emperror.Wrap(err, "failed to list persistentVolumeClaims")
+	}
+	sort.Slice(pvcList.Items, func(i, j int) bool {
+		return pvcList.Items[i].Name < pvcList.Items[j].Name
+	})
+
+	stsList := &appsv1.StatefulSetList{}
+	if err := s.Client.List(ctx, stsList,
+		client.InNamespace(instance.Namespace),
+		client.MatchingLabels(appsv2beta1.DefaultCoreLabels(instance)),
+	); err != nil {
+		return emperror.Wrap(err, "failed to list statefulSets")
+	}
+	stsHashes := map[string]bool{}
+	for _, sts := range stsList.Items {
+		if sts.DeletionTimestamp == nil {
+			stsHashes[sts.Labels[appsv2beta1.LabelsPodTemplateHashKey]] = true
+		}
+	}
+
+	policy := instance.Spec.CoreTemplate.Spec.PVCRetentionPolicy
+	var retired []appsv2beta1.RetiredVolumeClaimStatus
+	for _, p := range pvcList.Items {
+		pvc := p.DeepCopy()
+		retiredAt, ok := pvc.Annotations[appsv2beta1.AnnotationsRetiredAtKey]
+		if !ok || pvc.DeletionTimestamp != nil {
+			continue
+		}
+		// The statefulSet has been created again by the rollback, so the persistentVolumeClaim is in use again
+		if stsHashes[pvc.Labels[appsv2beta1.LabelsPodTemplateHashKey]] {
+			patch := client.MergeFrom(pvc.DeepCopy())
+			delete(pvc.Annotations, appsv2beta1.AnnotationsRetiredAtKey)
+			if err := s.Client.Patch(ctx, pvc, patch); err != nil && !k8sErrors.IsNotFound(err) {
+				return emperror.Wrap(err, "failed to patch persistentVolumeClaim")
+			}
+			continue
+		}
+		retiredTime, err := time.Parse(time.RFC3339, retiredAt)
+		if err != nil {
+			retiredTime = pvc.CreationTimestamp.Time
+		}
+		status := appsv2beta1.RetiredVolumeClaimStatus{
+			Name:        pvc.Name,
+			RetiredTime: metav1.NewTime(retiredTime),
+		}
+
+		if policy != nil && policy.Type == appsv2beta1.PVCRetentionPolicyRetain {
+			status.Phase = appsv2beta1.RetiredVolumeClaimRetained
+			retired = append(retired, status)
+			continue
+		}
+		if policy != nil && policy.Type == appsv2beta1.PVCRetentionPolicyDeleteAfter && policy.DeleteAfter != nil {
+			deletionTime := retiredTime.Add(policy.DeleteAfter.Duration)
+			if time.Now().Before(deletionTime) {
+				status.Phase = appsv2beta1.RetiredVolumeClaimPendingDeletion
+				status.DeletionTime = &metav1.Time{Time: deletionTime}
+				retired = append(retired, status)
+				continue
+			}
+		}
+		if policy != nil && policy.Snapshot != nil {
+			status.Snapshot = pvc.Name
+			ready, err := s.snapshotPVC(ctx, instance, pvc, policy.Snapshot.VolumeSnapshotClassName)
+			if err != nil || !ready {
+				status.Phase = appsv2beta1.RetiredVolumeClaimSnapshotting
+				if err != nil {
+					status.Message = err.Error()
 				}
+				retired = append(retired, status)
+				continue
 			}
 		}
+
+		logger.Info("trying to cleanup retired persistentVolumeClaim for EMQX", "persistentVolumeClaim", klog.KObj(pvc), "EMQX", klog.KObj(instance))
+		if err := s.Client.Delete(ctx, pvc); err != nil && !k8sErrors.IsNotFound(err) {
+			return emperror.Wrap(err, "failed to delete persistentVolumeClaim")
+		}
 	}
 
-	return subResult{}
+	if !equality.Semantic.DeepEqual(instance.Status.RetiredVolumeClaims, retired) {
+		instance.Status.RetiredVolumeClaims = retired
+		if err := s.Client.Status().Update(ctx, instance); err != nil {
+			return emperror.Wrap(err, "failed to update status")
+		}
+	}
+	return nil
+}
+
+// snapshotPVC creates a VolumeSnapshot with the same name as the persistentVolumeClaim, and returns whether it is ready to use.
+// The VolumeSnapshot is owned by the EMQX, so it is garbage collected with the EMQX.
+func (s *syncSets) snapshotPVC(ctx context.Context, instance *appsv2beta1.EMQX, pvc *corev1.PersistentVolumeClaim, volumeSnapshotClassName string) (bool, error) {
+	snapshot := &unstructured.Unstructured{}
+	snapshot.SetGroupVersionKind(schema.GroupVersionKind{Group: "snapshot.storage.k8s.io", Version: "v1", Kind: "VolumeSnapshot"})
+	if err := s.Client.Get(ctx, client.ObjectKeyFromObject(pvc), snapshot); err != nil {
+		if !k8sErrors.IsNotFound(err) {
+			return false, emperror.Wrap(err, "failed to get volumeSnapshot")
+		}
+		snapshot.SetNamespace(pvc.Namespace)
+		snapshot.SetName(pvc.Name)
+		snapshot.SetLabels(appsv2beta1.DefaultCoreLabels(instance))
+		if err := ctrl.SetControllerReference(instance, snapshot, s.Scheme); err != nil {
+			return false, emperror.Wrap(err, "failed to set controller reference")
+		}
+		_ = unstructured.SetNestedField(snapshot.Object, pvc.Name, "spec", "source", "persistentVolumeClaimName")
+		if volumeSnapshotClassName != "" {
+			_ = unstructured.SetNestedField(snapshot.Object, volumeSnapshotClassName, "spec", "volumeSnapshotClassName")
+		}
+		if err := s.Client.Create(ctx, snapshot); err != nil {
+			return false, emperror.Wrap(err, "failed to create volumeSnapshot")
+		}
+		s.EventRecorder.Event(instance, corev1.EventTypeNormal, "CreateVolumeSnapshot", fmt.Sprintf("Create volumeSnapshot %s for retired persistentVolumeClaim %s", snapshot.GetName(), pvc.Name))
+		return false, nil
+	}
+	ready, _, _ := unstructured.NestedBool(snapshot.Object, "status", "readyToUse")
+	return ready, nil
+}
+
+// deleteStatefulSet deletes the statefulSet and the persistentVolumeClaims of it
+func deleteStatefulSet(ctx context.Context, k8sClient client.Client, logger logr.Logger, instance *appsv2beta1.EMQX, sts *appsv1.StatefulSet) error {
+	logger.Info("trying to cleanup statefulSet for EMQX", "statefulSet", klog.KObj(sts), "EMQX", klog.KObj(instance))
+	if err := k8sClient.Delete(ctx, sts); err != nil && !k8sErrors.IsNotFound(err) {
+		return err
+	}
+
+	// Delete PVCs
+	pvcList := &corev1.PersistentVolumeClaimList{}
+	_ = k8sClient.List(ctx, pvcList,
+		client.InNamespace(instance.Namespace),
+		client.MatchingLabels(sts.Spec.Selector.MatchLabels),
+	)
+
+	// The persistentVolumeClaims are retired instead of being deleted, they are handled by syncRetiredPVCs
+	policy := instance.Spec.CoreTemplate.Spec.PVCRetentionPolicy
+	retire := policy != nil && (policy.Type != appsv2beta1.PVCRetentionPolicyDelete || policy.Snapshot != nil)
+	retiredAt := time.Now().UTC().Format(time.RFC3339)
+
+	for _, p := range pvcList.Items {
+		pvc := p.DeepCopy()
+		if pvc.DeletionTimestamp != nil {
+			continue
+		}
+		if retire {
+			if _, ok := pvc.Annotations[appsv2beta1.AnnotationsRetiredAtKey]; ok {
+				continue
+			}
+			logger.Info("trying to retire persistentVolumeClaim for EMQX", "persistentVolumeClaim", klog.KObj(pvc), "EMQX", klog.KObj(instance))
+			patch := client.MergeFrom(pvc.DeepCopy())
+			pvc.Annotations = appsv2beta1.CloneAndMergeMap(map[string]string{appsv2beta1.AnnotationsRetiredAtKey: retiredAt}, pvc.Annotations)
+			if err := k8sClient.Patch(ctx, pvc, patch); err != nil && !k8sErrors.IsNotFound(err) {
+				return err
+			}
+			continue
+		}
+		logger.Info("trying to cleanup persistentVolumeClaim for EMQX", "persistentVolumeClaim", klog.KObj(pvc), "EMQX", klog.KObj(instance))
+		if err := k8sClient.Delete(ctx, pvc); err != nil && !k8sErrors.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
 }
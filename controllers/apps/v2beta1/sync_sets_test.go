@@ -0,0 +1,168 @@
+package v2beta1
+
+import (
+	"testing"
+	"time"
+
+	appsv2beta1 "github.com/emqx/emqx-operator/apis/apps/v2beta1"
+	"github.com/emqx/emqx-operator/internal/handler"
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestRetiredPVCs(t *testing.T) {
+	snapshotGVK := schema.GroupVersionKind{Group: "snapshot.storage.k8s.io", Version: "v1", Kind: "VolumeSnapshot"}
+	scheme := runtime.NewScheme()
+	_ = appsv2beta1.AddToScheme(scheme)
+	_ = appsv1.AddToScheme(scheme)
+	_ = corev1.AddToScheme(scheme)
+	scheme.AddKnownTypeWithName(snapshotGVK, &unstructured.Unstructured{})
+	scheme.AddKnownTypeWithName(snapshotGVK.GroupVersion().WithKind("VolumeSnapshotList"), &unstructured.UnstructuredList{})
+
+	instance := &appsv2beta1.EMQX{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "emqx",
+			Namespace: "emqx",
+		},
+	}
+	labels := appsv2beta1.CloneAndAddLabel(appsv2beta1.DefaultCoreLabels(instance), appsv2beta1.LabelsPodTemplateHashKey, "fake")
+	sts := &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "emqx-core-fake",
+			Namespace: "emqx",
+			Labels:    labels,
+		},
+		Spec: appsv1.StatefulSetSpec{
+			Selector: &metav1.LabelSelector{MatchLabels: labels},
+		},
+	}
+	pvc := &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "emqx-core-data-emqx-core-fake-0",
+			Namespace: "emqx",
+			Labels:    labels,
+		},
+	}
+
+	newSyncSets := func(emqx *appsv2beta1.EMQX, objs ...client.Object) *syncSets {
+		fakeClient := fake.NewClientBuilder().WithScheme(scheme).
+			WithObjects(emqx).
+			WithObjects(objs...).
+			WithStatusSubresource(emqx).
+			Build()
+		return &syncSets{&EMQXReconciler{
+			Handler:       &handler.Handler{Client: fakeClient},
+			Scheme:        scheme,
+			EventRecorder: record.NewFakeRecorder(10),
+		}}
+	}
+
+	t.Run("delete pvc with statefulSet by default", func(t *testing.T) {
+		emqx := instance.DeepCopy()
+		s := newSyncSets(emqx, sts.DeepCopy(), pvc.DeepCopy())
+		assert.NoError(t, deleteStatefulSet(ctx, s.Client, logger, emqx, sts.DeepCopy()))
+
+		err := s.Client.Get(ctx, client.ObjectKeyFromObject(pvc), &corev1.PersistentVolumeClaim{})
+		assert.True(t, k8sErrors.IsNotFound(err))
+	})
+
+	t.Run("retain pvc", func(t *testing.T) {
+		emqx := instance.DeepCopy()
+		emqx.Spec.CoreTemplate.Spec.PVCRetentionPolicy = &appsv2beta1.PVCRetentionPolicy{
+			Type: appsv2beta1.PVCRetentionPolicyRetain,
+		}
+		s := newSyncSets(emqx, sts.DeepCopy(), pvc.DeepCopy())
+		assert.NoError(t, deleteStatefulSet(ctx, s.Client, logger, emqx, sts.DeepCopy()))
+		assert.NoError(t, s.syncRetiredPVCs(ctx, logger, emqx))
+
+		got := &corev1.PersistentVolumeClaim{}
+		assert.NoError(t, s.Client.Get(ctx, client.ObjectKeyFromObject(pvc), got))
+		assert.Contains(t, got.Annotations, appsv2beta1.AnnotationsRetiredAtKey)
+
+		assert.Len(t, emqx.Status.RetiredVolumeClaims, 1)
+		assert.Equal(t, pvc.Name, emqx.Status.RetiredVolumeClaims[0].Name)
+		assert.Equal(t, appsv2beta1.RetiredVolumeClaimRetained, emqx.Status.RetiredVolumeClaims[0].Phase)
+	})
+
+	t.Run("delete pvc after duration", func(t *testing.T) {
+		emqx := instance.DeepCopy()
+		emqx.Spec.CoreTemplate.Spec.PVCRetentionPolicy = &appsv2beta1.PVCRetentionPolicy{
+			Type:        appsv2beta1.PVCRetentionPolicyDeleteAfter,
+			DeleteAfter: &metav1.Duration{Duration: time.Hour},
+		}
+		retired := pvc.DeepCopy()
+		retired.Annotations = map[string]string{
+			appsv2beta1.AnnotationsRetiredAtKey: time.Now().Add(-30 * time.Minute).UTC().Format(time.RFC3339),
+		}
+		s := newSyncSets(emqx, retired)
+		assert.NoError(t, s.syncRetiredPVCs(ctx, logger, emqx))
+		assert.Len(t, emqx.Status.RetiredVolumeClaims, 1)
+		assert.Equal(t, appsv2beta1.RetiredVolumeClaimPendingDeletion, emqx.Status.RetiredVolumeClaims[0].Phase)
+		assert.NotNil(t, emqx.Status.RetiredVolumeClaims[0].DeletionTime)
+
+		emqx.Spec.CoreTemplate.Spec.PVCRetentionPolicy.DeleteAfter = &metav1.Duration{Duration: 10 * time.Minute}
+		assert.NoError(t, s.syncRetiredPVCs(ctx, logger, emqx))
+		assert.Empty(t, emqx.Status.RetiredVolumeClaims)
+		err := s.Client.Get(ctx, client.ObjectKeyFromObject(pvc), &corev1.PersistentVolumeClaim{})
+		assert.True(t, k8sErrors.IsNotFound(err))
+	})
+
+	t.Run("snapshot before delete", func(t *testing.T) {
+		emqx := instance.DeepCopy()
+		emqx.Spec.CoreTemplate.Spec.PVCRetentionPolicy = &appsv2beta1.PVCRetentionPolicy{
+			Type:     appsv2beta1.PVCRetentionPolicyDelete,
+			Snapshot: &appsv2beta1.PVCSnapshot{VolumeSnapshotClassName: "csi-snapclass"},
+		}
+		s := newSyncSets(emqx, sts.DeepCopy(), pvc.DeepCopy())
+		assert.NoError(t, deleteStatefulSet(ctx, s.Client, logger, emqx, sts.DeepCopy()))
+		assert.NoError(t, s.syncRetiredPVCs(ctx, logger, emqx))
+
+		assert.Len(t, emqx.Status.RetiredVolumeClaims, 1)
+		assert.Equal(t, appsv2beta1.RetiredVolumeClaimSnapshotting, emqx.Status.RetiredVolumeClaims[0].Phase)
+		assert.Equal(t, pvc.Name, emqx.Status.RetiredVolumeClaims[0].Snapshot)
+
+		snapshot := &unstructured.Unstructured{}
+		snapshot.SetGroupVersionKind(snapshotGVK)
+		assert.NoError(t, s.Client.Get(ctx, client.ObjectKeyFromObject(pvc), snapshot))
+		claimName, _, _ := unstructured.NestedString(snapshot.Object, "spec", "source", "persistentVolumeClaimName")
+		assert.Equal(t, pvc.Name, claimName)
+		className, _, _ := unstructured.NestedString(snapshot.Object, "spec", "volumeSnapshotClassName")
+		assert.Equal(t, "csi-snapclass", className)
+		// The snapshot is garbage collected with the EMQX
+		assert.Equal(t, "emqx", metav1.GetControllerOf(snapshot).Name)
+
+		_ = unstructured.SetNestedField(snapshot.Object, true, "status", "readyToUse")
+		assert.NoError(t, s.Client.Update(ctx, snapshot))
+		assert.NoError(t, s.syncRetiredPVCs(ctx, logger, emqx))
+		assert.Empty(t, emqx.Status.RetiredVolumeClaims)
+		err := s.Client.Get(ctx, client.ObjectKeyFromObject(pvc), &corev1.PersistentVolumeClaim{})
+		assert.True(t, k8sErrors.IsNotFound(err))
+	})
+
+	t.Run("statefulSet is created again", func(t *testing.T) {
+		emqx := instance.DeepCopy()
+		emqx.Spec.CoreTemplate.Spec.PVCRetentionPolicy = &appsv2beta1.PVCRetentionPolicy{
+			Type: appsv2beta1.PVCRetentionPolicyRetain,
+		}
+		retired := pvc.DeepCopy()
+		retired.Annotations = map[string]string{
+			appsv2beta1.AnnotationsRetiredAtKey: time.Now().UTC().Format(time.RFC3339),
+		}
+		s := newSyncSets(emqx, sts.DeepCopy(), retired)
+		assert.NoError(t, s.syncRetiredPVCs(ctx, logger, emqx))
+		assert.Empty(t, emqx.Status.RetiredVolumeClaims)
+
+		got := &corev1.PersistentVolumeClaim{}
+		assert.NoError(t, s.Client.Get(ctx, client.ObjectKeyFromObject(pvc), got))
+		assert.NotContains(t, got.Annotations, appsv2beta1.AnnotationsRetiredAtKey)
+	})
+}
@@ -0,0 +1,312 @@
+package v2beta1
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"net/http"
+
+	emperror "emperror.dev/errors"
+	appsv2beta1 "github.com/emqx/emqx-operator/apis/apps/v2beta1"
+	innerReq "github.com/emqx/emqx-operator/internal/requester"
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/rand"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	listenersTLSVolumeName = "listeners-tls"
+	listenersTLSMountPath  = "/mounted/tls/listeners"
+	dashboardTLSVolumeName = "dashboard-tls"
+	dashboardTLSMountPath  = "/mounted/tls/dashboard"
+	tlsCACertKey           = "ca.crt"
+)
+
+type syncTLS struct {
+	*EMQXReconciler
+}
+
+func (s *syncTLS) reconcile(ctx context.Context, logger logr.Logger, instance *appsv2beta1.EMQX, r innerReq.RequesterInterface) subResult {
+	if instance.Spec.TLS == nil {
+		return subResult{}
+	}
+
+	certificates := []client.Object{}
+	if cert := instance.Spec.TLS.Listeners; cert != nil && cert.IssuerRef != nil {
+		certificates = append(certificates, generateCertificate(instance, cert, instance.ListenersTLSNamespacedName().Name, instance.ListenersServiceNamespacedName()))
+	}
+	if cert := instance.Spec.TLS.Dashboard; cert != nil && cert.IssuerRef != nil {
+		certificates = append(certificates, generateCertificate(instance, cert, instance.DashboardTLSNamespacedName().Name, instance.DashboardServiceNamespacedName()))
+	}
+	if len(certificates) > 0 {
+		gvk := certificates[0].GetObjectKind().GroupVersionKind()
+		if _, err := s.Client.RESTMapper().RESTMapping(gvk.GroupKind(), gvk.Version); err != nil {
+			if !meta.IsNoMatchError(err) {
+				return subResult{err: emperror.Wrap(err, "failed to get REST mapping for certificate")}
+			}
+			logger.V(1).Info("the cert-manager.io CRDs are not installed, skip to create certificate")
+		} else if err := s.CreateOrUpdateList(ctx, s.Scheme, logger, instance, certificates); err != nil {
+			return subResult{err: emperror.Wrap(err, "failed to create or update certificate")}
+		}
+	}
+
+	if r == nil || !instance.Status.IsConditionTrue(appsv2beta1.CoreNodesReady) {
+		return subResult{}
+	}
+
+	// The pods load the certificates from the mounted secrets when they start,
+	// the running EMQX nodes are reloaded by the EMQX configs API when the secrets are rotated.
+	// Only the paths of the mounted files are put into the EMQX configs, the private keys are not stored in cluster.hocon
+	config := ""
+	// The paths are not changed when the secrets are rotated, so the resource versions of the secrets are hashed too
+	hashes := map[string]string{}
+	if instance.Spec.TLS.Listeners != nil {
+		secret := &corev1.Secret{}
+		if err := s.Client.Get(ctx, instance.ListenersTLSNamespacedName(), secret); err != nil {
+			if k8sErrors.IsNotFound(err) {
+				return subResult{}
+			}
+			return subResult{err: emperror.Wrap(err, "failed to get listeners tls secret")}
+		}
+		listenersConfig := generateTLSConfig([]string{"listeners.ssl.default", "listeners.wss.default"}, listenersTLSMountPath, secret)
+		config += listenersConfig
+		hashes["listeners"] = computeConfigHash(listenersConfig + secret.ResourceVersion)
+	}
+	if instance.Spec.TLS.Dashboard != nil {
+		secret := &corev1.Secret{}
+		if err := s.Client.Get(ctx, instance.DashboardTLSNamespacedName(), secret); err != nil {
+			if k8sErrors.IsNotFound(err) {
+				return subResult{}
+			}
+			return subResult{err: emperror.Wrap(err, "failed to get dashboard tls secret")}
+		}
+		dashboardConfig := generateTLSConfig([]string{"dashboard.listeners.https"}, dashboardTLSMountPath, secret)
+		config += dashboardConfig
+		hashes["dashboard"] = computeConfigHash(dashboardConfig + secret.ResourceVersion)
+	}
+	if config == "" {
+		return subResult{}
+	}
+
+	data, _ := json.Marshal(hashes)
+	lastHashes := map[string]string{}
+	if last, ok := instance.Annotations[appsv2beta1.AnnotationsLastTLSCertificateKey]; ok {
+		if last == string(data) {
+			return subResult{}
+		}
+		_ = json.Unmarshal([]byte(last), &lastHashes)
+	}
+
+	if err := putEMQXConfigsByAPI(r, "Merge", config); err != nil {
+		return subResult{err: emperror.Wrap(err, "failed to reload tls certificates")}
+	}
+	// EMQX doesn't reload the listeners when the same paths are put, so the listeners are restarted to read the rotated files.
+	// They are not restarted when the certificates are loaded for the first time, the connections are closed by the restart.
+	if lastHashes["listeners"] != "" && lastHashes["listeners"] != hashes["listeners"] {
+		for _, id := range []string{"ssl:default", "wss:default"} {
+			if err := restartListenerByAPI(r, id); err != nil {
+				return subResult{err: emperror.Wrap(err, "failed to restart listener")}
+			}
+		}
+	}
+	s.EventRecorder.Event(instance, corev1.EventTypeNormal, "TLSCertificateReloaded", "Reloaded the TLS certificates of EMQX listeners")
+
+	if instance.Annotations == nil {
+		instance.Annotations = map[string]string{}
+	}
+	instance.Annotations[appsv2beta1.AnnotationsLastTLSCertificateKey] = string(data)
+	if err := s.Client.Update(ctx, instance); err != nil {
+		return subResult{err: emperror.Wrap(err, "failed to update emqx instance annotation")}
+	}
+	return subResult{}
+}
+
+// restartListenerByAPI restarts the listener on all of the EMQX nodes, it is ignored if the listener does not exist
+func restartListenerByAPI(r innerReq.RequesterInterface, id string) error {
+	url := r.GetURL("api/v5/listeners/" + id + "/restart")
+	resp, body, err := r.Request("POST", url, nil, nil)
+	if err != nil {
+		return emperror.Wrapf(err, "failed to request API %s", url.String())
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		return nil
+	}
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
+		return emperror.Errorf("failed to request API %s, status : %s, body: %s", url.String(), resp.Status, body)
+	}
+	return nil
+}
+
+// generateTLSConfig returns the EMQX config which sets the certificates of the listeners to the files of the secret
+// mounted by generateTLSPodSpec, EMQX reloads the listeners and reads the files again
+func generateTLSConfig(listeners []string, mountPath string, secret *corev1.Secret) string {
+	config := ""
+	for _, listener := range listeners {
+		config += fmt.Sprintf("%s.ssl_options.certfile = \"%s/%s\"\n", listener, mountPath, corev1.TLSCertKey)
+		config += fmt.Sprintf("%s.ssl_options.keyfile = \"%s/%s\"\n", listener, mountPath, corev1.TLSPrivateKeyKey)
+		if len(secret.Data[tlsCACertKey]) > 0 {
+			config += fmt.Sprintf("%s.ssl_options.cacertfile = \"%s/%s\"\n", listener, mountPath, tlsCACertKey)
+		}
+	}
+	return config
+}
+
+// getTLSSecretsWithCA returns the names of the secrets of ".spec.tls" which contain "ca.crt",
+// the secrets which are not found yet are treated as without "ca.crt"
+func getTLSSecretsWithCA(ctx context.Context, k8sClient client.Client, instance *appsv2beta1.EMQX) (map[string]bool, error) {
+	caSecrets := map[string]bool{}
+	if instance.Spec.TLS == nil {
+		return caSecrets, nil
+	}
+	names := []types.NamespacedName{}
+	if instance.Spec.TLS.Listeners != nil {
+		names = append(names, instance.ListenersTLSNamespacedName())
+	}
+	if instance.Spec.TLS.Dashboard != nil {
+		names = append(names, instance.DashboardTLSNamespacedName())
+	}
+	for _, name := range names {
+		secret := &corev1.Secret{}
+		if err := k8sClient.Get(ctx, name, secret); err != nil {
+			if k8sErrors.IsNotFound(err) {
+				continue
+			}
+			return nil, emperror.Wrapf(err, "failed to get tls secret %s", name.Name)
+		}
+		if len(secret.Data[tlsCACertKey]) > 0 {
+			caSecrets[name.Name] = true
+		}
+	}
+	return caSecrets, nil
+}
+
+// generateTLSPodSpec returns the volumes, the volume mounts and the environment variables of the EMQX container,
+// which make the listeners load the certificates from the mounted secrets when the EMQX node starts.
+// The secrets are not mounted by subPath, so the kubelet keeps the files up to date when they are rotated.
+// The caSecrets are the secrets which contain "ca.crt", see getTLSSecretsWithCA.
+func generateTLSPodSpec(instance *appsv2beta1.EMQX, caSecrets map[string]bool) ([]corev1.Volume, []corev1.VolumeMount, []corev1.EnvVar) {
+	type tlsMount struct {
+		secretName  string
+		volumeName  string
+		mountPath   string
+		envPrefixes []string
+	}
+
+	mounts := []tlsMount{}
+	if instance.Spec.TLS != nil && instance.Spec.TLS.Listeners != nil {
+		mounts = append(mounts, tlsMount{
+			secretName:  instance.ListenersTLSNamespacedName().Name,
+			volumeName:  listenersTLSVolumeName,
+			mountPath:   listenersTLSMountPath,
+			envPrefixes: []string{"EMQX_LISTENERS__SSL__DEFAULT", "EMQX_LISTENERS__WSS__DEFAULT"},
+		})
+	}
+	if instance.Spec.TLS != nil && instance.Spec.TLS.Dashboard != nil {
+		mounts = append(mounts, tlsMount{
+			secretName:  instance.DashboardTLSNamespacedName().Name,
+			volumeName:  dashboardTLSVolumeName,
+			mountPath:   dashboardTLSMountPath,
+			envPrefixes: []string{"EMQX_DASHBOARD__LISTENERS__HTTPS"},
+		})
+	}
+	// The certificates of ".spec.listeners" are configured by generateListenersConfig
+	for _, listener := range instance.Spec.Listeners {
+		if listener.TLSSecretName != "" {
+			mounts = append(mounts, tlsMount{
+				secretName: listener.TLSSecretName,
+				volumeName: getListenerTLSVolumeName(listener),
+				mountPath:  getListenerTLSMountPath(listener),
+			})
+		}
+	}
+
+	volumes := []corev1.Volume{}
+	volumeMounts := []corev1.VolumeMount{}
+	env := []corev1.EnvVar{}
+	for _, tls := range mounts {
+		volumes = append(volumes, corev1.Volume{
+			Name: tls.volumeName,
+			VolumeSource: corev1.VolumeSource{
+				Secret: &corev1.SecretVolumeSource{
+					SecretName: tls.secretName,
+				},
+			},
+		})
+		volumeMounts = append(volumeMounts, corev1.VolumeMount{
+			Name:      tls.volumeName,
+			MountPath: tls.mountPath,
+			ReadOnly:  true,
+		})
+		for _, prefix := range tls.envPrefixes {
+			env = append(env,
+				corev1.EnvVar{
+					Name:  prefix + "__SSL_OPTIONS__CERTFILE",
+					Value: fmt.Sprintf(`"%s/%s"`, tls.mountPath, corev1.TLSCertKey),
+				},
+				corev1.EnvVar{
+					Name:  prefix + "__SSL_OPTIONS__KEYFILE",
+					Value: fmt.Sprintf(`"%s/%s"`, tls.mountPath, corev1.TLSPrivateKeyKey),
+				},
+			)
+			// "ca.crt" is optional, EMQX fails to start the listener if the cacertfile does not exist, the same as generateTLSConfig
+			if caSecrets[tls.secretName] {
+				env = append(env, corev1.EnvVar{
+					Name:  prefix + "__SSL_OPTIONS__CACERTFILE",
+					Value: fmt.Sprintf(`"%s/%s"`, tls.mountPath, tlsCACertKey),
+				})
+			}
+		}
+	}
+	return volumes, volumeMounts, env
+}
+
+func generateCertificate(instance *appsv2beta1.EMQX, cert *appsv2beta1.TLSCertificate, secretName string, svc types.NamespacedName) *unstructured.Unstructured {
+	dnsNames := []interface{}{}
+	if len(cert.DNSNames) > 0 {
+		for _, dnsName := range cert.DNSNames {
+			dnsNames = append(dnsNames, dnsName)
+		}
+	} else {
+		dnsNames = append(dnsNames,
+			svc.Name,
+			fmt.Sprintf("%s.%s", svc.Name, svc.Namespace),
+			fmt.Sprintf("%s.%s.svc", svc.Name, svc.Namespace),
+			fmt.Sprintf("%s.%s.svc.%s", svc.Name, svc.Namespace, instance.Spec.ClusterDomain),
+		)
+	}
+
+	issuerRef := map[string]interface{}{
+		"name": cert.IssuerRef.Name,
+	}
+	if cert.IssuerRef.Kind != "" {
+		issuerRef["kind"] = cert.IssuerRef.Kind
+	}
+	if cert.IssuerRef.Group != "" {
+		issuerRef["group"] = cert.IssuerRef.Group
+	}
+
+	certificate := &unstructured.Unstructured{}
+	certificate.SetGroupVersionKind(schema.GroupVersionKind{Group: "cert-manager.io", Version: "v1", Kind: "Certificate"})
+	certificate.SetNamespace(instance.Namespace)
+	certificate.SetName(secretName)
+	certificate.SetLabels(appsv2beta1.CloneAndMergeMap(appsv2beta1.DefaultLabels(instance), instance.Labels))
+	_ = unstructured.SetNestedField(certificate.Object, map[string]interface{}{
+		"secretName": secretName,
+		"dnsNames":   dnsNames,
+		"issuerRef":  issuerRef,
+	}, "spec")
+	return certificate
+}
+
+func computeConfigHash(config string) string {
+	hasher := fnv.New32a()
+	_, _ = hasher.Write([]byte(config))
+	return rand.SafeEncodeString(fmt.Sprint(hasher.Sum32()))
+}
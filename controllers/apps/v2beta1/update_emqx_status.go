@@ -8,6 +8,7 @@ import (
 
 	emperror "emperror.dev/errors"
 	appsv2beta1 "github.com/emqx/emqx-operator/apis/apps/v2beta1"
+	"github.com/emqx/emqx-operator/internal/metrics"
 	innerReq "github.com/emqx/emqx-operator/internal/requester"
 	"github.com/go-logr/logr"
 	"github.com/tidwall/gjson"
@@ -23,7 +24,7 @@ type updateStatus struct {
 func (u *updateStatus) reconcile(ctx context.Context, logger logr.Logger, instance *appsv2beta1.EMQX, r innerReq.RequesterInterface) subResult {
 	instance.Status.CoreNodesStatus.Replicas = *instance.Spec.CoreTemplate.Spec.Replicas
 	if instance.Spec.ReplicantTemplate != nil {
-		instance.Status.ReplicantNodesStatus.Replicas = *instance.Spec.ReplicantTemplate.Spec.Replicas
+		instance.Status.ReplicantNodesStatus.Replicas = appsv2beta1.GetReplicantReplicas(instance)
 	}
 
 	if instance.Status.CoreNodesStatus.UpdateRevision != "" && instance.Status.CoreNodesStatus.CurrentRevision == "" {
@@ -134,6 +135,7 @@ func (u *updateStatus) reconcile(ctx context.Context, logger logr.Logger, instan
 
 	// update status condition
 	newEMQXStatusMachine(u.Client, instance).NextStatus(ctx)
+	metrics.ObserveEMQX(instance)
 
 	if err := u.Client.Status().Update(ctx, instance); err != nil {
 		return subResult{err: emperror.Wrap(err, "failed to update status")}
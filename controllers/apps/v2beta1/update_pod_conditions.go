@@ -135,10 +135,11 @@ func (u *updatePodConditions) checkRebalanceStatus(instance *appsv2beta1.EMQX, r
 	}
 
 	requester := &innerReq.Requester{
-		Schema:   schema,
-		Host:     net.JoinHostPort(pod.Status.PodIP, port),
-		Username: r.GetUsername(),
-		Password: r.GetPassword(),
+		Schema:    schema,
+		Host:      net.JoinHostPort(pod.Status.PodIP, port),
+		Username:  r.GetUsername(),
+		Password:  r.GetPassword(),
+		Transport: r.GetTransport(),
 	}
 
 	url := requester.GetURL("api/v5/load_rebalance/availability_check")
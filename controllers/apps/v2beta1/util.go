@@ -19,6 +19,7 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/apimachinery/pkg/util/rand"
 	"k8s.io/client-go/kubernetes"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -139,6 +140,19 @@ func checkInitialDelaySecondsReady(instance *appsv2beta1.EMQX) bool {
 	return int32(delay) > instance.Spec.UpdateStrategy.InitialDelaySeconds
 }
 
+// checkProgressDeadlineExceeded returns true if the nodes have been progressing longer than the progress deadline
+func checkProgressDeadlineExceeded(instance *appsv2beta1.EMQX, conditionType string) bool {
+	deadline := instance.Spec.UpdateStrategy.ProgressDeadlineSeconds
+	if deadline == 0 {
+		return false
+	}
+	_, condition := instance.Status.GetCondition(conditionType)
+	if condition == nil || condition.Status != metav1.ConditionTrue {
+		return false
+	}
+	return time.Since(condition.LastTransitionTime.Time) > time.Duration(deadline)*time.Second
+}
+
 func checkWaitTakeoverReady(instance *appsv2beta1.EMQX, eList []*corev1.Event) bool {
 	if len(eList) == 0 {
 		return true
@@ -149,6 +163,126 @@ func checkWaitTakeoverReady(instance *appsv2beta1.EMQX, eList []*corev1.Event) b
 	return int32(delay) > instance.Spec.UpdateStrategy.EvacuationStrategy.WaitTakeover
 }
 
+// getRollingUpdateParams returns the absolute maxSurge and maxUnavailable of the replicant nodes
+func getRollingUpdateParams(instance *appsv2beta1.EMQX) (maxSurge, maxUnavailable int32) {
+	replicas := int(appsv2beta1.GetReplicantReplicas(instance))
+
+	surge, unavailable := intstr.FromString("25%"), intstr.FromString("25%")
+	if rollingUpdate := instance.Spec.UpdateStrategy.RollingUpdate; rollingUpdate != nil {
+		if rollingUpdate.MaxSurge != nil {
+			surge = *rollingUpdate.MaxSurge
+		}
+		if rollingUpdate.MaxUnavailable != nil {
+			unavailable = *rollingUpdate.MaxUnavailable
+		}
+	}
+
+	s, _ := intstr.GetScaledValueFromIntOrPercent(&surge, replicas, true)
+	u, _ := intstr.GetScaledValueFromIntOrPercent(&unavailable, replicas, false)
+	if s == 0 && u == 0 {
+		// Same as Deployment, the update can not make progress if both of them are 0
+		u = 1
+	}
+	return int32(s), int32(u)
+}
+
+// getUpdateRsTargetReplicas returns the number of replicant nodes should be moved to the update revision,
+// for Canary strategy, it is the canary replicas until the EMQX Custom Resource is promoted
+func getUpdateRsTargetReplicas(instance *appsv2beta1.EMQX) int32 {
+	replicas := appsv2beta1.GetReplicantReplicas(instance)
+	if instance.Spec.UpdateStrategy.Type != appsv2beta1.UpdateStrategyCanary || isPromoted(instance) {
+		return replicas
+	}
+
+	canary := intstr.FromString("10%")
+	if instance.Spec.UpdateStrategy.Canary != nil && instance.Spec.UpdateStrategy.Canary.Replicas != nil {
+		canary = *instance.Spec.UpdateStrategy.Canary.Replicas
+	}
+	target, _ := intstr.GetScaledValueFromIntOrPercent(&canary, int(replicas), true)
+	if target < 1 {
+		target = 1
+	}
+	if int32(target) > replicas {
+		return replicas
+	}
+	return int32(target)
+}
+
+// getUpdateRsReplicas returns the replicas of the update replicaSet, for RollingUpdate and Canary strategy,
+// the update replicaSet will be scaled up step by step, and the total replicas will not exceed replicas + maxSurge
+func getUpdateRsReplicas(instance *appsv2beta1.EMQX, updateRs, currentRs *appsv1.ReplicaSet) int32 {
+	replicas := appsv2beta1.GetReplicantReplicas(instance)
+	// The replicaSet is scaled in by syncPods node by node, after the nodes are evacuated
+	if updateRs != nil && currentRs != nil && updateRs.UID == currentRs.UID && replicas < *updateRs.Spec.Replicas {
+		return *updateRs.Spec.Replicas
+	}
+	if instance.Spec.UpdateStrategy.Type != appsv2beta1.UpdateStrategyRollingUpdate && instance.Spec.UpdateStrategy.Type != appsv2beta1.UpdateStrategyCanary {
+		return replicas
+	}
+	// The update replicaSet is nil when it is going to be created
+	if currentRs == nil || (updateRs != nil && updateRs.UID == currentRs.UID) {
+		return replicas
+	}
+
+	maxSurge, _ := getRollingUpdateParams(instance)
+	newReplicas := replicas + maxSurge - *currentRs.Spec.Replicas
+	if updateRs != nil && *updateRs.Spec.Replicas > newReplicas {
+		newReplicas = *updateRs.Spec.Replicas
+	}
+	if target := getUpdateRsTargetReplicas(instance); newReplicas > target {
+		newReplicas = target
+	}
+	if newReplicas < 0 {
+		newReplicas = 0
+	}
+	return newReplicas
+}
+
+// getCurrentRsScaleDownReplicas returns the number of pods of the current replicaSet can be scaled down in one batch,
+// the available pods will not be less than replicas - maxUnavailable
+func getCurrentRsScaleDownReplicas(instance *appsv2beta1.EMQX, updateRs, currentRs *appsv1.ReplicaSet) int32 {
+	replicas := appsv2beta1.GetReplicantReplicas(instance)
+	_, maxUnavailable := getRollingUpdateParams(instance)
+
+	count := updateRs.Status.ReadyReplicas + currentRs.Status.ReadyReplicas - (replicas - maxUnavailable)
+	// Keep the nodes that will not be moved to the update revision, like the nodes are waiting for the canary promoted
+	if left := *currentRs.Spec.Replicas - (replicas - getUpdateRsTargetReplicas(instance)); left < count {
+		count = left
+	}
+	if count < 0 {
+		count = 0
+	}
+	return count
+}
+
+func isPromoted(instance *appsv2beta1.EMQX) bool {
+	return instance.Annotations[appsv2beta1.AnnotationsPromoteKey] == "true"
+}
+
+func isAborted(instance *appsv2beta1.EMQX) bool {
+	return instance.Annotations[appsv2beta1.AnnotationsAbortKey] == "true"
+}
+
+// isRollingBack returns true if the update is rolling back, but it has not been finished yet
+func isRollingBack(instance *appsv2beta1.EMQX) bool {
+	return instance.Status.IsConditionTrue(appsv2beta1.RollingBack)
+}
+
+// isUpdatePaused returns true if the update is paused and not promoted, the old nodes should not be scaled down
+func isUpdatePaused(instance *appsv2beta1.EMQX) bool {
+	return instance.Spec.UpdateStrategy.Paused && !isPromoted(instance)
+}
+
+// setConditionIfChanged just sets the condition when its status, reason or message is changed, to keep the lastTransitionTime
+func setConditionIfChanged(instance *appsv2beta1.EMQX, condition metav1.Condition) bool {
+	_, c := instance.Status.GetCondition(condition.Type)
+	if c != nil && c.Status == condition.Status && c.Reason == condition.Reason && c.Message == condition.Message {
+		return false
+	}
+	instance.Status.SetCondition(condition)
+	return true
+}
+
 // JustCheckPodTemplate will check only the differences between the podTemplate of the two statefulSets
 func justCheckPodTemplate() patch.CalculateOption {
 	getPodTemplate := func(obj []byte) ([]byte, error) {
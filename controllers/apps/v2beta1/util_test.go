@@ -6,8 +6,11 @@ import (
 
 	appsv2beta1 "github.com/emqx/emqx-operator/apis/apps/v2beta1"
 	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/utils/ptr"
 )
 
 func TestCheckInitialDelaySecondsReady(t *testing.T) {
@@ -138,3 +141,288 @@ func TestHandlerEventList(t *testing.T) {
 		assert.ElementsMatch(t, []string{"emqx-0", "emqx-1"}, l)
 	})
 }
+
+func TestGetRollingUpdateParams(t *testing.T) {
+	emqx := &appsv2beta1.EMQX{
+		Spec: appsv2beta1.EMQXSpec{
+			ReplicantTemplate: &appsv2beta1.EMQXReplicantTemplate{
+				Spec: appsv2beta1.EMQXReplicantTemplateSpec{
+					Replicas: ptr.To(int32(10)),
+				},
+			},
+		},
+	}
+
+	t.Run("default value", func(t *testing.T) {
+		maxSurge, maxUnavailable := getRollingUpdateParams(emqx)
+		assert.Equal(t, int32(3), maxSurge)
+		assert.Equal(t, int32(2), maxUnavailable)
+	})
+
+	t.Run("absolute number", func(t *testing.T) {
+		e := emqx.DeepCopy()
+		e.Spec.UpdateStrategy.RollingUpdate = &appsv2beta1.RollingUpdateStrategy{
+			MaxSurge:       ptr.To(intstr.FromInt(1)),
+			MaxUnavailable: ptr.To(intstr.FromInt(0)),
+		}
+		maxSurge, maxUnavailable := getRollingUpdateParams(e)
+		assert.Equal(t, int32(1), maxSurge)
+		assert.Equal(t, int32(0), maxUnavailable)
+	})
+
+	t.Run("both of them are 0", func(t *testing.T) {
+		e := emqx.DeepCopy()
+		e.Spec.UpdateStrategy.RollingUpdate = &appsv2beta1.RollingUpdateStrategy{
+			MaxSurge:       ptr.To(intstr.FromString("0%")),
+			MaxUnavailable: ptr.To(intstr.FromInt(0)),
+		}
+		maxSurge, maxUnavailable := getRollingUpdateParams(e)
+		assert.Equal(t, int32(0), maxSurge)
+		assert.Equal(t, int32(1), maxUnavailable)
+	})
+}
+
+func TestGetUpdateRsTargetReplicas(t *testing.T) {
+	emqx := &appsv2beta1.EMQX{
+		Spec: appsv2beta1.EMQXSpec{
+			UpdateStrategy: appsv2beta1.UpdateStrategy{
+				Type: appsv2beta1.UpdateStrategyCanary,
+			},
+			ReplicantTemplate: &appsv2beta1.EMQXReplicantTemplate{
+				Spec: appsv2beta1.EMQXReplicantTemplateSpec{
+					Replicas: ptr.To(int32(5)),
+				},
+			},
+		},
+	}
+
+	t.Run("canary default value", func(t *testing.T) {
+		assert.Equal(t, int32(1), getUpdateRsTargetReplicas(emqx))
+	})
+
+	t.Run("canary replicas", func(t *testing.T) {
+		e := emqx.DeepCopy()
+		e.Spec.UpdateStrategy.Canary = &appsv2beta1.CanaryStrategy{
+			Replicas: ptr.To(intstr.FromString("50%")),
+		}
+		assert.Equal(t, int32(3), getUpdateRsTargetReplicas(e))
+
+		e.Spec.UpdateStrategy.Canary.Replicas = ptr.To(intstr.FromInt(10))
+		assert.Equal(t, int32(5), getUpdateRsTargetReplicas(e))
+	})
+
+	t.Run("canary is promoted", func(t *testing.T) {
+		e := emqx.DeepCopy()
+		e.Annotations = map[string]string{appsv2beta1.AnnotationsPromoteKey: "true"}
+		assert.Equal(t, int32(5), getUpdateRsTargetReplicas(e))
+	})
+
+	t.Run("rolling update", func(t *testing.T) {
+		e := emqx.DeepCopy()
+		e.Spec.UpdateStrategy.Type = appsv2beta1.UpdateStrategyRollingUpdate
+		assert.Equal(t, int32(5), getUpdateRsTargetReplicas(e))
+	})
+}
+
+func TestGetUpdateRsReplicas(t *testing.T) {
+	emqx := &appsv2beta1.EMQX{
+		Spec: appsv2beta1.EMQXSpec{
+			UpdateStrategy: appsv2beta1.UpdateStrategy{
+				Type: appsv2beta1.UpdateStrategyRollingUpdate,
+				RollingUpdate: &appsv2beta1.RollingUpdateStrategy{
+					MaxSurge:       ptr.To(intstr.FromInt(1)),
+					MaxUnavailable: ptr.To(intstr.FromInt(1)),
+				},
+			},
+			ReplicantTemplate: &appsv2beta1.EMQXReplicantTemplate{
+				Spec: appsv2beta1.EMQXReplicantTemplateSpec{
+					Replicas: ptr.To(int32(4)),
+				},
+			},
+		},
+	}
+	updateRs := &appsv1.ReplicaSet{
+		ObjectMeta: metav1.ObjectMeta{UID: "update"},
+		Spec:       appsv1.ReplicaSetSpec{Replicas: ptr.To(int32(1))},
+	}
+	currentRs := &appsv1.ReplicaSet{
+		ObjectMeta: metav1.ObjectMeta{UID: "current"},
+		Spec:       appsv1.ReplicaSetSpec{Replicas: ptr.To(int32(4))},
+	}
+
+	t.Run("recreate", func(t *testing.T) {
+		e := emqx.DeepCopy()
+		e.Spec.UpdateStrategy.Type = appsv2beta1.UpdateStrategyRecreate
+		assert.Equal(t, int32(4), getUpdateRsReplicas(e, nil, currentRs))
+	})
+
+	t.Run("no current replicaSet", func(t *testing.T) {
+		assert.Equal(t, int32(4), getUpdateRsReplicas(emqx, nil, nil))
+		assert.Equal(t, int32(4), getUpdateRsReplicas(emqx, currentRs, currentRs))
+	})
+
+	t.Run("scale in", func(t *testing.T) {
+		current := currentRs.DeepCopy()
+		current.Spec.Replicas = ptr.To(int32(6))
+		assert.Equal(t, int32(6), getUpdateRsReplicas(emqx, current, current))
+
+		e := emqx.DeepCopy()
+		e.Spec.UpdateStrategy.Type = appsv2beta1.UpdateStrategyRecreate
+		assert.Equal(t, int32(6), getUpdateRsReplicas(e, current, current))
+	})
+
+	t.Run("create update replicaSet", func(t *testing.T) {
+		assert.Equal(t, int32(1), getUpdateRsReplicas(emqx, nil, currentRs))
+	})
+
+	t.Run("scale up update replicaSet", func(t *testing.T) {
+		current := currentRs.DeepCopy()
+		current.Spec.Replicas = ptr.To(int32(2))
+		assert.Equal(t, int32(3), getUpdateRsReplicas(emqx, updateRs, current))
+
+		current.Spec.Replicas = ptr.To(int32(0))
+		assert.Equal(t, int32(4), getUpdateRsReplicas(emqx, updateRs, current))
+	})
+
+	t.Run("canary", func(t *testing.T) {
+		e := emqx.DeepCopy()
+		e.Spec.UpdateStrategy.Type = appsv2beta1.UpdateStrategyCanary
+		e.Spec.UpdateStrategy.Canary = &appsv2beta1.CanaryStrategy{
+			Replicas: ptr.To(intstr.FromInt(1)),
+		}
+		current := currentRs.DeepCopy()
+		current.Spec.Replicas = ptr.To(int32(3))
+		assert.Equal(t, int32(1), getUpdateRsReplicas(e, updateRs, current))
+
+		e.Annotations = map[string]string{appsv2beta1.AnnotationsPromoteKey: "true"}
+		assert.Equal(t, int32(2), getUpdateRsReplicas(e, updateRs, current))
+	})
+}
+
+func TestGetCurrentRsScaleDownReplicas(t *testing.T) {
+	emqx := &appsv2beta1.EMQX{
+		Spec: appsv2beta1.EMQXSpec{
+			UpdateStrategy: appsv2beta1.UpdateStrategy{
+				Type: appsv2beta1.UpdateStrategyRollingUpdate,
+				RollingUpdate: &appsv2beta1.RollingUpdateStrategy{
+					MaxSurge:       ptr.To(intstr.FromInt(0)),
+					MaxUnavailable: ptr.To(intstr.FromInt(2)),
+				},
+			},
+			ReplicantTemplate: &appsv2beta1.EMQXReplicantTemplate{
+				Spec: appsv2beta1.EMQXReplicantTemplateSpec{
+					Replicas: ptr.To(int32(4)),
+				},
+			},
+		},
+	}
+	updateRs := &appsv1.ReplicaSet{
+		Spec:   appsv1.ReplicaSetSpec{Replicas: ptr.To(int32(0))},
+		Status: appsv1.ReplicaSetStatus{ReadyReplicas: 0},
+	}
+	currentRs := &appsv1.ReplicaSet{
+		Spec:   appsv1.ReplicaSetSpec{Replicas: ptr.To(int32(4))},
+		Status: appsv1.ReplicaSetStatus{ReadyReplicas: 4},
+	}
+
+	t.Run("rolling update", func(t *testing.T) {
+		assert.Equal(t, int32(2), getCurrentRsScaleDownReplicas(emqx, updateRs, currentRs))
+	})
+
+	t.Run("not enough available pods", func(t *testing.T) {
+		current := currentRs.DeepCopy()
+		current.Status.ReadyReplicas = 2
+		assert.Equal(t, int32(0), getCurrentRsScaleDownReplicas(emqx, updateRs, current))
+	})
+
+	t.Run("canary", func(t *testing.T) {
+		e := emqx.DeepCopy()
+		e.Spec.UpdateStrategy.Type = appsv2beta1.UpdateStrategyCanary
+		e.Spec.UpdateStrategy.Canary = &appsv2beta1.CanaryStrategy{
+			Replicas: ptr.To(intstr.FromInt(1)),
+		}
+		assert.Equal(t, int32(1), getCurrentRsScaleDownReplicas(e, updateRs, currentRs))
+
+		current := currentRs.DeepCopy()
+		current.Spec.Replicas = ptr.To(int32(3))
+		current.Status.ReadyReplicas = 3
+		assert.Equal(t, int32(0), getCurrentRsScaleDownReplicas(e, updateRs, current))
+	})
+}
+
+func TestIsUpdatePaused(t *testing.T) {
+	instance := &appsv2beta1.EMQX{}
+	assert.False(t, isUpdatePaused(instance))
+
+	instance.Spec.UpdateStrategy.Paused = true
+	assert.True(t, isUpdatePaused(instance))
+
+	instance.Annotations = map[string]string{appsv2beta1.AnnotationsPromoteKey: "true"}
+	assert.False(t, isUpdatePaused(instance))
+}
+
+func TestSetConditionIfChanged(t *testing.T) {
+	instance := &appsv2beta1.EMQX{}
+	assert.True(t, setConditionIfChanged(instance, metav1.Condition{
+		Type:   appsv2beta1.UpdatePaused,
+		Status: metav1.ConditionTrue,
+		Reason: appsv2beta1.UpdatePaused,
+	}))
+	lastTransitionTime := instance.Status.Conditions[0].LastTransitionTime
+
+	assert.False(t, setConditionIfChanged(instance, metav1.Condition{
+		Type:   appsv2beta1.UpdatePaused,
+		Status: metav1.ConditionTrue,
+		Reason: appsv2beta1.UpdatePaused,
+	}))
+	assert.Equal(t, lastTransitionTime, instance.Status.Conditions[0].LastTransitionTime)
+
+	assert.True(t, setConditionIfChanged(instance, metav1.Condition{
+		Type:   appsv2beta1.UpdatePaused,
+		Status: metav1.ConditionFalse,
+		Reason: "UpdateResumed",
+	}))
+	assert.Equal(t, metav1.ConditionFalse, instance.Status.Conditions[0].Status)
+}
+
+func TestCheckProgressDeadlineExceeded(t *testing.T) {
+	instance := &appsv2beta1.EMQX{
+		Status: appsv2beta1.EMQXStatus{
+			Conditions: []metav1.Condition{
+				{
+					Type:               appsv2beta1.CoreNodesProgressing,
+					Status:             metav1.ConditionTrue,
+					LastTransitionTime: metav1.Time{Time: time.Now().Add(-time.Minute)},
+				},
+			},
+		},
+	}
+	// never roll back
+	assert.False(t, checkProgressDeadlineExceeded(instance, appsv2beta1.CoreNodesProgressing))
+
+	instance.Spec.UpdateStrategy.ProgressDeadlineSeconds = 600
+	assert.False(t, checkProgressDeadlineExceeded(instance, appsv2beta1.CoreNodesProgressing))
+
+	instance.Spec.UpdateStrategy.ProgressDeadlineSeconds = 30
+	assert.True(t, checkProgressDeadlineExceeded(instance, appsv2beta1.CoreNodesProgressing))
+	assert.False(t, checkProgressDeadlineExceeded(instance, appsv2beta1.ReplicantNodesProgressing))
+}
+
+func TestIsRollingBack(t *testing.T) {
+	instance := &appsv2beta1.EMQX{}
+	assert.False(t, isRollingBack(instance))
+
+	instance.Status.SetCondition(metav1.Condition{
+		Type:   appsv2beta1.RollingBack,
+		Status: metav1.ConditionTrue,
+		Reason: "ProgressDeadlineExceeded",
+	})
+	assert.True(t, isRollingBack(instance))
+
+	instance.Status.SetCondition(metav1.Condition{
+		Type:   appsv2beta1.RollingBack,
+		Status: metav1.ConditionFalse,
+		Reason: appsv2beta1.RolledBack,
+	})
+	assert.False(t, isRollingBack(instance))
+}
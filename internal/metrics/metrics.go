@@ -0,0 +1,191 @@
+package metrics
+
+import (
+	"time"
+
+	appsv2beta1 "github.com/emqx/emqx-operator/apis/apps/v2beta1"
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+const namespace = "emqx_operator"
+
+var (
+	// EMQX cluster
+	EMQXNodeSessions = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "emqx",
+		Name:      "node_sessions",
+		Help:      "Number of MQTT sessions of the EMQX node.",
+	}, []string{"namespace", "name", "node", "role"})
+	EMQXNodeLiveConnections = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "emqx",
+		Name:      "node_live_connections",
+		Help:      "Number of connected MQTT clients of the EMQX node.",
+	}, []string{"namespace", "name", "node", "role"})
+	EMQXReplicas = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "emqx",
+		Name:      "replicas",
+		Help:      "Desired number of the EMQX nodes.",
+	}, []string{"namespace", "name", "role"})
+	EMQXReadyReplicas = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "emqx",
+		Name:      "ready_replicas",
+		Help:      "Number of the running EMQX nodes.",
+	}, []string{"namespace", "name", "role"})
+	EMQXCondition = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "emqx",
+		Name:      "condition",
+		Help:      "The current condition of the EMQX cluster, the value is 1 for the current condition.",
+	}, []string{"namespace", "name", "type"})
+	EMQXNodeEvacuationSessions = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "emqx",
+		Name:      "node_evacuation_sessions",
+		Help:      "Number of MQTT sessions of the evacuating EMQX node, the stage is initial or current.",
+	}, []string{"namespace", "name", "node", "stage"})
+	EMQXNodeEvacuationConnections = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "emqx",
+		Name:      "node_evacuation_connections",
+		Help:      "Number of connected MQTT clients of the evacuating EMQX node, the stage is initial or current.",
+	}, []string{"namespace", "name", "node", "stage"})
+	EMQXConfigSyncFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "emqx",
+		Name:      "config_sync_failures_total",
+		Help:      "Total number of failures to sync the config to the EMQX cluster.",
+	}, []string{"namespace", "name"})
+
+	// Rebalance
+	RebalancePhase = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "rebalance",
+		Name:      "phase",
+		Help:      "The current phase of the Rebalance, the value is 1 for the current phase.",
+	}, []string{"namespace", "name", "phase"})
+	RebalanceDuration = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "rebalance",
+		Name:      "duration_seconds",
+		Help:      "Duration of the Rebalance since it started, until it completed.",
+	}, []string{"namespace", "name"})
+
+	// EMQX HTTP API
+	RequesterRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "requester",
+		Name:      "request_duration_seconds",
+		Help:      "Latency of the requests to the EMQX HTTP API.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "code"})
+	RequesterRequestErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "requester",
+		Name:      "request_errors_total",
+		Help:      "Total number of the requests to the EMQX HTTP API which failed without response.",
+	}, []string{"method"})
+)
+
+func init() {
+	metrics.Registry.MustRegister(
+		EMQXNodeSessions,
+		EMQXNodeLiveConnections,
+		EMQXReplicas,
+		EMQXReadyReplicas,
+		EMQXCondition,
+		EMQXNodeEvacuationSessions,
+		EMQXNodeEvacuationConnections,
+		EMQXConfigSyncFailures,
+		RebalancePhase,
+		RebalanceDuration,
+		RequesterRequestDuration,
+		RequesterRequestErrors,
+	)
+}
+
+// ObserveEMQX refreshes the metrics of the EMQX cluster by its status
+func ObserveEMQX(instance *appsv2beta1.EMQX) {
+	// The nodes may be changed, so delete the old metrics at first
+	deleteEMQXNodes(instance.Namespace, instance.Name)
+
+	for role, nodes := range map[string][]appsv2beta1.EMQXNode{
+		"core":      instance.Status.CoreNodes,
+		"replicant": instance.Status.ReplicantNodes,
+	} {
+		for _, node := range nodes {
+			EMQXNodeSessions.WithLabelValues(instance.Namespace, instance.Name, node.Node, role).Set(float64(node.Session))
+			EMQXNodeLiveConnections.WithLabelValues(instance.Namespace, instance.Name, node.Node, role).Set(float64(node.Connections))
+		}
+	}
+
+	EMQXReplicas.WithLabelValues(instance.Namespace, instance.Name, "core").Set(float64(instance.Status.CoreNodesStatus.Replicas))
+	EMQXReadyReplicas.WithLabelValues(instance.Namespace, instance.Name, "core").Set(float64(instance.Status.CoreNodesStatus.ReadyReplicas))
+	if appsv2beta1.IsExistReplicant(instance) {
+		EMQXReplicas.WithLabelValues(instance.Namespace, instance.Name, "replicant").Set(float64(instance.Status.ReplicantNodesStatus.Replicas))
+		EMQXReadyReplicas.WithLabelValues(instance.Namespace, instance.Name, "replicant").Set(float64(instance.Status.ReplicantNodesStatus.ReadyReplicas))
+	}
+
+	if condition := instance.Status.GetLastTrueCondition(); condition != nil {
+		EMQXCondition.WithLabelValues(instance.Namespace, instance.Name, condition.Type).Set(1)
+	}
+
+	for _, evacuation := range instance.Status.NodeEvacuationsStatus {
+		for stage, stats := range map[string][2]*int32{
+			"initial": {evacuation.Stats.InitialSessions, evacuation.Stats.InitialConnected},
+			"current": {evacuation.Stats.CurrentSessions, evacuation.Stats.CurrentConnected},
+		} {
+			if stats[0] != nil {
+				EMQXNodeEvacuationSessions.WithLabelValues(instance.Namespace, instance.Name, evacuation.Node, stage).Set(float64(*stats[0]))
+			}
+			if stats[1] != nil {
+				EMQXNodeEvacuationConnections.WithLabelValues(instance.Namespace, instance.Name, evacuation.Node, stage).Set(float64(*stats[1]))
+			}
+		}
+	}
+}
+
+// DeleteEMQX deletes all of the metrics of the EMQX cluster, it should be called when the EMQX Custom Resource is deleted
+func DeleteEMQX(namespace, name string) {
+	deleteEMQXNodes(namespace, name)
+	EMQXConfigSyncFailures.DeletePartialMatch(prometheus.Labels{"namespace": namespace, "name": name})
+}
+
+func deleteEMQXNodes(namespace, name string) {
+	labels := prometheus.Labels{"namespace": namespace, "name": name}
+	EMQXNodeSessions.DeletePartialMatch(labels)
+	EMQXNodeLiveConnections.DeletePartialMatch(labels)
+	EMQXReplicas.DeletePartialMatch(labels)
+	EMQXReadyReplicas.DeletePartialMatch(labels)
+	EMQXCondition.DeletePartialMatch(labels)
+	EMQXNodeEvacuationSessions.DeletePartialMatch(labels)
+	EMQXNodeEvacuationConnections.DeletePartialMatch(labels)
+}
+
+// ObserveRebalance refreshes the metrics of the Rebalance by its status
+func ObserveRebalance(rebalance *appsv2beta1.Rebalance) {
+	RebalancePhase.DeletePartialMatch(prometheus.Labels{"namespace": rebalance.Namespace, "name": rebalance.Name})
+	if rebalance.Status.Phase != "" {
+		RebalancePhase.WithLabelValues(rebalance.Namespace, rebalance.Name, string(rebalance.Status.Phase)).Set(1)
+	}
+
+	if rebalance.Status.StartedTime.IsZero() {
+		return
+	}
+	end := time.Now()
+	if !rebalance.Status.CompletedTime.IsZero() {
+		end = rebalance.Status.CompletedTime.Time
+	}
+	RebalanceDuration.WithLabelValues(rebalance.Namespace, rebalance.Name).Set(end.Sub(rebalance.Status.StartedTime.Time).Seconds())
+}
+
+// DeleteRebalance deletes all of the metrics of the Rebalance, it should be called when the Rebalance Custom Resource is deleted
+func DeleteRebalance(namespace, name string) {
+	labels := prometheus.Labels{"namespace": namespace, "name": name}
+	RebalancePhase.DeletePartialMatch(labels)
+	RebalanceDuration.DeletePartialMatch(labels)
+}
@@ -0,0 +1,89 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+
+	appsv2beta1 "github.com/emqx/emqx-operator/apis/apps/v2beta1"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+)
+
+func TestObserveEMQX(t *testing.T) {
+	instance := &appsv2beta1.EMQX{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "emqx",
+			Namespace: "default",
+		},
+		Status: appsv2beta1.EMQXStatus{
+			Conditions: []metav1.Condition{
+				{Type: appsv2beta1.Ready, Status: metav1.ConditionTrue},
+			},
+			CoreNodes: []appsv2beta1.EMQXNode{
+				{Node: "emqx@core-0", Session: 10, Connections: 8},
+			},
+			CoreNodesStatus: appsv2beta1.EMQXNodesStatus{
+				Replicas:      3,
+				ReadyReplicas: 2,
+			},
+			NodeEvacuationsStatus: []appsv2beta1.NodeEvacuationStatus{
+				{
+					Node: "emqx@core-0",
+					Stats: appsv2beta1.NodeEvacuationStats{
+						InitialSessions: ptr.To(int32(100)),
+						CurrentSessions: ptr.To(int32(10)),
+					},
+				},
+			},
+		},
+	}
+	ObserveEMQX(instance)
+
+	assert.Equal(t, float64(10), testutil.ToFloat64(EMQXNodeSessions.WithLabelValues("default", "emqx", "emqx@core-0", "core")))
+	assert.Equal(t, float64(8), testutil.ToFloat64(EMQXNodeLiveConnections.WithLabelValues("default", "emqx", "emqx@core-0", "core")))
+	assert.Equal(t, float64(3), testutil.ToFloat64(EMQXReplicas.WithLabelValues("default", "emqx", "core")))
+	assert.Equal(t, float64(2), testutil.ToFloat64(EMQXReadyReplicas.WithLabelValues("default", "emqx", "core")))
+	assert.Equal(t, float64(1), testutil.ToFloat64(EMQXCondition.WithLabelValues("default", "emqx", appsv2beta1.Ready)))
+	assert.Equal(t, float64(100), testutil.ToFloat64(EMQXNodeEvacuationSessions.WithLabelValues("default", "emqx", "emqx@core-0", "initial")))
+	assert.Equal(t, float64(10), testutil.ToFloat64(EMQXNodeEvacuationSessions.WithLabelValues("default", "emqx", "emqx@core-0", "current")))
+
+	t.Run("the node is gone", func(t *testing.T) {
+		instance.Status.CoreNodes = nil
+		instance.Status.NodeEvacuationsStatus = nil
+		ObserveEMQX(instance)
+		assert.Equal(t, 0, testutil.CollectAndCount(EMQXNodeSessions))
+		assert.Equal(t, 0, testutil.CollectAndCount(EMQXNodeEvacuationSessions))
+	})
+
+	t.Run("the EMQX is deleted", func(t *testing.T) {
+		EMQXConfigSyncFailures.WithLabelValues("default", "emqx").Inc()
+		DeleteEMQX("default", "emqx")
+		assert.Equal(t, 0, testutil.CollectAndCount(EMQXReplicas))
+		assert.Equal(t, 0, testutil.CollectAndCount(EMQXCondition))
+		assert.Equal(t, 0, testutil.CollectAndCount(EMQXConfigSyncFailures))
+	})
+}
+
+func TestObserveRebalance(t *testing.T) {
+	rebalance := &appsv2beta1.Rebalance{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "rebalance",
+			Namespace: "default",
+		},
+		Status: appsv2beta1.RebalanceStatus{
+			Phase:         appsv2beta1.RebalancePhaseCompleted,
+			StartedTime:   metav1.NewTime(time.Now().Add(-time.Minute)),
+			CompletedTime: metav1.NewTime(time.Now()),
+		},
+	}
+	ObserveRebalance(rebalance)
+
+	assert.Equal(t, float64(1), testutil.ToFloat64(RebalancePhase.WithLabelValues("default", "rebalance", "Completed")))
+	assert.InDelta(t, float64(60), testutil.ToFloat64(RebalanceDuration.WithLabelValues("default", "rebalance")), 1)
+
+	DeleteRebalance("default", "rebalance")
+	assert.Equal(t, 0, testutil.CollectAndCount(RebalancePhase))
+	assert.Equal(t, 0, testutil.CollectAndCount(RebalanceDuration))
+}
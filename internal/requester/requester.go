@@ -5,8 +5,11 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"strconv"
+	"time"
 
 	emperror "emperror.dev/errors"
+	"github.com/emqx/emqx-operator/internal/metrics"
 )
 
 type HeaderOpt struct {
@@ -19,7 +22,10 @@ type RequesterInterface interface {
 	GetHost() string
 	GetUsername() string
 	GetPassword() string
+	GetTransport() http.RoundTripper
 	Request(method string, url url.URL, body []byte, header http.Header) (resp *http.Response, respBody []byte, err error)
+	// RequestStream is the same as Request, but the body is read while it is being sent, so it is not loaded into the memory
+	RequestStream(method string, url url.URL, body io.Reader, header http.Header) (resp *http.Response, respBody []byte, err error)
 }
 
 type Requester struct {
@@ -27,6 +33,9 @@ type Requester struct {
 	Host     string
 	Username string
 	Password string
+	// Transport is shared by the requesters of the same EMQX cluster to reuse the connections, see GetTransport.
+	// Defaults to http.DefaultTransport.
+	Transport http.RoundTripper
 }
 
 func (requester *Requester) GetUsername() string {
@@ -41,6 +50,13 @@ func (requester *Requester) GetHost() string {
 	return requester.Host
 }
 
+func (requester *Requester) GetTransport() http.RoundTripper {
+	if requester.Transport == nil {
+		return http.DefaultTransport
+	}
+	return requester.Transport
+}
+
 func (requester *Requester) GetSchema() string {
 	if requester.Schema == "" {
 		return "http"
@@ -65,6 +81,10 @@ func (requester *Requester) GetURL(path string, query ...string) url.URL {
 }
 
 func (requester *Requester) Request(method string, url url.URL, body []byte, header http.Header) (resp *http.Response, respBody []byte, err error) {
+	return requester.RequestStream(method, url, bytes.NewReader(body), header)
+}
+
+func (requester *Requester) RequestStream(method string, url url.URL, body io.Reader, header http.Header) (resp *http.Response, respBody []byte, err error) {
 	if url.Scheme == "" {
 		url.Scheme = requester.GetSchema()
 	}
@@ -72,7 +92,7 @@ func (requester *Requester) Request(method string, url url.URL, body []byte, hea
 		url.Host = requester.GetHost()
 	}
 
-	req, err := http.NewRequest(method, url.String(), bytes.NewReader(body))
+	req, err := http.NewRequest(method, url.String(), body)
 	if err != nil {
 		return nil, nil, emperror.Wrap(err, "failed to create request")
 	}
@@ -89,22 +109,22 @@ func (requester *Requester) Request(method string, url url.URL, body []byte, hea
 	if req.Header.Get("Accept") == "" {
 		req.Header.Set("Accept", "application/json")
 	}
-	req.Close = true
 
-	tr := http.DefaultTransport.(*http.Transport).Clone()
-	tr.TLSClientConfig.InsecureSkipVerify = true
-	httpClient := http.Client{Transport: tr}
+	httpClient := http.Client{Transport: requester.GetTransport()}
+	start := time.Now()
 	resp, err = httpClient.Do(req)
 	if err != nil {
+		metrics.RequesterRequestErrors.WithLabelValues(method).Inc()
 		return nil, nil, emperror.Wrap(err, "failed to request API")
 	}
+	metrics.RequesterRequestDuration.WithLabelValues(method, strconv.Itoa(resp.StatusCode)).Observe(time.Since(start).Seconds())
 
 	defer resp.Body.Close()
-	body, err = io.ReadAll(resp.Body)
+	respBody, err = io.ReadAll(resp.Body)
 	if err != nil {
 		return resp, nil, emperror.Wrap(err, "failed to read response body")
 	}
-	return resp, body, nil
+	return resp, respBody, nil
 }
 
 // Mock
@@ -117,6 +137,14 @@ func (f *FakeRequester) GetSchema() string                           { return "h
 func (f *FakeRequester) GetHost() string                             { return "" }
 func (f *FakeRequester) GetUsername() string                         { return "" }
 func (f *FakeRequester) GetPassword() string                         { return "" }
+func (f *FakeRequester) GetTransport() http.RoundTripper             { return nil }
 func (f *FakeRequester) Request(method string, url url.URL, body []byte, header http.Header) (resp *http.Response, respBody []byte, err error) {
 	return f.ReqFunc(method, url, body, header)
 }
+func (f *FakeRequester) RequestStream(method string, url url.URL, body io.Reader, header http.Header) (resp *http.Response, respBody []byte, err error) {
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return nil, nil, err
+	}
+	return f.ReqFunc(method, url, data, header)
+}
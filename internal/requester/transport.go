@@ -0,0 +1,115 @@
+package requester
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"net/http"
+	"sync"
+
+	emperror "emperror.dev/errors"
+)
+
+type TLSOptions struct {
+	// PEM encoded CA bundle to verify the server certificate.
+	CA []byte
+	// PEM encoded client certificate and key for mutual TLS.
+	Cert []byte
+	Key  []byte
+	// ServerName is used to verify the hostname of the server certificate,
+	// the requesters connect to the EMQX nodes by the pod IP.
+	ServerName string
+	// InsecureSkipVerify skips the verification of the server certificate,
+	// it is used when no CA bundle is provided.
+	InsecureSkipVerify bool
+}
+
+func (o *TLSOptions) hash() string {
+	if o == nil {
+		return ""
+	}
+	hasher := sha256.New()
+	for _, b := range [][]byte{o.CA, o.Cert, o.Key, []byte(o.ServerName)} {
+		_, _ = hasher.Write(b)
+		_, _ = hasher.Write([]byte{0})
+	}
+	if o.InsecureSkipVerify {
+		_, _ = hasher.Write([]byte("insecure"))
+	}
+	return hex.EncodeToString(hasher.Sum(nil))
+}
+
+type pooledTransport struct {
+	hash      string
+	transport *http.Transport
+}
+
+var (
+	transportsMu sync.Mutex
+	transports   = map[string]*pooledTransport{}
+)
+
+// GetTransport returns the transport of the key, the key is usually the namespaced name of the EMQX cluster.
+// The transport is reused until the TLS options are changed, for example when the certificates are rotated.
+func GetTransport(key string, opts *TLSOptions) (*http.Transport, error) {
+	hash := opts.hash()
+
+	transportsMu.Lock()
+	defer transportsMu.Unlock()
+
+	if p, ok := transports[key]; ok {
+		if p.hash == hash {
+			return p.transport, nil
+		}
+		p.transport.CloseIdleConnections()
+		delete(transports, key)
+	}
+
+	transport, err := newTransport(opts)
+	if err != nil {
+		return nil, err
+	}
+	transports[key] = &pooledTransport{hash: hash, transport: transport}
+	return transport, nil
+}
+
+// DeleteTransport closes the idle connections of the transport of the key and removes it.
+func DeleteTransport(key string) {
+	transportsMu.Lock()
+	defer transportsMu.Unlock()
+
+	if p, ok := transports[key]; ok {
+		p.transport.CloseIdleConnections()
+		delete(transports, key)
+	}
+}
+
+func newTransport(opts *TLSOptions) (*http.Transport, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if opts == nil {
+		return transport, nil
+	}
+
+	tlsConfig := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		ServerName:         opts.ServerName,
+		InsecureSkipVerify: opts.InsecureSkipVerify,
+	}
+	if len(opts.CA) > 0 {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(opts.CA) {
+			return nil, emperror.New("failed to parse CA bundle")
+		}
+		tlsConfig.RootCAs = pool
+	}
+	if len(opts.Cert) > 0 || len(opts.Key) > 0 {
+		cert, err := tls.X509KeyPair(opts.Cert, opts.Key)
+		if err != nil {
+			return nil, emperror.Wrap(err, "failed to parse client certificate")
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	transport.TLSClientConfig = tlsConfig
+	return transport, nil
+}
@@ -0,0 +1,44 @@
+package requester
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetTransport(t *testing.T) {
+	defer DeleteTransport("emqx/emqx")
+
+	t.Run("reuse the transport", func(t *testing.T) {
+		got, err := GetTransport("emqx/emqx", nil)
+		assert.NoError(t, err)
+
+		again, err := GetTransport("emqx/emqx", nil)
+		assert.NoError(t, err)
+		assert.Same(t, got, again)
+	})
+
+	t.Run("create a new transport when the tls options are changed", func(t *testing.T) {
+		old, _ := GetTransport("emqx/emqx", nil)
+		got, err := GetTransport("emqx/emqx", &TLSOptions{ServerName: "emqx-dashboard", InsecureSkipVerify: true})
+		assert.NoError(t, err)
+		assert.NotSame(t, old, got)
+		assert.Equal(t, "emqx-dashboard", got.TLSClientConfig.ServerName)
+		assert.True(t, got.TLSClientConfig.InsecureSkipVerify)
+	})
+
+	t.Run("invalid tls options", func(t *testing.T) {
+		_, err := GetTransport("emqx/emqx", &TLSOptions{CA: []byte("fake")})
+		assert.ErrorContains(t, err, "failed to parse CA bundle")
+
+		_, err = GetTransport("emqx/emqx", &TLSOptions{Cert: []byte("fake"), Key: []byte("fake")})
+		assert.ErrorContains(t, err, "failed to parse client certificate")
+	})
+
+	t.Run("delete the transport", func(t *testing.T) {
+		old, _ := GetTransport("emqx/emqx", nil)
+		DeleteTransport("emqx/emqx")
+		got, _ := GetTransport("emqx/emqx", nil)
+		assert.NotSame(t, old, got)
+	})
+}